@@ -2,10 +2,20 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	stdsync "sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/beekhof/calendar-sync/internal/auth"
 	calclient "github.com/beekhof/calendar-sync/internal/calendar"
@@ -13,25 +23,43 @@ import (
 	"github.com/beekhof/calendar-sync/internal/sync"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/microsoft"
+	"google.golang.org/api/calendar/v3"
 )
 
 func printHelp() {
 	fmt.Fprintf(os.Stderr, `Calendar Sync Tool
 
 A one-way synchronization tool that syncs events from a work Google Calendar
-to one or more destination calendars (Google Calendar or Apple Calendar/iCloud),
-creating read-only "Work Sync" calendars in each destination.
+to one or more destination calendars (Google Calendar, Apple Calendar/iCloud or
+Outlook.com/Office 365), creating read-only "Work Sync" calendars in each destination.
 
 USAGE:
-    %s [OPTIONS]
+    %s [COMMAND] [OPTIONS]
+
+COMMANDS:
+    test-reminder                 Create/update the token-refresh reminder event in the
+                                  --destination calendar right away, scheduled 15 minutes
+                                  from now, to check that reminders show up
+    selftest                      Insert, read back, update and delete a temporary event
+                                  in the --destination calendar, reporting each step
+    purge                         Delete every event in the --destination calendar within the
+                                  sync window, so the next sync starts afresh. Requires
+                                  --i-understand-destructive, or --dry-run to count them
 
 OPTIONS:
     -h, --help                    Show this help message and exit
     -v, --verbose                 Enable verbose output (show DEBUG logs)
-    --config FILE                 Path to JSON config file (required)
+    --config FILE                 Path to JSON or YAML (.yaml, .yml) config file (required)
                                   All settings must be specified in the config file
     --destination NAME            Sync only to the named destination (optional)
                                   If not specified, syncs to all destinations
+    --calendar-name NAME          Sync every destination to the calendar NAME instead of its
+                                  configured calendars and routes, e.g. a throwaway test calendar
+    --profile NAME                Use the settings and destinations of the named profile
+                                  from the config file's "profiles" map (optional)
+    --strict-config=false         Ignore unknown keys in the config file instead of failing
+                                  on them (default: true)
     --work-token-path PATH        Path to store the work account OAuth token
                                   (overrides config file and WORK_TOKEN_PATH env var)
     --work-email EMAIL            Email of the work account, needed for checking if event was declined
@@ -40,12 +68,44 @@ OPTIONS:
                                   (overrides config file and GOOGLE_CREDENTIALS_PATH env var)
     --include-ooo BOOL            Enable sync of Out of Office events, defaults to false
                                   (overrides config file and INCLUDE_OOO env var)
+    --verify-writes               After syncing, re-read inserted/updated events and report
+                                  any that are missing or differ from what was written
+                                  (overrides config file)
+    --dry-run                     Log the inserts, updates and deletes a sync would make
+                                  without changing any calendar (overrides config file)
+    --insert-only                 Only insert missing events; log the updates and deletes a sync
+                                  would make without applying them (overrides config file)
+    --i-understand-destructive    Allow the first sync to a destination to delete events that are
+                                  not in the work calendar; later runs remember the acknowledgement
+    --full-resync                 List all work events again instead of only those changed since
+                                  the last run, when sync_state_path is set
+    --concurrency N               Sync up to N destinations at the same time (default: 1)
+    --summary-json FILE           After all destinations are processed, write a JSON summary of the
+                                  run (per-destination counts, sync window, timestamps and errors)
+    --save-snapshot FILE          Save the events of the --destination calendar to a JSON snapshot
+                                  file and exit, without syncing
+    --diff-snapshot FILE          Log the changes a sync to --destination would make relative to a
+                                  snapshot saved with --save-snapshot; implies --dry-run and never
+                                  reads or writes the live destination calendar
+    --serve ADDR                  Keep running after the sync to --destination, re-syncing every
+                                  --serve-interval, and serve its calendar as an iCalendar feed at
+                                  http://ADDR/calendar.ics for apps that subscribe to ICS URLs
+    --serve-interval DURATION     How often --serve re-syncs, e.g. 30m (default: 15m)
+    --preview ADDR                Serve http://ADDR/preview without syncing: a JSON document of the
+                                  work events a sync to --destination would copy and the inserts,
+                                  updates and deletes it would make, worked out by a dry run for
+                                  each request. --serve serves /preview as well
+    --check-auth                  Check the credentials of the work account and every destination
+                                  with a cheap authenticated call, without starting the browser
+                                  flow; print OK, EXPIRED or ERROR for each and exit, non-zero
+                                  if any failed
 
 CONFIGURATION PRECEDENCE (highest to lowest):
     1. Command-line flags
     2. Environment variables (WORK_TOKEN_PATH, WORK_EMAIL, GOOGLE_CREDENTIALS_PATH, SYNC_WINDOW_WEEKS, SYNC_WINDOW_WEEKS_PAST)
-    3. Config file (--config)
-    4. Defaults
+    3. Selected profile in the config file (--profile)
+    4. Config file (--config)
+    5. Defaults
 
 CONFIG FILE:
     All settings must be specified in a JSON config file. The destinations array
@@ -57,6 +117,7 @@ CONFIG FILE:
       "sync_window_weeks": 2,
       "sync_window_weeks_past": 0,
       "include_ooo": false,
+      "verify_writes": false,
       "destinations": [
         {
           "name": "Personal Google",
@@ -97,7 +158,7 @@ ENVIRONMENT VARIABLES:
 
 DESCRIPTION:
     This tool performs a one-way sync from your work Google Calendar to one or more
-    destination calendars (Google Calendar or Apple Calendar/iCloud). It creates a
+    destination calendars (Google Calendar, Apple Calendar/iCloud or Outlook). It creates a
     separate "Work Sync" calendar in each destination account and populates it with
     filtered events from your work calendar.
 
@@ -124,6 +185,7 @@ DESCRIPTION:
     - Work account: OAuth 2.0 (you'll be prompted on first run)
     - Google Calendar destinations: OAuth 2.0 (you'll be prompted on first run)
     - Apple Calendar destinations: App-specific password (no OAuth)
+    - Outlook destinations: Microsoft OAuth 2.0 (you'll be prompted on first run)
 
     Interactive vs Non-Interactive Mode:
     - When run interactively (from a terminal), the tool will prompt for confirmation
@@ -139,19 +201,433 @@ EXAMPLES:
     # Sync only to a specific destination
     %s --config /path/to/config.json --destination "Personal Google Calendar"
 
+    # Sync using the settings and destinations of the "side-gig" profile
+    %s --config /path/to/config.json --profile side-gig
+
     # Run the sync with config file, but override credentials path via environment
     GOOGLE_CREDENTIALS_PATH="/path/to/creds.json" %s --config /path/to/config.json
 
     # Run the sync with config file, overriding work token path
     %s --config /path/to/config.json --work-token-path /path/to/work_token.json
 
+    # Save a snapshot of a destination, then later review what a sync would change against it
+    %s --config /path/to/config.json --destination "Personal Google" --save-snapshot snapshot.json
+    %s --config /path/to/config.json --destination "Personal Google" --diff-snapshot snapshot.json
+
+    # Serve a destination as a subscribable ICS feed, re-syncing every 30 minutes
+    %s --config /path/to/config.json --destination "Personal Google" --serve :8090 --serve-interval 30m
+
+    # Check that every account's credentials still work, e.g. before scheduling syncs
+    %s --config /path/to/config.json --check-auth
+
+    # Check that the token-refresh reminder shows up in a destination calendar
+    %s test-reminder --config /path/to/config.json --destination "Personal Google"
+
+    # Check that events can be written to a destination calendar
+    %s selftest --config /path/to/config.json --destination "iCloud"
+
+    # Empty a destination calendar over the sync window, e.g. before re-syncing it
+    %s purge --config /path/to/config.json --destination "iCloud" --i-understand-destructive
+
     # Show help
     %s --help
 
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
+
+// newGoogleOAuthConfig returns the OAuth2 configuration for Google Calendar
+// access with the given scopes.
+func newGoogleOAuthConfig(clientID, clientSecret string, scopes []string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  "http://127.0.0.1:8080", // Will be updated dynamically by auth flow
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+	}
+}
+
+// workAuthenticatedClient returns the HTTP client for the work account: as
+// the impersonated user through the service account when one is configured,
+// and otherwise through the OAuth token at work_token_path.
+func workAuthenticatedClient(ctx context.Context, cfg *config.Config, workOAuthConfig *oauth2.Config) (*http.Client, error) {
+	if cfg.ServiceAccountKeyPath != "" {
+		return auth.GetServiceAccountClient(ctx, cfg.ServiceAccountKeyPath, cfg.ImpersonateSubject, cfg.WorkOAuthScopes)
+	}
+	workTokenStore, err := auth.NewTokenStore(cfg.TokenStore, cfg.WorkTokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open work token store: %w", err)
+	}
+	return auth.GetAuthenticatedClient(ctx, workOAuthConfig, workTokenStore)
+}
+
+// newOutlookOAuthConfig returns the OAuth2 configuration for Microsoft Graph
+// calendar access. offline_access is needed to receive a refresh token.
+func newOutlookOAuthConfig(cfg *config.Config) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.OutlookClientID,
+		ClientSecret: cfg.OutlookClientSecret,
+		RedirectURL:  "http://127.0.0.1:8080", // Will be updated dynamically by auth flow
+		Scopes: []string{
+			"offline_access",
+			"https://graph.microsoft.com/Calendars.ReadWrite",
+		},
+		Endpoint: microsoft.AzureADEndpoint("common"),
+	}
+}
+
+// newDestinationClient creates the calendar client for a destination based on its type.
+func newDestinationClient(ctx context.Context, cfg *config.Config, dest config.Destination, googleOAuthConfig *oauth2.Config) (calclient.CalendarClient, error) {
+	if dest.Type == "outlook" {
+		outlookTokenStore, err := auth.NewTokenStore(cfg.TokenStore, dest.OutlookTokenPath)
+		if err != nil {
+			return nil, err
+		}
+		outlookHTTPClient, err := auth.GetAuthenticatedClient(ctx, newOutlookOAuthConfig(cfg), outlookTokenStore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+		client, err := calclient.NewOutlookCalendarClient(ctx, outlookHTTPClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Outlook calendar client: %w", err)
+		}
+		return client, nil
+	}
+	if dest.Type == "apple" && dest.AuthMode == "oauth" {
+		// CalDAV with OAuth bearer tokens (e.g. Google's CalDAV endpoint)
+		tokenStore, err := auth.NewTokenStore(cfg.TokenStore, dest.TokenPath)
+		if err != nil {
+			return nil, err
+		}
+		tokenSource, err := auth.GetTokenSource(ctx, googleOAuthConfig, tokenStore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+		client, err := calclient.NewAppleCalendarClientWithTokenSource(ctx, dest.ServerURL, tokenSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CalDAV client: %w", err)
+		}
+		client.SetRetryPolicy(retryPolicy(cfg))
+		client.SetProductID(cfg.ICalProductID)
+		client.SetColorCategories(cfg.ColorCategories)
+		return client, nil
+	}
+	if dest.IsCalDAV() {
+		password := dest.Password
+		if dest.PasswordSource == "keychain" {
+			keychainPassword, err := auth.KeychainPassword(dest.KeychainService, dest.KeychainAccount)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CalDAV password: %w", err)
+			}
+			password = keychainPassword
+		}
+		if dest.Type == "caldav" {
+			// Any CalDAV server, found through its well-known URL
+			client, err := calclient.NewCalDAVClient(ctx, dest.ServerURL, dest.Username, password)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create CalDAV client: %w", err)
+			}
+			client.SetRetryPolicy(retryPolicy(cfg))
+			client.SetProductID(cfg.ICalProductID)
+			client.SetColorCategories(cfg.ColorCategories)
+			return client, nil
+		}
+		// Create Apple Calendar client using CalDAV
+		client, err := calclient.NewAppleCalendarClient(ctx, dest.ServerURL, dest.Username, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Apple Calendar client: %w", err)
+		}
+		client.SetRetryPolicy(retryPolicy(cfg))
+		client.SetProductID(cfg.ICalProductID)
+		client.SetColorCategories(cfg.ColorCategories)
+		return client, nil
+	}
+
+	// Google Calendar
+	personalTokenStore, err := auth.NewTokenStore(cfg.TokenStore, dest.TokenPath)
+	if err != nil {
+		return nil, err
+	}
+	personalHTTPClient, err := auth.GetAuthenticatedClient(ctx, googleOAuthConfig, personalTokenStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	client, err := calclient.NewClient(ctx, personalHTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar client: %w", err)
+	}
+	client.SetRetryPolicy(retryPolicy(cfg))
+	client.SetListAllAttendees(cfg.CopyAttendees)
+	client.SetDuplicateCalendarPolicy(cfg.DuplicateCalendars)
+	return client, nil
+}
+
+// retryPolicy returns the configured policy for retrying transient API errors.
+func retryPolicy(cfg *config.Config) calclient.RetryPolicy {
+	return calclient.RetryPolicy{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  time.Duration(cfg.RetryBaseDelaySeconds) * time.Second,
+	}
+}
+
+// runTestReminder writes the token-refresh reminder event to a single destination
+// immediately, for the "test-reminder" command.
+func runTestReminder(ctx context.Context, cfg *config.Config, destinationName string, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	if destinationName == "" {
+		return fmt.Errorf("test-reminder requires --destination NAME")
+	}
+	for _, dest := range cfg.Destinations {
+		if dest.Name != destinationName {
+			continue
+		}
+		personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+		if err != nil {
+			return err
+		}
+		// The work calendar is not needed to write the reminder
+		syncer := sync.NewSyncer(nil, personalClient, cfg, &dest, verbose)
+		return syncer.SendTestReminder(ctx)
+	}
+	return fmt.Errorf("destination '%s' not found in config. Available destinations: %v", destinationName, getDestinationNames(cfg.Destinations))
+}
+
+// runSelfTest runs the self-test against a single destination, for the
+// "selftest" command, printing the outcome of each step. It fails if any step did.
+func runSelfTest(ctx context.Context, cfg *config.Config, destinationName string, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	if destinationName == "" {
+		return fmt.Errorf("selftest requires --destination NAME")
+	}
+	for _, dest := range cfg.Destinations {
+		if dest.Name != destinationName {
+			continue
+		}
+		personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+		if err != nil {
+			return err
+		}
+		// The work calendar is not needed to write the test event
+		syncer := sync.NewSyncer(nil, personalClient, cfg, &dest, verbose)
+		failed := 0
+		for _, step := range syncer.SelfTest(ctx) {
+			switch {
+			case step.Skipped:
+				fmt.Printf("SKIP  %s\n", step.Name)
+			case step.Err != nil:
+				fmt.Printf("FAIL  %s: %v\n", step.Name, step.Err)
+				failed++
+			default:
+				fmt.Printf("PASS  %s\n", step.Name)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d step(s) failed against destination '%s'", failed, dest.Name)
+		}
+		log.Printf("[%s] Self-test passed", dest.Name)
+		return nil
+	}
+	return fmt.Errorf("destination '%s' not found in config. Available destinations: %v", destinationName, getDestinationNames(cfg.Destinations))
+}
+
+// runPurge deletes every event in a single destination calendar within the
+// sync window, for the "purge" command. Unless it is a dry run, the deletion
+// has to be acknowledged with --i-understand-destructive.
+func runPurge(ctx context.Context, cfg *config.Config, destinationName string, acknowledged bool, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	if destinationName == "" {
+		return fmt.Errorf("purge requires --destination NAME")
+	}
+	if !acknowledged && !cfg.DryRun {
+		return fmt.Errorf("purge deletes every event in the sync window, including ones you added; pass --i-understand-destructive, or --dry-run to count them")
+	}
+	for _, dest := range cfg.Destinations {
+		if dest.Name != destinationName {
+			continue
+		}
+		personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+		if err != nil {
+			return err
+		}
+		// The work calendar is not needed to empty the destination
+		syncer := sync.NewSyncer(nil, personalClient, cfg, &dest, verbose)
+		_, err = syncer.Purge(ctx)
+		return err
+	}
+	return fmt.Errorf("destination '%s' not found in config. Available destinations: %v", destinationName, getDestinationNames(cfg.Destinations))
+}
+
+// runSaveSnapshot saves the events of a single destination calendar to a
+// snapshot file, for the --save-snapshot flag.
+func runSaveSnapshot(ctx context.Context, cfg *config.Config, destinationName, path string, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	if destinationName == "" {
+		return fmt.Errorf("--save-snapshot requires --destination NAME")
+	}
+	for _, dest := range cfg.Destinations {
+		if dest.Name != destinationName {
+			continue
+		}
+		personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+		if err != nil {
+			return err
+		}
+		// The work calendar is not needed to read the destination
+		syncer := sync.NewSyncer(nil, personalClient, cfg, &dest, verbose)
+		snapshot, err := syncer.TakeSnapshot(ctx)
+		if err != nil {
+			return err
+		}
+		if err := calclient.SaveSnapshot(path, snapshot); err != nil {
+			return err
+		}
+		log.Printf("[%s] Saved %d event(s) to snapshot %s", dest.Name, len(snapshot.Events), path)
+		return nil
+	}
+	return fmt.Errorf("destination '%s' not found in config. Available destinations: %v", destinationName, getDestinationNames(cfg.Destinations))
+}
+
+// authCheck is the outcome of checking one account's credentials, for the
+// --check-auth flag.
+type authCheck struct {
+	Account string
+	Err     error
+}
+
+// authStatus returns the status an authCheck is reported with: OK, EXPIRED
+// for a token that can no longer be refreshed, or ERROR.
+func authStatus(err error) string {
+	var expired *auth.TokenExpiredError
+	switch {
+	case err == nil:
+		return "OK"
+	case errors.As(err, &expired):
+		return "EXPIRED"
+	default:
+		return "ERROR"
+	}
+}
+
+// writeAuthChecks prints checks as a table and returns how many failed.
+func writeAuthChecks(w io.Writer, checks []authCheck) int {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ACCOUNT\tSTATUS\tDETAIL")
+	failed := 0
+	for _, check := range checks {
+		status := authStatus(check.Err)
+		detail := ""
+		switch status {
+		case "EXPIRED":
+			detail = "run a sync interactively to authorize again"
+		case "ERROR":
+			detail = check.Err.Error()
+		}
+		if check.Err != nil {
+			failed++
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", check.Account, status, detail)
+	}
+	table.Flush()
+	return failed
+}
+
+// runCheckAuth checks the credentials of the work account and of every
+// enabled destination with a cheap authenticated call, for the --check-auth
+// flag, without starting the interactive flow for any of them. It fails if
+// any check did.
+func runCheckAuth(ctx context.Context, cfg *config.Config, destinations []config.Destination, workOAuthConfig, googleOAuthConfig *oauth2.Config) error {
+	checks := []authCheck{{Account: "work", Err: checkWorkAuth(ctx, cfg, workOAuthConfig)}}
+	for _, dest := range destinations {
+		checks = append(checks, authCheck{
+			Account: fmt.Sprintf("%s (%s)", dest.Name, dest.Type),
+			Err:     checkDestinationAuth(ctx, cfg, dest, googleOAuthConfig),
+		})
+	}
+	if failed := writeAuthChecks(os.Stdout, checks); failed > 0 {
+		return fmt.Errorf("%d of %d account(s) failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkTokenSource returns a token source for the token at path, without
+// starting the interactive flow.
+func checkTokenSource(ctx context.Context, cfg *config.Config, oauthConfig *oauth2.Config, path string) (oauth2.TokenSource, error) {
+	tokenStore, err := auth.NewTokenStore(cfg.TokenStore, path)
+	if err != nil {
+		return nil, err
+	}
+	return auth.CheckTokenSource(ctx, oauthConfig, tokenStore)
+}
+
+// checkWorkAuth reads the first work calendar with the work account's
+// credentials.
+func checkWorkAuth(ctx context.Context, cfg *config.Config, workOAuthConfig *oauth2.Config) error {
+	var tokenSource oauth2.TokenSource
+	var err error
+	if cfg.ServiceAccountKeyPath != "" {
+		tokenSource, err = auth.ServiceAccountTokenSource(ctx, cfg.ServiceAccountKeyPath, cfg.ImpersonateSubject, cfg.WorkOAuthScopes)
+	} else {
+		tokenSource, err = checkTokenSource(ctx, cfg, workOAuthConfig, cfg.WorkTokenPath)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := calclient.NewClient(ctx, oauth2.NewClient(ctx, tokenSource))
+	if err != nil {
+		return err
+	}
+	_, err = client.GetCalendar(cfg.SourceCalendarIDs()[0])
+	return err
+}
+
+// checkDestinationAuth lists a destination's calendars with its credentials:
+// Google and Outlook look up the destination calendar, which needn't exist
+// yet, and CalDAV clients find the calendars through PROPFIND when created.
+func checkDestinationAuth(ctx context.Context, cfg *config.Config, dest config.Destination, googleOAuthConfig *oauth2.Config) error {
+	var client calclient.CalendarClient
+	switch {
+	case dest.Type == "outlook":
+		tokenSource, err := checkTokenSource(ctx, cfg, newOutlookOAuthConfig(cfg), dest.OutlookTokenPath)
+		if err != nil {
+			return err
+		}
+		if client, err = calclient.NewOutlookCalendarClient(ctx, oauth2.NewClient(ctx, tokenSource)); err != nil {
+			return err
+		}
+	case dest.Type == "apple" && dest.AuthMode == "oauth":
+		tokenSource, err := checkTokenSource(ctx, cfg, googleOAuthConfig, dest.TokenPath)
+		if err != nil {
+			return err
+		}
+		_, err = calclient.NewAppleCalendarClientWithTokenSource(ctx, dest.ServerURL, tokenSource)
+		return err
+	case dest.IsCalDAV():
+		// Password authentication has no token to expire
+		_, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+		return err
+	default:
+		tokenSource, err := checkTokenSource(ctx, cfg, googleOAuthConfig, dest.TokenPath)
+		if err != nil {
+			return err
+		}
+		if client, err = calclient.NewClient(ctx, oauth2.NewClient(ctx, tokenSource)); err != nil {
+			return err
+		}
+	}
+	if _, err := client.FindCalendarByName(dest.CalendarName); err != nil && !errors.Is(err, calclient.ErrCalendarNotFound) {
+		return err
+	}
+	return nil
 }
 
 func main() {
+	// A leading "test-reminder", "selftest" or "purge" selects that command instead of a sync
+	command := ""
+	if len(os.Args) > 1 && (os.Args[1] == "test-reminder" || os.Args[1] == "selftest" || os.Args[1] == "purge") {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	// Parse command-line flags
 	helpFlag := flag.Bool("help", false, "Show help message")
 	helpFlagShort := flag.Bool("h", false, "Show help message (shorthand)")
@@ -159,10 +635,26 @@ func main() {
 	verboseFlagShort := flag.Bool("v", false, "Enable verbose output (shorthand)")
 	configFile := flag.String("config", "", "Path to JSON config file (required)")
 	destinationName := flag.String("destination", "", "Sync only to the named destination (optional)")
+	calendarName := flag.String("calendar-name", "", "Sync every destination to this calendar name instead of its configured calendars (optional)")
+	profileName := flag.String("profile", "", "Use the named profile from the config file (optional)")
+	strictConfig := flag.Bool("strict-config", true, "Fail on unknown keys in the config file; set to false to ignore them")
 	workTokenPath := flag.String("work-token-path", "", "Path to store the work account OAuth token (overrides config file and WORK_TOKEN_PATH env var)")
 	workEmail := flag.String("work-email", "", "Email of the work account, needed for checking if event was declined (overrides config file and WORK_TOKEN_PATH env var)")
 	googleCredentialsPath := flag.String("google-credentials-path", "", "Path to Google OAuth credentials JSON file (overrides config file and GOOGLE_CREDENTIALS_PATH env var)")
 	includeOOO := flag.Bool("include-ooo", false, "Enable sync of Out of Office events, defaults to false (overrides config file and INCLUDE_OOO env var)")
+	verifyWrites := flag.Bool("verify-writes", false, "Re-read written events after syncing and report any that did not round-trip (overrides config file)")
+	dryRun := flag.Bool("dry-run", false, "Log planned changes without modifying any calendar (overrides config file)")
+	insertOnly := flag.Bool("insert-only", false, "Only insert missing events, logging the updates and deletes a sync would make (overrides config file)")
+	acknowledgeDestructive := flag.Bool("i-understand-destructive", false, "Allow the first sync to a destination to delete events that are not in the work calendar")
+	saveSnapshot := flag.String("save-snapshot", "", "Save the --destination calendar's events to a JSON snapshot file and exit")
+	fullResync := flag.Bool("full-resync", false, "Ignore the stored sync tokens and list all work events again")
+	concurrency := flag.Int("concurrency", 1, "Number of destinations to sync at the same time")
+	summaryJSON := flag.String("summary-json", "", "Write a JSON summary of the run to this file after all destinations are processed")
+	diffSnapshot := flag.String("diff-snapshot", "", "Dry-run the sync to --destination against a JSON snapshot file instead of the live calendar")
+	serveAddr := flag.String("serve", "", "Keep re-syncing --destination and serve its calendar as an ICS feed on this address, e.g. :8090")
+	checkAuth := flag.Bool("check-auth", false, "Check the credentials of the work account and every destination, print a table of the results and exit")
+	previewAddr := flag.String("preview", "", "Serve a JSON preview of the next sync to --destination at /preview on this address, without syncing, e.g. :8091")
+	serveInterval := flag.Duration("serve-interval", 15*time.Minute, "How often --serve re-syncs the destination")
 	flag.Parse()
 
 	verbose := *verboseFlag || *verboseFlagShort
@@ -176,17 +668,77 @@ func main() {
 	// Set up logging
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	ctx := context.Background()
+	// Cancel the context on SIGINT/SIGTERM so a running sync can stop cleanly
+	// after the current event operation instead of dying mid-write
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restore default signal handling after the first signal so a second
+	// Ctrl-C kills the process even if something ignores the context
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	// Load configuration (precedence: flags > env vars > config file > defaults)
 	if *configFile == "" {
 		log.Fatalf("--config FILE is required. Use --help for more information.")
 	}
-	cfg, err := config.LoadConfig(*configFile, *workTokenPath, *workEmail, *googleCredentialsPath, *includeOOO)
+	config.StrictKeys = *strictConfig
+	cfg, err := config.LoadConfig(*configFile, config.Overrides{
+		Profile:               *profileName,
+		WorkTokenPath:         *workTokenPath,
+		WorkEmail:             *workEmail,
+		GoogleCredentialsPath: *googleCredentialsPath,
+		IncludeOOO:            *includeOOO,
+	})
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if *calendarName != "" {
+		cfg.OverrideCalendarName(*calendarName)
+	}
+	if *verifyWrites {
+		cfg.VerifyWrites = true
+	}
+	if *dryRun {
+		cfg.DryRun = true
+	}
+	if *insertOnly {
+		cfg.SafeMode = true
+	}
+	if *acknowledgeDestructive {
+		cfg.AcknowledgeDestructive = true
+	}
+	if *fullResync {
+		cfg.FullResync = true
+	}
+
+	if *concurrency < 1 {
+		log.Fatalf("--concurrency must be at least 1, got %d", *concurrency)
+	}
+	if *serveAddr != "" {
+		if *destinationName == "" {
+			log.Fatalf("--serve requires --destination NAME")
+		}
+		if *diffSnapshot != "" {
+			log.Fatalf("--serve can't be combined with --diff-snapshot")
+		}
+		if *serveInterval <= 0 {
+			log.Fatalf("--serve-interval must be positive, got %v", *serveInterval)
+		}
+	}
+	if *previewAddr != "" {
+		if *destinationName == "" {
+			log.Fatalf("--preview requires --destination NAME")
+		}
+		if *serveAddr != "" || *diffSnapshot != "" {
+			log.Fatalf("--preview can't be combined with --serve, which serves /preview too, or --diff-snapshot")
+		}
+	}
+
+	auth.OAuthTimeout = time.Duration(cfg.OAuthTimeoutMinutes) * time.Minute
+
 	if cfg.WorkEmail == "" {
 		log.Printf("WARNING: work email not configured, won't be able to check if event was declined")
 	}
@@ -198,25 +750,62 @@ func main() {
 		log.Fatalf("Failed to load Google credentials: %v", err)
 	}
 
-	googleOAuthConfig := &oauth2.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURL:  "http://127.0.0.1:8080", // Will be updated dynamically by auth flow
-		Scopes: []string{
-			"https://www.googleapis.com/auth/calendar",
-			"https://www.googleapis.com/auth/calendar.events",
-		},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
-			TokenURL: "https://oauth2.googleapis.com/token",
-		},
+	// The work account and Google destinations may be granted different scopes
+	workOAuthConfig := newGoogleOAuthConfig(clientID, clientSecret, cfg.WorkOAuthScopes)
+	googleOAuthConfig := newGoogleOAuthConfig(clientID, clientSecret, cfg.DestinationOAuthScopes)
+
+	switch command {
+	case "test-reminder":
+		if err := runTestReminder(ctx, cfg, *destinationName, googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Failed to send test reminder: %v", err)
+		}
+		return
+	case "selftest":
+		if err := runSelfTest(ctx, cfg, *destinationName, googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Self-test failed: %v", err)
+		}
+		return
+	case "purge":
+		if err := runPurge(ctx, cfg, *destinationName, *acknowledgeDestructive, googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Purge failed: %v", err)
+		}
+		return
 	}
 
-	// Create the work token store (always Google)
-	workTokenStore := auth.NewFileTokenStore(cfg.WorkTokenPath)
+	if *checkAuth {
+		if err := runCheckAuth(ctx, cfg, enabledDestinations(cfg.Destinations), workOAuthConfig, googleOAuthConfig); err != nil {
+			log.Fatalf("Auth check failed: %v", err)
+		}
+		return
+	}
+
+	if *saveSnapshot != "" {
+		if err := runSaveSnapshot(ctx, cfg, *destinationName, *saveSnapshot, googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Failed to save snapshot: %v", err)
+		}
+		return
+	}
+
+	// Diffing against a snapshot is a dry run of a single destination that reads
+	// the snapshot in place of the live calendar
+	var snapshotClient *calclient.SnapshotCalendarClient
+	if *diffSnapshot != "" {
+		if *destinationName == "" {
+			log.Fatalf("--diff-snapshot requires --destination NAME")
+		}
+		snapshot, err := calclient.LoadSnapshot(*diffSnapshot)
+		if err != nil {
+			log.Fatalf("Failed to load snapshot: %v", err)
+		}
+		if snapshot.Destination != *destinationName {
+			log.Printf("WARNING: snapshot %s was taken of destination '%s', not '%s'", *diffSnapshot, snapshot.Destination, *destinationName)
+		}
+		snapshotClient = calclient.NewSnapshotCalendarClient(snapshot)
+		cfg.DryRun = true
+	}
 
 	// Get the authenticated work client (always Google)
-	workHTTPClient, err := auth.GetAuthenticatedClient(ctx, googleOAuthConfig, workTokenStore)
+	workHTTPClient, err := workAuthenticatedClient(ctx, cfg, workOAuthConfig)
 	if err != nil {
 		log.Fatalf("Failed to authenticate work account: %v", err)
 	}
@@ -226,6 +815,18 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create work calendar client: %v", err)
 	}
+	workClient.SetRetryPolicy(retryPolicy(cfg))
+	workClient.SetListAllAttendees(cfg.CopyAttendees)
+	workClient.SetListCancelled(cfg.KeepCancelled)
+
+	// Fail fast on a misconfigured source calendar, rather than syncing nothing
+	for _, calendarID := range cfg.SourceCalendarIDs() {
+		sourceCalendarName, err := workClient.GetCalendar(calendarID)
+		if err != nil {
+			log.Fatalf("Failed to find work calendar '%s': %v", calendarID, err)
+		}
+		log.Printf("Syncing from work calendar: %s", sourceCalendarName)
+	}
 
 	// Filter destinations if --destination flag is provided
 	destinations := cfg.Destinations
@@ -246,50 +847,102 @@ func main() {
 		log.Printf("Syncing only to destination: %s", *destinationName)
 	}
 
-	// Sync to selected destinations
+	destinations = enabledDestinations(destinations)
+
+	if *serveAddr != "" {
+		if len(destinations) == 0 {
+			log.Fatalf("Destination '%s' is disabled", *destinationName)
+		}
+		if err := runServe(ctx, *serveAddr, *serveInterval, workClient, cfg, destinations[0], googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Failed to serve calendar: %v", err)
+		}
+		return
+	}
+	if *previewAddr != "" {
+		if len(destinations) == 0 {
+			log.Fatalf("Destination '%s' is disabled", *destinationName)
+		}
+		if err := runPreview(ctx, *previewAddr, workClient, cfg, destinations[0], googleOAuthConfig, verbose); err != nil {
+			log.Fatalf("Failed to serve preview: %v", err)
+		}
+		return
+	}
+
+	// Create each destination's client up front, one at a time, as creating a
+	// client may run an interactive OAuth flow
 	var syncErrors []error
+	summary := &runSummary{StartedAt: time.Now()}
+	var runs []destinationRun
 	for _, dest := range destinations {
-		log.Printf("Syncing to destination: %s (type: %s)", dest.Name, dest.Type)
-
-		// Create the destination calendar client based on destination type
-		var personalClient calclient.CalendarClient
-		if dest.Type == "apple" {
-			// Create Apple Calendar client using CalDAV
-			personalClient, err = calclient.NewAppleCalendarClient(ctx, dest.ServerURL, dest.Username, dest.Password)
+		var personalClient calclient.CalendarClient = snapshotClient
+		if snapshotClient == nil {
+			personalClient, err = newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
 			if err != nil {
-				log.Printf("[%s] Failed to create Apple Calendar client: %v", dest.Name, err)
-				syncErrors = append(syncErrors, fmt.Errorf("%s: %w", dest.Name, err))
-				continue
-			}
-		} else {
-			// Google Calendar
-			personalTokenStore := auth.NewFileTokenStore(dest.TokenPath)
-			personalHTTPClient, err := auth.GetAuthenticatedClient(ctx, googleOAuthConfig, personalTokenStore)
-			if err != nil {
-				log.Printf("[%s] Failed to authenticate: %v", dest.Name, err)
+				log.Printf("[%s] %v", dest.Name, err)
 				syncErrors = append(syncErrors, fmt.Errorf("%s: %w", dest.Name, err))
+				summary.add(dest.Name, nil, err)
 				continue
 			}
+		}
+		runs = append(runs, destinationRun{dest: dest, client: personalClient})
+	}
 
-			personalClient, err = calclient.NewClient(ctx, personalHTTPClient)
-			if err != nil {
-				log.Printf("[%s] Failed to create calendar client: %v", dest.Name, err)
-				syncErrors = append(syncErrors, fmt.Errorf("%s: %w", dest.Name, err))
-				continue
+	// Fetch the work events once and share them across the destinations
+	var sourceEvents []*calendar.Event
+	if len(runs) > 0 {
+		runDestinations := make([]config.Destination, len(runs))
+		for i, run := range runs {
+			runDestinations[i] = run.dest
+		}
+		sourceEvents, err = sync.FetchSourceEvents(workClient, cfg, runDestinations)
+		if err != nil {
+			// Every destination needs the work events, so none can sync
+			log.Printf("Failed to fetch work events: %v", err)
+			for _, run := range runs {
+				syncErrors = append(syncErrors, fmt.Errorf("%s: %w", run.dest.Name, err))
+				summary.add(run.dest.Name, nil, err)
 			}
+			runs = nil
 		}
+	}
 
-		// Create the Syncer for this destination
-		syncer := sync.NewSyncer(workClient, personalClient, cfg, &dest, verbose)
+	// Sync to selected destinations, up to --concurrency at a time
+	// Destinations on the same account share resolved calendar IDs
+	calendarCache := calclient.NewCalendarIDCache()
+	var mu stdsync.Mutex
+	var wg stdsync.WaitGroup
+	queue := make(chan destinationRun)
+	for range min(*concurrency, len(runs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range queue {
+				// Once interrupted, destinations that haven't started are skipped
+				if ctx.Err() != nil {
+					continue
+				}
+				result, err := syncDestination(ctx, workClient, run.client, cfg, run.dest, sourceEvents, calendarCache, verbose)
+				mu.Lock()
+				summary.add(run.dest.Name, result, err)
+				if err != nil {
+					syncErrors = append(syncErrors, fmt.Errorf("%s: %w", run.dest.Name, err))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, run := range runs {
+		queue <- run
+	}
+	close(queue)
+	wg.Wait()
 
-		// Run the sync
-		if err := syncer.Sync(ctx); err != nil {
-			log.Printf("[%s] Sync failed: %v", dest.Name, err)
-			syncErrors = append(syncErrors, fmt.Errorf("%s: %w", dest.Name, err))
-			continue
+	if *summaryJSON != "" {
+		summary.FinishedAt = time.Now()
+		if err := summary.write(*summaryJSON); err != nil {
+			log.Printf("Failed to write run summary: %v", err)
+			syncErrors = append(syncErrors, err)
 		}
-
-		log.Printf("[%s] Sync completed successfully.", dest.Name)
 	}
 
 	// Report results
@@ -304,6 +957,271 @@ func main() {
 	log.Printf("All syncs completed successfully (%d destination(s))", len(destinations))
 }
 
+// destinationRun is a destination ready to sync, with its calendar client.
+type destinationRun struct {
+	dest   config.Destination
+	client calclient.CalendarClient
+}
+
+// syncDestination syncs the work events to one destination, logging the
+// outcome. It returns the sync result, which may be partial or nil, and the
+// error to report for the destination, if any.
+func syncDestination(ctx context.Context, workClient, personalClient calclient.CalendarClient, cfg *config.Config, dest config.Destination, sourceEvents []*calendar.Event, calendarCache *calclient.CalendarIDCache, verbose bool) (*sync.SyncResult, error) {
+	log.Printf("Syncing to destination: %s (type: %s)", dest.Name, dest.Type)
+
+	// Create the Syncer for this destination, reading the shared work events
+	syncer := sync.NewSyncerWithSource(workClient, personalClient, cfg, &dest, sourceEvents, verbose)
+	syncer.SetCalendarCache(calendarCache)
+
+	// Run the sync
+	result, err := syncer.Sync(ctx)
+	if err != nil && errors.Is(err, context.Canceled) {
+		// Interrupted by a signal: report what was done
+		if result != nil {
+			log.Printf("[%s] Partial sync: inserted %d, updated %d, deleted %d before interruption",
+				dest.Name, result.Inserted, result.Updated, result.Deleted)
+		}
+		return result, err
+	}
+	if err != nil {
+		log.Printf("[%s] Sync failed: %v", dest.Name, err)
+		return result, err
+	}
+
+	if len(result.VerifyFailures) > 0 {
+		return result, fmt.Errorf("%d event(s) failed write verification", len(result.VerifyFailures))
+	}
+
+	log.Printf("[%s] Sync completed successfully.", dest.Name)
+	log.Printf("[%s] API calls: work calendar %v, destination calendar %v", dest.Name, result.WorkCalls, result.DestinationCalls)
+	return result, nil
+}
+
+// runServe syncs a single destination every interval and serves its calendar
+// as an iCalendar feed at /calendar.ics, for the --serve flag. It returns once
+// the context is cancelled.
+func runServe(ctx context.Context, addr string, interval time.Duration, workClient calclient.CalendarClient, cfg *config.Config, dest config.Destination, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+	if err != nil {
+		return err
+	}
+
+	// Listen before the first sync so a bad address fails straight away
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	feed := &icsFeed{}
+	mux := http.NewServeMux()
+	mux.Handle("GET /calendar.ics", feed)
+	mux.Handle("GET /preview", newPreviewHandler(workClient, personalClient, cfg, dest, verbose))
+	server := &http.Server{Handler: mux}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	log.Printf("[%s] Serving the synced calendar at http://%s/calendar.ics, and a preview of the next sync at /preview", dest.Name, listener.Addr())
+
+	calendarCache := calclient.NewCalendarIDCache()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := refreshFeed(ctx, feed, workClient, personalClient, cfg, dest, calendarCache, verbose); err != nil {
+			// The last good feed is served until the next sync
+			log.Printf("[%s] Failed to refresh the served calendar: %v", dest.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		case err := <-serveErr:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshFeed syncs the work events to the destination, then replaces the
+// feed with the destination calendar's events.
+func refreshFeed(ctx context.Context, feed *icsFeed, workClient, personalClient calclient.CalendarClient, cfg *config.Config, dest config.Destination, calendarCache *calclient.CalendarIDCache, verbose bool) error {
+	sourceEvents, err := sync.FetchSourceEvents(workClient, cfg, []config.Destination{dest})
+	if err != nil {
+		return fmt.Errorf("failed to fetch work events: %w", err)
+	}
+	if _, err := syncDestination(ctx, workClient, personalClient, cfg, dest, sourceEvents, calendarCache, verbose); err != nil {
+		return err
+	}
+
+	syncer := sync.NewSyncer(workClient, personalClient, cfg, &dest, verbose)
+	snapshot, err := syncer.TakeSnapshot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the destination calendar: %w", err)
+	}
+	body, err := calclient.EncodeICS(dest.CalendarName, cfg.ICalProductID, snapshot.Events)
+	if err != nil {
+		return err
+	}
+	feed.set(body)
+	log.Printf("[%s] Serving %d event(s)", dest.Name, len(snapshot.Events))
+	return nil
+}
+
+// icsFeed serves the most recently synced calendar as an iCalendar feed.
+type icsFeed struct {
+	mu   stdsync.RWMutex
+	body []byte
+}
+
+// set replaces the served calendar.
+func (f *icsFeed) set(body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.body = body
+}
+
+func (f *icsFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.RLock()
+	body := f.body
+	f.mu.RUnlock()
+	if body == nil {
+		http.Error(w, "calendar has not been synced yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(body)
+}
+
+// runPreview serves a preview of the next sync to a single destination at
+// /preview, for the --preview flag, without syncing. It returns once the
+// context is cancelled.
+func runPreview(ctx context.Context, addr string, workClient calclient.CalendarClient, cfg *config.Config, dest config.Destination, googleOAuthConfig *oauth2.Config, verbose bool) error {
+	personalClient, err := newDestinationClient(ctx, cfg, dest, googleOAuthConfig)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /preview", newPreviewHandler(workClient, personalClient, cfg, dest, verbose))
+	server := &http.Server{Handler: mux}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	log.Printf("[%s] Serving a preview of the next sync at http://%s/preview", dest.Name, listener.Addr())
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-serveErr:
+		return err
+	}
+}
+
+// previewHandler serves, as JSON, the work events a sync to a destination
+// would copy and the changes it would make, worked out afresh by a dry run
+// for each request. Previews are made one at a time.
+type previewHandler struct {
+	mu      stdsync.Mutex
+	preview func(ctx context.Context) (*sync.Preview, error)
+}
+
+// newPreviewHandler returns a previewHandler for a destination.
+func newPreviewHandler(workClient, personalClient calclient.CalendarClient, cfg *config.Config, dest config.Destination, verbose bool) *previewHandler {
+	return &previewHandler{preview: func(ctx context.Context) (*sync.Preview, error) {
+		return sync.NewSyncer(workClient, personalClient, cfg, &dest, verbose).Preview(ctx)
+	}}
+}
+
+func (h *previewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	preview, err := h.preview(r.Context())
+	h.mu.Unlock()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to preview the sync: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(preview); err != nil {
+		log.Printf("[%s] Failed to write the preview: %v", preview.Destination, err)
+	}
+}
+
+// runSummary is the machine-readable result of a run, written by --summary-json.
+type runSummary struct {
+	StartedAt    time.Time            `json:"started_at"`
+	FinishedAt   time.Time            `json:"finished_at"`
+	Destinations []destinationSummary `json:"destinations"`
+}
+
+// destinationSummary is the result of syncing one destination. The counts are
+// those of the changes made before any error.
+type destinationSummary struct {
+	Name        string         `json:"name"`
+	Inserted    int            `json:"inserted"`
+	Updated     int            `json:"updated"`
+	Deleted     int            `json:"deleted"`
+	Skipped     int            `json:"skipped"` // already up to date
+	Failed      int            `json:"failed"`
+	Withheld    int            `json:"withheld"`
+	Filtered    map[string]int `json:"filtered,omitempty"`
+	WindowStart time.Time      `json:"window_start,omitzero"`
+	WindowEnd   time.Time      `json:"window_end,omitzero"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// add records the outcome of syncing a destination. result may be nil if the
+// sync failed before doing anything.
+func (s *runSummary) add(name string, result *sync.SyncResult, err error) {
+	entry := destinationSummary{Name: name}
+	if result != nil {
+		entry.Inserted = result.Inserted
+		entry.Updated = result.Updated
+		entry.Deleted = result.Deleted
+		entry.Skipped = result.Unchanged
+		entry.Failed = result.Failed
+		entry.Withheld = result.Withheld
+		entry.Filtered = result.Filtered
+		entry.WindowStart = result.TimeMin
+		entry.WindowEnd = result.TimeMax
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.Destinations = append(s.Destinations, entry)
+}
+
+// write saves the summary to path as JSON.
+func (s *runSummary) write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run summary: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write run summary: %w", err)
+	}
+	return nil
+}
+
+// enabledDestinations returns the destinations that are not disabled,
+// logging each one that is skipped.
+func enabledDestinations(destinations []config.Destination) []config.Destination {
+	enabled := make([]config.Destination, 0, len(destinations))
+	for _, dest := range destinations {
+		if !dest.IsEnabled() {
+			log.Printf("[%s] Destination is disabled, skipping", dest.Name)
+			continue
+		}
+		enabled = append(enabled, dest)
+	}
+	return enabled
+}
+
 // getDestinationNames returns a slice of destination names from the destinations array.
 func getDestinationNames(destinations []config.Destination) []string {
 	names := make([]string, len(destinations))
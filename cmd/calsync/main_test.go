@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beekhof/calendar-sync/internal/auth"
+	calclient "github.com/beekhof/calendar-sync/internal/calendar"
+	"github.com/beekhof/calendar-sync/internal/config"
+	"github.com/beekhof/calendar-sync/internal/sync"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEnabledDestinations_SkipsDisabled(t *testing.T) {
+	disabled := false
+	enabled := true
+	destinations := []config.Destination{
+		{Name: "Default"},
+		{Name: "Disabled", Enabled: &disabled},
+		{Name: "Enabled", Enabled: &enabled},
+	}
+
+	// Only the returned destinations get a Syncer in main
+	got := getDestinationNames(enabledDestinations(destinations))
+	if len(got) != 2 || got[0] != "Default" || got[1] != "Enabled" {
+		t.Errorf("Expected destinations [Default Enabled], got %v", got)
+	}
+}
+
+func TestWriteAuthChecks(t *testing.T) {
+	checks := []authCheck{
+		{Account: "work"},
+		{Account: "Personal (google)", Err: fmt.Errorf("failed to authenticate: %w", &auth.TokenExpiredError{OriginalError: errors.New("invalid_grant")})},
+		{Account: "iCloud (apple)", Err: errors.New("401 Unauthorized")},
+	}
+
+	var out bytes.Buffer
+	if failed := writeAuthChecks(&out, checks); failed != 2 {
+		t.Errorf("Expected 2 failed checks, got %d", failed)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 rows, got:\n%s", out.String())
+	}
+	for i, want := range []string{"OK", "EXPIRED", "ERROR"} {
+		if fields := strings.Fields(lines[i+1]); !slices.Contains(fields, want) {
+			t.Errorf("Expected %s for %s, got %q", want, checks[i].Account, lines[i+1])
+		}
+	}
+	if !strings.Contains(lines[3], "401 Unauthorized") {
+		t.Errorf("Expected the error in the detail, got %q", lines[3])
+	}
+}
+
+func TestRunSummary_Write(t *testing.T) {
+	windowStart := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	windowEnd := time.Date(2024, 1, 28, 23, 59, 59, 0, time.UTC)
+	summary := &runSummary{StartedAt: windowStart, FinishedAt: windowStart.Add(time.Minute)}
+	summary.add("Personal", &sync.SyncResult{Inserted: 2, Updated: 1, Deleted: 3, Unchanged: 4, TimeMin: windowStart, TimeMax: windowEnd}, nil)
+	summary.add("iCloud", nil, errors.New("failed to authenticate"))
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := summary.write(path); err != nil {
+		t.Fatalf("write() returned an error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	var got struct {
+		Destinations []map[string]any `json:"destinations"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Summary is not valid JSON: %v", err)
+	}
+	if len(got.Destinations) != 2 {
+		t.Fatalf("Expected 2 destinations, got %d", len(got.Destinations))
+	}
+
+	personal := got.Destinations[0]
+	if personal["inserted"] != 2.0 || personal["updated"] != 1.0 || personal["deleted"] != 3.0 || personal["skipped"] != 4.0 {
+		t.Errorf("Expected counts 2/1/3/4, got %v", personal)
+	}
+	if personal["window_start"] != "2024-01-15T00:00:00Z" || personal["window_end"] != "2024-01-28T23:59:59Z" {
+		t.Errorf("Expected the sync window, got %v - %v", personal["window_start"], personal["window_end"])
+	}
+	if _, ok := personal["error"]; ok {
+		t.Errorf("Expected no error for a successful destination, got %v", personal["error"])
+	}
+
+	failed := got.Destinations[1]
+	if failed["error"] != "failed to authenticate" {
+		t.Errorf("Expected the error string, got %v", failed["error"])
+	}
+	if _, ok := failed["window_start"]; ok {
+		t.Errorf("Expected no window for a destination that failed before syncing")
+	}
+}
+
+func TestNewGoogleOAuthConfig_UsesConfiguredScopes(t *testing.T) {
+	scopes := []string{"https://www.googleapis.com/auth/calendar.readonly"}
+	oauthConfig := newGoogleOAuthConfig("client-id", "client-secret", scopes)
+	if !slices.Equal(oauthConfig.Scopes, scopes) {
+		t.Errorf("Expected scopes %v, got %v", scopes, oauthConfig.Scopes)
+	}
+	if oauthConfig.ClientID != "client-id" || oauthConfig.ClientSecret != "client-secret" {
+		t.Errorf("Expected the client credentials to be set, got %q/%q", oauthConfig.ClientID, oauthConfig.ClientSecret)
+	}
+}
+
+func TestICSFeed_ServesLatestCalendar(t *testing.T) {
+	feed := &icsFeed{}
+
+	// Nothing to serve until the first sync finishes
+	recorder := httptest.NewRecorder()
+	feed.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/calendar.ics", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before the first sync, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	feed.set([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	feed.set([]byte("BEGIN:VCALENDAR\r\nX-WR-CALNAME:Work Sync\r\nEND:VCALENDAR\r\n"))
+	recorder = httptest.NewRecorder()
+	feed.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/calendar.ics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/calendar; charset=utf-8" {
+		t.Errorf("Expected Content-Type text/calendar, got %q", contentType)
+	}
+	if body := recorder.Body.String(); body != "BEGIN:VCALENDAR\r\nX-WR-CALNAME:Work Sync\r\nEND:VCALENDAR\r\n" {
+		t.Errorf("Expected the latest calendar to be served, got %q", body)
+	}
+}
+
+func TestPreviewHandler_ServesPlan(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	start := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	// Snapshots stand in for both calendars, and fail any write
+	workClient := calclient.NewSnapshotCalendarClient(&calclient.Snapshot{Events: []*calendar.Event{
+		{Id: "standup", Summary: "Standup", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}})
+	personalClient := calclient.NewSnapshotCalendarClient(&calclient.Snapshot{CalendarName: "Work Sync", Events: []*calendar.Event{
+		{
+			Id:                 "dest-stale",
+			Summary:            "Cancelled Meeting",
+			Start:              &calendar.EventDateTime{DateTime: start},
+			End:                &calendar.EventDateTime{DateTime: end},
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": "stale"}},
+		},
+	}})
+	cfg := &config.Config{SourceCalendarID: "snapshot", SyncWindowWeeks: 2}
+	dest := config.Destination{Name: "Personal", Type: "google", CalendarName: "Work Sync"}
+
+	server := httptest.NewServer(newPreviewHandler(workClient, personalClient, cfg, dest, false))
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/preview")
+	if err != nil {
+		t.Fatalf("GET /preview returned an error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON response, got status %d and Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var preview sync.Preview
+	if err := json.NewDecoder(resp.Body).Decode(&preview); err != nil {
+		t.Fatalf("Failed to decode the preview: %v", err)
+	}
+	if preview.Destination != "Personal" || len(preview.Events) != 1 || preview.Events[0].Summary != "Standup" {
+		t.Errorf("Expected the Standup work event for Personal, got %+v", preview)
+	}
+	expected := []sync.Change{
+		{Action: "delete", EventID: "dest-stale", WorkEventID: "stale", Summary: "Cancelled Meeting"},
+		{Action: "insert", WorkEventID: "standup", Summary: "Standup"},
+	}
+	if !slices.Equal(preview.Changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, preview.Changes)
+	}
+}
+
+func TestRunPurge_RequiresAcknowledgement(t *testing.T) {
+	cfg := &config.Config{Destinations: []config.Destination{{Name: "Personal", Type: "google"}}}
+	// Refused before any client is built, so the missing credentials don't matter
+	err := runPurge(context.Background(), cfg, "Personal", false, nil, false)
+	if err == nil || !strings.Contains(err.Error(), "--i-understand-destructive") {
+		t.Errorf("Expected purge to require --i-understand-destructive, got %v", err)
+	}
+	if err := runPurge(context.Background(), cfg, "", true, nil, false); err == nil || !strings.Contains(err.Error(), "requires --destination") {
+		t.Errorf("Expected purge to require --destination, got %v", err)
+	}
+}
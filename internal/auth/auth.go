@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -14,6 +15,14 @@ import (
 	"golang.org/x/term"
 )
 
+// OAuthTimeout is how long the interactive OAuth flow waits for the user to
+// authorize the application before giving up.
+var OAuthTimeout = 5 * time.Minute
+
+// oauthTimeoutWarning is how long before OAuthTimeout a reminder is printed
+// that the authorization is about to time out.
+const oauthTimeoutWarning = time.Minute
+
 // TokenStore is an interface for saving and loading OAuth tokens.
 type TokenStore interface {
 	SaveToken(token *oauth2.Token) error
@@ -231,6 +240,18 @@ func startLocalServer() (string, <-chan string, <-chan error, error) {
 // If a token has expired and we're running interactively, it will automatically
 // reset the token and launch the authentication flow.
 func GetAuthenticatedClient(ctx context.Context, oauthConfig *oauth2.Config, tokenStore TokenStore) (*http.Client, error) {
+	tokenSource, err := GetTokenSource(ctx, oauthConfig, tokenStore)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(ctx, tokenSource), nil
+}
+
+// GetTokenSource returns an OAuth 2.0 token source for callers that need to
+// authenticate requests themselves (e.g. CalDAV with bearer tokens). It follows
+// the same load/refresh/interactive-flow rules as GetAuthenticatedClient, and
+// refreshed tokens are saved back to tokenStore.
+func GetTokenSource(ctx context.Context, oauthConfig *oauth2.Config, tokenStore TokenStore) (oauth2.TokenSource, error) {
 	// Attempt to load an existing token
 	token, err := tokenStore.LoadToken()
 	if err != nil {
@@ -272,22 +293,51 @@ func GetAuthenticatedClient(ctx context.Context, oauthConfig *oauth2.Config, tok
 		return nil, fmt.Errorf("failed to get token: %w", err)
 	}
 
-	// Token is valid, use it
-	token = testToken
+	// Token is valid, use it, wrapping the token source to auto-save refreshed tokens
+	return newAutoSaveTokenSource(tokenSource, testToken, tokenStore), nil
+}
+
+// ErrNoToken is returned by CheckTokenSource when no token has been stored,
+// as before the account's first authorization.
+var ErrNoToken = errors.New("no token stored; run a sync interactively to authorize")
 
-	// Wrap the token source to auto-save refreshed tokens
-	autoSaveSource := &autoSaveTokenSource{
+// CheckTokenSource returns a token source for the stored token, like
+// GetTokenSource, but never starts the interactive flow or deletes a token: it
+// returns ErrNoToken if there is none, and a *TokenExpiredError if it can't be
+// refreshed.
+func CheckTokenSource(ctx context.Context, oauthConfig *oauth2.Config, tokenStore TokenStore) (oauth2.TokenSource, error) {
+	token, err := tokenStore.LoadToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token: %w", err)
+	}
+	if token == nil {
+		return nil, ErrNoToken
+	}
+
+	tokenSource := oauthConfig.TokenSource(ctx, token)
+	testToken, err := tokenSource.Token()
+	if err != nil {
+		if isTokenExpiredError(err) {
+			return nil, &TokenExpiredError{OriginalError: err}
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return newAutoSaveTokenSource(tokenSource, testToken, tokenStore), nil
+}
+
+// newAutoSaveTokenSource wraps tokenSource so that token is reused until it
+// expires, and refreshed tokens are saved to tokenStore.
+func newAutoSaveTokenSource(tokenSource oauth2.TokenSource, token *oauth2.Token, tokenStore TokenStore) oauth2.TokenSource {
+	return &autoSaveTokenSource{
 		source:     oauth2.ReuseTokenSource(token, tokenSource),
 		tokenStore: tokenStore,
 		lastToken:  token,
 	}
-
-	// Return a new HTTP client using the auto-save token source
-	return oauth2.NewClient(ctx, autoSaveSource), nil
 }
 
-// performOAuthFlow performs the interactive OAuth 2.0 flow.
-func performOAuthFlow(ctx context.Context, oauthConfig *oauth2.Config, tokenStore TokenStore) (*http.Client, error) {
+// performOAuthFlow performs the interactive OAuth 2.0 flow and returns a token
+// source for the new token.
+func performOAuthFlow(ctx context.Context, oauthConfig *oauth2.Config, tokenStore TokenStore) (oauth2.TokenSource, error) {
 	// Start local server to receive callback
 	redirectURL, codeChan, errorChan, err := startLocalServer()
 	if err != nil {
@@ -297,8 +347,10 @@ func performOAuthFlow(ctx context.Context, oauthConfig *oauth2.Config, tokenStor
 	// Update the redirect URL in the config
 	oauthConfig.RedirectURL = redirectURL
 
-	// Generate auth URL
-	authURL := oauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	// Generate auth URL, with a PKCE challenge so an intercepted code is
+	// useless without the verifier
+	verifier := oauth2.GenerateVerifier()
+	authURL := authCodeURL(oauthConfig, verifier)
 
 	fmt.Printf("Starting local server on %s\n", redirectURL)
 	if redirectURL != "http://127.0.0.1:8080" {
@@ -306,25 +358,40 @@ func performOAuthFlow(ctx context.Context, oauthConfig *oauth2.Config, tokenStor
 	}
 	fmt.Println("\nPlease visit the following URL to authorize the application:")
 	printWrappedURL(authURL)
-	fmt.Println("\nWaiting for authorization...")
+	fmt.Printf("\nWaiting for authorization (times out in %s)...\n", OAuthTimeout)
+
+	// Remind the user shortly before the timeout, unless the timeout is too short to bother
+	var warning <-chan time.Time
+	if OAuthTimeout > 2*oauthTimeoutWarning {
+		warningTimer := time.NewTimer(OAuthTimeout - oauthTimeoutWarning)
+		defer warningTimer.Stop()
+		warning = warningTimer.C
+	}
+	timeout := time.NewTimer(OAuthTimeout)
+	defer timeout.Stop()
 
 	// Wait for the authorization code
 	var code string
-	select {
-	case code = <-codeChan:
-		// Successfully received code
-	case err := <-errorChan:
-		return nil, fmt.Errorf("failed to receive authorization code: %w", err)
-	case <-time.After(5 * time.Minute):
-		return nil, fmt.Errorf("authorization timeout: no response received within 5 minutes")
-	}
-
-	if code == "" {
-		return nil, fmt.Errorf("no authorization code received")
+	for code == "" {
+		select {
+		case code = <-codeChan:
+			// Successfully received code
+			if code == "" {
+				return nil, fmt.Errorf("no authorization code received")
+			}
+		case err := <-errorChan:
+			return nil, fmt.Errorf("failed to receive authorization code: %w", err)
+		case <-warning:
+			fmt.Printf("\n⚠️  Still waiting for authorization, %s left before timing out. Complete the sign-in in your browser to continue.\n", oauthTimeoutWarning)
+		case <-timeout.C:
+			return nil, fmt.Errorf("authorization timeout: no response received within %s (increase oauth_timeout_minutes if you need longer)", OAuthTimeout)
+		case <-ctx.Done():
+			return nil, fmt.Errorf("authorization cancelled: %w", ctx.Err())
+		}
 	}
 
 	// Exchange the code for a token
-	token, err := oauthConfig.Exchange(ctx, code)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
 	}
@@ -336,18 +403,13 @@ func performOAuthFlow(ctx context.Context, oauthConfig *oauth2.Config, tokenStor
 
 	fmt.Println("Authorization successful!")
 
-	// Create a token source
-	tokenSource := oauthConfig.TokenSource(ctx, token)
-
-	// Wrap the token source to auto-save refreshed tokens
-	autoSaveSource := &autoSaveTokenSource{
-		source:     oauth2.ReuseTokenSource(token, tokenSource),
-		tokenStore: tokenStore,
-		lastToken:  token,
-	}
+	return newAutoSaveTokenSource(oauthConfig.TokenSource(ctx, token), token, tokenStore), nil
+}
 
-	// Return a new HTTP client using the auto-save token source
-	return oauth2.NewClient(ctx, autoSaveSource), nil
+// authCodeURL returns the URL the user visits to authorize the application,
+// asking for offline access and carrying the S256 PKCE challenge of verifier.
+func authCodeURL(oauthConfig *oauth2.Config, verifier string) string {
+	return oauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
 }
 
 // GetAuthenticatedClientWithReader is a helper function for testing that allows
@@ -359,43 +421,51 @@ func GetAuthenticatedClientWithReader(ctx context.Context, oauthConfig *oauth2.C
 		return nil, fmt.Errorf("failed to load token: %w", err)
 	}
 
-	// If token is nil (first run), perform interactive OAuth flow
-	if token == nil {
-		// Generate auth URL
-		authURL := oauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-
-		fmt.Println("Please visit the following URL to authorize the application:")
-		printWrappedURL(authURL)
-		fmt.Print("Enter the authorization code: ")
-
-		// Read the auth code from the provided reader
-		var code string
-		if _, err := fmt.Fscanln(reader, &code); err != nil {
-			return nil, fmt.Errorf("failed to read authorization code: %w", err)
+	// Check the stored token through the auto-saving source the client will
+	// use, so a refreshed token is saved and reused rather than refreshed
+	// again. An expired token is reset, as GetTokenSource does when
+	// interactive, so both entry points recover from invalid_grant.
+	if token != nil {
+		autoSaveSource := newAutoSaveTokenSource(oauthConfig.TokenSource(ctx, token), token, tokenStore)
+		_, err := autoSaveSource.Token()
+		if err == nil {
+			return oauth2.NewClient(ctx, autoSaveSource), nil
 		}
-
-		// Exchange the code for a token
-		token, err = oauthConfig.Exchange(ctx, code)
-		if err != nil {
-			return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+		var expiredErr *TokenExpiredError
+		if !errors.As(err, &expiredErr) {
+			return nil, fmt.Errorf("failed to get token: %w", err)
 		}
-
-		// Save the new token
-		if err := tokenStore.SaveToken(token); err != nil {
-			return nil, fmt.Errorf("failed to save token: %w", err)
+		fmt.Printf("\n⚠️  OAuth token has expired. Resetting token and launching authentication flow...\n\n")
+		if err := tokenStore.DeleteToken(); err != nil {
+			return nil, fmt.Errorf("failed to delete expired token: %w", err)
 		}
 	}
 
-	// Create a token source
-	tokenSource := oauthConfig.TokenSource(ctx, token)
+	// No token (first run), or it was reset: perform the interactive OAuth flow
+	authURL := oauthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
 
-	// Wrap the token source to auto-save refreshed tokens
-	autoSaveSource := &autoSaveTokenSource{
-		source:     oauth2.ReuseTokenSource(token, tokenSource),
-		tokenStore: tokenStore,
-		lastToken:  token,
+	fmt.Println("Please visit the following URL to authorize the application:")
+	printWrappedURL(authURL)
+	fmt.Print("Enter the authorization code: ")
+
+	// Read the auth code from the provided reader
+	var code string
+	if _, err := fmt.Fscanln(reader, &code); err != nil {
+		return nil, fmt.Errorf("failed to read authorization code: %w", err)
+	}
+
+	// Exchange the code for a token
+	token, err = oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	// Save the new token
+	if err := tokenStore.SaveToken(token); err != nil {
+		return nil, fmt.Errorf("failed to save token: %w", err)
 	}
 
-	// Return a new HTTP client using the auto-save token source
+	// Return a new HTTP client that auto-saves refreshed tokens
+	autoSaveSource := newAutoSaveTokenSource(oauthConfig.TokenSource(ctx, token), token, tokenStore)
 	return oauth2.NewClient(ctx, autoSaveSource), nil
 }
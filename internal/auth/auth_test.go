@@ -2,6 +2,14 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -69,3 +77,209 @@ func TestGetAuthenticatedClient_TokenExists(t *testing.T) {
 	_ = client
 }
 
+
+func TestGetTokenSource_TokenExists(t *testing.T) {
+	ctx := context.Background()
+
+	mockStore := &mockTokenStore{
+		token: &oauth2.Token{
+			AccessToken:  "test-access-token",
+			RefreshToken: "test-refresh-token",
+			Expiry:       time.Now().Add(1 * time.Hour),
+			TokenType:    "Bearer",
+		},
+	}
+	oauthConfig := &oauth2.Config{
+		ClientID:     "test-client-id",
+		ClientSecret: "test-client-secret",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  "https://accounts.google.com/o/oauth2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+		},
+	}
+
+	tokenSource, err := GetTokenSource(ctx, oauthConfig, mockStore)
+	if err != nil {
+		t.Fatalf("GetTokenSource() returned an error: %v", err)
+	}
+	if _, ok := tokenSource.(*autoSaveTokenSource); !ok {
+		t.Errorf("Expected an auto-saving token source, got %T", tokenSource)
+	}
+
+	token, err := tokenSource.Token()
+	if err != nil {
+		t.Fatalf("Token() returned an error: %v", err)
+	}
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("Expected the stored access token, got '%s'", token.AccessToken)
+	}
+}
+
+func TestPerformOAuthFlow_Timeout(t *testing.T) {
+	oldTimeout := OAuthTimeout
+	OAuthTimeout = 50 * time.Millisecond
+	defer func() { OAuthTimeout = oldTimeout }()
+
+	oauthConfig := &oauth2.Config{
+		ClientID: "test-client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://example.com/auth", TokenURL: "https://example.com/token"},
+	}
+	mockStore := &mockTokenStore{}
+
+	// Nobody visits the callback, so no code ever arrives
+	_, err := performOAuthFlow(context.Background(), oauthConfig, mockStore)
+	if err == nil {
+		t.Fatal("Expected a timeout error, got nil")
+	}
+	if !strings.Contains(err.Error(), "authorization timeout") || !strings.Contains(err.Error(), "50ms") {
+		t.Errorf("Expected an authorization timeout error mentioning 50ms, got: %v", err)
+	}
+	if len(mockStore.savedTokens) != 0 {
+		t.Errorf("Expected no token to be saved, got %d", len(mockStore.savedTokens))
+	}
+}
+
+func TestAuthCodeURL_PKCE(t *testing.T) {
+	oauthConfig := &oauth2.Config{
+		ClientID:    "test-client-id",
+		RedirectURL: "http://127.0.0.1:8080",
+		Endpoint:    oauth2.Endpoint{AuthURL: "https://example.com/auth", TokenURL: "https://example.com/token"},
+	}
+
+	authURL, err := url.Parse(authCodeURL(oauthConfig, oauth2.GenerateVerifier()))
+	if err != nil {
+		t.Fatalf("Failed to parse the auth URL: %v", err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge") == "" {
+		t.Errorf("Expected a code_challenge in the auth URL, got %s", authURL)
+	}
+	if method := query.Get("code_challenge_method"); method != "S256" {
+		t.Errorf("Expected code_challenge_method=S256 in the auth URL, got '%s'", method)
+	}
+	if query.Get("access_type") != "offline" {
+		t.Errorf("Expected access_type=offline in the auth URL, got %s", authURL)
+	}
+}
+
+func TestCheckTokenSource(t *testing.T) {
+	// The token endpoint refuses to refresh, as for a revoked refresh token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`)
+	}))
+	defer server.Close()
+	oauthConfig := &oauth2.Config{
+		ClientID: "test-client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"},
+	}
+
+	// No token: nothing to check, and no flow is started
+	if _, err := CheckTokenSource(context.Background(), oauthConfig, &mockTokenStore{}); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken without a token, got %v", err)
+	}
+
+	// A stale token that can't be refreshed is reported as expired, and kept
+	store := &mockTokenStore{token: &oauth2.Token{AccessToken: "old", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)}}
+	_, err := CheckTokenSource(context.Background(), oauthConfig, store)
+	var expired *TokenExpiredError
+	if !errors.As(err, &expired) {
+		t.Errorf("Expected a TokenExpiredError, got %v", err)
+	}
+	if store.token == nil {
+		t.Error("Expected the expired token to be kept")
+	}
+
+	// A valid token passes
+	store = &mockTokenStore{token: &oauth2.Token{AccessToken: "valid", Expiry: time.Now().Add(time.Hour)}}
+	if _, err := CheckTokenSource(context.Background(), oauthConfig, store); err != nil {
+		t.Errorf("Expected a valid token to pass, got %v", err)
+	}
+}
+
+func TestGetAuthenticatedClientWithReader_ResetsExpiredToken(t *testing.T) {
+	// The token endpoint refuses refreshes and grants a new token for a code
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("grant_type") == "refresh_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "fresh-%s", "refresh_token": "new-refresh", "token_type": "Bearer", "expires_in": 3600}`, r.FormValue("code"))
+	}))
+	defer server.Close()
+	oauthConfig := &oauth2.Config{
+		ClientID: "test-client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"},
+	}
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+
+	// An expired token is deleted and replaced through a fresh flow
+	if err := store.SaveToken(&oauth2.Token{AccessToken: "old", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("SaveToken() returned an error: %v", err)
+	}
+	if _, err := GetAuthenticatedClientWithReader(context.Background(), oauthConfig, store, strings.NewReader("code-1\n")); err != nil {
+		t.Fatalf("GetAuthenticatedClientWithReader() returned an error: %v", err)
+	}
+	token, err := store.LoadToken()
+	if err != nil || token == nil || token.AccessToken != "fresh-code-1" {
+		t.Fatalf("Expected the token from the fresh flow to be saved, got %v (%v)", token, err)
+	}
+
+	// So is a deleted token file
+	if err := store.DeleteToken(); err != nil {
+		t.Fatalf("DeleteToken() returned an error: %v", err)
+	}
+	if _, err := GetAuthenticatedClientWithReader(context.Background(), oauthConfig, store, strings.NewReader("code-2\n")); err != nil {
+		t.Fatalf("GetAuthenticatedClientWithReader() returned an error: %v", err)
+	}
+	if token, err := store.LoadToken(); err != nil || token == nil || token.AccessToken != "fresh-code-2" {
+		t.Errorf("Expected a fresh flow after deleting the token file, got %v (%v)", token, err)
+	}
+}
+
+func TestGetAuthenticatedClientWithReader_RefreshesStaleTokenOnce(t *testing.T) {
+	refreshes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+			return
+		}
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "refreshed", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+	oauthConfig := &oauth2.Config{
+		ClientID: "test-client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"},
+	}
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	if err := store.SaveToken(&oauth2.Token{AccessToken: "stale", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("SaveToken() returned an error: %v", err)
+	}
+
+	// No code is read: the stored token is refreshed instead
+	client, err := GetAuthenticatedClientWithReader(context.Background(), oauthConfig, store, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("GetAuthenticatedClientWithReader() returned an error: %v", err)
+	}
+	resp, err := client.Get(server.URL + "/api")
+	if err != nil {
+		t.Fatalf("GET returned an error: %v", err)
+	}
+	defer resp.Body.Close()
+	authorization, _ := io.ReadAll(resp.Body)
+
+	if string(authorization) != "Bearer refreshed" {
+		t.Errorf("Expected the refreshed token to be sent, got %q", authorization)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", refreshes)
+	}
+	if token, err := store.LoadToken(); err != nil || token == nil || token.AccessToken != "refreshed" {
+		t.Errorf("Expected the refreshed token to be saved, got %v (%v)", token, err)
+	}
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+// TokenKeyEnv is the environment variable holding the passphrase that
+// EncryptedFileTokenStore encrypts tokens with.
+const TokenKeyEnv = "CALSYNC_TOKEN_KEY"
+
+// ErrWrongTokenKey is returned when an encrypted token file can't be
+// decrypted with the passphrase, which is then most likely the wrong one.
+var ErrWrongTokenKey = errors.New("failed to decrypt token file: wrong " + TokenKeyEnv + " passphrase, or the file is corrupted")
+
+// scrypt parameters for deriving the AES-256 key from the passphrase.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// encryptedToken is the content of an encrypted token file.
+type encryptedToken struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileTokenStore is a file-based token store that encrypts tokens at
+// rest with AES-GCM, using a key derived with scrypt from a passphrase.
+type EncryptedFileTokenStore struct {
+	Path       string
+	passphrase string
+}
+
+// NewEncryptedFileTokenStore creates a new EncryptedFileTokenStore with the
+// given path and passphrase.
+func NewEncryptedFileTokenStore(path, passphrase string) *EncryptedFileTokenStore {
+	return &EncryptedFileTokenStore{Path: path, passphrase: passphrase}
+}
+
+// DeleteToken deletes the token file, effectively resetting the token.
+func (store *EncryptedFileTokenStore) DeleteToken() error {
+	return NewFileTokenStore(store.Path).DeleteToken()
+}
+
+// SaveToken encrypts an OAuth token and saves it to the file at store.Path.
+// Each save uses a fresh salt and nonce.
+func (store *EncryptedFileTokenStore) SaveToken(token *oauth2.Token) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := store.cipher(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(encryptedToken{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal encrypted token: %w", err)
+	}
+	if err := os.WriteFile(store.Path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+// LoadToken loads and decrypts an OAuth token from the file at store.Path.
+// Returns nil, nil if the file does not exist (no error), and ErrWrongTokenKey
+// if it can't be decrypted.
+func (store *EncryptedFileTokenStore) LoadToken() (*oauth2.Token, error) {
+	data, err := os.ReadFile(store.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var encrypted encryptedToken
+	if err := json.Unmarshal(data, &encrypted); err != nil || len(encrypted.Ciphertext) == 0 {
+		return nil, fmt.Errorf("%s is not an encrypted token file; delete it to authorize again with token_store 'encrypted'", store.Path)
+	}
+	aead, err := store.cipher(encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongTokenKey
+	}
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongTokenKey
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	return &token, nil
+}
+
+// cipher returns the AES-GCM cipher keyed by the passphrase and salt.
+func (store *EncryptedFileTokenStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(store.passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive token key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,7 @@
+package auth
+
+import "errors"
+
+// ErrKeychainUnsupported is returned by KeychainPassword on systems without
+// the macOS keychain.
+var ErrKeychainUnsupported = errors.New("the macOS keychain is only available on macOS; set password in the config instead")
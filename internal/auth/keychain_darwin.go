@@ -0,0 +1,29 @@
+//go:build darwin
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// KeychainPassword returns the password of the generic password item with the
+// given service and account in the user's macOS keychain, as stored with e.g.
+// `security add-generic-password -s SERVICE -a ACCOUNT -w`.
+func KeychainPassword(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("keychain item (service: %s, account: %s) not readable: %s", service, account, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to run security: %w", err)
+	}
+	password := strings.TrimSuffix(string(out), "\n")
+	if password == "" {
+		return "", fmt.Errorf("keychain item (service: %s, account: %s) has an empty password", service, account)
+	}
+	return password, nil
+}
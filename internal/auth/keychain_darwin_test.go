@@ -0,0 +1,35 @@
+//go:build darwin
+
+package auth
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestKeychainPassword(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping keychain test in short mode")
+	}
+	service := fmt.Sprintf("calendar-sync-test-%d", time.Now().UnixNano())
+	account := "test@example.com"
+
+	if _, err := KeychainPassword(service, account); err == nil {
+		t.Fatal("Expected an error for a missing keychain item")
+	}
+
+	if out, err := exec.Command("security", "add-generic-password", "-s", service, "-a", account, "-w", "app-specific-password").CombinedOutput(); err != nil {
+		t.Skipf("Cannot add a keychain item: %v: %s", err, out)
+	}
+	defer exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run()
+
+	password, err := KeychainPassword(service, account)
+	if err != nil {
+		t.Fatalf("KeychainPassword() returned an error: %v", err)
+	}
+	if password != "app-specific-password" {
+		t.Errorf("Expected password 'app-specific-password', got %q", password)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package auth
+
+// KeychainPassword always fails with ErrKeychainUnsupported, as there is no
+// macOS keychain on this system.
+func KeychainPassword(service, account string) (string, error) {
+	return "", ErrKeychainUnsupported
+}
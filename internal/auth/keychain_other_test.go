@@ -0,0 +1,14 @@
+//go:build !darwin
+
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeychainPassword_Unsupported(t *testing.T) {
+	if _, err := KeychainPassword("calendar-sync", "test@example.com"); !errors.Is(err, ErrKeychainUnsupported) {
+		t.Errorf("Expected ErrKeychainUnsupported, got %v", err)
+	}
+}
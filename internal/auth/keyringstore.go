@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+// KeyringService is the service name tokens are stored under in the OS
+// keyring (the macOS keychain, the Linux secret service or the Windows
+// credential manager).
+const KeyringService = "calendar-sync"
+
+// KeyringTokenStore stores tokens in the OS keyring, as the item of Service
+// and Account.
+type KeyringTokenStore struct {
+	Service string
+	Account string
+}
+
+// NewKeyringTokenStore creates a new KeyringTokenStore for the keyring item
+// of service and account.
+func NewKeyringTokenStore(service, account string) *KeyringTokenStore {
+	return &KeyringTokenStore{Service: service, Account: account}
+}
+
+// DeleteToken deletes the token from the keyring, effectively resetting it.
+func (store *KeyringTokenStore) DeleteToken() error {
+	if err := keyring.Delete(store.Service, store.Account); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return keyringError("delete token from", err)
+	}
+	return nil
+}
+
+// SaveToken saves an OAuth token to the keyring.
+func (store *KeyringTokenStore) SaveToken(token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+	if err := keyring.Set(store.Service, store.Account, string(data)); err != nil {
+		return keyringError("save token to", err)
+	}
+	return nil
+}
+
+// LoadToken loads an OAuth token from the keyring.
+// Returns nil, nil if the keyring has no token (no error).
+func (store *KeyringTokenStore) LoadToken() (*oauth2.Token, error) {
+	data, err := keyring.Get(store.Service, store.Account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, keyringError("load token from", err)
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	return &token, nil
+}
+
+// keyringError describes a failure to use the keyring, which on a system
+// without one, such as headless Linux without a secret service, is unusable.
+func keyringError(action string, err error) error {
+	return fmt.Errorf("failed to %s the OS keyring: %w (without a keyring, e.g. on headless Linux without a secret service, use token_store 'file' or 'encrypted' instead)", action, err)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceAccountTokenSource_ImpersonatesSubject(t *testing.T) {
+	// The token endpoint records the claims of the JWT it's given
+	var claims map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse the token request: %v", err)
+		}
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Errorf("Expected a signed JWT assertion, got %q", r.Form.Get("assertion"))
+		} else if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			t.Errorf("Failed to decode the JWT claims: %v", err)
+		} else if err := json.Unmarshal(payload, &claims); err != nil {
+			t.Errorf("Failed to parse the JWT claims: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "delegated-token", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	key, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "calsync@example.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode the key file: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "service-account.json")
+	if err := os.WriteFile(keyPath, key, 0600); err != nil {
+		t.Fatalf("Failed to write the key file: %v", err)
+	}
+
+	tokenSource, err := ServiceAccountTokenSource(context.Background(), keyPath, "user@example.com", []string{"https://www.googleapis.com/auth/calendar.readonly"})
+	if err != nil {
+		t.Fatalf("ServiceAccountTokenSource() returned an error: %v", err)
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		t.Fatalf("Token() returned an error: %v", err)
+	}
+	if token.AccessToken != "delegated-token" {
+		t.Errorf("Expected the delegated access token, got '%s'", token.AccessToken)
+	}
+	if claims["sub"] != "user@example.com" || claims["iss"] != "calsync@example.iam.gserviceaccount.com" {
+		t.Errorf("Expected the service account to act as user@example.com, got claims %v", claims)
+	}
+}
+
+func TestServiceAccountTokenSource_MissingKey(t *testing.T) {
+	_, err := ServiceAccountTokenSource(context.Background(), filepath.Join(t.TempDir(), "missing.json"), "user@example.com", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to read service account key") {
+		t.Errorf("Expected an error reading the key, got %v", err)
+	}
+}
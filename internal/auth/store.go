@@ -18,6 +18,26 @@ func NewFileTokenStore(path string) *FileTokenStore {
 	return &FileTokenStore{Path: path}
 }
 
+// NewTokenStore returns the token store for the token_store setting: "file"
+// (or empty) for plain token files, "encrypted" for token files encrypted
+// with the passphrase in CALSYNC_TOKEN_KEY, or "keyring" for the OS keyring,
+// with the token path as the account.
+func NewTokenStore(kind, path string) (TokenStore, error) {
+	switch kind {
+	case "", "file":
+		return NewFileTokenStore(path), nil
+	case "encrypted":
+		passphrase := os.Getenv(TokenKeyEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("token_store 'encrypted' needs the passphrase in the %s environment variable", TokenKeyEnv)
+		}
+		return NewEncryptedFileTokenStore(path, passphrase), nil
+	case "keyring":
+		return NewKeyringTokenStore(KeyringService, path), nil
+	}
+	return nil, fmt.Errorf("unknown token store '%s'", kind)
+}
+
 // DeleteToken deletes the token file, effectively resetting the token.
 func (store *FileTokenStore) DeleteToken() error {
 	if err := os.Remove(store.Path); err != nil {
@@ -1,9 +1,14 @@
 package auth
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/zalando/go-keyring"
 	"golang.org/x/oauth2"
 )
 
@@ -72,3 +77,126 @@ func TestFileTokenStore_LoadEmpty(t *testing.T) {
 	}
 }
 
+
+func TestEncryptedFileTokenStore_SaveLoad(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	store := NewEncryptedFileTokenStore(tokenPath, "correct horse battery staple")
+
+	token := &oauth2.Token{
+		AccessToken:  "test-access-token",
+		RefreshToken: "test-refresh-token",
+		Expiry:       time.Now().Add(1 * time.Hour),
+		TokenType:    "Bearer",
+	}
+	if err := store.SaveToken(token); err != nil {
+		t.Fatalf("SaveToken() returned an error: %v", err)
+	}
+
+	// The refresh token must not be readable from the file
+	data, err := os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatalf("Failed to read token file: %v", err)
+	}
+	if strings.Contains(string(data), "test-refresh-token") {
+		t.Errorf("Expected the token file to be encrypted, got %s", data)
+	}
+
+	loadedToken, err := store.LoadToken()
+	if err != nil {
+		t.Fatalf("LoadToken() returned an error: %v", err)
+	}
+	if loadedToken == nil || loadedToken.RefreshToken != token.RefreshToken || !loadedToken.Expiry.Equal(token.Expiry) {
+		t.Errorf("Expected the saved token back, got %+v", loadedToken)
+	}
+
+	// A wrong passphrase fails clearly
+	_, err = NewEncryptedFileTokenStore(tokenPath, "wrong passphrase").LoadToken()
+	if !errors.Is(err, ErrWrongTokenKey) {
+		t.Errorf("Expected ErrWrongTokenKey with the wrong passphrase, got %v", err)
+	}
+
+	if err := store.DeleteToken(); err != nil {
+		t.Fatalf("DeleteToken() returned an error: %v", err)
+	}
+	if loadedToken, err := store.LoadToken(); err != nil || loadedToken != nil {
+		t.Errorf("Expected no token after DeleteToken(), got %v, %v", loadedToken, err)
+	}
+}
+
+func TestEncryptedFileTokenStore_PlainTokenFile(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	if err := NewFileTokenStore(tokenPath).SaveToken(&oauth2.Token{RefreshToken: "plain"}); err != nil {
+		t.Fatalf("SaveToken() returned an error: %v", err)
+	}
+	_, err := NewEncryptedFileTokenStore(tokenPath, "passphrase").LoadToken()
+	if err == nil || !strings.Contains(err.Error(), "not an encrypted token file") {
+		t.Errorf("Expected an error about a plain token file, got %v", err)
+	}
+}
+
+func TestNewTokenStore(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+
+	if store, err := NewTokenStore("file", tokenPath); err != nil {
+		t.Errorf("NewTokenStore(file) returned an error: %v", err)
+	} else if _, ok := store.(*FileTokenStore); !ok {
+		t.Errorf("Expected a FileTokenStore, got %T", store)
+	}
+
+	t.Setenv(TokenKeyEnv, "")
+	if _, err := NewTokenStore("encrypted", tokenPath); err == nil || !strings.Contains(err.Error(), TokenKeyEnv) {
+		t.Errorf("Expected an error naming %s when it is unset, got %v", TokenKeyEnv, err)
+	}
+	t.Setenv(TokenKeyEnv, "passphrase")
+	if store, err := NewTokenStore("encrypted", tokenPath); err != nil {
+		t.Errorf("NewTokenStore(encrypted) returned an error: %v", err)
+	} else if _, ok := store.(*EncryptedFileTokenStore); !ok {
+		t.Errorf("Expected an EncryptedFileTokenStore, got %T", store)
+	}
+}
+
+func TestKeyringTokenStore_SaveLoadDelete(t *testing.T) {
+	keyring.MockInit()
+	store := NewKeyringTokenStore(KeyringService, "/tmp/work_token.json")
+
+	if token, err := store.LoadToken(); err != nil || token != nil {
+		t.Fatalf("Expected no token before the first save, got %v, %v", token, err)
+	}
+
+	token := &oauth2.Token{AccessToken: "test-access-token", RefreshToken: "test-refresh-token", TokenType: "Bearer"}
+	if err := store.SaveToken(token); err != nil {
+		t.Fatalf("SaveToken() returned an error: %v", err)
+	}
+	loadedToken, err := store.LoadToken()
+	if err != nil {
+		t.Fatalf("LoadToken() returned an error: %v", err)
+	}
+	if loadedToken == nil || loadedToken.RefreshToken != token.RefreshToken {
+		t.Errorf("Expected the saved token back, got %+v", loadedToken)
+	}
+
+	// Tokens of other paths are separate keyring items
+	if other, err := NewKeyringTokenStore(KeyringService, "/tmp/personal_token.json").LoadToken(); err != nil || other != nil {
+		t.Errorf("Expected no token for another path, got %v, %v", other, err)
+	}
+
+	if err := store.DeleteToken(); err != nil {
+		t.Fatalf("DeleteToken() returned an error: %v", err)
+	}
+	if err := store.DeleteToken(); err != nil {
+		t.Errorf("Expected deleting a missing token to succeed, got %v", err)
+	}
+	if loadedToken, err := store.LoadToken(); err != nil || loadedToken != nil {
+		t.Errorf("Expected no token after DeleteToken(), got %v, %v", loadedToken, err)
+	}
+}
+
+func TestKeyringTokenStore_NoKeyring(t *testing.T) {
+	keyring.MockInitWithError(errors.New("The name org.freedesktop.secrets was not provided by any .service files"))
+	defer keyring.MockInit()
+
+	_, err := NewKeyringTokenStore(KeyringService, "/tmp/work_token.json").LoadToken()
+	if err == nil || !strings.Contains(err.Error(), "token_store 'file'") {
+		t.Errorf("Expected an error suggesting the file store, got %v", err)
+	}
+}
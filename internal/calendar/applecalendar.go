@@ -5,24 +5,58 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"maps"
 	"net/http"
+	neturl "net/url"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/emersion/go-ical"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/calendar/v3"
 )
 
+// caldavMaxRetryDelay caps the delay taken from a Retry-After header.
+const caldavMaxRetryDelay = 60 * time.Second
+
 // AppleCalendarClient is a client for Apple Calendar/iCloud using CalDAV.
 type AppleCalendarClient struct {
-	httpClient *http.Client
-	username   string
-	password   string
-	serverURL  string
-	basePath   string
+	httpClient  *http.Client
+	username    string
+	password    string
+	tokenSource oauth2.TokenSource // If set, requests use OAuth bearer tokens instead of basic auth
+	serverURL   string
+	basePath    string
+
+	ctx         context.Context                                  // Cancels waits between retries
+	sleep       func(ctx context.Context, d time.Duration) error // Waits between retries, replaced in tests
+	retryPolicy RetryPolicy                                      // Unset for DefaultRetryPolicy
+	productID   string                                           // PRODID of written events, unset for DefaultProductID
+
+	colorCategories map[string]string // Categories of written events by their Google color ID
+
+	// eventCache holds, per calendar, the events of its last listing, which
+	// serves later listings within the same range and FindEventsByWorkID. A
+	// calendar's entry is dropped when it is written to, and all of them by
+	// ResetEventCache, so a sync run reads each calendar about once.
+	eventCacheMu sync.Mutex
+	eventCache   map[string]*cachedEvents
+}
+
+// cachedEvents is a calendar listing kept by AppleCalendarClient.
+type cachedEvents struct {
+	timeMin, timeMax time.Time
+	events           []*calendar.Event
+	byWorkID         map[string][]*calendar.Event // Built on the first FindEventsByWorkID
 }
 
 // NewAppleCalendarClient creates a new Apple Calendar client using CalDAV.
@@ -40,6 +74,8 @@ func NewAppleCalendarClient(ctx context.Context, serverURL, username, password s
 		username:   username,
 		password:   password,
 		serverURL:  serverURL,
+		ctx:        ctx,
+		sleep:      sleepContext,
 	}
 
 	// Discover the principal and calendar home path
@@ -52,6 +88,136 @@ func NewAppleCalendarClient(ctx context.Context, serverURL, username, password s
 	return client, nil
 }
 
+// NewAppleCalendarClientWithTokenSource creates a CalDAV client that authenticates
+// with OAuth bearer tokens from tokenSource instead of basic auth, for providers
+// such as Google's CalDAV endpoint.
+func NewAppleCalendarClientWithTokenSource(ctx context.Context, serverURL string, tokenSource oauth2.TokenSource) (*AppleCalendarClient, error) {
+	// The oauth2 transport adds "Authorization: Bearer <token>" to every request
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+	httpClient.Timeout = 30 * time.Second
+
+	client := &AppleCalendarClient{
+		httpClient:  httpClient,
+		tokenSource: tokenSource,
+		serverURL:   serverURL,
+		ctx:         ctx,
+		sleep:       sleepContext,
+	}
+
+	// Discover the principal and calendar home path
+	basePath, err := client.discoverPrincipal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover CalDAV principal: %w", err)
+	}
+	client.basePath = basePath
+
+	return client, nil
+}
+
+// NewCalDAVClient creates a client for any CalDAV server, such as Fastmail,
+// Nextcloud or Radicale, with basic auth. serverURL may be just the server's
+// address: the calendar home is discovered through its /.well-known/caldav
+// URL (RFC 6764), or failing that by asking serverURL itself.
+func NewCalDAVClient(ctx context.Context, serverURL, username, password string) (*AppleCalendarClient, error) {
+	client := &AppleCalendarClient{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			// Discovery follows redirects itself, keeping the method and credentials
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		username:  username,
+		password:  password,
+		serverURL: serverURL,
+		ctx:       ctx,
+		sleep:     sleepContext,
+	}
+
+	basePath, err := client.discoverCalendarHome()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover CalDAV calendar home: %w", err)
+	}
+	client.basePath = basePath
+
+	return client, nil
+}
+
+// SetProductID sets the PRODID of the events the client writes, which
+// identifies the program that wrote them. Empty uses DefaultProductID.
+func (c *AppleCalendarClient) SetProductID(productID string) {
+	c.productID = productID
+}
+
+// SetColorCategories sets the categories the events the client writes get,
+// by their Google color ID, as CalDAV has no per-event colors.
+func (c *AppleCalendarClient) SetColorCategories(categories map[string]string) {
+	c.colorCategories = categories
+}
+
+// SetRetryPolicy sets how the client retries transient request failures.
+func (c *AppleCalendarClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// do sends req, retrying it with jittered exponential backoff, as set by the
+// client's retry policy, while it fails with a network error or a transient
+// status (429, 500, 502, 503 or 504). A Retry-After header is honored, up to
+// caldavMaxRetryDelay. After the last attempt, the failed response is returned
+// for the caller to report. CalDAV writes are PUTs and DELETEs of a given
+// resource, so they are safe to repeat.
+func (c *AppleCalendarClient) do(req *http.Request) (*http.Response, error) {
+	policy := c.retryPolicy.orDefault()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		transient := isNetworkError(err) || (err == nil && isTransientStatus(resp.StatusCode))
+		// A body that can't be rebuilt can't be sent again
+		if !transient || attempt == policy.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		wait := policy.backoff(attempt)
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			if suggested, ok := retryAfterHeader(resp.Header); ok {
+				wait = min(suggested, caldavMaxRetryDelay)
+			}
+			reason = resp.Status
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		log.Printf("CalDAV %s %s failed, retrying in %v: %s", req.Method, req.URL.Path, wait, reason)
+
+		ctx := c.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		sleep := c.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// setAuth adds basic auth credentials to req. In OAuth mode the bearer token is
+// added by the HTTP client's transport instead.
+func (c *AppleCalendarClient) setAuth(req *http.Request) {
+	if c.tokenSource != nil {
+		return
+	}
+	req.SetBasicAuth(c.username, c.password)
+}
+
 // makeRequest makes an authenticated HTTP request to the CalDAV server.
 func (c *AppleCalendarClient) makeRequest(method, path string, body io.Reader) (*http.Response, error) {
 	// Ensure path starts with / and doesn't contain the server URL
@@ -66,7 +232,7 @@ func (c *AppleCalendarClient) makeRequest(method, path string, body io.Reader) (
 		return nil, err
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	// Set User-Agent header (required by some CalDAV servers)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	if body != nil {
@@ -76,7 +242,7 @@ func (c *AppleCalendarClient) makeRequest(method, path string, body io.Reader) (
 		req.Header.Set("Depth", "1")
 	}
 
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // discoverPrincipal discovers the CalDAV principal and calendar home path.
@@ -93,12 +259,12 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
 	req.Header.Set("Depth", "0") // Use Depth: 0 for principal discovery
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to discover principal: %w", err)
 	}
@@ -127,12 +293,12 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 			testURL := strings.TrimSuffix(c.serverURL, "/") + path
 			testReq, err := http.NewRequest("PROPFIND", testURL, strings.NewReader(propfindBody))
 			if err == nil {
-				testReq.SetBasicAuth(c.username, c.password)
+				c.setAuth(testReq)
 				testReq.Header.Set("User-Agent", "calendar-sync/1.0")
 				testReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 				testReq.Header.Set("Depth", "0")
 
-				testResp, err := c.httpClient.Do(testReq)
+				testResp, err := c.do(testReq)
 				if err == nil {
 					testResp.Body.Close()
 					if testResp.StatusCode == http.StatusOK || testResp.StatusCode == http.StatusMultiStatus {
@@ -172,11 +338,11 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 		principalURL := strings.TrimSuffix(c.serverURL, "/") + principal
 		principalReq, err := http.NewRequest("PROPFIND", principalURL, strings.NewReader(principalPropfindBody))
 		if err == nil {
-			principalReq.SetBasicAuth(c.username, c.password)
+			c.setAuth(principalReq)
 			principalReq.Header.Set("User-Agent", "calendar-sync/1.0")
 			principalReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 			principalReq.Header.Set("Depth", "0")
-			principalResp, err := c.httpClient.Do(principalReq)
+			principalResp, err := c.do(principalReq)
 			if err == nil {
 				defer principalResp.Body.Close()
 				if principalResp.StatusCode == http.StatusOK || principalResp.StatusCode == http.StatusMultiStatus {
@@ -195,56 +361,6 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 		}
 	}
 
-	if principal == "" {
-		// If we still can't find it, the XML might be in a different format
-		// Try a more flexible extraction - look for any href in the response
-		// that looks like a principal or calendar path
-		bodyStr := string(body)
-		// Look for href patterns that might be the principal
-		hrefPatterns := []string{"<d:href>", "<href>", "href=\""}
-		for _, pattern := range hrefPatterns {
-			idx := strings.Index(bodyStr, pattern)
-			if idx != -1 {
-				// Extract the href value
-				start := idx + len(pattern)
-				if pattern == "href=\"" {
-					start = idx + 6 // "href=\""
-				}
-				end := strings.Index(bodyStr[start:], "<")
-				if end == -1 {
-					end = strings.Index(bodyStr[start:], "\"")
-				}
-				if end == -1 {
-					end = strings.Index(bodyStr[start:], ">")
-				}
-				if end > 0 {
-					potentialPath := strings.TrimSpace(bodyStr[start : start+end])
-					// Check if it looks like a valid path (contains / and doesn't have XML attributes)
-					if strings.Contains(potentialPath, "/") && !strings.Contains(potentialPath, "xmlns") && !strings.Contains(potentialPath, "=") {
-						if !strings.HasPrefix(potentialPath, "/") {
-							potentialPath = "/" + potentialPath
-						}
-						// Test if this path works for calendar listing
-						testURL := strings.TrimSuffix(c.serverURL, "/") + potentialPath
-						testReq, _ := http.NewRequest("PROPFIND", testURL, strings.NewReader(propfindBody))
-						testReq.SetBasicAuth(c.username, c.password)
-						testReq.Header.Set("User-Agent", "calendar-sync/1.0")
-						testReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
-						testReq.Header.Set("Depth", "0")
-						testResp, err := c.httpClient.Do(testReq)
-						if err == nil {
-							testResp.Body.Close()
-							if testResp.StatusCode == http.StatusOK || testResp.StatusCode == http.StatusMultiStatus {
-								principal = potentialPath
-								break
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
 	if principal != "" {
 		// Ensure principal is a relative path (starts with /)
 		if !strings.HasPrefix(principal, "/") {
@@ -289,11 +405,11 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 			testURL := strings.TrimSuffix(c.serverURL, "/") + testPath
 			// Try with Depth: 1 to see if there are children (calendars)
 			testReq, _ := http.NewRequest("PROPFIND", testURL, strings.NewReader(testPropfindBody))
-			testReq.SetBasicAuth(c.username, c.password)
+			c.setAuth(testReq)
 			testReq.Header.Set("User-Agent", "calendar-sync/1.0")
 			testReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 			testReq.Header.Set("Depth", "1")
-			testResp, err := c.httpClient.Do(testReq)
+			testResp, err := c.do(testReq)
 			if err == nil {
 				testResp.Body.Close()
 				if testResp.StatusCode == http.StatusOK || testResp.StatusCode == http.StatusMultiStatus {
@@ -319,275 +435,275 @@ func (c *AppleCalendarClient) discoverPrincipal() (string, error) {
 	return fmt.Sprintf("/%s/calendars/", usernamePart), nil
 }
 
-// extractPrincipalFromXML extracts the current-user-principal href from XML response.
-func (c *AppleCalendarClient) extractPrincipalFromXML(body []byte) string {
-	bodyStr := string(body)
+// maxDiscoveryRedirects bounds the redirects followed by a discovery request.
+const maxDiscoveryRedirects = 5
 
-	// Look for current-user-principal
-	startIdx := strings.Index(bodyStr, "current-user-principal")
-	if startIdx == -1 {
-		return ""
+// discoverCalendarHome finds the current user's calendar home as RFC 6764
+// describes: the server's /.well-known/caldav URL, or failing that serverURL,
+// is asked for the current-user-principal, and the principal for its
+// calendar-home-set. Both may be on another host than serverURL, which is
+// then set to the home's scheme and host, as requests use paths on it.
+func (c *AppleCalendarClient) discoverCalendarHome() (string, error) {
+	start, err := neturl.Parse(c.serverURL)
+	if err != nil || !start.IsAbs() {
+		return "", fmt.Errorf("invalid server URL '%s'", c.serverURL)
 	}
 
-	// The href can be nested inside current-user-principal with namespace
-	// Example: <current-user-principal xmlns="DAV:"><href xmlns="DAV:">/88940651/principal/</href></current-user-principal>
-	// Find the <href> tag that comes after current-user-principal (may have namespace)
-	searchStart := startIdx
-
-	// Look for <href> or <href xmlns="DAV:"> pattern within the current-user-principal element
-	// First, find where current-user-principal ends
-	principalEnd := strings.Index(bodyStr[searchStart:], "</current-user-principal>")
-	if principalEnd == -1 {
-		principalEnd = strings.Index(bodyStr[searchStart:], "</d:current-user-principal>")
-	}
-	if principalEnd == -1 {
-		// Fallback: search within next 500 chars
-		principalEnd = 500
+	principalProp := func(prop davProp) davHrefs { return prop.CurrentUserPrincipal }
+	principalBody := `<propfind xmlns='DAV:'><prop><current-user-principal/></prop></propfind>`
+	principal, err := c.propfindHref(start.ResolveReference(&neturl.URL{Path: "/.well-known/caldav"}), principalBody, principalProp)
+	if err != nil {
+		log.Printf("CalDAV well-known discovery failed, asking %s instead: %v", c.serverURL, err)
+		principal, err = c.propfindHref(start, principalBody, principalProp)
+		if err != nil {
+			return "", fmt.Errorf("failed to find the current user principal: %w", err)
+		}
 	}
-	searchEnd := searchStart + principalEnd
 
-	// Look for <href> within this range
-	hrefTagStart := strings.Index(bodyStr[searchStart:searchEnd], "<href")
-	if hrefTagStart == -1 {
-		hrefTagStart = strings.Index(bodyStr[searchStart:searchEnd], "<d:href")
-	}
-	if hrefTagStart == -1 {
-		return ""
+	homeBody := `<propfind xmlns='DAV:'><prop><calendar-home-set xmlns='urn:ietf:params:xml:ns:caldav'/></prop></propfind>`
+	home, err := c.propfindHref(principal, homeBody, func(prop davProp) davHrefs { return prop.CalendarHomeSet })
+	if err != nil {
+		return "", fmt.Errorf("failed to find the calendar home of %s: %w", principal, err)
 	}
 
-	hrefTagStart += searchStart
+	c.serverURL = home.Scheme + "://" + home.Host
+	return collectionPath(home.Path), nil
+}
 
-	// Find the start of the href value (skip past the tag and any attributes)
-	// Look for the > that closes the opening tag
-	valueStart := strings.Index(bodyStr[hrefTagStart:], ">")
-	if valueStart == -1 {
-		return ""
-	}
-	valueStart += hrefTagStart + 1 // Skip the >
+// propfindHref asks u for a property holding an href with a Depth 0 PROPFIND,
+// following redirects, and returns the href resolved against the URL that
+// answered. Redirects from https to http are refused, as they would send the
+// credentials in the clear.
+func (c *AppleCalendarClient) propfindHref(u *neturl.URL, body string, property func(davProp) davHrefs) (*neturl.URL, error) {
+	for redirects := 0; ; redirects++ {
+		req, err := http.NewRequest("PROPFIND", u.String(), strings.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		c.setAuth(req)
+		req.Header.Set("User-Agent", "calendar-sync/1.0")
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+		req.Header.Set("Depth", "0")
 
-	// Find the closing tag
-	hrefEnd := strings.Index(bodyStr[valueStart:], "</href>")
-	if hrefEnd == -1 {
-		hrefEnd = strings.Index(bodyStr[valueStart:], "</d:href>")
-	}
-	if hrefEnd == -1 {
-		return ""
-	}
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
 
-	href := strings.TrimSpace(bodyStr[valueStart : valueStart+hrefEnd])
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusMultiStatus:
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			location, err := resp.Location()
+			if err != nil {
+				return nil, fmt.Errorf("PROPFIND %s: redirect without a location", u)
+			}
+			if redirects == maxDiscoveryRedirects {
+				return nil, fmt.Errorf("PROPFIND %s: too many redirects", u)
+			}
+			if u.Scheme == "https" && location.Scheme != "https" {
+				return nil, fmt.Errorf("PROPFIND %s: refusing redirect to %s", u, location)
+			}
+			u = location
+			continue
+		default:
+			return nil, fmt.Errorf("PROPFIND %s: HTTP %d", u, resp.StatusCode)
+		}
 
-	// Validate href - reject if it contains XML attributes
-	if strings.Contains(href, "xmlns") || strings.Contains(href, "=") || strings.Contains(href, "<") || strings.Contains(href, ">") {
-		return ""
+		href := firstRawHref(respBody, property)
+		if href == "" {
+			return nil, fmt.Errorf("PROPFIND %s: property not in the response", u)
+		}
+		ref, err := neturl.Parse(href)
+		if err != nil {
+			return nil, fmt.Errorf("PROPFIND %s: invalid href '%s': %w", u, href, err)
+		}
+		return u.ResolveReference(ref), nil
 	}
+}
 
-	// Ensure it's a relative path starting with /
-	if !strings.HasPrefix(href, "/") {
-		href = "/" + href
-	}
+// davMultistatus is a WebDAV multistatus response (RFC 4918), as returned by
+// PROPFIND. Elements are matched by namespace, so it parses the same whichever
+// prefix (or default namespace) the server uses.
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
 
-	// Make sure it ends with /
-	if !strings.HasSuffix(href, "/") {
-		href += "/"
-	}
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
 
-	return href
+type davPropstat struct {
+	Prop davProp `xml:"DAV: prop"`
 }
 
-// extractCalendarHomeFromXML extracts the calendar-home-set href from XML response.
-func (c *AppleCalendarClient) extractCalendarHomeFromXML(body []byte) string {
-	// Simple extraction - look for calendar-home-set href
-	// This is a simplified parser; a full implementation would use proper XML parsing
-	bodyStr := string(body)
+type davProp struct {
+	DisplayName          string   `xml:"DAV: displayname"`
+	CalendarColor        string   `xml:"http://apple.com/ns/ical/ calendar-color"`
+	CurrentUserPrincipal davHrefs `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHrefs `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+}
 
-	// Look for calendar-home-set
-	startIdx := strings.Index(bodyStr, "calendar-home-set")
-	if startIdx == -1 {
-		return ""
+type davHrefs struct {
+	Hrefs []string `xml:"DAV: href"`
+}
+
+// parseMultistatus parses a PROPFIND response body.
+func parseMultistatus(body []byte) (*davMultistatus, error) {
+	var multistatus davMultistatus
+	if err := xml.Unmarshal(body, &multistatus); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
 	}
+	return &multistatus, nil
+}
 
-	// Find the <href> tag that comes after calendar-home-set
-	// Look for <href> or <d:href> or href=" pattern
-	searchStart := startIdx
-	// First try to find <href> tag
-	hrefTagStart := strings.Index(bodyStr[searchStart:], "<href>")
-	if hrefTagStart == -1 {
-		hrefTagStart = strings.Index(bodyStr[searchStart:], "<d:href>")
-	}
-	if hrefTagStart == -1 {
-		// Try href=" pattern
-		hrefTagStart = strings.Index(bodyStr[searchStart:], "href=\"")
-		if hrefTagStart == -1 {
-			hrefTagStart = strings.Index(bodyStr[searchStart:], "href='")
-		}
-		if hrefTagStart != -1 {
-			// For href=" pattern, skip href="
-			hrefStart := searchStart + hrefTagStart + 6 // "href=\""
-			hrefEnd := strings.Index(bodyStr[hrefStart:], "\"")
-			if hrefEnd == -1 {
-				hrefEnd = strings.Index(bodyStr[hrefStart:], "'")
-			}
-			if hrefEnd > 0 {
-				href := bodyStr[hrefStart : hrefStart+hrefEnd]
-				// Validate href - reject if it contains XML attributes
-				if strings.Contains(href, "xmlns") || strings.Contains(href, "=") || strings.Contains(href, "<") || strings.Contains(href, ">") {
-					return ""
-				}
-				// Ensure it's a relative path starting with /
-				if !strings.HasPrefix(href, "/") {
-					href = "/" + href
-				}
-				if !strings.HasSuffix(href, "/") {
-					href += "/"
+// firstHref returns the first href that the selected property holds in any
+// response, normalized to a collection path, or "" if none does.
+func firstHref(body []byte, property func(davProp) davHrefs) string {
+	multistatus, err := parseMultistatus(body)
+	if err != nil {
+		return ""
+	}
+	for _, resp := range multistatus.Responses {
+		for _, propstat := range resp.Propstats {
+			for _, href := range property(propstat.Prop).Hrefs {
+				if path := collectionPath(href); path != "" {
+					return path
 				}
-				return href
 			}
 		}
-		return ""
-	}
-
-	// Found <href> tag, extract content
-	hrefStart := searchStart + hrefTagStart
-	// Skip past <href> or <d:href>
-	if strings.HasPrefix(bodyStr[hrefStart:], "<d:href>") {
-		hrefStart += 8 // "<d:href>"
-	} else {
-		hrefStart += 6 // "<href>"
 	}
+	return ""
+}
 
-	// Find closing tag
-	hrefEnd := strings.Index(bodyStr[hrefStart:], "</href>")
-	if hrefEnd == -1 {
-		hrefEnd = strings.Index(bodyStr[hrefStart:], "</d:href>")
-	}
-	if hrefEnd == -1 {
+// firstRawHref returns the first href that the selected property holds in any
+// response, as the server wrote it, or "" if none does.
+func firstRawHref(body []byte, property func(davProp) davHrefs) string {
+	multistatus, err := parseMultistatus(body)
+	if err != nil {
 		return ""
 	}
+	for _, resp := range multistatus.Responses {
+		for _, propstat := range resp.Propstats {
+			for _, href := range property(propstat.Prop).Hrefs {
+				if href = strings.TrimSpace(href); href != "" {
+					return href
+				}
+			}
+		}
+	}
+	return ""
+}
 
-	href := strings.TrimSpace(bodyStr[hrefStart : hrefStart+hrefEnd])
-
-	// Validate href - reject if it contains XML attributes
-	if strings.Contains(href, "xmlns") || strings.Contains(href, "=") || strings.Contains(href, "<") || strings.Contains(href, ">") {
+// collectionPath turns an href into a path starting and ending with "/".
+// Some servers (iCloud) return absolute URLs; only their path is kept.
+func collectionPath(href string) string {
+	href = strings.TrimSpace(href)
+	if u, err := neturl.Parse(href); err == nil && u.IsAbs() {
+		href = u.Path
+	}
+	if href == "" {
 		return ""
 	}
-
-	// Ensure it's a relative path starting with /
 	if !strings.HasPrefix(href, "/") {
 		href = "/" + href
 	}
-
-	// Make sure it ends with /
 	if !strings.HasSuffix(href, "/") {
 		href += "/"
 	}
-
 	return href
 }
 
+// extractPrincipalFromXML extracts the current-user-principal href from XML response.
+func (c *AppleCalendarClient) extractPrincipalFromXML(body []byte) string {
+	return firstHref(body, func(prop davProp) davHrefs { return prop.CurrentUserPrincipal })
+}
+
+// extractCalendarHomeFromXML extracts the calendar-home-set href from XML response.
+func (c *AppleCalendarClient) extractCalendarHomeFromXML(body []byte) string {
+	return firstHref(body, func(prop davProp) davHrefs { return prop.CalendarHomeSet })
+}
+
 // CalendarInfo represents a calendar found in the CalDAV response.
 type CalendarInfo struct {
-	Name string
-	Path string
+	Name  string
+	Path  string
+	Color string // calendar-color, if the server returned it
 }
 
 // parseCalendarListFromXML parses the PROPFIND response to extract calendar list.
+// Every response is listed, including the calendar home itself, with the
+// displayname from whichever propstat carries it.
 func (c *AppleCalendarClient) parseCalendarListFromXML(body []byte) []CalendarInfo {
-	var calendars []CalendarInfo
-	bodyStr := string(body)
+	multistatus, err := parseMultistatus(body)
+	if err != nil {
+		return nil
+	}
 
-	// Look for all <response> blocks
-	responseIdx := 0
-	for {
-		responseStart := strings.Index(bodyStr[responseIdx:], "<response")
-		if responseStart == -1 {
-			break
+	var calendars []CalendarInfo
+	for _, resp := range multistatus.Responses {
+		path := strings.TrimSpace(resp.Href)
+		if path == "" {
+			continue
 		}
-		responseStart += responseIdx
-
-		// Find the end of this response block
-		responseEnd := strings.Index(bodyStr[responseStart:], "</response>")
-		if responseEnd == -1 {
-			break
+		var name, color string
+		for _, propstat := range resp.Propstats {
+			if name == "" {
+				name = strings.TrimSpace(propstat.Prop.DisplayName)
+			}
+			if color == "" {
+				color = strings.TrimSpace(propstat.Prop.CalendarColor)
+			}
 		}
-		responseEnd += responseStart + len("</response>")
-
-		responseBlock := bodyStr[responseStart:responseEnd]
-
-		// Extract href (the path)
-		hrefStart := strings.Index(responseBlock, "href")
-		if hrefStart != -1 {
-			// Find href value
-			hrefValueStart := strings.Index(responseBlock[hrefStart:], ">")
-			if hrefValueStart == -1 {
-				hrefValueStart = strings.Index(responseBlock[hrefStart:], "=\"")
-				if hrefValueStart != -1 {
-					hrefValueStart += 2
-					hrefValueEnd := strings.Index(responseBlock[hrefStart+hrefValueStart:], "\"")
-					if hrefValueEnd > 0 {
-						path := responseBlock[hrefStart+hrefValueStart : hrefStart+hrefValueStart+hrefValueEnd]
-
-						// Extract displayname
-						displayNameStart := strings.Index(responseBlock, "displayname")
-						var name string
-						if displayNameStart != -1 {
-							nameStart := strings.Index(responseBlock[displayNameStart:], ">")
-							if nameStart != -1 {
-								nameStart += displayNameStart + 1
-								nameEnd := strings.Index(responseBlock[nameStart:], "<")
-								if nameEnd > 0 {
-									name = strings.TrimSpace(responseBlock[nameStart : nameStart+nameEnd])
-								}
-							}
-						}
+		calendars = append(calendars, CalendarInfo{Name: name, Path: path, Color: color})
+	}
+	return calendars
+}
 
-						if path != "" {
-							calendars = append(calendars, CalendarInfo{
-								Name: name,
-								Path: path,
-							})
-						}
-					}
-				}
-			} else {
-				hrefValueStart += hrefStart + 1
-				hrefValueEnd := strings.Index(responseBlock[hrefValueStart:], "<")
-				if hrefValueEnd > 0 {
-					path := strings.TrimSpace(responseBlock[hrefValueStart : hrefValueStart+hrefValueEnd])
-
-					// Extract displayname
-					displayNameStart := strings.Index(responseBlock, "displayname")
-					var name string
-					if displayNameStart != -1 {
-						nameStart := strings.Index(responseBlock[displayNameStart:], ">")
-						if nameStart != -1 {
-							nameStart += displayNameStart + 1
-							nameEnd := strings.Index(responseBlock[nameStart:], "<")
-							if nameEnd > 0 {
-								name = strings.TrimSpace(responseBlock[nameStart : nameStart+nameEnd])
-							}
-						}
-					}
+// googleCalendarColors maps the IDs of Google's calendar color palette to the
+// #RRGGBBAA values Apple Calendar uses for its calendar-color property.
+var googleCalendarColors = map[string]string{
+	"1": "#AC725EFF", "2": "#D06B64FF", "3": "#F83A22FF", "4": "#FA573CFF",
+	"5": "#FF7537FF", "6": "#FFAD46FF", "7": "#42D692FF", "8": "#16A765FF",
+	"9": "#7BD148FF", "10": "#B3DC6CFF", "11": "#FBE983FF", "12": "#FAD165FF",
+	"13": "#92E1C0FF", "14": "#9FE1E7FF", "15": "#9FC6E7FF", "16": "#4986E7FF",
+	"17": "#9A9CFFFF", "18": "#B99AFFFF", "19": "#C2C2C2FF", "20": "#CABDBFFF",
+	"21": "#CCA6ACFF", "22": "#F691B2FF", "23": "#CD74E6FF", "24": "#A47AE2FF",
+}
 
-					if path != "" {
-						calendars = append(calendars, CalendarInfo{
-							Name: name,
-							Path: path,
-						})
-					}
-				}
-			}
-		}
+// escapeXMLText escapes s for use as character data in a request body, so
+// calendar names such as "R&D <team>" don't break the XML.
+func escapeXMLText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
 
-		responseIdx = responseEnd
+// calendarColorProp returns the calendar-color property for a Google calendar
+// color ID, or "" if the ID is unset or unknown.
+func calendarColorProp(colorID string) string {
+	color, ok := googleCalendarColors[colorID]
+	if !ok {
+		if colorID != "" {
+			fmt.Printf("Warning: Unknown calendar color ID %q, creating the calendar without a color\n", colorID)
+		}
+		return ""
 	}
-
-	return calendars
+	return `
+      <A:calendar-color xmlns:A="http://apple.com/ns/ical/">` + color + `</A:calendar-color>`
 }
 
 // createCalendar creates a new calendar using CalDAV MKCALENDAR method (RFC 4791).
-// Falls back to MKCOL if MKCALENDAR is not supported.
-func (c *AppleCalendarClient) createCalendar(path, name string) error {
+// Falls back to MKCOL if MKCALENDAR is not supported. The calendar is given the
+// color of colorID, a Google calendar color ID, if it is one.
+func (c *AppleCalendarClient) createCalendar(path, name, colorID string) error {
 	url := strings.TrimSuffix(c.serverURL, "/") + path
+	colorProp := calendarColorProp(colorID)
 
 	// First, try MKCALENDAR (RFC 4791) - the proper CalDAV method for creating calendars
 	// According to https://www.onecal.io/blog/how-to-integrate-icloud-calendar-api-into-your-app
@@ -596,7 +712,7 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
 <mkcalendar xmlns="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
   <set>
     <prop>
-      <displayname xmlns="DAV:">` + name + `</displayname>
+      <displayname xmlns="DAV:">` + escapeXMLText(name) + `</displayname>` + colorProp + `
       <C:calendar-description xmlns:C="urn:ietf:params:xml:ns:caldav">Synced calendar from work account</C:calendar-description>
     </prop>
   </set>
@@ -607,11 +723,11 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to create calendar: %w", err)
 	}
@@ -636,17 +752,17 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
         <collection/>
         <C:calendar/>
       </resourcetype>
-      <displayname xmlns="DAV:">` + name + `</displayname>
+      <displayname xmlns="DAV:">` + escapeXMLText(name) + `</displayname>` + colorProp + `
     </prop>
   </set>
 </mkcalendar>`
 
 		req1b, err := http.NewRequest("MKCALENDAR", url, strings.NewReader(mkcalendarBody2))
 		if err == nil {
-			req1b.SetBasicAuth(c.username, c.password)
+			c.setAuth(req1b)
 			req1b.Header.Set("User-Agent", "calendar-sync/1.0")
 			req1b.Header.Set("Content-Type", "application/xml; charset=utf-8")
-			resp1b, err := c.httpClient.Do(req1b)
+			resp1b, err := c.do(req1b)
 			if err == nil {
 				resp1bBody, _ := io.ReadAll(resp1b.Body)
 				resp1b.Body.Close()
@@ -672,7 +788,7 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
         <collection/>
         <C:calendar/>
       </resourcetype>
-      <displayname xmlns="DAV:">` + name + `</displayname>
+      <displayname xmlns="DAV:">` + escapeXMLText(name) + `</displayname>` + colorProp + `
       <C:calendar-description xmlns:C="urn:ietf:params:xml:ns:caldav">Synced calendar from work account</C:calendar-description>
     </prop>
   </set>
@@ -683,11 +799,11 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
 			return fmt.Errorf("failed to create request: %w", err)
 		}
 
-		req2.SetBasicAuth(c.username, c.password)
+		c.setAuth(req2)
 		req2.Header.Set("User-Agent", "calendar-sync/1.0")
 		req2.Header.Set("Content-Type", "application/xml; charset=utf-8")
 
-		resp2, err := c.httpClient.Do(req2)
+		resp2, err := c.do(req2)
 		if err != nil {
 			return fmt.Errorf("failed to create calendar: %w", err)
 		}
@@ -713,11 +829,105 @@ func (c *AppleCalendarClient) createCalendar(path, name string) error {
 		resp.StatusCode, url, mkcalendarBody, respBodyStr, headers)
 }
 
+// sameCalendarColor reports whether a calendar-color value already is the
+// color of colorID. An unset or unknown colorID matches any color, as there is
+// nothing to change it to.
+func sameCalendarColor(color, colorID string) bool {
+	want, ok := googleCalendarColors[colorID]
+	if !ok {
+		return true
+	}
+	// Servers may leave out the alpha channel
+	color = strings.ToUpper(color)
+	if len(color) == len("#RRGGBB") {
+		color += "FF"
+	}
+	return color == want
+}
+
+// updateCalendarProperties sets the display name of the calendar at path, and
+// its color to that of colorID if it is a Google calendar color ID, with
+// PROPPATCH. An error names the properties the server did not set.
+func (c *AppleCalendarClient) updateCalendarProperties(path, name, colorID string) error {
+	proppatchBody := `<?xml version="1.0" encoding="utf-8"?>
+<propertyupdate xmlns="DAV:">
+  <set>
+    <prop>
+      <displayname xmlns="DAV:">` + escapeXMLText(name) + `</displayname>` + calendarColorProp(colorID) + `
+    </prop>
+  </set>
+</propertyupdate>`
+
+	resp, err := c.makeRequest("PROPPATCH", path, strings.NewReader(proppatchBody))
+	if err != nil {
+		return fmt.Errorf("failed to update calendar properties: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return fmt.Errorf("failed to update calendar properties: HTTP %d - %s", resp.StatusCode, string(body))
+	}
+
+	// Each property is reported in a propstat with the status of its update
+	type Prop struct {
+		Names []struct {
+			XMLName xml.Name
+		} `xml:",any"`
+	}
+	type Propstat struct {
+		Prop   Prop   `xml:"DAV: prop"`
+		Status string `xml:"DAV: status"`
+	}
+	type Multistatus struct {
+		Responses []struct {
+			Propstats []Propstat `xml:"DAV: propstat"`
+		} `xml:"DAV: response"`
+	}
+	var multistatus Multistatus
+	if err := xml.Unmarshal(body, &multistatus); err != nil {
+		return fmt.Errorf("failed to parse PROPPATCH response: %w", err)
+	}
+
+	var failed []string
+	for _, r := range multistatus.Responses {
+		for _, propstat := range r.Propstats {
+			// The status is a line such as "HTTP/1.1 200 OK"
+			fields := strings.Fields(propstat.Status)
+			if len(fields) >= 2 && strings.HasPrefix(fields[1], "2") {
+				continue
+			}
+			for _, prop := range propstat.Prop.Names {
+				failed = append(failed, fmt.Sprintf("%s (%s)", prop.XMLName.Local, strings.TrimSpace(propstat.Status)))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update calendar properties: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 // FindOrCreateCalendarByName finds an existing calendar by name or creates a new one.
 // Returns the calendar path.
 func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	return c.findCalendar(name, colorID, true)
+}
+
+// FindCalendarByName returns the path of the calendar with the given name, or
+// ErrCalendarNotFound if there is none.
+func (c *AppleCalendarClient) FindCalendarByName(name string) (string, error) {
+	return c.findCalendar(name, "", false)
+}
+
+// findCalendar looks up a calendar by name, creating it with MKCALENDAR if it
+// doesn't exist and create is set.
+func (c *AppleCalendarClient) findCalendar(name string, colorID string, create bool) (string, error) {
 	// List calendars using PROPFIND - request displayname to identify calendars
-	propfindBody := `<propfind xmlns='DAV:'><prop><displayname xmlns='DAV:'/></prop></propfind>`
+	propfindBody := `<propfind xmlns='DAV:'><prop><displayname xmlns='DAV:'/><A:calendar-color xmlns:A='http://apple.com/ns/ical/'/></prop></propfind>`
 
 	// Use Depth: 1 to get immediate children (calendars)
 	url := strings.TrimSuffix(c.serverURL, "/") + c.basePath
@@ -726,12 +936,12 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 		return "", fmt.Errorf("apple: failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
 	req.Header.Set("Depth", "1") // Depth: 1 for listing calendars
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("apple: failed to list calendars: %w", err)
 	}
@@ -750,11 +960,11 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 			// Try with propname (just property names, no values)
 			propnameBody := `<propfind xmlns='DAV:'><propname/></propfind>`
 			propnameReq, _ := http.NewRequest("PROPFIND", url, strings.NewReader(propnameBody))
-			propnameReq.SetBasicAuth(c.username, c.password)
+			c.setAuth(propnameReq)
 			propnameReq.Header.Set("User-Agent", "calendar-sync/1.0")
 			propnameReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 			propnameReq.Header.Set("Depth", "1")
-			propnameResp, err := c.httpClient.Do(propnameReq)
+			propnameResp, err := c.do(propnameReq)
 			if err == nil {
 				defer propnameResp.Body.Close()
 				if propnameResp.StatusCode == http.StatusOK || propnameResp.StatusCode == http.StatusMultiStatus {
@@ -775,11 +985,11 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 			// Try with specific properties but without redundant namespace declarations
 			specificBody := `<propfind xmlns='DAV:'><prop><displayname/></prop></propfind>`
 			specificReq, _ := http.NewRequest("PROPFIND", url, strings.NewReader(specificBody))
-			specificReq.SetBasicAuth(c.username, c.password)
+			c.setAuth(specificReq)
 			specificReq.Header.Set("User-Agent", "calendar-sync/1.0")
 			specificReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 			specificReq.Header.Set("Depth", "1")
-			specificResp, err := c.httpClient.Do(specificReq)
+			specificResp, err := c.do(specificReq)
 			if err == nil {
 				defer specificResp.Body.Close()
 				if specificResp.StatusCode == http.StatusOK || specificResp.StatusCode == http.StatusMultiStatus {
@@ -808,17 +1018,17 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 				}
 				altURL := strings.TrimSuffix(c.serverURL, "/") + altPath
 				altReq, _ := http.NewRequest("PROPFIND", altURL, strings.NewReader(propfindBody))
-				altReq.SetBasicAuth(c.username, c.password)
+				c.setAuth(altReq)
 				altReq.Header.Set("User-Agent", "calendar-sync/1.0")
 				altReq.Header.Set("Content-Type", "application/xml; charset=utf-8")
 				altReq.Header.Set("Depth", "1")
-				altResp, err := c.httpClient.Do(altReq)
+				altResp, err := c.do(altReq)
 				if err == nil {
 					altResp.Body.Close()
 					if altResp.StatusCode == http.StatusOK || altResp.StatusCode == http.StatusMultiStatus {
 						// Update basePath and retry
 						c.basePath = altPath
-						return c.FindOrCreateCalendarByName(name, colorID)
+						return c.findCalendar(name, colorID, create)
 					}
 				}
 			}
@@ -839,10 +1049,19 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 	// Check if a calendar with the given name exists
 	for _, cal := range calendars {
 		if cal.Name == name {
+			if create && !sameCalendarColor(cal.Color, colorID) {
+				if err := c.updateCalendarProperties(cal.Path, name, colorID); err != nil {
+					fmt.Printf("Warning: Failed to update the color of calendar '%s': %v\n", name, err)
+				}
+			}
 			return cal.Path, nil
 		}
 	}
 
+	if !create {
+		return "", fmt.Errorf("apple: calendar '%s': %w", name, ErrCalendarNotFound)
+	}
+
 	// Calendar doesn't exist - try to create it
 	// According to RFC 4791 and iCloud documentation, MKCALENDAR is supported
 	// iCloud typically uses UUID-based paths for calendars (as seen in existing calendars)
@@ -865,7 +1084,7 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 	calendarPath = strings.ReplaceAll(calendarPath, "//", "/")
 
 	// Create calendar using MKCOL
-	err = c.createCalendar(calendarPath, name)
+	err = c.createCalendar(calendarPath, name, colorID)
 	if err != nil {
 		// If creation fails, provide helpful error message
 		return "", fmt.Errorf("apple: calendar '%s' not found and automatic creation failed: %w\n\nPlease create the calendar '%s' manually in Apple Calendar/iCloud, then run the sync again.", name, err, name)
@@ -874,11 +1093,11 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 	// After creation, re-list to get the actual path (iCloud may assign a different path)
 	req2, err := http.NewRequest("PROPFIND", url, strings.NewReader(propfindBody))
 	if err == nil {
-		req2.SetBasicAuth(c.username, c.password)
+		c.setAuth(req2)
 		req2.Header.Set("User-Agent", "calendar-sync/1.0")
 		req2.Header.Set("Content-Type", "application/xml; charset=utf-8")
 		req2.Header.Set("Depth", "1")
-		resp2, err := c.httpClient.Do(req2)
+		resp2, err := c.do(req2)
 		if err == nil {
 			defer resp2.Body.Close()
 			if resp2.StatusCode == http.StatusOK || resp2.StatusCode == http.StatusMultiStatus {
@@ -900,7 +1119,85 @@ func (c *AppleCalendarClient) FindOrCreateCalendarByName(name string, colorID st
 }
 
 // GetEvents retrieves events from a calendar within the specified time window.
+// A window within that of an earlier listing is served from the event cache.
 func (c *AppleCalendarClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	cached, err := c.cachedListing(calendarID, timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	var events []*calendar.Event
+	for _, event := range cached.events {
+		if eventOverlaps(event, timeMin, timeMax) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// cachedListing returns the cached listing of a calendar if it covers the
+// window, and lists the calendar over the window and caches it otherwise.
+// The caller must not modify the listing.
+func (c *AppleCalendarClient) cachedListing(calendarID string, timeMin, timeMax time.Time) (*cachedEvents, error) {
+	key := strings.TrimSuffix(calendarID, "/")
+	c.eventCacheMu.Lock()
+	defer c.eventCacheMu.Unlock()
+
+	if cached, ok := c.eventCache[key]; ok && !timeMin.Before(cached.timeMin) && !timeMax.After(cached.timeMax) {
+		return cached, nil
+	}
+	events, err := c.queryEvents(calendarID, timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	cached := &cachedEvents{timeMin: timeMin, timeMax: timeMax, events: events}
+	if c.eventCache == nil {
+		c.eventCache = make(map[string]*cachedEvents)
+	}
+	c.eventCache[key] = cached
+	return cached, nil
+}
+
+// eventOverlaps reports whether an event overlaps the window, as a
+// calendar-query time-range would have matched it. A recurring series is
+// taken to overlap once it has started, as its end isn't known unexpanded.
+func eventOverlaps(event *calendar.Event, timeMin, timeMax time.Time) bool {
+	start, okStart := eventBoundary(event.Start)
+	end, okEnd := eventBoundary(event.End)
+	if !okStart || !okEnd {
+		// Keep what can't be placed, as the server listed it
+		return true
+	}
+	if len(event.Recurrence) > 0 {
+		return start.Before(timeMax)
+	}
+	return start.Before(timeMax) && end.After(timeMin)
+}
+
+// eventBoundary parses the start or end of an event. All-day dates are taken
+// as midnight UTC.
+func eventBoundary(dt *calendar.EventDateTime) (time.Time, bool) {
+	if dt == nil {
+		return time.Time{}, false
+	}
+	if dt.Date != "" {
+		t, err := time.Parse("2006-01-02", dt.Date)
+		return t, err == nil
+	}
+	t, err := time.Parse(time.RFC3339, dt.DateTime)
+	return t, err == nil
+}
+
+// ResetEventCache drops the cached listings of every calendar, so the next
+// sync run sees the changes made to them since.
+func (c *AppleCalendarClient) ResetEventCache() {
+	c.eventCacheMu.Lock()
+	defer c.eventCacheMu.Unlock()
+	c.eventCache = nil
+}
+
+// queryEvents lists the events of a calendar within a time window with a
+// calendar-query REPORT.
+func (c *AppleCalendarClient) queryEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
 	// Build CalDAV REPORT query
 	queryBody := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8" ?>
 <C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
@@ -966,6 +1263,13 @@ func (c *AppleCalendarClient) GetEvents(calendarID string, timeMin, timeMax time
 	return googleEvents, nil
 }
 
+// GetEventsUnexpanded retrieves events like GetEvents. The REPORT query does not
+// ask the server to expand recurrences, so series are already returned as their
+// master event with RRULE/EXDATE in Recurrence.
+func (c *AppleCalendarClient) GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	return c.GetEvents(calendarID, timeMin, timeMax)
+}
+
 // GetEvent retrieves a single event by ID.
 func (c *AppleCalendarClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
 	// Fetch the event using GET
@@ -988,20 +1292,50 @@ func (c *AppleCalendarClient) GetEvent(calendarID, eventID string) (*calendar.Ev
 	return icalToGoogleEvent(icalCal)
 }
 
+// originalUIDProperty holds the ID an event was inserted with, when it had to
+// be sanitized to serve as the UID and file name.
+const originalUIDProperty = "X-CALSYNC-ORIGINAL-UID"
+
+// privateProperty holds the private extended properties that have no
+// iCalendar property of their own, as a JSON object, so tags like those of
+// preserve_tag survive the round trip.
+const privateProperty = "X-CALSYNC-PRIVATE"
+
+// sanitizeEventID replaces the characters of an event ID that can't appear in
+// a file name on every server: path separators and colons.
+func sanitizeEventID(eventID string) string {
+	return strings.NewReplacer("/", "-", "\\", "-", ":", "-").Replace(eventID)
+}
+
+// eventFileName returns the name of the resource an event is stored under,
+// given either its ID or the file name itself.
+func eventFileName(eventID string) string {
+	name := sanitizeEventID(eventID)
+	if !strings.HasSuffix(name, ".ics") {
+		name += ".ics"
+	}
+	return name
+}
+
+// crlfLines returns an encoded iCalendar object with every line ended by
+// CRLF, as RFC 5545 requires and strict CalDAV servers insist on, whatever
+// line endings it was written with.
+func crlfLines(data []byte) []byte {
+	lf := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+}
+
 // InsertEvent inserts a new event into a calendar.
 func (c *AppleCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	c.forgetEvents(calendarID)
+
 	// Convert Google Calendar Event to iCalendar format
 	icalCal, err := googleEventToICal(event)
 	if err != nil {
 		return fmt.Errorf("failed to convert event: %w", err)
 	}
-
-	// Serialize to iCalendar format
-	var buf bytes.Buffer
-	enc := ical.NewEncoder(&buf)
-	if err := enc.Encode(icalCal); err != nil {
-		return fmt.Errorf("failed to encode iCalendar: %w", err)
-	}
+	setProductID(icalCal, c.productID)
+	setCategories(icalCal, c.colorCategories[event.ColorId])
 
 	// Generate a unique event ID - ensure it ends with .ics
 	// The event.Id from Google Calendar might contain special characters that need to be sanitized
@@ -1010,34 +1344,47 @@ func (c *AppleCalendarClient) InsertEvent(calendarID string, event *calendar.Eve
 		// Generate a UID if not present
 		originalEventID = fmt.Sprintf("%s@calendar-sync", time.Now().Format(time.RFC3339Nano))
 	}
+	sanitizedEventID := eventFileName(originalEventID)
 
-	// Sanitize the event ID for use in URL (remove special characters that might cause issues)
-	sanitizedEventID := strings.ReplaceAll(originalEventID, "/", "-")
-	sanitizedEventID = strings.ReplaceAll(sanitizedEventID, "\\", "-")
-	sanitizedEventID = strings.ReplaceAll(sanitizedEventID, ":", "-")
+	// The UID matches the file name, so servers relating the two aren't
+	// confused; an ID that had to change is kept to be read back instead
+	for _, comp := range icalCal.Children {
+		if comp.Name == ical.CompEvent {
+			uid := sanitizeEventID(originalEventID)
+			comp.Props.SetText(ical.PropUID, uid)
+			if event.Id != "" && uid != event.Id {
+				comp.Props.SetText(originalUIDProperty, event.Id)
+			}
+			break
+		}
+	}
 
-	if !strings.HasSuffix(sanitizedEventID, ".ics") {
-		sanitizedEventID = sanitizedEventID + ".ics"
+	// Serialize to iCalendar format
+	var buf bytes.Buffer
+	enc := ical.NewEncoder(&buf)
+	if err := enc.Encode(icalCal); err != nil {
+		return fmt.Errorf("failed to encode iCalendar: %w", err)
 	}
+	body := crlfLines(buf.Bytes())
 
 	// Build the full URL - ensure calendarID ends with / and we don't have double slashes
 	calendarPath := strings.TrimSuffix(calendarID, "/") + "/"
 	url := strings.TrimSuffix(c.serverURL, "/") + calendarPath + sanitizedEventID
 
 	// Create PUT request with proper headers for iCalendar
-	req, err := http.NewRequest("PUT", url, &buf)
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
 
 	// Get iCalendar content for error reporting
-	icalContent := buf.String()
+	icalContent := string(body)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to insert event: %w", err)
 	}
@@ -1047,6 +1394,10 @@ func (c *AppleCalendarClient) InsertEvent(calendarID string, event *calendar.Eve
 	respBody, _ := io.ReadAll(resp.Body)
 	respBodyStr := string(respBody)
 
+	if (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict) && !c.calendarExists(calendarID) {
+		return fmt.Errorf("failed to insert event: %w: %s", ErrCalendarNotFound, calendarID)
+	}
+
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		// Include detailed error information
 		headers := ""
@@ -1067,13 +1418,15 @@ func (c *AppleCalendarClient) InsertEvent(calendarID string, event *calendar.Eve
 
 // UpdateEvent updates an existing event in a calendar.
 func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	c.forgetEvents(calendarID)
+
 	// For CalDAV, update is the same as insert (PUT), but we need to use the existing eventID
 	// (filename) instead of generating a new one from event.Id
 	// IMPORTANT: We must preserve the original UID from the existing event to avoid creating duplicates
 	// CalDAV servers use the UID to identify events. If the UID changes, it creates a new event instead of updating.
 	// Fetch the raw iCalendar to get the original UID
-	existingUID := ""
-	resp, err := c.makeRequest("GET", calendarID+eventID, nil)
+	existingUID, existingOriginalID := "", ""
+	resp, err := c.makeRequest("GET", strings.TrimSuffix(calendarID, "/")+"/"+eventFileName(eventID), nil)
 	if err == nil {
 		defer resp.Body.Close()
 		if resp.StatusCode == http.StatusOK {
@@ -1087,6 +1440,7 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 								existingUID = uidText
 							}
 						}
+						existingOriginalID, _ = comp.Props.Text(originalUIDProperty)
 						break
 					}
 				}
@@ -1113,6 +1467,8 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 	if err != nil {
 		return fmt.Errorf("failed to convert event: %w", err)
 	}
+	setProductID(icalCal, c.productID)
+	setCategories(icalCal, c.colorCategories[event.ColorId])
 
 	// If we have an original UID, use it instead of the generated one
 	if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
@@ -1121,6 +1477,9 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 			for _, comp := range icalCal.Children {
 				if comp.Name == ical.CompEvent {
 					comp.Props.SetText(ical.PropUID, originalUID)
+					if existingOriginalID != "" {
+						comp.Props.SetText(originalUIDProperty, existingOriginalID)
+					}
 					break
 				}
 			}
@@ -1135,36 +1494,30 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 	if err := enc.Encode(icalCal); err != nil {
 		return fmt.Errorf("failed to encode iCalendar: %w", err)
 	}
+	body := crlfLines(buf.Bytes())
 
 	// Use the provided eventID (which is the filename from GetEvents)
 	// Sanitize it just in case
-	sanitizedEventID := strings.ReplaceAll(eventID, "/", "-")
-	sanitizedEventID = strings.ReplaceAll(sanitizedEventID, "\\", "-")
-	sanitizedEventID = strings.ReplaceAll(sanitizedEventID, ":", "-")
-
-	// Ensure it ends with .ics
-	if !strings.HasSuffix(sanitizedEventID, ".ics") {
-		sanitizedEventID = sanitizedEventID + ".ics"
-	}
+	sanitizedEventID := eventFileName(eventID)
 
 	// Build the full URL - ensure calendarID ends with / and we don't have double slashes
 	calendarPath := strings.TrimSuffix(calendarID, "/") + "/"
 	url := strings.TrimSuffix(c.serverURL, "/") + calendarPath + sanitizedEventID
 
 	// Create PUT request with proper headers for iCalendar
-	req, err := http.NewRequest("PUT", url, &buf)
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
 
 	// Get iCalendar content for error reporting
-	icalContent := buf.String()
+	icalContent := string(body)
 
-	resp2, err2 := c.httpClient.Do(req)
+	resp2, err2 := c.do(req)
 	if err2 != nil {
 		return fmt.Errorf("failed to update event: %w", err2)
 	}
@@ -1175,6 +1528,10 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 	respBody, _ := io.ReadAll(resp.Body)
 	respBodyStr := string(respBody)
 
+	if (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict) && !c.calendarExists(calendarID) {
+		return fmt.Errorf("failed to update event: %w: %s", ErrCalendarNotFound, calendarID)
+	}
+
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		// Include detailed error information
 		headers := ""
@@ -1195,16 +1552,11 @@ func (c *AppleCalendarClient) UpdateEvent(calendarID, eventID string, event *cal
 
 // DeleteEvent deletes an event from a calendar.
 func (c *AppleCalendarClient) DeleteEvent(calendarID, eventID string) error {
+	c.forgetEvents(calendarID)
+
 	// The eventID should already be the filename (href) from GetEvents, which includes .ics
 	// But we'll sanitize it just in case and ensure it has .ics
-	sanitizedID := strings.ReplaceAll(eventID, "/", "-")
-	sanitizedID = strings.ReplaceAll(sanitizedID, "\\", "-")
-	sanitizedID = strings.ReplaceAll(sanitizedID, ":", "-")
-
-	// Ensure it ends with .ics
-	if !strings.HasSuffix(sanitizedID, ".ics") {
-		sanitizedID = sanitizedID + ".ics"
-	}
+	sanitizedID := eventFileName(eventID)
 
 	// Build the full URL - ensure calendarID ends with / and we don't have double slashes
 	calendarPath := strings.TrimSuffix(calendarID, "/") + "/"
@@ -1216,10 +1568,10 @@ func (c *AppleCalendarClient) DeleteEvent(calendarID, eventID string) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.setAuth(req)
 	req.Header.Set("User-Agent", "calendar-sync/1.0")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
@@ -1243,30 +1595,109 @@ func (c *AppleCalendarClient) DeleteEvent(calendarID, eventID string) error {
 	return nil
 }
 
+// calendarExists reports whether the calendar collection at calendarID is still
+// present on the server. Used to tell a missing calendar from a missing event.
+func (c *AppleCalendarClient) calendarExists(calendarID string) bool {
+	resp, err := c.makeRequest("PROPFIND", calendarID, nil)
+	if err != nil {
+		// Can't tell; assume it exists so the original error is reported
+		return true
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode != http.StatusNotFound
+}
+
+// CalendarExists checks that a calendar collection exists. Returns
+// ErrCalendarNotFound (wrapped) if the server doesn't know it.
+func (c *AppleCalendarClient) CalendarExists(calendarID string) error {
+	resp, err := c.makeRequest("PROPFIND", calendarID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("apple: calendar %s: %w", calendarID, ErrCalendarNotFound)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("failed to check calendar: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// appleClearConcurrency bounds the number of DELETE requests ClearRange keeps in
+// flight against the CalDAV server.
+const appleClearConcurrency = 4
+
+// ClearRange deletes every event in a calendar within the specified time window.
+// CalDAV has no bulk delete, so the per-resource DELETEs are issued concurrently.
+func (c *AppleCalendarClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	events, err := c.GetEvents(calendarID, timeMin, timeMax)
+	if err != nil {
+		return fmt.Errorf("failed to list events to clear: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, appleClearConcurrency)
+	for _, event := range events {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(eventID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := c.DeleteEvent(calendarID, eventID); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(event.Id)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
 // FindEventsByWorkID finds events in a calendar that have a specific workEventId
 // in their private extended properties.
+// CalDAV can't search by an X- property, so the calendar's events over a wide
+// window are listed once and indexed by workEventId for the lookups that follow.
 func (c *AppleCalendarClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
-	// Get all events in a wide time range
-	now := time.Now()
-	timeMin := now.AddDate(-1, 0, 0) // 1 year ago
-	timeMax := now.AddDate(1, 0, 0)  // 1 year from now
+	// Get all events in a wide time range, in whole days so that every
+	// lookup of the day asks for the same one
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	timeMin := today.AddDate(-1, 0, 0) // 1 year ago
+	timeMax := today.AddDate(1, 0, 1)  // 1 year from now
 
-	events, err := c.GetEvents(calendarID, timeMin, timeMax)
+	cached, err := c.cachedListing(calendarID, timeMin, timeMax)
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter events by workEventId
-	var results []*calendar.Event
-	for _, event := range events {
-		if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
-			if event.ExtendedProperties.Private["workEventId"] == workEventID {
-				results = append(results, event)
+	c.eventCacheMu.Lock()
+	defer c.eventCacheMu.Unlock()
+	if cached.byWorkID == nil {
+		cached.byWorkID = make(map[string][]*calendar.Event)
+		for _, event := range cached.events {
+			if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
+				if workID := event.ExtendedProperties.Private["workEventId"]; workID != "" {
+					cached.byWorkID[workID] = append(cached.byWorkID[workID], event)
+				}
 			}
 		}
 	}
+	return slices.Clone(cached.byWorkID[workEventID]), nil
+}
 
-	return results, nil
+// forgetEvents drops the cached listing of a calendar, if it has one.
+func (c *AppleCalendarClient) forgetEvents(calendarID string) {
+	c.eventCacheMu.Lock()
+	defer c.eventCacheMu.Unlock()
+	delete(c.eventCache, strings.TrimSuffix(calendarID, "/"))
 }
 
 // CalDAVEvent represents an event with its href (filename) and iCalendar data.
@@ -1340,10 +1771,13 @@ func icalToGoogleEvent(icalCal *ical.Calendar) (*calendar.Event, error) {
 
 	event := &calendar.Event{}
 
-	// Extract UID (event ID)
+	// Extract UID (event ID), or the ID it was sanitized from
 	if uid := vevent.Props.Get(ical.PropUID); uid != nil {
 		event.Id = uid.Value
 	}
+	if original, err := vevent.Props.Text(originalUIDProperty); err == nil && original != "" {
+		event.Id = original
+	}
 
 	// Extract summary (use Text() to unescape iCalendar escaping)
 	if summary := vevent.Props.Get(ical.PropSummary); summary != nil {
@@ -1394,6 +1828,7 @@ func icalToGoogleEvent(icalCal *ical.Calendar) (*calendar.Event, error) {
 				// Timed event
 				event.Start = &calendar.EventDateTime{
 					DateTime: startTime.Format(time.RFC3339),
+					TimeZone: icalTimeZone(dtstart),
 				}
 			}
 		}
@@ -1416,12 +1851,20 @@ func icalToGoogleEvent(icalCal *ical.Calendar) (*calendar.Event, error) {
 				if event.End == nil {
 					event.End = &calendar.EventDateTime{
 						DateTime: endTime.Format(time.RFC3339),
+						TimeZone: icalTimeZone(dtend),
 					}
 				}
 			}
 		}
 	}
 
+	// Extract recurrence lines in Google's "NAME;PARAM=VALUE:VALUE" form
+	for _, name := range recurrencePropNames {
+		for _, prop := range vevent.Props.Values(name) {
+			event.Recurrence = append(event.Recurrence, recurrenceLine(prop))
+		}
+	}
+
 	// Extract transparency (for OOF detection)
 	if transp := vevent.Props.Get("TRANSP"); transp != nil {
 		if text, err := transp.Text(); err == nil && text == "TRANSPARENT" {
@@ -1431,13 +1874,44 @@ func icalToGoogleEvent(icalCal *ical.Calendar) (*calendar.Event, error) {
 
 	// Extract extended properties (for workEventId tracking)
 	// Store in X- properties
-	if xWorkID := vevent.Props.Get("X-WORK-EVENT-ID"); xWorkID != nil {
+	if workID := findWorkEventID(vevent.Props); workID != "" {
 		if event.ExtendedProperties == nil {
 			event.ExtendedProperties = &calendar.EventExtendedProperties{
 				Private: make(map[string]string),
 			}
 		}
-		event.ExtendedProperties.Private["workEventId"] = xWorkID.Value
+		event.ExtendedProperties.Private["workEventId"] = workID
+	}
+
+	// Extract the other private properties
+	if prop := vevent.Props.Get(privateProperty); prop != nil {
+		var stored map[string]string
+		if text, err := prop.Text(); err == nil && json.Unmarshal([]byte(text), &stored) == nil && len(stored) > 0 {
+			if event.ExtendedProperties == nil {
+				event.ExtendedProperties = &calendar.EventExtendedProperties{}
+			}
+			if event.ExtendedProperties.Private == nil {
+				event.ExtendedProperties.Private = make(map[string]string)
+			}
+			for key, value := range stored {
+				if _, set := event.ExtendedProperties.Private[key]; !set {
+					event.ExtendedProperties.Private[key] = value
+				}
+			}
+		}
+	}
+
+	// Extract coordinates from GEO
+	if geoProp := vevent.Props.Get("GEO"); geoProp != nil {
+		if geo, ok := NormalizeGeo(geoProp.Value); ok {
+			if event.ExtendedProperties == nil {
+				event.ExtendedProperties = &calendar.EventExtendedProperties{}
+			}
+			if event.ExtendedProperties.Private == nil {
+				event.ExtendedProperties.Private = make(map[string]string)
+			}
+			event.ExtendedProperties.Private[GeoPropertyKey] = geo
+		}
 	}
 
 	// Extract Google Meet/conference data from URL property or X-GOOGLE-CONFERENCE
@@ -1466,14 +1940,177 @@ func icalToGoogleEvent(icalCal *ical.Calendar) (*calendar.Event, error) {
 		}
 	}
 
+	// Extract reminders from VALARM components
+	event.Reminders = alarmReminders(vevent)
+
+	// Extract status (CONFIRMED, TENTATIVE or CANCELLED)
+	if status, err := vevent.Props.Text(ical.PropStatus); err == nil {
+		switch status = strings.ToLower(status); status {
+		case "confirmed", "tentative", "cancelled":
+			event.Status = status
+		}
+	}
+
 	return event, nil
 }
 
+// alarmReminders returns the reminders of an event's VALARM components, or nil
+// if it has none. Only alarms a number of minutes before the start can be
+// expressed as reminders; others are left out.
+func alarmReminders(vevent *ical.Component) *calendar.EventReminders {
+	var overrides []*calendar.EventReminder
+	for _, alarm := range vevent.Children {
+		// iCloud adds its own default alarms to events that have none
+		if alarm.Name != ical.CompAlarm || alarm.Props.Get("X-APPLE-DEFAULT-ALARM") != nil {
+			continue
+		}
+		trigger := alarm.Props.Get(ical.PropTrigger)
+		if trigger == nil || trigger.Params.Get(ical.ParamRelated) == "END" {
+			continue
+		}
+		// An absolute trigger (a DATE-TIME) fails to parse as a duration
+		before, err := trigger.Duration()
+		if err != nil || before > 0 {
+			continue
+		}
+		minutes := int64(-before / time.Minute)
+		method := "popup"
+		if action, _ := alarm.Props.Text(ical.PropAction); strings.EqualFold(action, "EMAIL") {
+			method = "email"
+		}
+		overrides = append(overrides, &calendar.EventReminder{Method: method, Minutes: minutes})
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	return &calendar.EventReminders{Overrides: overrides, ForceSendFields: []string{"UseDefault"}}
+}
+
+// findWorkEventID returns the value of the X-WORK-EVENT-ID property, or an empty
+// string if it is not present.
+// Some servers re-emit X- properties with different case or separators
+// (e.g. "x-work-event-id", "X-WORK_EVENT_ID"), so the name is matched
+// case-insensitively with '-' and '_' ignored. Losing the tag would make a
+// synced event look manually created and get it deleted.
+func findWorkEventID(props ical.Props) string {
+	if prop := props.Get("X-WORK-EVENT-ID"); prop != nil {
+		return workEventIDValue(prop)
+	}
+	for name, values := range props {
+		if len(values) == 0 {
+			continue
+		}
+		normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToUpper(name))
+		if normalized == "XWORKEVENTID" {
+			return workEventIDValue(&values[0])
+		}
+	}
+	return ""
+}
+
+// workEventIDValue returns the unescaped, trimmed text of a workEventId property.
+func workEventIDValue(prop *ical.Prop) string {
+	if text, err := prop.Text(); err == nil {
+		return strings.TrimSpace(text)
+	}
+	return strings.TrimSpace(prop.Value)
+}
+
+// recurrencePropNames lists the iCalendar properties carried in an event's Recurrence.
+var recurrencePropNames = []string{ical.PropRecurrenceRule, ical.PropRecurrenceDates, ical.PropExceptionDates}
+
+// recurrenceLine formats a recurrence property as a Google Recurrence line.
+// Parameters are sorted so the same property always gives the same line.
+func recurrenceLine(prop ical.Prop) string {
+	var b strings.Builder
+	b.WriteString(prop.Name)
+	names := make([]string, 0, len(prop.Params))
+	for name := range prop.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(";" + name + "=" + strings.Join(prop.Params[name], ","))
+	}
+	b.WriteString(":" + prop.Value)
+	return b.String()
+}
+
+// recurrenceProp parses a Google Recurrence line such as "RRULE:FREQ=WEEKLY"
+// or "EXDATE;VALUE=DATE:20240115" into an iCalendar property. Returns nil for
+// lines that are not a recurrence property.
+func recurrenceProp(line string) *ical.Prop {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil
+	}
+	parts := strings.Split(head, ";")
+	name := strings.ToUpper(parts[0])
+	if !slices.Contains(recurrencePropNames, name) {
+		return nil
+	}
+	prop := ical.NewProp(name)
+	for _, param := range parts[1:] {
+		if key, val, ok := strings.Cut(param, "="); ok {
+			prop.Params.Set(strings.ToUpper(key), val)
+		}
+	}
+	prop.Value = value
+	return prop
+}
+
+// DefaultProductID is the PRODID of written events unless one is configured.
+const DefaultProductID = "-//Calendar Sync//EN"
+
+// setProductID sets the PRODID of cal, using DefaultProductID if productID is empty.
+func setProductID(cal *ical.Calendar, productID string) {
+	if productID == "" {
+		productID = DefaultProductID
+	}
+	cal.Props.SetText(ical.PropProductID, productID)
+}
+
+// setCategories sets the CATEGORIES of cal's event to category, if any.
+func setCategories(cal *ical.Calendar, category string) {
+	if category == "" {
+		return
+	}
+	for _, comp := range cal.Children {
+		if comp.Name == ical.CompEvent {
+			comp.Props.SetText(ical.PropCategories, category)
+		}
+	}
+}
+
+// icalDateTime returns the start or end of a timed event as a DATE-TIME
+// property. A time with an IANA time zone, such as "America/New_York", is
+// written as local time with that TZID, so clients keep it in that zone
+// across daylight saving changes, e.g. for recurring events. Others are
+// written in UTC, as an offset alone names no zone.
+func icalDateTime(name string, dt *calendar.EventDateTime) (*ical.Prop, error) {
+	t, err := time.Parse(time.RFC3339, dt.DateTime)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.UTC
+	if dt.TimeZone != "" {
+		if zone, err := time.LoadLocation(dt.TimeZone); err == nil {
+			loc = zone
+		} else {
+			log.Printf("Warning: unknown time zone '%s', writing %s in UTC", dt.TimeZone, name)
+		}
+	}
+	prop := ical.NewProp(name)
+	// SetDateTime writes UTC times with a Z suffix and others with their TZID
+	prop.SetDateTime(t.In(loc))
+	return prop, nil
+}
+
 // googleEventToICal converts a Google Calendar Event to iCalendar format.
 func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 	cal := ical.NewCalendar()
 	cal.Props.SetText(ical.PropVersion, "2.0")
-	cal.Props.SetText(ical.PropProductID, "-//Calendar Sync//EN")
+	setProductID(cal, "")
 
 	vevent := ical.NewComponent(ical.CompEvent)
 	cal.Children = append(cal.Children, vevent)
@@ -1509,20 +2146,14 @@ func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 			if err == nil {
 				dtstart := ical.NewProp("DTSTART")
 				dtstart.SetDate(startDate)
-				// Set VALUE=DATE parameter for all-day events
+				// Set VALUE=DATE parameter for all-day events. SetDate writes no TZID, which
+				// keeps the date floating so a UTC-defaulted calendar can't shift it a day.
 				dtstart.Params.Set("VALUE", "DATE")
 				vevent.Props.Set(dtstart)
 			}
 		} else if event.Start.DateTime != "" {
 			// Timed event
-			startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
-			if err == nil {
-				dtstart := ical.NewProp("DTSTART")
-				dtstart.SetDateTime(startTime)
-				// Ensure timezone is UTC if not specified
-				if startTime.Location() == time.UTC {
-					dtstart.Params.Set("TZID", "UTC")
-				}
+			if dtstart, err := icalDateTime(ical.PropDateTimeStart, event.Start); err == nil {
 				vevent.Props.Set(dtstart)
 			}
 		}
@@ -1542,19 +2173,19 @@ func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 			}
 		} else if event.End.DateTime != "" {
 			// Timed event
-			endTime, err := time.Parse(time.RFC3339, event.End.DateTime)
-			if err == nil {
-				dtend := ical.NewProp("DTEND")
-				dtend.SetDateTime(endTime)
-				// Ensure timezone is UTC if not specified
-				if endTime.Location() == time.UTC {
-					dtend.Params.Set("TZID", "UTC")
-				}
+			if dtend, err := icalDateTime(ical.PropDateTimeEnd, event.End); err == nil {
 				vevent.Props.Set(dtend)
 			}
 		}
 	}
 
+	// Set recurrence (RRULE/EXDATE lines of a series master)
+	for _, line := range event.Recurrence {
+		if prop := recurrenceProp(line); prop != nil {
+			vevent.Props.Add(prop)
+		}
+	}
+
 	// Set transparency
 	if event.Transparency == "transparent" {
 		vevent.Props.SetText("TRANSP", "TRANSPARENT")
@@ -1565,6 +2196,20 @@ func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 		if workID := event.ExtendedProperties.Private["workEventId"]; workID != "" {
 			vevent.Props.SetText("X-WORK-EVENT-ID", workID)
 		}
+		// GEO is a FLOAT pair, so set the raw value rather than escaped text
+		if geo, ok := NormalizeGeo(event.ExtendedProperties.Private[GeoPropertyKey]); ok {
+			geoProp := ical.NewProp("GEO")
+			geoProp.Value = geo
+			vevent.Props.Set(geoProp)
+		}
+		other := maps.Clone(event.ExtendedProperties.Private)
+		for _, key := range []string{"workEventId", GeoPropertyKey, "_originalUID"} {
+			delete(other, key)
+		}
+		if len(other) > 0 {
+			data, _ := json.Marshal(other)
+			vevent.Props.SetText(privateProperty, string(data))
+		}
 	}
 
 	// Store Google Meet/conference data
@@ -1581,6 +2226,25 @@ func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 		}
 	}
 
+	// Set status (CONFIRMED, TENTATIVE or CANCELLED)
+	switch event.Status {
+	case "confirmed", "tentative", "cancelled":
+		vevent.Props.SetText(ical.PropStatus, strings.ToUpper(event.Status))
+	}
+
+	// Add a DISPLAY alarm for each reminder
+	if event.Reminders != nil && !event.Reminders.UseDefault {
+		for _, override := range event.Reminders.Overrides {
+			alarm := ical.NewComponent(ical.CompAlarm)
+			alarm.Props.SetText(ical.PropAction, "DISPLAY")
+			trigger := ical.NewProp(ical.PropTrigger)
+			trigger.Value = fmt.Sprintf("-PT%dM", override.Minutes)
+			alarm.Props.Set(trigger)
+			alarm.Props.SetText(ical.PropDescription, "Reminder")
+			vevent.Children = append(vevent.Children, alarm)
+		}
+	}
+
 	// Set created and last modified timestamps
 	now := time.Now().UTC()
 	vevent.Props.SetDateTime(ical.PropDateTimeStamp, now)
@@ -1595,6 +2259,19 @@ func googleEventToICal(event *calendar.Event) (*ical.Calendar, error) {
 	return cal, nil
 }
 
+// icalTimeZone returns the IANA time zone a DATE-TIME property is in, or ""
+// if it is in UTC, floating or in a zone Go doesn't know.
+func icalTimeZone(prop *ical.Prop) string {
+	tzid := prop.Params.Get(ical.PropTimezoneID)
+	if tzid == "" || tzid == "UTC" {
+		return ""
+	}
+	if _, err := time.LoadLocation(tzid); err != nil {
+		return ""
+	}
+	return tzid
+}
+
 // parseICalDateTime parses an iCalendar date-time property.
 func parseICalDateTime(prop *ical.Prop) (time.Time, error) {
 	// Use the library's DateTime method which handles parsing
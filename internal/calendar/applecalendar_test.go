@@ -3,12 +3,22 @@ package calendar
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/beekhof/calendar-sync/internal/config"
+	"github.com/emersion/go-ical"
+	"golang.org/x/oauth2"
 
 	"google.golang.org/api/calendar/v3"
 )
@@ -26,12 +36,12 @@ func loadTestConfig(t *testing.T) *config.Config {
 	}
 
 	// Load the config with environment variable overrides
-	loadedConfig, err := config.LoadConfig(
-		"../../config.json",   // config file path
-		cfgData.WorkTokenPath, // work token path override
-		cfgData.WorkEmail,
-		cfgData.GoogleCredentialsPath, // google credentials path override
-	)
+	loadedConfig, err := config.LoadConfig("../../config.json", config.Overrides{
+		WorkTokenPath:         cfgData.WorkTokenPath,
+		WorkEmail:             cfgData.WorkEmail,
+		GoogleCredentialsPath: cfgData.GoogleCredentialsPath,
+		IncludeOOO:            cfgData.IncludeOOO,
+	})
 	if err != nil {
 		t.Fatalf("Failed to load config: %v", err)
 	}
@@ -984,3 +994,1225 @@ func TestAppleCalendar_SpecialCharacters(t *testing.T) {
 		}
 	})
 }
+
+// TestICalToGoogleEvent_LowercaseWorkEventID verifies that the workEventId tag is
+// recovered when a server re-emits X-WORK-EVENT-ID in lowercase.
+func TestICalToGoogleEvent_LowercaseWorkEventID(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Test//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:test-uid\r\n" +
+		"DTSTAMP:20240115T100000Z\r\n" +
+		"DTSTART:20240115T100000Z\r\n" +
+		"DTEND:20240115T110000Z\r\n" +
+		"SUMMARY:Work Meeting\r\n" +
+		"x-work-event-id:work-123\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	icalCal, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+
+	event, err := icalToGoogleEvent(icalCal)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private["workEventId"] != "work-123" {
+		t.Errorf("Expected workEventId 'work-123' to be recovered, got %+v", event.ExtendedProperties)
+	}
+}
+
+// TestFindWorkEventID_Variants verifies that common name variants of the
+// X-WORK-EVENT-ID property are recognised.
+func TestFindWorkEventID_Variants(t *testing.T) {
+	for _, name := range []string{"X-WORK-EVENT-ID", "x-work-event-id", "X-Work-Event-Id", "X-WORK_EVENT_ID", "X-WORKEVENTID"} {
+		props := make(ical.Props)
+		prop := ical.NewProp(name)
+		prop.Name = name // keep the original case, as a non-normalizing producer would
+		prop.Value = "work-123"
+		props[name] = []ical.Prop{*prop}
+
+		if got := findWorkEventID(props); got != "work-123" {
+			t.Errorf("findWorkEventID() with property %q = %q, want %q", name, got, "work-123")
+		}
+	}
+}
+
+// TestAppleCalendar_ClearRange verifies that ClearRange issues a DELETE for every
+// event returned by the calendar query.
+func TestAppleCalendar_ClearRange(t *testing.T) {
+	const calendarPath = "/123/calendars/work-sync/"
+	eventIDs := []string{"event-1.ics", "event-2.ics", "event-3.ics", "event-4.ics", "event-5.ics"}
+
+	var mu sync.Mutex
+	deleted := make(map[string]bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "REPORT":
+			var sb strings.Builder
+			sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`)
+			for _, id := range eventIDs {
+				fmt.Fprintf(&sb, `<D:response><D:href>%s%s</D:href><D:propstat><D:prop><C:calendar-data>`+
+					"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VEVENT\r\nUID:%s\r\nDTSTAMP:20240115T100000Z\r\n"+
+					"DTSTART:20240115T100000Z\r\nDTEND:20240115T110000Z\r\nSUMMARY:Event\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"+
+					`</C:calendar-data></D:prop></D:propstat></D:response>`, calendarPath, id, id)
+			}
+			sb.WriteString(`</D:multistatus>`)
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(sb.String()))
+		case "DELETE":
+			mu.Lock()
+			deleted[strings.TrimPrefix(r.URL.Path, calendarPath)] = true
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{
+		httpClient: server.Client(),
+		serverURL:  server.URL,
+	}
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if err := client.ClearRange(calendarPath, start, start.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("ClearRange() returned an error: %v", err)
+	}
+
+	if len(deleted) != len(eventIDs) {
+		t.Errorf("Expected %d events to be deleted, got %d: %v", len(eventIDs), len(deleted), deleted)
+	}
+	for _, id := range eventIDs {
+		if !deleted[id] {
+			t.Errorf("Expected event '%s' to be deleted", id)
+		}
+	}
+}
+
+// TestGoogleEventToICal_AllDayIsFloating verifies that all-day events are written
+// as floating VALUE=DATE values without a TZID, and read back on the same day.
+func TestGoogleEventToICal_AllDayIsFloating(t *testing.T) {
+	event := &calendar.Event{
+		Id:      "all-day-1",
+		Summary: "Company Holiday",
+		Start:   &calendar.EventDateTime{Date: "2024-03-01", TimeZone: "America/Los_Angeles"},
+		End:     &calendar.EventDateTime{Date: "2024-03-02", TimeZone: "America/Los_Angeles"},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	data := buf.String()
+
+	if !strings.Contains(data, "DTSTART;VALUE=DATE:20240301") {
+		t.Errorf("Expected floating DTSTART;VALUE=DATE:20240301, got:\n%s", data)
+	}
+	if !strings.Contains(data, "DTEND;VALUE=DATE:20240302") {
+		t.Errorf("Expected floating DTEND;VALUE=DATE:20240302, got:\n%s", data)
+	}
+	if strings.Contains(data, "TZID") {
+		t.Errorf("Expected no TZID on an all-day event, got:\n%s", data)
+	}
+
+	decoded, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if roundTripped.Start == nil || roundTripped.Start.Date != "2024-03-01" {
+		t.Errorf("Expected all-day event to read back on 2024-03-01, got %+v", roundTripped.Start)
+	}
+	if roundTripped.End == nil || roundTripped.End.Date != "2024-03-02" {
+		t.Errorf("Expected all-day event to end on 2024-03-02, got %+v", roundTripped.End)
+	}
+}
+
+// TestGoogleEventToICal_KeepsTimeZone verifies that a timed event in an IANA
+// time zone is written as local time with that TZID, and read back at the
+// same instant and zone, while one without a zone is written in UTC.
+func TestGoogleEventToICal_KeepsTimeZone(t *testing.T) {
+	event := &calendar.Event{
+		Id:      "tz-1",
+		Summary: "Standup",
+		// Given in UTC, as Google may return it: 14:00Z is 10:00 EDT
+		Start: &calendar.EventDateTime{DateTime: "2024-07-01T14:00:00Z", TimeZone: "America/New_York"},
+		End:   &calendar.EventDateTime{DateTime: "2024-07-01T10:30:00-04:00", TimeZone: "America/New_York"},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	data := buf.String()
+
+	if !strings.Contains(data, "DTSTART;TZID=America/New_York:20240701T100000\r\n") {
+		t.Errorf("Expected DTSTART;TZID=America/New_York:20240701T100000, got:\n%s", data)
+	}
+	if !strings.Contains(data, "DTEND;TZID=America/New_York:20240701T103000\r\n") {
+		t.Errorf("Expected DTEND;TZID=America/New_York:20240701T103000, got:\n%s", data)
+	}
+
+	decoded, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if roundTripped.Start.DateTime != "2024-07-01T10:00:00-04:00" || roundTripped.Start.TimeZone != "America/New_York" {
+		t.Errorf("Expected start 2024-07-01T10:00:00-04:00 in America/New_York, got %+v", roundTripped.Start)
+	}
+	if roundTripped.End.DateTime != "2024-07-01T10:30:00-04:00" || roundTripped.End.TimeZone != "America/New_York" {
+		t.Errorf("Expected end 2024-07-01T10:30:00-04:00 in America/New_York, got %+v", roundTripped.End)
+	}
+
+	// Without a zone, an offset is converted to UTC
+	event.Start = &calendar.EventDateTime{DateTime: "2024-07-01T10:00:00-04:00"}
+	event.End = &calendar.EventDateTime{DateTime: "2024-07-01T10:30:00-04:00"}
+	icalCal, err = googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+	buf.Reset()
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	if data := buf.String(); !strings.Contains(data, "DTSTART:20240701T140000Z\r\n") || strings.Contains(data, "TZID") {
+		t.Errorf("Expected DTSTART:20240701T140000Z without a TZID, got:\n%s", data)
+	}
+}
+
+// TestAppleCalendar_OAuthModeSendsBearerToken verifies that a client created with
+// a token source authenticates with a Bearer header instead of basic auth.
+func TestAppleCalendar_OAuthModeSendsBearerToken(t *testing.T) {
+	var mu sync.Mutex
+	var authHeaders []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token", TokenType: "Bearer"})
+	// Discovery fails against the stub server; only the headers it sent matter here
+	_, _ = NewAppleCalendarClientWithTokenSource(context.Background(), server.URL, tokenSource)
+
+	if len(authHeaders) == 0 {
+		t.Fatal("Expected at least one request to the CalDAV server")
+	}
+	for i, header := range authHeaders {
+		if header != "Bearer test-token" {
+			t.Errorf("Request %d: expected Authorization 'Bearer test-token', got %q", i, header)
+		}
+	}
+}
+
+// TestGeoRoundTrip verifies that coordinates stored in the geo extended property
+// are written as an iCalendar GEO property and read back unchanged.
+func TestGeoRoundTrip(t *testing.T) {
+	event := &calendar.Event{
+		Id:       "geo-1",
+		Summary:  "Offsite",
+		Location: "Googleplex",
+		Start:    &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:      &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{
+				"workEventId":  "work-geo",
+				GeoPropertyKey: "37.422; -122.0841",
+			},
+		},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	if !strings.Contains(buf.String(), "GEO:37.422000;-122.084100") {
+		t.Errorf("Expected GEO:37.422000;-122.084100 in iCalendar, got:\n%s", buf.String())
+	}
+
+	decoded, err := ical.NewDecoder(strings.NewReader(buf.String())).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if geo := roundTripped.ExtendedProperties.Private[GeoPropertyKey]; geo != "37.422000;-122.084100" {
+		t.Errorf("Expected geo '37.422000;-122.084100' after round trip, got %q", geo)
+	}
+
+	// Events without coordinates get no GEO property
+	event.ExtendedProperties.Private[GeoPropertyKey] = ""
+	icalCal, err = googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+	for _, comp := range icalCal.Children {
+		if comp.Props.Get("GEO") != nil {
+			t.Errorf("Expected no GEO property for an event without coordinates")
+		}
+	}
+}
+
+func TestRecurrenceRoundTrip(t *testing.T) {
+	event := &calendar.Event{
+		Id:      "series-1",
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:30:00Z"},
+		Recurrence: []string{
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+			"EXDATE:20240117T100000Z",
+			"EXDATE;VALUE=DATE:20240122",
+		},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	if !strings.Contains(buf.String(), "RRULE:FREQ=WEEKLY;BYDAY=MO,WE") {
+		t.Errorf("Expected the RRULE in iCalendar, got:\n%s", buf.String())
+	}
+
+	decoded, err := ical.NewDecoder(strings.NewReader(buf.String())).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if strings.Join(roundTripped.Recurrence, "\n") != strings.Join(event.Recurrence, "\n") {
+		t.Errorf("Expected recurrence %v after round trip, got %v", event.Recurrence, roundTripped.Recurrence)
+	}
+}
+
+func TestReminderRoundTrip(t *testing.T) {
+	event := &calendar.Event{
+		Id:      "event-1",
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:30:00Z"},
+		Reminders: &calendar.EventReminders{
+			Overrides: []*calendar.EventReminder{
+				{Method: "popup", Minutes: 10},
+				{Method: "popup", Minutes: 1440},
+			},
+		},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	for _, want := range []string{"BEGIN:VALARM", "ACTION:DISPLAY", "TRIGGER:-PT10M", "TRIGGER:-PT1440M"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %s in iCalendar, got:\n%s", want, buf.String())
+		}
+	}
+
+	// iCloud's own default alarms and absolute ones are not reminders
+	data := strings.Replace(buf.String(), "END:VEVENT", "BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nX-APPLE-DEFAULT-ALARM:TRUE\r\nEND:VALARM\r\n"+
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;VALUE=DATE-TIME:20240115T090000Z\r\nEND:VALARM\r\nEND:VEVENT", 1)
+	decoded, err := ical.NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if roundTripped.Reminders == nil || roundTripped.Reminders.UseDefault {
+		t.Fatalf("Expected reminder overrides after round trip, got %+v", roundTripped.Reminders)
+	}
+	var got []string
+	for _, override := range roundTripped.Reminders.Overrides {
+		got = append(got, fmt.Sprintf("%s %d", override.Method, override.Minutes))
+	}
+	if want := []string{"popup 10", "popup 1440"}; !slices.Equal(got, want) {
+		t.Errorf("Expected reminders %v after round trip, got %v", want, got)
+	}
+}
+
+func TestStatusRoundTrip(t *testing.T) {
+	for _, status := range []string{"confirmed", "tentative", "cancelled"} {
+		event := &calendar.Event{
+			Id:      "event-1",
+			Summary: "Standup",
+			Status:  status,
+			Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:30:00Z"},
+		}
+
+		icalCal, err := googleEventToICal(event)
+		if err != nil {
+			t.Fatalf("googleEventToICal() returned an error: %v", err)
+		}
+
+		var buf strings.Builder
+		if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+			t.Fatalf("Failed to encode iCalendar: %v", err)
+		}
+		if want := "STATUS:" + strings.ToUpper(status) + "\r\n"; !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in iCalendar, got:\n%s", want, buf.String())
+		}
+
+		decoded, err := ical.NewDecoder(strings.NewReader(buf.String())).Decode()
+		if err != nil {
+			t.Fatalf("Failed to decode iCalendar: %v", err)
+		}
+		roundTripped, err := icalToGoogleEvent(decoded)
+		if err != nil {
+			t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+		}
+		if roundTripped.Status != status {
+			t.Errorf("Expected status %q after round trip, got %q", status, roundTripped.Status)
+		}
+	}
+}
+
+func TestPrivatePropertiesRoundTrip(t *testing.T) {
+	event := &calendar.Event{
+		Id:      "event-1",
+		Summary: "Prep notes",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:30:00Z"},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{"keepMe": "yes, really; keep", "workEventId": "work-1"},
+		},
+	}
+
+	icalCal, err := googleEventToICal(event)
+	if err != nil {
+		t.Fatalf("googleEventToICal() returned an error: %v", err)
+	}
+	var buf strings.Builder
+	if err := ical.NewEncoder(&buf).Encode(icalCal); err != nil {
+		t.Fatalf("Failed to encode iCalendar: %v", err)
+	}
+	if !strings.Contains(buf.String(), privateProperty) {
+		t.Errorf("Expected %s in iCalendar, got:\n%s", privateProperty, buf.String())
+	}
+
+	decoded, err := ical.NewDecoder(strings.NewReader(buf.String())).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode iCalendar: %v", err)
+	}
+	roundTripped, err := icalToGoogleEvent(decoded)
+	if err != nil {
+		t.Fatalf("icalToGoogleEvent() returned an error: %v", err)
+	}
+	if !maps.Equal(roundTripped.ExtendedProperties.Private, event.ExtendedProperties.Private) {
+		t.Errorf("Expected private properties %v after round trip, got %v", event.ExtendedProperties.Private, roundTripped.ExtendedProperties.Private)
+	}
+}
+
+func TestExtractCalendarHomeFromXML(t *testing.T) {
+	client := &AppleCalendarClient{}
+
+	// iCloud answers the root PROPFIND with the principal only, in the default
+	// namespace, and the principal PROPFIND with an absolute calendar-home URL
+	icloudRoot := `<?xml version="1.0" encoding="UTF-8"?>
+<multistatus xmlns="DAV:">
+  <response>
+    <href>/</href>
+    <propstat>
+      <prop>
+        <current-user-principal>
+          <href>/123456789/principal/</href>
+        </current-user-principal>
+      </prop>
+      <status>HTTP/1.1 200 OK</status>
+    </propstat>
+    <propstat>
+      <prop>
+        <calendar-home-set xmlns="urn:ietf:params:xml:ns:caldav"/>
+      </prop>
+      <status>HTTP/1.1 404 Not Found</status>
+    </propstat>
+  </response>
+</multistatus>`
+	icloudPrincipal := `<?xml version="1.0" encoding="UTF-8"?>
+<multistatus xmlns="DAV:"><response><href>/123456789/principal/</href><propstat><prop><calendar-home-set xmlns="urn:ietf:params:xml:ns:caldav"><href xmlns="DAV:">https://p42-caldav.icloud.com:443/123456789/calendars/</href></calendar-home-set></prop><status>HTTP/1.1 200 OK</status></propstat></response></multistatus>`
+
+	// Fastmail uses prefixes for both namespaces and returns both properties at once
+	fastmail := `<?xml version="1.0" encoding="UTF-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">
+ <d:response>
+  <d:href>/</d:href>
+  <d:propstat>
+   <d:prop>
+    <d:current-user-principal>
+     <d:href>/dav/principals/user/jane@fastmail.com/</d:href>
+    </d:current-user-principal>
+    <cal:calendar-home-set>
+     <d:href>/dav/calendars/user/jane@fastmail.com/</d:href>
+    </cal:calendar-home-set>
+   </d:prop>
+   <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+ </d:response>
+</d:multistatus>`
+
+	tests := []struct {
+		name          string
+		body          string
+		wantPrincipal string
+		wantHome      string
+	}{
+		{"iCloud root", icloudRoot, "/123456789/principal/", ""},
+		{"iCloud principal", icloudPrincipal, "", "/123456789/calendars/"},
+		{"Fastmail", fastmail, "/dav/principals/user/jane@fastmail.com/", "/dav/calendars/user/jane@fastmail.com/"},
+		{"not XML", "Service Unavailable", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.extractPrincipalFromXML([]byte(tt.body)); got != tt.wantPrincipal {
+				t.Errorf("extractPrincipalFromXML() = %q, want %q", got, tt.wantPrincipal)
+			}
+			if got := client.extractCalendarHomeFromXML([]byte(tt.body)); got != tt.wantHome {
+				t.Errorf("extractCalendarHomeFromXML() = %q, want %q", got, tt.wantHome)
+			}
+		})
+	}
+}
+
+func TestParseCalendarListFromXML(t *testing.T) {
+	client := &AppleCalendarClient{}
+
+	icloud := `<?xml version="1.0" encoding="UTF-8"?>
+<multistatus xmlns="DAV:">
+  <response>
+    <href>/123456789/calendars/</href>
+    <propstat><prop><resourcetype><collection/></resourcetype></prop><status>HTTP/1.1 200 OK</status></propstat>
+    <propstat><prop><displayname/></prop><status>HTTP/1.1 404 Not Found</status></propstat>
+  </response>
+  <response>
+    <href>/123456789/calendars/home/</href>
+    <propstat>
+      <prop>
+        <displayname>Home</displayname>
+        <resourcetype><collection/><calendar xmlns="urn:ietf:params:xml:ns:caldav"/></resourcetype>
+      </prop>
+      <status>HTTP/1.1 200 OK</status>
+    </propstat>
+  </response>
+  <response>
+    <href>/123456789/calendars/6B29FC40-CA47-1067-B31D-00DD010662DA/</href>
+    <propstat>
+      <prop>
+        <displayname>Work Sync</displayname>
+        <resourcetype><collection/><calendar xmlns="urn:ietf:params:xml:ns:caldav"/></resourcetype>
+      </prop>
+      <status>HTTP/1.1 200 OK</status>
+    </propstat>
+  </response>
+</multistatus>`
+
+	fastmail := `<?xml version="1.0" encoding="UTF-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+ <d:response>
+  <d:href>/dav/calendars/user/jane@fastmail.com/</d:href>
+  <d:propstat>
+   <d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop>
+   <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+ </d:response>
+ <d:response>
+  <d:href>/dav/calendars/user/jane@fastmail.com/Default/</d:href>
+  <d:propstat>
+   <d:prop>
+    <d:displayname>
+     Personal
+    </d:displayname>
+    <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+   </d:prop>
+   <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+ </d:response>
+ <d:response>
+  <d:href>/dav/calendars/user/jane@fastmail.com/a1b2c3/</d:href>
+  <d:propstat>
+   <d:prop>
+    <d:displayname>Work &amp; Travel</d:displayname>
+    <d:resourcetype><d:collection/><cal:calendar/></d:resourcetype>
+   </d:prop>
+   <d:status>HTTP/1.1 200 OK</d:status>
+  </d:propstat>
+ </d:response>
+</d:multistatus>`
+
+	tests := []struct {
+		name string
+		body string
+		want []CalendarInfo
+	}{
+		{"iCloud", icloud, []CalendarInfo{
+			{Name: "", Path: "/123456789/calendars/"},
+			{Name: "Home", Path: "/123456789/calendars/home/"},
+			{Name: "Work Sync", Path: "/123456789/calendars/6B29FC40-CA47-1067-B31D-00DD010662DA/"},
+		}},
+		{"Fastmail", fastmail, []CalendarInfo{
+			{Name: "", Path: "/dav/calendars/user/jane@fastmail.com/"},
+			{Name: "Personal", Path: "/dav/calendars/user/jane@fastmail.com/Default/"},
+			{Name: "Work & Travel", Path: "/dav/calendars/user/jane@fastmail.com/a1b2c3/"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := client.parseCalendarListFromXML([]byte(tt.body))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseCalendarListFromXML() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAppleCalendarClient_RetriesTransientFailures verifies that CalDAV writes
+// are retried with backoff after transient failures, resending the same body,
+// while other errors fail straight away.
+func TestAppleCalendarClient_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		failStatus   int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "503 is retried", failStatus: http.StatusServiceUnavailable, wantRequests: 3},
+		{name: "429 is retried", failStatus: http.StatusTooManyRequests, wantRequests: 3},
+		{name: "401 is not retried", failStatus: http.StatusUnauthorized, wantRequests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if requests <= 2 {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				if r.Method == "PUT" {
+					w.WriteHeader(http.StatusCreated)
+				} else {
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer server.Close()
+
+			var sleeps []time.Duration
+			client := &AppleCalendarClient{
+				httpClient:  server.Client(),
+				serverURL:   server.URL,
+				retryPolicy: RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond},
+				sleep: func(ctx context.Context, d time.Duration) error {
+					sleeps = append(sleeps, d)
+					return nil
+				},
+			}
+
+			err := client.InsertEvent("/calendars/work/", &calendar.Event{
+				Id:      "event-1",
+				Summary: "Standup",
+				Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+				End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InsertEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Fatalf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+			for i, body := range bodies {
+				if !strings.Contains(body, "SUMMARY:Standup") || body != bodies[0] {
+					t.Errorf("Expected request %d to resend the event, got %q", i+1, body)
+				}
+			}
+			// Exponential backoff, jittered down by up to half
+			for i, sleep := range sleeps {
+				delay := 100 * time.Millisecond << i
+				if sleep < delay/2 || sleep > delay {
+					t.Errorf("Expected retry %d to wait between %v and %v, got %v", i+1, delay/2, delay, sleep)
+				}
+			}
+			if len(sleeps) != tt.wantRequests-1 {
+				t.Errorf("Expected %d waits, got %v", tt.wantRequests-1, sleeps)
+			}
+
+			// Deletes are retried the same way
+			requests = 0
+			bodies = nil
+			if err := client.DeleteEvent("/calendars/work/", "event-1.ics"); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d delete requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestAppleCalendarClient_GivesUpAfterMaxRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	client := &AppleCalendarClient{
+		httpClient:  server.Client(),
+		serverURL:   server.URL,
+		retryPolicy: RetryPolicy{MaxRetries: 2, BaseDelay: time.Second},
+		sleep: func(ctx context.Context, d time.Duration) error {
+			sleeps = append(sleeps, d)
+			return nil
+		},
+	}
+
+	err := client.DeleteEvent("/calendars/work/", "event-1.ics")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("Expected the last failure to be reported, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	if fmt.Sprint(sleeps) != "[2s 2s]" {
+		t.Errorf("Expected the Retry-After delay to be used, got %v", sleeps)
+	}
+}
+
+func TestAppleCalendarClient_CreateCalendarSetsColor(t *testing.T) {
+	tests := []struct {
+		colorID   string
+		wantColor string
+	}{
+		{colorID: "7", wantColor: "#42D692FF"},
+		{colorID: "99"},
+		{colorID: ""},
+	}
+	for _, tt := range tests {
+		t.Run("color "+tt.colorID, func(t *testing.T) {
+			var method, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				method, body = r.Method, string(data)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+			if err := client.createCalendar("/calendars/work-sync/", "Work Sync", tt.colorID); err != nil {
+				t.Fatalf("createCalendar() returned an error: %v", err)
+			}
+
+			if method != "MKCALENDAR" {
+				t.Errorf("Expected a MKCALENDAR request, got %s", method)
+			}
+			hasColor := strings.Contains(body, "calendar-color")
+			if tt.wantColor == "" {
+				if hasColor {
+					t.Errorf("Expected no calendar-color property, got body:\n%s", body)
+				}
+				return
+			}
+			want := `<A:calendar-color xmlns:A="http://apple.com/ns/ical/">` + tt.wantColor + `</A:calendar-color>`
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected body to contain %s, got:\n%s", want, body)
+			}
+		})
+	}
+}
+
+func TestAppleCalendarClient_UpdatesExistingCalendarColor(t *testing.T) {
+	tests := []struct {
+		name          string
+		existingColor string
+		wantProppatch bool
+	}{
+		{name: "different color", existingColor: "#FF0000FF", wantProppatch: true},
+		{name: "no color", existingColor: "", wantProppatch: true},
+		{name: "same color without alpha", existingColor: "#42d692", wantProppatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var proppatchPath, proppatchBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				switch r.Method {
+				case "PROPFIND":
+					w.WriteHeader(http.StatusMultiStatus)
+					fmt.Fprintf(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:a="http://apple.com/ns/ical/">
+  <d:response>
+    <d:href>/calendars/work-sync/</d:href>
+    <d:propstat>
+      <d:prop><d:displayname>Work Sync</d:displayname><a:calendar-color>%s</a:calendar-color></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`, tt.existingColor)
+				case "PROPPATCH":
+					proppatchPath, proppatchBody = r.URL.Path, string(data)
+					w.WriteHeader(http.StatusMultiStatus)
+					fmt.Fprint(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response>
+    <d:href>/calendars/work-sync/</d:href>
+    <d:propstat>
+      <d:prop><d:displayname/><calendar-color xmlns="http://apple.com/ns/ical/"/></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`)
+				default:
+					t.Errorf("Unexpected %s request", r.Method)
+				}
+			}))
+			defer server.Close()
+
+			client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL, basePath: "/calendars/"}
+			path, err := client.FindOrCreateCalendarByName("Work Sync", "7")
+			if err != nil {
+				t.Fatalf("FindOrCreateCalendarByName() returned an error: %v", err)
+			}
+			if path != "/calendars/work-sync/" {
+				t.Errorf("Expected path /calendars/work-sync/, got %s", path)
+			}
+
+			if !tt.wantProppatch {
+				if proppatchBody != "" {
+					t.Errorf("Expected no PROPPATCH, got body:\n%s", proppatchBody)
+				}
+				return
+			}
+			if proppatchPath != "/calendars/work-sync/" {
+				t.Errorf("Expected PROPPATCH of /calendars/work-sync/, got %q", proppatchPath)
+			}
+			for _, want := range []string{"<displayname", "Work Sync", "#42D692FF"} {
+				if !strings.Contains(proppatchBody, want) {
+					t.Errorf("Expected PROPPATCH body to contain %s, got:\n%s", want, proppatchBody)
+				}
+			}
+		})
+	}
+}
+
+func TestAppleCalendarClient_UpdateCalendarPropertiesReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?>
+<multistatus xmlns="DAV:">
+  <response>
+    <href>/calendars/work-sync/</href>
+    <propstat>
+      <prop><displayname/></prop>
+      <status>HTTP/1.1 200 OK</status>
+    </propstat>
+    <propstat>
+      <prop><calendar-color xmlns="http://apple.com/ns/ical/"/></prop>
+      <status>HTTP/1.1 403 Forbidden</status>
+    </propstat>
+  </response>
+</multistatus>`)
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	err := client.updateCalendarProperties("/calendars/work-sync/", "Work Sync", "7")
+	if err == nil {
+		t.Fatal("Expected an error for the property the server rejected")
+	}
+	if !strings.Contains(err.Error(), "calendar-color (HTTP/1.1 403 Forbidden)") || strings.Contains(err.Error(), "displayname") {
+		t.Errorf("Expected the error to name only calendar-color, got %v", err)
+	}
+}
+
+func TestAppleCalendarClient_UpdateCalendarPropertiesEscapesName(t *testing.T) {
+	var displayName string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update struct {
+			DisplayName string `xml:"set>prop>displayname"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		displayName = update.DisplayName
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	if err := client.updateCalendarProperties("/calendars/work-sync/", "R&D <team>", ""); err != nil {
+		t.Fatalf("updateCalendarProperties() returned an error: %v", err)
+	}
+	if displayName != "R&D <team>" {
+		t.Errorf("Expected the display name 'R&D <team>', got %q", displayName)
+	}
+}
+
+func TestAppleCalendarClient_SanitizedEventIDRoundTrip(t *testing.T) {
+	var mu sync.Mutex
+	resources := map[string]string{}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "PUT":
+			data, _ := io.ReadAll(r.Body)
+			resources[r.URL.Path] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		case "GET":
+			data, ok := resources[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, data)
+		case "DELETE":
+			delete(resources, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	const calendarPath = "/calendars/work-sync/"
+	const resourcePath = calendarPath + "work-123-abc.ics"
+	event := &calendar.Event{
+		Id:      "work:123/abc",
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+	}
+
+	if err := client.InsertEvent(calendarPath, event); err != nil {
+		t.Fatalf("InsertEvent() returned an error: %v", err)
+	}
+	stored, ok := resources[resourcePath]
+	if !ok {
+		t.Fatalf("Expected the event to be stored at %s, got requests %v", resourcePath, requests)
+	}
+	// The UID matches the file name, and the original ID is kept alongside
+	if !strings.Contains(stored, "UID:work-123-abc\r\n") || !strings.Contains(stored, originalUIDProperty) || !strings.Contains(stored, ":work:123/abc\r\n") {
+		t.Errorf("Expected a sanitized UID and the original ID, got:\n%s", stored)
+	}
+
+	got, err := client.GetEvent(calendarPath, "work-123-abc.ics")
+	if err != nil {
+		t.Fatalf("GetEvent() returned an error: %v", err)
+	}
+	if got.Id != event.Id {
+		t.Errorf("Expected the original ID %s to be read back, got %s", event.Id, got.Id)
+	}
+
+	// Updates and deletes find the resource from either the ID or the file name
+	requests = nil
+	updated := &calendar.Event{Summary: "Renamed Standup", Start: event.Start, End: event.End}
+	if err := client.UpdateEvent(calendarPath, "work:123/abc", updated); err != nil {
+		t.Fatalf("UpdateEvent() returned an error: %v", err)
+	}
+	stored = resources[resourcePath]
+	if !strings.Contains(stored, "Renamed Standup") || !strings.Contains(stored, "UID:work-123-abc\r\n") || !strings.Contains(stored, originalUIDProperty) || !strings.Contains(stored, ":work:123/abc\r\n") {
+		t.Errorf("Expected the update to keep the UID and original ID, got:\n%s", stored)
+	}
+	if err := client.DeleteEvent(calendarPath, "work-123-abc.ics"); err != nil {
+		t.Fatalf("DeleteEvent() returned an error: %v", err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("Expected the event to be deleted, still have %v", resources)
+	}
+	for _, request := range requests {
+		if !strings.HasSuffix(request, " "+resourcePath) {
+			t.Errorf("Expected every request to target %s, got %s", resourcePath, request)
+		}
+	}
+}
+
+func TestAppleCalendarClient_FindEventsByWorkIDListsOnce(t *testing.T) {
+	icsEvent := func(uid, workID string) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:test\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20240115T000000Z\r\nDTSTART:20240115T100000Z\r\nDTEND:20240115T110000Z\r\nSUMMARY:" + uid +
+			"\r\nX-WORK-EVENT-ID:" + workID + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	var reports int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "REPORT":
+			reports++
+			w.WriteHeader(http.StatusMultiStatus)
+			fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+			for i, workID := range []string{"work-1", "work-2", "work-2"} {
+				uid := fmt.Sprintf("event-%d", i)
+				fmt.Fprintf(w, `<d:response><d:href>/calendars/work-sync/%s.ics</d:href><d:propstat><d:prop><c:calendar-data>%s</c:calendar-data></d:prop></d:propstat></d:response>`,
+					uid, icsEvent(uid, workID))
+			}
+			fmt.Fprint(w, `</d:multistatus>`)
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	const calendarPath = "/calendars/work-sync/"
+	want := map[string]int{"work-1": 1, "work-2": 2, "work-3": 0}
+	for _, workID := range []string{"work-1", "work-2", "work-3", "work-1"} {
+		events, err := client.FindEventsByWorkID(calendarPath, workID)
+		if err != nil {
+			t.Fatalf("FindEventsByWorkID(%s) returned an error: %v", workID, err)
+		}
+		if len(events) != want[workID] {
+			t.Errorf("Expected %d event(s) for %s, got %d", want[workID], workID, len(events))
+		}
+	}
+	if reports != 1 {
+		t.Errorf("Expected one listing to serve every lookup, got %d", reports)
+	}
+
+	// A write to the calendar means listing it again
+	if err := client.DeleteEvent(calendarPath, "event-0.ics"); err != nil {
+		t.Fatalf("DeleteEvent() returned an error: %v", err)
+	}
+	if _, err := client.FindEventsByWorkID(calendarPath, "work-1"); err != nil {
+		t.Fatalf("FindEventsByWorkID() returned an error: %v", err)
+	}
+	if reports != 2 {
+		t.Errorf("Expected the calendar to be listed again after a delete, got %d listings", reports)
+	}
+}
+
+func TestAppleCalendarClient_EventCacheServesListings(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	icsEvent := func(uid, workID string, start time.Time) string {
+		return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:test\r\nBEGIN:VEVENT\r\nUID:" + uid +
+			"\r\nDTSTAMP:20240115T000000Z\r\nDTSTART:" + start.Format("20060102T150405Z") +
+			"\r\nDTEND:" + start.Add(time.Hour).Format("20060102T150405Z") + "\r\nSUMMARY:" + uid +
+			"\r\nX-WORK-EVENT-ID:" + workID + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	var reports int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "REPORT" {
+			return
+		}
+		reports++
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+		for i, start := range []time.Time{today.Add(10 * time.Hour), today.AddDate(0, 3, 0)} {
+			uid := fmt.Sprintf("event-%d", i)
+			fmt.Fprintf(w, `<d:response><d:href>/calendars/work-sync/%s.ics</d:href><d:propstat><d:prop><c:calendar-data>%s</c:calendar-data></d:prop></d:propstat></d:response>`,
+				uid, icsEvent(uid, fmt.Sprintf("work-%d", i), start))
+		}
+		fmt.Fprint(w, `</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	const calendarPath = "/calendars/work-sync/"
+
+	// The wide lookup listing serves the lookups and the narrower listings after it
+	for _, workID := range []string{"work-0", "work-1", "work-0"} {
+		if events, err := client.FindEventsByWorkID(calendarPath, workID); err != nil || len(events) != 1 {
+			t.Fatalf("FindEventsByWorkID(%s) = %d event(s), %v; want 1", workID, len(events), err)
+		}
+	}
+	events, err := client.GetEvents(calendarPath, today, today.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("GetEvents() returned an error: %v", err)
+	}
+	if len(events) != 1 || events[0].Summary != "event-0" {
+		t.Errorf("Expected only event-0 in the two-week window, got %d event(s)", len(events))
+	}
+	if reports != 1 {
+		t.Errorf("Expected one listing to serve the lookups and the window, got %d", reports)
+	}
+
+	// A wider window, or a new run, lists the calendar again
+	if _, err := client.GetEvents(calendarPath, today.AddDate(-2, 0, 0), today); err != nil {
+		t.Fatalf("GetEvents() returned an error: %v", err)
+	}
+	if reports != 2 {
+		t.Errorf("Expected a window outside the cached one to be listed, got %d listings", reports)
+	}
+	client.ResetEventCache()
+	if _, err := client.FindEventsByWorkID(calendarPath, "work-0"); err != nil {
+		t.Fatalf("FindEventsByWorkID() returned an error: %v", err)
+	}
+	if reports != 3 {
+		t.Errorf("Expected the calendar to be listed again after ResetEventCache, got %d listings", reports)
+	}
+}
+
+// TestNewCalDAVClient_Discovery verifies that the calendar home is found through
+// the server's well-known URL, following its redirect, and that discovery falls
+// back to the configured URL when the server has no well-known URL.
+func TestNewCalDAVClient_Discovery(t *testing.T) {
+	propResponse := func(href, prop string) string {
+		return `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:response><d:href>` + href + `</d:href><d:propstat><d:prop>` + prop + `</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+</d:multistatus>`
+	}
+
+	for _, wellKnown := range []bool{true, false} {
+		t.Run(fmt.Sprintf("wellKnown=%v", wellKnown), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "PROPFIND" {
+					t.Errorf("Expected PROPFIND, got %s", r.Method)
+				}
+				if user, _, ok := r.BasicAuth(); !ok || user != "me" {
+					t.Errorf("Expected basic auth for 'me' on %s", r.URL.Path)
+				}
+				switch r.URL.Path {
+				case "/.well-known/caldav":
+					if !wellKnown {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					http.Redirect(w, r, "/dav/", http.StatusMovedPermanently)
+				case "/dav/":
+					w.WriteHeader(http.StatusMultiStatus)
+					fmt.Fprint(w, propResponse("/dav/", `<d:current-user-principal><d:href>/dav/principals/user/</d:href></d:current-user-principal>`))
+				case "/dav/principals/user/":
+					w.WriteHeader(http.StatusMultiStatus)
+					fmt.Fprint(w, propResponse("/dav/principals/user/", `<c:calendar-home-set><d:href>calendars/user/</d:href></c:calendar-home-set>`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			serverURL := server.URL
+			if !wellKnown {
+				serverURL += "/dav/"
+			}
+			client, err := NewCalDAVClient(context.Background(), serverURL, "me", "secret")
+			if err != nil {
+				t.Fatalf("NewCalDAVClient() returned an error: %v", err)
+			}
+			if client.basePath != "/dav/principals/user/calendars/user/" {
+				t.Errorf("Expected calendar home /dav/principals/user/calendars/user/, got %q", client.basePath)
+			}
+			if client.serverURL != server.URL {
+				t.Errorf("Expected server URL %q, got %q", server.URL, client.serverURL)
+			}
+		})
+	}
+}
+
+func TestAppleCalendarClient_ColorCategories(t *testing.T) {
+	var mu sync.Mutex
+	resources := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		data, _ := io.ReadAll(r.Body)
+		resources[r.URL.Path] = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	client.SetColorCategories(map[string]string{"11": "Urgent"})
+	const calendarPath = "/calendars/work-sync/"
+	for _, event := range []*calendar.Event{
+		{Id: "red", ColorId: "11", Summary: "Incident review", Start: &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"}},
+		{Id: "blue", ColorId: "9", Summary: "Standup", Start: &calendar.EventDateTime{DateTime: "2024-01-15T12:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2024-01-15T12:15:00Z"}},
+	} {
+		if err := client.InsertEvent(calendarPath, event); err != nil {
+			t.Fatalf("InsertEvent() returned an error: %v", err)
+		}
+	}
+
+	if stored := resources[calendarPath+"red.ics"]; !strings.Contains(stored, "CATEGORIES:Urgent\r\n") {
+		t.Errorf("Expected the red event to get the Urgent category, got:\n%s", stored)
+	}
+	if stored := resources[calendarPath+"blue.ics"]; strings.Contains(stored, "CATEGORIES") {
+		t.Errorf("Expected no category for an unmapped color, got:\n%s", stored)
+	}
+}
+
+func TestAppleCalendarClient_InsertEventUsesCRLF(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := &AppleCalendarClient{httpClient: server.Client(), serverURL: server.URL}
+	event := &calendar.Event{
+		Id:          "standup",
+		Summary:     "Standup",
+		Description: "Agenda:\nupdates\nblockers",
+		Start:       &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:         &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+	}
+	if err := client.InsertEvent("/calendars/work-sync/", event); err != nil {
+		t.Fatalf("InsertEvent() returned an error: %v", err)
+	}
+
+	if !strings.Contains(body, "BEGIN:VCALENDAR\r\n") || !strings.Contains(body, "\r\nSUMMARY:Standup\r\n") {
+		t.Errorf("Expected CRLF between properties, got %q", body)
+	}
+	if strings.Count(body, "\n") != strings.Count(body, "\r\n") {
+		t.Errorf("Expected no bare LF line endings, got %q", body)
+	}
+
+	// Bodies written with bare LF are normalized too
+	if got := string(crlfLines([]byte("BEGIN:VCALENDAR\nVERSION:2.0\r\nEND:VCALENDAR\n"))); got != "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR\r\n" {
+		t.Errorf("Expected CRLF line endings, got %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package calendar
+
+import "sync"
+
+// CalendarIDCache caches resolved calendar IDs per (account, calendar name), so
+// destinations that share an account don't each look up, or race to create, the
+// same calendar. It is safe for concurrent use.
+type CalendarIDCache struct {
+	mu      sync.Mutex
+	entries map[calendarKey]*calendarEntry
+}
+
+type calendarKey struct {
+	account string
+	name    string
+}
+
+// calendarEntry holds one resolved ID. Its mutex is held while resolving, so
+// concurrent callers for the same key wait for the first lookup to finish.
+type calendarEntry struct {
+	mu sync.Mutex
+	id string
+}
+
+// NewCalendarIDCache creates an empty calendar ID cache.
+func NewCalendarIDCache() *CalendarIDCache {
+	return &CalendarIDCache{entries: make(map[calendarKey]*calendarEntry)}
+}
+
+// FindOrCreate returns the cached ID for the calendar, resolving it with
+// client.FindOrCreateCalendarByName on first use.
+func (c *CalendarIDCache) FindOrCreate(client CalendarClient, account, name, colorID string) (string, error) {
+	entry := c.entry(account, name)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.id != "" {
+		return entry.id, nil
+	}
+	id, err := client.FindOrCreateCalendarByName(name, colorID)
+	if err != nil {
+		return "", err
+	}
+	entry.id = id
+	return id, nil
+}
+
+// Invalidate forgets the cached ID for the calendar, e.g. after it was deleted.
+func (c *CalendarIDCache) Invalidate(account, name string) {
+	entry := c.entry(account, name)
+	entry.mu.Lock()
+	entry.id = ""
+	entry.mu.Unlock()
+}
+
+// entry returns the cache entry for the key, creating it if needed.
+func (c *CalendarIDCache) entry(account, name string) *calendarEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := calendarKey{account: account, name: name}
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &calendarEntry{}
+		c.entries[key] = entry
+	}
+	return entry
+}
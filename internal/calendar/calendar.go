@@ -1,19 +1,114 @@
 package calendar
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
 )
 
+// ErrCalendarNotFound is returned (wrapped) by write operations when the target
+// calendar itself no longer exists, e.g. because the user deleted it.
+var ErrCalendarNotFound = errors.New("calendar not found")
+
+// ErrDuplicateCalendars is returned (wrapped) when several calendars have the
+// name being looked up and the client was set to fail rather than pick one.
+var ErrDuplicateCalendars = errors.New("several calendars have the same name")
+
+// ErrEventExists is returned (wrapped) by InsertEvent when an event with the
+// same ID already exists in the calendar, e.g. when inserting with a
+// deterministic ID that an earlier run already used.
+var ErrEventExists = errors.New("event already exists")
+
+// ErrEventConflict is returned (wrapped) by write operations when the event was
+// changed since it was read and the write's precondition failed.
+var ErrEventConflict = errors.New("event was modified concurrently")
+
+// ErrSyncTokenExpired is returned (wrapped) by GetEventsIncremental when the
+// sync token is no longer accepted and the events have to be listed in full.
+var ErrSyncTokenExpired = errors.New("sync token expired")
+
+// GeoPropertyKey is the private extended property holding an event's
+// coordinates as "latitude;longitude", mirroring the iCalendar GEO property.
+const GeoPropertyKey = "geo"
+
+// NormalizeGeo parses a "latitude;longitude" value and returns it in a canonical
+// form, so coordinates compare equal regardless of formatting. Returns false if
+// the value is not a valid coordinate pair.
+func NormalizeGeo(value string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ";")
+	if len(parts) != 2 {
+		return "", false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return "", false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return "", false
+	}
+	return strconv.FormatFloat(lat, 'f', 6, 64) + ";" + strconv.FormatFloat(lon, 'f', 6, 64), true
+}
+
 // CalendarClient is a generic interface for calendar operations.
 // Both Google Calendar and Apple Calendar clients implement this interface.
 type CalendarClient interface {
 	FindOrCreateCalendarByName(name string, colorID string) (string, error)
+	// FindCalendarByName looks up a calendar without creating it. Returns
+	// ErrCalendarNotFound (wrapped) if no calendar has the name.
+	FindCalendarByName(name string) (string, error)
 	GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error)
 	GetEvent(calendarID, eventID string) (*calendar.Event, error)
 	InsertEvent(calendarID string, event *calendar.Event) error
 	UpdateEvent(calendarID, eventID string, event *calendar.Event) error
 	DeleteEvent(calendarID, eventID string) error
 	FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error)
+	ClearRange(calendarID string, timeMin, timeMax time.Time) error
+}
+
+// TimeSlot is a busy period returned by a free/busy query.
+type TimeSlot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// RecurringEventLister is implemented by clients that can list recurring events
+// as their series master, with the RRULE/EXDATE lines in Recurrence, instead of
+// expanding them into single instances. Modified and cancelled instances are
+// listed as separate events with RecurringEventId set.
+type RecurringEventLister interface {
+	GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error)
+}
+
+// FreeBusySource is implemented by source calendars that can report busy
+// periods directly, without exposing event details.
+type FreeBusySource interface {
+	GetFreeBusy(calendarID string, timeMin, timeMax time.Time) ([]TimeSlot, error)
+}
+
+// IncrementalEventLister is implemented by clients that can list only the
+// events changed since an earlier listing. Given an empty syncToken, it lists
+// the events in the time window like GetEvents; otherwise it lists the events
+// changed since the listing that returned syncToken, whatever their time, with
+// deleted events included as cancelled. Either way it returns the token for
+// the next listing.
+type IncrementalEventLister interface {
+	GetEventsIncremental(calendarID, syncToken string, timeMin, timeMax time.Time) (events []*calendar.Event, nextSyncToken string, err error)
+}
+
+// EventCache is implemented by clients that keep the events they list in
+// memory, to serve the lookups that follow. ResetEventCache drops them, so a
+// client used for several sync runs reads the calendars afresh for each.
+type EventCache interface {
+	ResetEventCache()
+}
+
+// CalendarChecker is implemented by clients that can check a calendar still
+// exists more cheaply than finding it by name. CalendarExists returns
+// ErrCalendarNotFound (wrapped) if the calendar is gone.
+type CalendarChecker interface {
+	CalendarExists(calendarID string) error
 }
@@ -1,21 +1,51 @@
 package calendar
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 // Client is a wrapper around the Google Calendar API service.
 type Client struct {
-	service *calendar.Service
+	service    *calendar.Service
+	httpClient *http.Client                                     // Used directly for batch requests, which the API library does not support
+	ctx        context.Context                                  // Cancels waits between retries
+	sleep      func(ctx context.Context, d time.Duration) error // Waits between retries, replaced in tests
+
+	retryPolicy  RetryPolicy // Unset for DefaultRetryPolicy
+	allAttendees bool        // List every attendee of an event, not just ourselves
+	cancelled    bool        // List cancelled events too
+
+	duplicateCalendars string // What FindCalendarByName does about several calendars with the name (default: "use-first")
 }
 
+// googleBatchLimit is the maximum number of calls the Calendar API accepts in a
+// single batch request.
+const googleBatchLimit = 50
+
+// maxCalendarColorID is the highest ID in Google's calendar color palette.
+const maxCalendarColorID = 24
+
+// googleMaxRetryDelay caps the delay taken from a Retry-After header.
+const googleMaxRetryDelay = 60 * time.Second
+
 // NewClient creates a new Google Calendar API client using the provided HTTP client.
 func NewClient(ctx context.Context, httpClient *http.Client) (*Client, error) {
 	service, err := calendar.NewService(ctx, option.WithHTTPClient(httpClient))
@@ -23,24 +53,151 @@ func NewClient(ctx context.Context, httpClient *http.Client) (*Client, error) {
 		return nil, fmt.Errorf("failed to create calendar service: %w", err)
 	}
 
-	return &Client{service: service}, nil
+	return &Client{service: service, httpClient: httpClient, ctx: ctx, sleep: sleepContext}, nil
 }
 
-// FindOrCreateCalendarByName finds an existing calendar by name or creates a new one.
-// Returns the calendar ID.
-func (c *Client) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+// SetRetryPolicy sets how the client retries transient API errors.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetListAllAttendees sets whether listed events carry their whole guest list.
+// By default only our own attendee entry is returned, which is all the
+// declined check needs.
+func (c *Client) SetListAllAttendees(all bool) {
+	c.allAttendees = all
+}
+
+// SetListCancelled sets whether listed events include cancelled ones, with
+// status "cancelled". Deleted events are listed as cancelled too.
+func (c *Client) SetListCancelled(cancelled bool) {
+	c.cancelled = cancelled
+}
+
+// SetDuplicateCalendarPolicy sets what FindCalendarByName does when several
+// calendars have the name: "error" fails with ErrDuplicateCalendars,
+// "use-first" takes the first listed, "use-newest" the most recently modified,
+// and "merge" moves the other calendars' events into the first.
+func (c *Client) SetDuplicateCalendarPolicy(policy string) {
+	c.duplicateCalendars = policy
+}
+
+// FindCalendarByName returns the ID of the calendar with the given name, or
+// ErrCalendarNotFound if there is none. Several calendars with the name are
+// resolved as set by SetDuplicateCalendarPolicy.
+func (c *Client) FindCalendarByName(name string) (string, error) {
 	// List the user's calendars
-	calendarList, err := c.service.CalendarList.List().Do()
+	var calendarList *calendar.CalendarList
+	err := c.retry(func() (err error) {
+		calendarList, err = c.service.CalendarList.List().Do()
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("Google: failed to list calendars: %w", err)
 	}
 
 	// Check if a calendar with the given name exists
+	var ids []string
 	for _, cal := range calendarList.Items {
 		if cal.Summary == name {
-			return cal.Id, nil
+			ids = append(ids, cal.Id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("Google: calendar '%s': %w", name, ErrCalendarNotFound)
+	case 1:
+		return ids[0], nil
+	}
+	return c.resolveDuplicateCalendars(name, ids)
+}
+
+// resolveDuplicateCalendars picks one of several calendars with the same name,
+// as set by SetDuplicateCalendarPolicy.
+func (c *Client) resolveDuplicateCalendars(name string, ids []string) (string, error) {
+	switch c.duplicateCalendars {
+	case "error":
+		return "", fmt.Errorf("Google: %d calendars named '%s' (%s): %w", len(ids), name, strings.Join(ids, ", "), ErrDuplicateCalendars)
+
+	case "use-newest":
+		newest, newestUpdated := ids[0], ""
+		for _, id := range ids {
+			var events *calendar.Events
+			err := c.retry(func() (err error) {
+				events, err = c.service.Events.List(id).MaxResults(1).Do()
+				return err
+			})
+			if err != nil {
+				return "", fmt.Errorf("Google: failed to read calendar %s: %w", id, err)
+			}
+			// RFC3339 UTC timestamps order as strings
+			if events.Updated > newestUpdated {
+				newest, newestUpdated = id, events.Updated
+			}
+		}
+		log.Printf("Warning: %d Google calendars are named '%s', using the most recently modified: %s", len(ids), name, newest)
+		return newest, nil
+
+	case "merge":
+		log.Printf("Warning: %d Google calendars are named '%s', moving their events into %s", len(ids), name, ids[0])
+		for _, id := range ids[1:] {
+			moved, err := c.moveEvents(id, ids[0])
+			if err != nil {
+				return "", fmt.Errorf("Google: failed to merge calendar %s into %s: %w", id, ids[0], err)
+			}
+			log.Printf("Moved %d event(s) from %s; the emptied calendar can be deleted", moved, id)
+		}
+		return ids[0], nil
+
+	default:
+		log.Printf("Warning: %d Google calendars are named '%s', using the first: %s (others: %s)", len(ids), name, ids[0], strings.Join(ids[1:], ", "))
+		return ids[0], nil
+	}
+}
+
+// moveEvents moves every event of one calendar into another, recurring
+// events as a whole series. Returns the number of events moved.
+func (c *Client) moveEvents(fromID, toID string) (int, error) {
+	// List them all first, as moving them would shift the pages
+	var ids []string
+	pageToken := ""
+	for {
+		var events *calendar.Events
+		err := c.retry(func() (err error) {
+			events, err = c.service.Events.List(fromID).PageToken(pageToken).Do()
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, event := range events.Items {
+			ids = append(ids, event.Id)
+		}
+		if events.NextPageToken == "" {
+			break
+		}
+		pageToken = events.NextPageToken
+	}
+
+	for moved, id := range ids {
+		err := c.retry(func() error {
+			_, err := c.service.Events.Move(fromID, id, toID).Do()
+			return err
+		})
+		if err != nil {
+			return moved, fmt.Errorf("event %s: %w", id, err)
 		}
 	}
+	return len(ids), nil
+}
+
+// FindOrCreateCalendarByName finds an existing calendar by name or creates a new one.
+// Returns the calendar ID.
+func (c *Client) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	id, err := c.FindCalendarByName(name)
+	if !errors.Is(err, ErrCalendarNotFound) {
+		return id, err
+	}
 
 	// Calendar doesn't exist, create it
 	newCalendar := &calendar.Calendar{
@@ -48,13 +205,23 @@ func (c *Client) FindOrCreateCalendarByName(name string, colorID string) (string
 		Description: "Synced calendar from work account",
 	}
 
-	created, err := c.service.Calendars.Insert(newCalendar).Do()
+	// Creating is not idempotent, so only retry errors that guarantee the
+	// request was rejected
+	var created *calendar.Calendar
+	err = c.retryWhen(isRateLimitError, func() (err error) {
+		created, err = c.service.Calendars.Insert(newCalendar).Do()
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to create calendar: %w", err)
 	}
 
-	// Set the color if provided
+	// Set the color if provided and valid
 	if colorID != "" {
+		if err := c.checkCalendarColor(colorID); err != nil {
+			fmt.Printf("Warning: not setting calendar color: %v\n", err)
+			return created.Id, nil
+		}
 		_, err = c.service.CalendarList.Patch(created.Id, &calendar.CalendarListEntry{
 			ColorId: colorID,
 		}).Do()
@@ -67,10 +234,61 @@ func (c *Client) FindOrCreateCalendarByName(name string, colorID string) (string
 	return created.Id, nil
 }
 
+// checkCalendarColor returns an error if colorID isn't in Google's calendar
+// color palette. Calendars and events have separate palettes, and event color
+// IDs are easily pasted by mistake. The palette is fetched from the API,
+// falling back to its known range if that fails.
+func (c *Client) checkCalendarColor(colorID string) error {
+	var colors *calendar.Colors
+	err := c.retry(func() (err error) {
+		colors, err = c.service.Colors.Get().Do()
+		return err
+	})
+	if err == nil {
+		if _, ok := colors.Calendar[colorID]; !ok {
+			return fmt.Errorf("color ID %s is not in the calendar color palette", colorID)
+		}
+		return nil
+	}
+	if id, err := strconv.Atoi(colorID); err != nil || id < 1 || id > maxCalendarColorID {
+		return fmt.Errorf("color ID %s is not in the calendar color palette (1-%d)", colorID, maxCalendarColorID)
+	}
+	return nil
+}
+
+// GetCalendar returns the name of the calendar with the given ID, or
+// ErrCalendarNotFound if it doesn't exist or isn't shared with the account.
+func (c *Client) GetCalendar(calendarID string) (string, error) {
+	var cal *calendar.Calendar
+	err := c.retry(func() (err error) {
+		cal, err = c.service.Calendars.Get(calendarID).Do()
+		return err
+	})
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return "", fmt.Errorf("Google: calendar %s: %w", calendarID, ErrCalendarNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get calendar: %w", err)
+	}
+	return cal.Summary, nil
+}
+
+// CalendarExists checks that a calendar exists. Returns ErrCalendarNotFound
+// (wrapped) if it doesn't.
+func (c *Client) CalendarExists(calendarID string) error {
+	_, err := c.GetCalendar(calendarID)
+	return err
+}
+
 // GetEvent retrieves a single event by ID.
 // Conference data (Google Meet links) is included by default if available.
 func (c *Client) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
-	event, err := c.service.Events.Get(calendarID, eventID).Do()
+	var event *calendar.Event
+	err := c.retry(func() (err error) {
+		event, err = c.service.Events.Get(calendarID, eventID).Do()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
@@ -81,14 +299,33 @@ func (c *Client) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
 // Important: Sets SingleEvents = true to expand recurring events.
 // Conference data (Google Meet links) is included by default if available.
 func (c *Client) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
-	eventsList, err := c.service.Events.List(calendarID).
-		TimeMin(timeMin.Format(time.RFC3339)).
-		TimeMax(timeMax.Format(time.RFC3339)).
-		SingleEvents(true).                                            // Expand recurring events
-		MaxAttendees(1).                                               // ourselves is always returned, needed fro declined check
-		EventTypes("default", "birthday", "fromGmail", "outOfOffice"). // skip workingLocation and focusTime
-		MaxResults(1000).                                              // get some more than default for longer lookahead without paging needed
-		Do()
+	return c.listEvents(calendarID, timeMin, timeMax, true)
+}
+
+// GetEventsUnexpanded retrieves events like GetEvents, but returns each recurring
+// series as its master event with the RRULE/EXDATE lines in Recurrence. Cancelled
+// instances are included, so callers can exclude their dates from the series.
+func (c *Client) GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	return c.listEvents(calendarID, timeMin, timeMax, false)
+}
+
+// listEvents lists the events in a time window, optionally expanding recurring events.
+func (c *Client) listEvents(calendarID string, timeMin, timeMax time.Time, singleEvents bool) ([]*calendar.Event, error) {
+	var eventsList *calendar.Events
+	err := c.retry(func() (err error) {
+		call := c.service.Events.List(calendarID).
+			TimeMin(timeMin.Format(time.RFC3339)).
+			TimeMax(timeMax.Format(time.RFC3339)).
+			SingleEvents(singleEvents).                                    // Expand recurring events unless unexpanded was asked for
+			ShowDeleted(!singleEvents || c.cancelled).                     // Cancelled instances of an unexpanded series become EXDATEs
+			EventTypes("default", "birthday", "fromGmail", "outOfOffice"). // skip workingLocation and focusTime
+			MaxResults(1000)                                               // get some more than default for longer lookahead without paging needed
+		if !c.allAttendees {
+			call = call.MaxAttendees(1) // ourselves is always returned, needed fro declined check
+		}
+		eventsList, err = call.Do()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
@@ -100,16 +337,105 @@ func (c *Client) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*ca
 	return eventsList.Items, nil
 }
 
+// GetEventsIncremental lists the events of a calendar through a sync token, as
+// an IncrementalEventLister. Recurring events are expanded, as by GetEvents.
+// Returns ErrSyncTokenExpired (wrapped) once Google no longer accepts the token.
+func (c *Client) GetEventsIncremental(calendarID, syncToken string, timeMin, timeMax time.Time) ([]*calendar.Event, string, error) {
+	var events []*calendar.Event
+	pageToken := ""
+	for {
+		var eventsList *calendar.Events
+		err := c.retry(func() (err error) {
+			call := c.service.Events.List(calendarID).
+				SingleEvents(true).
+				EventTypes("default", "birthday", "fromGmail", "outOfOffice").
+				MaxResults(1000)
+			if !c.allAttendees {
+				call = call.MaxAttendees(1)
+			}
+			// A sync token can't be combined with a time window; the changes
+			// since the token was issued are listed wherever they are
+			if syncToken != "" {
+				call = call.SyncToken(syncToken)
+			} else {
+				call = call.TimeMin(timeMin.Format(time.RFC3339)).TimeMax(timeMax.Format(time.RFC3339))
+			}
+			// The sync token comes with the last page, so all pages are read
+			if pageToken != "" {
+				call = call.PageToken(pageToken)
+			}
+			eventsList, err = call.Do()
+			return err
+		})
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusGone {
+			return nil, "", fmt.Errorf("Google: calendar %s: %w", calendarID, ErrSyncTokenExpired)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list events: %w", err)
+		}
+
+		events = append(events, eventsList.Items...)
+		if eventsList.NextPageToken == "" {
+			return events, eventsList.NextSyncToken, nil
+		}
+		pageToken = eventsList.NextPageToken
+	}
+}
+
+// GetFreeBusy returns the busy periods of a calendar within the specified time window.
+func (c *Client) GetFreeBusy(calendarID string, timeMin, timeMax time.Time) ([]TimeSlot, error) {
+	var resp *calendar.FreeBusyResponse
+	err := c.retry(func() (err error) {
+		resp, err = c.service.Freebusy.Query(&calendar.FreeBusyRequest{
+			TimeMin: timeMin.Format(time.RFC3339),
+			TimeMax: timeMax.Format(time.RFC3339),
+			Items:   []*calendar.FreeBusyRequestItem{{Id: calendarID}},
+		}).Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query free/busy: %w", err)
+	}
+
+	busy, ok := resp.Calendars[calendarID]
+	if !ok {
+		return nil, fmt.Errorf("failed to query free/busy: no result for calendar %s", calendarID)
+	}
+	if len(busy.Errors) > 0 {
+		return nil, fmt.Errorf("failed to query free/busy: %s", busy.Errors[0].Reason)
+	}
+
+	slots := make([]TimeSlot, 0, len(busy.Busy))
+	for _, period := range busy.Busy {
+		start, err := time.Parse(time.RFC3339, period.Start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse busy period start: %w", err)
+		}
+		end, err := time.Parse(time.RFC3339, period.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse busy period end: %w", err)
+		}
+		slots = append(slots, TimeSlot{Start: start, End: end})
+	}
+
+	return slots, nil
+}
+
 // FindEventsByWorkID finds events in a calendar that have a specific workEventId
 // in their private extended properties.
 func (c *Client) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
 	// Use privateExtendedProperty to search for events with the workEventId
 	query := fmt.Sprintf("workEventId=%s", workEventID)
 
-	eventsList, err := c.service.Events.List(calendarID).
-		PrivateExtendedProperty(query).
-		SingleEvents(true).
-		Do()
+	var eventsList *calendar.Events
+	err := c.retry(func() (err error) {
+		eventsList, err = c.service.Events.List(calendarID).
+			PrivateExtendedProperty(query).
+			SingleEvents(true).
+			Do()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to find events by work ID: %w", err)
 	}
@@ -119,6 +445,8 @@ func (c *Client) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar
 
 // InsertEvent inserts a new event into a calendar.
 // Important: Sets sendUpdates="none" to prevent notifications.
+// Only rate-limit errors are retried: after a 5xx or 429 the event may already
+// have been created, and retrying would duplicate it.
 // If the event contains conferenceData, sets conferenceDataVersion=1 to preserve Google Meet links.
 func (c *Client) InsertEvent(calendarID string, event *calendar.Event) error {
 	call := c.service.Events.Insert(calendarID, event).
@@ -129,9 +457,12 @@ func (c *Client) InsertEvent(calendarID string, event *calendar.Event) error {
 		call = call.ConferenceDataVersion(1)
 	}
 
-	_, err := call.Do()
+	err := c.retryWhen(isRateLimitError, func() error {
+		_, err := call.Do()
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to insert event: %w", err)
+		return fmt.Errorf("failed to insert event: %w", writeError(c.checkCalendarGone(calendarID, err)))
 	}
 
 	return nil
@@ -148,9 +479,12 @@ func (c *Client) UpdateEvent(calendarID, eventID string, event *calendar.Event)
 		call = call.ConferenceDataVersion(1)
 	}
 
-	_, err := call.Do()
+	err := c.retry(func() error {
+		_, err := call.Do()
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update event: %w", err)
+		return fmt.Errorf("failed to update event: %w", writeError(c.checkCalendarGone(calendarID, err)))
 	}
 
 	return nil
@@ -158,12 +492,225 @@ func (c *Client) UpdateEvent(calendarID, eventID string, event *calendar.Event)
 
 // DeleteEvent deletes an event from a calendar.
 func (c *Client) DeleteEvent(calendarID, eventID string) error {
-	err := c.service.Events.Delete(calendarID, eventID).
-		SendUpdates("none"). // Disable notifications
-		Do()
+	err := c.retry(func() error {
+		return c.service.Events.Delete(calendarID, eventID).
+			SendUpdates("none"). // Disable notifications
+			Do()
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete event: %w", err)
+		return fmt.Errorf("failed to delete event: %w", c.checkCalendarGone(calendarID, err))
 	}
 
 	return nil
 }
+
+// retry calls op, retrying it with jittered exponential backoff, as set by the
+// client's retry policy, while it fails with a retryable error. The delay
+// suggested by a Retry-After header is honored when present, up to
+// googleMaxRetryDelay. Waiting stops as soon as the client's context is cancelled.
+func (c *Client) retry(op func() error) error {
+	return c.retryWhen(isRetryableError, op)
+}
+
+// retryWhen is retry with a caller-supplied test for which errors to retry.
+func (c *Client) retryWhen(retryable func(error) bool, op func() error) error {
+	policy := c.retryPolicy.orDefault()
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || attempt == policy.MaxRetries || !retryable(err) {
+			return err
+		}
+
+		wait := policy.backoff(attempt)
+		if suggested, ok := retryAfter(err); ok {
+			wait = min(suggested, googleMaxRetryDelay)
+		}
+		log.Printf("Google API request failed, retrying in %v: %v", wait, err)
+		ctx := c.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		sleep := c.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// isRetryableError reports whether a Google API error is transient.
+// 429 and 5xx responses and network errors are always retryable. A 403 is
+// normally a permission error, except when its reason says a rate limit was
+// exceeded.
+func isRetryableError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return isNetworkError(err)
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= 500 {
+		return true
+	}
+	return isRateLimitError(err)
+}
+
+// isRateLimitError reports whether err is a 403 whose reason says a rate limit
+// was exceeded. The API rejects these before doing any work, so they are safe
+// to retry even for requests that are not idempotent.
+func isRateLimitError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter returns the backoff suggested by the Retry-After header of an API
+// error, given either as a number of seconds or as an HTTP date.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Header == nil {
+		return 0, false
+	}
+	return retryAfterHeader(apiErr.Header)
+}
+
+// writeError maps the statuses of a rejected insert or update to typed errors:
+// 409 to ErrEventExists and 412 to ErrEventConflict. Other errors are
+// returned unchanged.
+func writeError(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Code {
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %w", ErrEventExists, err)
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("%w: %w", ErrEventConflict, err)
+	}
+	return err
+}
+
+// checkCalendarGone distinguishes a 404 for a missing event from a 404 for a
+// missing calendar. If the calendar no longer exists, ErrCalendarNotFound is
+// returned; otherwise err is returned unchanged.
+func (c *Client) checkCalendarGone(calendarID string, err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		return err
+	}
+
+	if _, getErr := c.service.Calendars.Get(calendarID).Do(); getErr != nil {
+		var getAPIErr *googleapi.Error
+		if errors.As(getErr, &getAPIErr) && getAPIErr.Code == http.StatusNotFound {
+			return fmt.Errorf("%w: %s", ErrCalendarNotFound, calendarID)
+		}
+	}
+
+	return err
+}
+
+// ClearRange deletes every event in a calendar within the specified time window.
+// Deletes are sent through the Calendar API batch endpoint, up to 50 per request,
+// instead of one round-trip per event.
+func (c *Client) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	events, err := c.GetEvents(calendarID, timeMin, timeMax)
+	if err != nil {
+		return fmt.Errorf("failed to list events to clear: %w", err)
+	}
+
+	var errs []error
+	for start := 0; start < len(events); start += googleBatchLimit {
+		end := min(start+googleBatchLimit, len(events))
+		if err := c.batchDelete(calendarID, events[start:end]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// batchDelete deletes the given events in a single multipart/mixed batch request.
+// Events that are already gone (404/410) are not treated as errors.
+func (c *Client) batchDelete(calendarID string, events []*calendar.Event) error {
+	basePath, err := url.Parse(c.service.BasePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse API base path: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i, event := range events {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", fmt.Sprintf("<item%d>", i))
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return fmt.Errorf("failed to build batch request: %w", err)
+		}
+		fmt.Fprintf(part, "DELETE %scalendars/%s/events/%s?sendUpdates=none HTTP/1.1\r\n\r\n",
+			basePath.Path, url.PathEscape(calendarID), url.PathEscape(event.Id))
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to build batch request: %w", err)
+	}
+
+	// The batch endpoint mirrors the API path under /batch, e.g.
+	// https://www.googleapis.com/batch/calendar/v3
+	batchURL := basePath.Scheme + "://" + basePath.Host + "/batch" + strings.TrimSuffix(basePath.Path, "/")
+	req, err := http.NewRequest("POST", batchURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create batch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send batch delete: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send batch delete: HTTP %d", resp.StatusCode)
+	}
+
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("failed to parse batch response: %w", err)
+	}
+
+	var errs []error
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read batch response: %w", err)
+		}
+
+		partResp, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return fmt.Errorf("failed to parse batch response part: %w", err)
+		}
+		partResp.Body.Close()
+
+		switch partResp.StatusCode {
+		case http.StatusOK, http.StatusNoContent, http.StatusNotFound, http.StatusGone:
+			// Deleted, or already deleted
+		default:
+			errs = append(errs, fmt.Errorf("failed to delete event %s: HTTP %d", part.Header.Get("Content-ID"), partResp.StatusCode))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -1,7 +1,24 @@
 package calendar
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
 // TestGetEvents_SingleEvents verifies that SingleEvents is set to true.
@@ -44,3 +61,591 @@ func TestFindEventsByWorkID(t *testing.T) {
 	// 3. Return mock calendar events
 }
 
+
+// TestClearRange_BatchDeletes verifies that ClearRange lists the events in the
+// window and deletes all of them through a single batch request.
+func TestClearRange_BatchDeletes(t *testing.T) {
+	eventIDs := []string{"event1", "event2", "event3"}
+	var deleted []string
+	batchRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/calendar/v3/calendars/cal-1/events":
+			var items []string
+			for _, id := range eventIDs {
+				items = append(items, fmt.Sprintf(`{"id": %q}`, id))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"items": [%s]}`, strings.Join(items, ","))
+		case r.Method == "POST" && r.URL.Path == "/batch/calendar/v3":
+			batchRequests++
+			_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil {
+				t.Errorf("Invalid batch Content-Type: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			var resp strings.Builder
+			reader := multipart.NewReader(r.Body, params["boundary"])
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Errorf("Failed to read batch part: %v", err)
+					break
+				}
+				inner, err := http.ReadRequest(bufio.NewReader(part))
+				if err != nil {
+					t.Errorf("Failed to parse batch part: %v", err)
+					break
+				}
+				if inner.Method != "DELETE" {
+					t.Errorf("Expected DELETE in batch part, got %s", inner.Method)
+				}
+				if inner.URL.Query().Get("sendUpdates") != "none" {
+					t.Errorf("Expected sendUpdates=none in batch part, got %q", inner.URL.RawQuery)
+				}
+				deleted = append(deleted, path.Base(inner.URL.Path))
+				fmt.Fprintf(&resp, "--resp\r\nContent-Type: application/http\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n\r\n")
+			}
+			resp.WriteString("--resp--\r\n")
+			w.Header().Set("Content-Type", "multipart/mixed; boundary=resp")
+			w.Write([]byte(resp.String()))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service, err := calendar.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/calendar/v3/"))
+	if err != nil {
+		t.Fatalf("Failed to create calendar service: %v", err)
+	}
+	client := &Client{service: service, httpClient: server.Client()}
+
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if err := client.ClearRange("cal-1", start, start.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("ClearRange() returned an error: %v", err)
+	}
+
+	if batchRequests != 1 {
+		t.Errorf("Expected 1 batch request, got %d", batchRequests)
+	}
+	if strings.Join(deleted, ",") != strings.Join(eventIDs, ",") {
+		t.Errorf("Expected events %v to be deleted, got %v", eventIDs, deleted)
+	}
+}
+
+// TestDeleteEvent_RetriesOnlyRateLimit403 verifies that a 403 rateLimitExceeded
+// is retried after the suggested Retry-After delay, while a 403
+// insufficientPermissions fails immediately.
+func TestDeleteEvent_RetriesOnlyRateLimit403(t *testing.T) {
+	tests := []struct {
+		name         string
+		reason       string
+		wantRequests int
+		wantErr      bool
+		wantSleeps   []time.Duration
+	}{
+		{
+			name:         "rateLimitExceeded is retried",
+			reason:       "rateLimitExceeded",
+			wantRequests: 2,
+			wantErr:      false,
+			wantSleeps:   []time.Duration{7 * time.Second},
+		},
+		{
+			name:         "insufficientPermissions is not retried",
+			reason:       "insufficientPermissions",
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests > 1 {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintf(w, `{"error": {"code": 403, "message": "denied", "errors": [{"reason": %q}]}}`, tt.reason)
+			}))
+			defer server.Close()
+
+			service, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/calendar/v3/"))
+			if err != nil {
+				t.Fatalf("Failed to create calendar service: %v", err)
+			}
+			var sleeps []time.Duration
+			client := &Client{service: service, httpClient: server.Client(), sleep: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}}
+
+			err = client.DeleteEvent("cal-1", "event1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+			if fmt.Sprint(sleeps) != fmt.Sprint(tt.wantSleeps) {
+				t.Errorf("Expected sleeps %v, got %v", tt.wantSleeps, sleeps)
+			}
+		})
+	}
+}
+
+// TestRetry_StopsWhenContextCancelled verifies that a cancelled context ends
+// the wait between retries instead of sleeping out the backoff.
+func TestRetry_StopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	client := &Client{ctx: ctx}
+	err := client.retry(func() error {
+		attempts++
+		return &googleapi.Error{Code: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"3600"}}}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+// TestInsertEvent_RetriesOnlyRateLimit403 verifies that an insert is not
+// retried after a 5xx, which may have created the event anyway, and that a
+// Retry-After delay is capped.
+func TestInsertEvent_RetriesOnlyRateLimit403(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantRequests int
+		wantErr      bool
+		wantSleeps   []time.Duration
+	}{
+		{
+			name:         "503 is not retried",
+			status:       http.StatusServiceUnavailable,
+			body:         `{"error": {"code": 503, "message": "backend error"}}`,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "rateLimitExceeded is retried with a capped delay",
+			status:       http.StatusForbidden,
+			body:         `{"error": {"code": 403, "message": "slow down", "errors": [{"reason": "rateLimitExceeded"}]}}`,
+			wantRequests: 2,
+			wantErr:      false,
+			wantSleeps:   []time.Duration{googleMaxRetryDelay},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				if requests > 1 {
+					fmt.Fprint(w, `{"id": "new-event"}`)
+					return
+				}
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			service, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/calendar/v3/"))
+			if err != nil {
+				t.Fatalf("Failed to create calendar service: %v", err)
+			}
+			var sleeps []time.Duration
+			client := &Client{service: service, httpClient: server.Client(), sleep: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}}
+
+			err = client.InsertEvent("cal-1", &calendar.Event{Summary: "Meeting"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InsertEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+			if fmt.Sprint(sleeps) != fmt.Sprint(tt.wantSleeps) {
+				t.Errorf("Expected sleeps %v, got %v", tt.wantSleeps, sleeps)
+			}
+		})
+	}
+}
+
+func TestWriteEvent_MapsConflictStatuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr error
+	}{
+		{"409 means the event exists", http.StatusConflict, ErrEventExists},
+		{"412 means the event changed", http.StatusPreconditionFailed, ErrEventConflict},
+		{"400 is left as is", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, `{"error": {"code": %d, "message": "rejected"}}`, tt.status)
+			}))
+			defer server.Close()
+
+			service, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/calendar/v3/"))
+			if err != nil {
+				t.Fatalf("Failed to create calendar service: %v", err)
+			}
+			client := &Client{service: service}
+
+			writes := map[string]error{
+				"InsertEvent": client.InsertEvent("cal-1", &calendar.Event{Id: "event1", Summary: "Meeting"}),
+				"UpdateEvent": client.UpdateEvent("cal-1", "event1", &calendar.Event{Summary: "Meeting"}),
+			}
+			for op, err := range writes {
+				if err == nil {
+					t.Fatalf("%s() returned no error", op)
+				}
+				for _, typed := range []error{ErrEventExists, ErrEventConflict} {
+					if want := typed == tt.wantErr; errors.Is(err, typed) != want {
+						t.Errorf("%s() error = %v, errors.Is(%v) should be %v", op, err, typed, want)
+					}
+				}
+				var apiErr *googleapi.Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.status {
+					t.Errorf("%s() error = %v, expected the API error to stay wrapped", op, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCalendar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/calendar/v3/calendars/team@group.calendar.google.com" {
+			fmt.Fprint(w, `{"id": "team@group.calendar.google.com", "summary": "Team"}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": {"code": 404, "message": "Not Found"}}`)
+	}))
+	defer server.Close()
+
+	service, err := calendar.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/calendar/v3/"))
+	if err != nil {
+		t.Fatalf("Failed to create calendar service: %v", err)
+	}
+	client := &Client{service: service}
+
+	name, err := client.GetCalendar("team@group.calendar.google.com")
+	if err != nil || name != "Team" {
+		t.Errorf("GetCalendar() = %q, %v, want \"Team\"", name, err)
+	}
+	if _, err := client.GetCalendar("missing@group.calendar.google.com"); !errors.Is(err, ErrCalendarNotFound) {
+		t.Errorf("Expected ErrCalendarNotFound for a missing calendar, got %v", err)
+	}
+}
+
+// TestFindOrCreateCalendarByName_ChecksColorPalette verifies that a color ID
+// outside the calendar palette, e.g. an event color ID, is not set on a new
+// calendar, and that the palette's known range is used if it can't be fetched.
+func TestFindOrCreateCalendarByName_ChecksColorPalette(t *testing.T) {
+	tests := []struct {
+		name        string
+		colorID     string
+		colorsFail  bool
+		expectPatch bool
+	}{
+		{name: "in palette", colorID: "7", expectPatch: true},
+		{name: "out of range", colorID: "25"},
+		{name: "not a number", colorID: "tomato"},
+		{name: "palette unavailable, in range", colorID: "24", colorsFail: true, expectPatch: true},
+		{name: "palette unavailable, out of range", colorID: "25", colorsFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.URL.Path == "/calendar/v3/colors":
+					if tt.colorsFail {
+						w.WriteHeader(http.StatusNotFound)
+						fmt.Fprint(w, `{"error": {"code": 404, "message": "Not Found"}}`)
+						return
+					}
+					palette := make([]string, 0, 24)
+					for id := 1; id <= 24; id++ {
+						palette = append(palette, fmt.Sprintf(`"%d": {"background": "#000000", "foreground": "#ffffff"}`, id))
+					}
+					fmt.Fprintf(w, `{"calendar": {%s}}`, strings.Join(palette, ", "))
+				case r.URL.Path == "/calendar/v3/users/me/calendarList" && r.Method == http.MethodGet:
+					fmt.Fprint(w, `{"items": []}`)
+				case r.URL.Path == "/calendar/v3/calendars" && r.Method == http.MethodPost:
+					fmt.Fprint(w, `{"id": "new-calendar", "summary": "Work Sync"}`)
+				case r.URL.Path == "/calendar/v3/users/me/calendarList/new-calendar" && r.Method == http.MethodPatch:
+					patched = true
+					fmt.Fprint(w, `{"id": "new-calendar"}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"error": {"code": 404, "message": "Not Found"}}`)
+				}
+			}))
+			defer server.Close()
+
+			service, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/calendar/v3/"))
+			if err != nil {
+				t.Fatalf("Failed to create calendar service: %v", err)
+			}
+			client := &Client{service: service}
+
+			id, err := client.FindOrCreateCalendarByName("Work Sync", tt.colorID)
+			if err != nil || id != "new-calendar" {
+				t.Fatalf("FindOrCreateCalendarByName() = %q, %v", id, err)
+			}
+			if patched != tt.expectPatch {
+				t.Errorf("Expected color to be set: %v, got %v", tt.expectPatch, patched)
+			}
+		})
+	}
+}
+
+// TestGetEventsIncremental verifies that a listing without a sync token is
+// limited to the window and read to its last page for the token, that a sync
+// token replaces the window, and that an expired token is reported as such.
+func TestGetEventsIncremental(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		switch {
+		case query.Get("syncToken") == "expired":
+			w.WriteHeader(http.StatusGone)
+			fmt.Fprint(w, `{"error": {"code": 410, "message": "Sync token is no longer valid"}}`)
+		case query.Get("syncToken") == "token-1":
+			if query.Has("timeMin") || query.Has("timeMax") {
+				t.Errorf("Expected no time window with a sync token, got %v", query)
+			}
+			fmt.Fprint(w, `{"items": [{"id": "event-2", "status": "cancelled"}], "nextSyncToken": "token-2"}`)
+		case !query.Has("timeMin") || !query.Has("timeMax"):
+			t.Errorf("Expected a time window without a sync token, got %v", query)
+		case query.Get("pageToken") == "":
+			fmt.Fprint(w, `{"items": [{"id": "event-1"}], "nextPageToken": "page-2"}`)
+		default:
+			fmt.Fprint(w, `{"items": [{"id": "event-2"}], "nextSyncToken": "token-1"}`)
+		}
+	}))
+	defer server.Close()
+
+	service, err := calendar.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/calendar/v3/"))
+	if err != nil {
+		t.Fatalf("Failed to create calendar service: %v", err)
+	}
+	client := &Client{service: service}
+	timeMin := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	timeMax := timeMin.AddDate(0, 0, 14)
+
+	events, token, err := client.GetEventsIncremental("primary", "", timeMin, timeMax)
+	if err != nil || token != "token-1" || len(events) != 2 || events[0].Id != "event-1" || events[1].Id != "event-2" {
+		t.Errorf("Expected both pages and token-1, got %v, %q, %v", events, token, err)
+	}
+
+	events, token, err = client.GetEventsIncremental("primary", "token-1", timeMin, timeMax)
+	if err != nil || token != "token-2" || len(events) != 1 || events[0].Status != "cancelled" {
+		t.Errorf("Expected the cancelled event and token-2, got %v, %q, %v", events, token, err)
+	}
+
+	if _, _, err := client.GetEventsIncremental("primary", "expired", timeMin, timeMax); !errors.Is(err, ErrSyncTokenExpired) {
+		t.Errorf("Expected ErrSyncTokenExpired, got %v", err)
+	}
+}
+
+// TestDeleteEvent_RetriesTransientFailures verifies that the client's retry
+// policy sets how often a transient failure is retried.
+func TestDeleteEvent_RetriesTransientFailures(t *testing.T) {
+	for _, maxRetries := range []int{1, 2} {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests > 2 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error": {"code": 503, "message": "backend error"}}`)
+		}))
+
+		service, err := calendar.NewService(context.Background(),
+			option.WithHTTPClient(server.Client()),
+			option.WithEndpoint(server.URL+"/calendar/v3/"))
+		if err != nil {
+			t.Fatalf("Failed to create calendar service: %v", err)
+		}
+		var sleeps []time.Duration
+		client := &Client{service: service, sleep: func(ctx context.Context, d time.Duration) error {
+			sleeps = append(sleeps, d)
+			return nil
+		}}
+		client.SetRetryPolicy(RetryPolicy{MaxRetries: maxRetries, BaseDelay: 10 * time.Millisecond})
+
+		err = client.DeleteEvent("cal-1", "event1")
+		server.Close()
+		if wantErr := maxRetries < 2; (err != nil) != wantErr {
+			t.Errorf("With %d retries: DeleteEvent() error = %v, wantErr %v", maxRetries, err, wantErr)
+		}
+		if requests != maxRetries+1 {
+			t.Errorf("With %d retries: expected %d requests, got %d", maxRetries, maxRetries+1, requests)
+		}
+		for i, sleep := range sleeps {
+			delay := 10 * time.Millisecond << i
+			if sleep < delay/2 || sleep > delay {
+				t.Errorf("Expected retry %d to wait between %v and %v, got %v", i+1, delay/2, delay, sleep)
+			}
+		}
+	}
+}
+
+func TestGetEvents_ListsAllAttendees(t *testing.T) {
+	var maxAttendees []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxAttendees = append(maxAttendees, r.URL.Query().Get("maxAttendees"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"items": []}`)
+	}))
+	defer server.Close()
+
+	service, err := calendar.NewService(context.Background(),
+		option.WithHTTPClient(server.Client()),
+		option.WithEndpoint(server.URL+"/calendar/v3/"))
+	if err != nil {
+		t.Fatalf("Failed to create calendar service: %v", err)
+	}
+	client := &Client{service: service}
+	timeMin := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	timeMax := timeMin.AddDate(0, 0, 14)
+
+	if _, err := client.GetEvents("primary", timeMin, timeMax); err != nil {
+		t.Fatalf("GetEvents() returned an error: %v", err)
+	}
+	client.SetListAllAttendees(true)
+	if _, err := client.GetEvents("primary", timeMin, timeMax); err != nil {
+		t.Fatalf("GetEvents() returned an error: %v", err)
+	}
+	if _, _, err := client.GetEventsIncremental("primary", "", timeMin, timeMax); err != nil {
+		t.Fatalf("GetEventsIncremental() returned an error: %v", err)
+	}
+
+	// Only our own attendee entry by default, the whole guest list once asked for
+	if fmt.Sprint(maxAttendees) != "[1  ]" {
+		t.Errorf("Expected maxAttendees 1 and then unset, got %q", maxAttendees)
+	}
+}
+
+// TestFindCalendarByName_DuplicatePolicy verifies how each duplicate calendar
+// policy resolves two calendars with the same name.
+func TestFindCalendarByName_DuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantID    string
+		wantErr   error
+		wantMoves []string
+	}{
+		{policy: "", wantID: "cal-old"},
+		{policy: "use-first", wantID: "cal-old"},
+		{policy: "use-newest", wantID: "cal-new"},
+		{policy: "merge", wantID: "cal-old", wantMoves: []string{"event-1 -> cal-old", "event-2 -> cal-old"}},
+		{policy: "error", wantErr: ErrDuplicateCalendars},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var moves []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/users/me/calendarList"):
+					fmt.Fprint(w, `{"items": [{"id": "cal-old", "summary": "Work Sync"}, {"id": "primary", "summary": "me"}, {"id": "cal-new", "summary": "Work Sync"}]}`)
+				case strings.HasSuffix(r.URL.Path, "/move"):
+					parts := strings.Split(r.URL.Path, "/")
+					moves = append(moves, parts[len(parts)-2]+" -> "+r.URL.Query().Get("destination"))
+					fmt.Fprint(w, `{}`)
+				case strings.Contains(r.URL.Path, "/calendars/cal-old/events"):
+					fmt.Fprint(w, `{"updated": "2024-01-10T00:00:00.000Z", "items": []}`)
+				case strings.Contains(r.URL.Path, "/calendars/cal-new/events"):
+					if r.URL.Query().Get("pageToken") == "" && r.URL.Query().Get("maxResults") == "" {
+						fmt.Fprint(w, `{"updated": "2024-01-15T00:00:00.000Z", "items": [{"id": "event-1"}], "nextPageToken": "page-2"}`)
+					} else if r.URL.Query().Get("pageToken") == "page-2" {
+						fmt.Fprint(w, `{"items": [{"id": "event-2"}]}`)
+					} else {
+						fmt.Fprint(w, `{"updated": "2024-01-15T00:00:00.000Z", "items": [{"id": "event-1"}]}`)
+					}
+				default:
+					t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+				}
+			}))
+			defer server.Close()
+
+			service, err := calendar.NewService(context.Background(),
+				option.WithHTTPClient(server.Client()),
+				option.WithEndpoint(server.URL+"/calendar/v3/"))
+			if err != nil {
+				t.Fatalf("Failed to create calendar service: %v", err)
+			}
+			client := &Client{service: service}
+			client.SetDuplicateCalendarPolicy(tt.policy)
+
+			id, err := client.FindCalendarByName("Work Sync")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %q, %v", tt.wantErr, id, err)
+				}
+				return
+			}
+			if err != nil || id != tt.wantID {
+				t.Errorf("Expected %s, got %q, %v", tt.wantID, id, err)
+			}
+			if !slices.Equal(moves, tt.wantMoves) {
+				t.Errorf("Expected moves %v, got %v", tt.wantMoves, moves)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/emersion/go-ical"
+	"google.golang.org/api/calendar/v3"
+)
+
+// EncodeICS encodes the events as a single iCalendar feed, converting each as
+// it is written to a CalDAV calendar. An empty productID uses DefaultProductID.
+func EncodeICS(name, productID string, events []*calendar.Event) ([]byte, error) {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	setProductID(cal, productID)
+	// Most subscribing apps show X-WR-CALNAME as the calendar's name
+	cal.Props.SetText("X-WR-CALNAME", name)
+
+	for _, event := range events {
+		eventCal, err := googleEventToICal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert event %s: %w", event.Id, err)
+		}
+		cal.Children = append(cal.Children, eventCal.Children...)
+	}
+
+	var buf bytes.Buffer
+	if err := ical.NewEncoder(&buf).Encode(cal); err != nil {
+		return nil, fmt.Errorf("failed to encode calendar: %w", err)
+	}
+	return buf.Bytes(), nil
+}
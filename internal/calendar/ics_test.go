@@ -0,0 +1,69 @@
+package calendar
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/emersion/go-ical"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEncodeICS(t *testing.T) {
+	events := []*calendar.Event{
+		{
+			Id:      "event-1",
+			Summary: "Standup",
+			Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+		},
+		{
+			Id:      "event-2",
+			Summary: "Offsite",
+			Start:   &calendar.EventDateTime{Date: "2024-01-16"},
+			End:     &calendar.EventDateTime{Date: "2024-01-17"},
+		},
+	}
+
+	data, err := EncodeICS("Work Sync", "", events)
+	if err != nil {
+		t.Fatalf("EncodeICS() returned an error: %v", err)
+	}
+
+	cal, err := ical.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Failed to decode the encoded calendar: %v", err)
+	}
+	if prodID, _ := cal.Props.Text(ical.PropProductID); prodID != DefaultProductID {
+		t.Errorf("Expected PRODID %q, got %q", DefaultProductID, prodID)
+	}
+	if name, _ := cal.Props.Text("X-WR-CALNAME"); name != "Work Sync" {
+		t.Errorf("Expected X-WR-CALNAME 'Work Sync', got %q", name)
+	}
+	vevents := cal.Events()
+	if len(vevents) != len(events) {
+		t.Fatalf("Expected %d events, got %d", len(events), len(vevents))
+	}
+	for i, vevent := range vevents {
+		if uid, _ := vevent.Props.Text(ical.PropUID); uid != events[i].Id {
+			t.Errorf("Expected event %d to have UID %s, got %s", i, events[i].Id, uid)
+		}
+		if summary, _ := vevent.Props.Text(ical.PropSummary); summary != events[i].Summary {
+			t.Errorf("Expected event %d to have summary %s, got %s", i, events[i].Summary, summary)
+		}
+	}
+}
+
+func TestEncodeICS_ProductID(t *testing.T) {
+	events := []*calendar.Event{{
+		Id:    "event-1",
+		Start: &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:   &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+	}}
+	data, err := EncodeICS("Work Sync", "-//Example Corp//Sync//EN", events)
+	if err != nil {
+		t.Fatalf("EncodeICS() returned an error: %v", err)
+	}
+	if !bytes.Contains(data, []byte("PRODID:-//Example Corp//Sync//EN\r\n")) {
+		t.Errorf("Expected the configured PRODID, got:\n%s", data)
+	}
+}
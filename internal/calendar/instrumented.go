@@ -0,0 +1,183 @@
+package calendar
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// CallStats records the calls made to one method of an InstrumentedClient.
+type CallStats struct {
+	Calls    int
+	Errors   int           // Calls that returned an error
+	Duration time.Duration // Total time spent in the calls
+}
+
+// InstrumentedClient wraps a CalendarClient, recording the number, errors and
+// latency of the calls made through it, by method. It implements the optional
+// client interfaces too, so check for those with As rather than a type
+// assertion. It is safe for concurrent use.
+type InstrumentedClient struct {
+	client CalendarClient
+	now    func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]CallStats
+}
+
+// NewInstrumentedClient wraps client to record its calls.
+func NewInstrumentedClient(client CalendarClient) *InstrumentedClient {
+	return &InstrumentedClient{client: client, now: time.Now, stats: make(map[string]CallStats)}
+}
+
+// Unwrap returns the wrapped client.
+func (c *InstrumentedClient) Unwrap() CalendarClient {
+	return c.client
+}
+
+// Stats returns the calls recorded so far, by method name.
+func (c *InstrumentedClient) Stats() map[string]CallStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.stats)
+}
+
+// Reset forgets the calls recorded so far.
+func (c *InstrumentedClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.stats)
+}
+
+// record adds a call to method that started at start to the stats.
+func (c *InstrumentedClient) record(method string, start time.Time, err error) {
+	elapsed := c.now().Sub(start)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats[method]
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.Duration += elapsed
+	c.stats[method] = stats
+}
+
+func (c *InstrumentedClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	start := c.now()
+	id, err := c.client.FindOrCreateCalendarByName(name, colorID)
+	c.record("FindOrCreateCalendarByName", start, err)
+	return id, err
+}
+
+func (c *InstrumentedClient) FindCalendarByName(name string) (string, error) {
+	start := c.now()
+	id, err := c.client.FindCalendarByName(name)
+	c.record("FindCalendarByName", start, err)
+	return id, err
+}
+
+func (c *InstrumentedClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	start := c.now()
+	events, err := c.client.GetEvents(calendarID, timeMin, timeMax)
+	c.record("GetEvents", start, err)
+	return events, err
+}
+
+func (c *InstrumentedClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
+	start := c.now()
+	event, err := c.client.GetEvent(calendarID, eventID)
+	c.record("GetEvent", start, err)
+	return event, err
+}
+
+func (c *InstrumentedClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	start := c.now()
+	err := c.client.InsertEvent(calendarID, event)
+	c.record("InsertEvent", start, err)
+	return err
+}
+
+func (c *InstrumentedClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	start := c.now()
+	err := c.client.UpdateEvent(calendarID, eventID, event)
+	c.record("UpdateEvent", start, err)
+	return err
+}
+
+func (c *InstrumentedClient) DeleteEvent(calendarID, eventID string) error {
+	start := c.now()
+	err := c.client.DeleteEvent(calendarID, eventID)
+	c.record("DeleteEvent", start, err)
+	return err
+}
+
+func (c *InstrumentedClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	start := c.now()
+	err := c.client.ClearRange(calendarID, timeMin, timeMax)
+	c.record("ClearRange", start, err)
+	return err
+}
+
+func (c *InstrumentedClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
+	start := c.now()
+	events, err := c.client.FindEventsByWorkID(calendarID, workEventID)
+	c.record("FindEventsByWorkID", start, err)
+	return events, err
+}
+
+// GetEventsUnexpanded forwards to the wrapped client, which must be a
+// RecurringEventLister.
+func (c *InstrumentedClient) GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	start := c.now()
+	events, err := c.client.(RecurringEventLister).GetEventsUnexpanded(calendarID, timeMin, timeMax)
+	c.record("GetEventsUnexpanded", start, err)
+	return events, err
+}
+
+// GetEventsIncremental forwards to the wrapped client, which must be an
+// IncrementalEventLister.
+func (c *InstrumentedClient) GetEventsIncremental(calendarID, syncToken string, timeMin, timeMax time.Time) ([]*calendar.Event, string, error) {
+	start := c.now()
+	events, nextSyncToken, err := c.client.(IncrementalEventLister).GetEventsIncremental(calendarID, syncToken, timeMin, timeMax)
+	c.record("GetEventsIncremental", start, err)
+	return events, nextSyncToken, err
+}
+
+// GetFreeBusy forwards to the wrapped client, which must be a FreeBusySource.
+func (c *InstrumentedClient) GetFreeBusy(calendarID string, timeMin, timeMax time.Time) ([]TimeSlot, error) {
+	start := c.now()
+	slots, err := c.client.(FreeBusySource).GetFreeBusy(calendarID, timeMin, timeMax)
+	c.record("GetFreeBusy", start, err)
+	return slots, err
+}
+
+// CalendarExists forwards to the wrapped client, which must be a
+// CalendarChecker.
+func (c *InstrumentedClient) CalendarExists(calendarID string) error {
+	start := c.now()
+	err := c.client.(CalendarChecker).CalendarExists(calendarID)
+	c.record("CalendarExists", start, err)
+	return err
+}
+
+// ResetEventCache forwards to the wrapped client, which must be an
+// EventCache. It makes no API call, so nothing is recorded.
+func (c *InstrumentedClient) ResetEventCache() {
+	c.client.(EventCache).ResetEventCache()
+}
+
+// As returns client as a T if it implements T. An InstrumentedClient is a T
+// only if the client it wraps is.
+func As[T any](client CalendarClient) (T, bool) {
+	if instrumented, ok := client.(*InstrumentedClient); ok {
+		if _, ok := instrumented.client.(T); !ok {
+			var zero T
+			return zero, false
+		}
+	}
+	t, ok := client.(T)
+	return t, ok
+}
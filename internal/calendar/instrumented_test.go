@@ -0,0 +1,146 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// stubClient is a CalendarClient recording the calls it receives.
+type stubClient struct {
+	calls     []string
+	insertErr error
+}
+
+func (c *stubClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	c.calls = append(c.calls, "FindOrCreateCalendarByName "+name+" "+colorID)
+	return "cal-" + name, nil
+}
+
+func (c *stubClient) FindCalendarByName(name string) (string, error) {
+	c.calls = append(c.calls, "FindCalendarByName "+name)
+	return "cal-" + name, nil
+}
+
+func (c *stubClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	c.calls = append(c.calls, "GetEvents "+calendarID)
+	return []*calendar.Event{{Id: "event-1"}}, nil
+}
+
+func (c *stubClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
+	c.calls = append(c.calls, "GetEvent "+calendarID+" "+eventID)
+	return &calendar.Event{Id: eventID}, nil
+}
+
+func (c *stubClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	c.calls = append(c.calls, "InsertEvent "+calendarID+" "+event.Id)
+	return c.insertErr
+}
+
+func (c *stubClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	c.calls = append(c.calls, "UpdateEvent "+calendarID+" "+eventID)
+	return nil
+}
+
+func (c *stubClient) DeleteEvent(calendarID, eventID string) error {
+	c.calls = append(c.calls, "DeleteEvent "+calendarID+" "+eventID)
+	return nil
+}
+
+func (c *stubClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	c.calls = append(c.calls, "ClearRange "+calendarID)
+	return nil
+}
+
+func (c *stubClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
+	c.calls = append(c.calls, "FindEventsByWorkID "+calendarID+" "+workEventID)
+	return nil, nil
+}
+
+func TestInstrumentedClient_ForwardsAndRecordsCalls(t *testing.T) {
+	stub := &stubClient{insertErr: errors.New("quota exceeded")}
+	client := NewInstrumentedClient(stub)
+	// Every call takes half a second on the fake clock
+	clock := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	client.now = func() time.Time {
+		clock = clock.Add(500 * time.Millisecond)
+		return clock
+	}
+
+	if id, err := client.FindOrCreateCalendarByName("Work Sync", "7"); err != nil || id != "cal-Work Sync" {
+		t.Errorf("FindOrCreateCalendarByName() = %q, %v", id, err)
+	}
+	if events, err := client.GetEvents("cal-1", time.Time{}, time.Time{}); err != nil || len(events) != 1 {
+		t.Errorf("GetEvents() = %v, %v", events, err)
+	}
+	client.GetEvents("cal-1", time.Time{}, time.Time{})
+	if event, err := client.GetEvent("cal-1", "event-1"); err != nil || event.Id != "event-1" {
+		t.Errorf("GetEvent() = %v, %v", event, err)
+	}
+	if err := client.InsertEvent("cal-1", &calendar.Event{Id: "event-2"}); err != stub.insertErr {
+		t.Errorf("Expected InsertEvent to return the wrapped client's error, got %v", err)
+	}
+	client.UpdateEvent("cal-1", "event-1", &calendar.Event{})
+	client.DeleteEvent("cal-1", "event-1")
+	client.FindEventsByWorkID("cal-1", "work-1")
+	client.FindCalendarByName("Work Sync")
+
+	wantCalls := []string{
+		"FindOrCreateCalendarByName Work Sync 7",
+		"GetEvents cal-1",
+		"GetEvents cal-1",
+		"GetEvent cal-1 event-1",
+		"InsertEvent cal-1 event-2",
+		"UpdateEvent cal-1 event-1",
+		"DeleteEvent cal-1 event-1",
+		"FindEventsByWorkID cal-1 work-1",
+		"FindCalendarByName Work Sync",
+	}
+	if len(stub.calls) != len(wantCalls) {
+		t.Fatalf("Expected calls %v, got %v", wantCalls, stub.calls)
+	}
+	for i, call := range wantCalls {
+		if stub.calls[i] != call {
+			t.Errorf("Expected call %d to be %q, got %q", i, call, stub.calls[i])
+		}
+	}
+
+	stats := client.Stats()
+	if got, want := stats["GetEvents"], (CallStats{Calls: 2, Duration: time.Second}); got != want {
+		t.Errorf("Expected GetEvents stats %+v, got %+v", want, got)
+	}
+	if got, want := stats["InsertEvent"], (CallStats{Calls: 1, Errors: 1, Duration: 500 * time.Millisecond}); got != want {
+		t.Errorf("Expected InsertEvent stats %+v, got %+v", want, got)
+	}
+	if len(stats) != 8 {
+		t.Errorf("Expected stats for 8 methods, got %v", stats)
+	}
+
+	client.Reset()
+	if stats := client.Stats(); len(stats) != 0 {
+		t.Errorf("Expected no stats after Reset, got %v", stats)
+	}
+}
+
+func TestAs_LooksThroughInstrumentedClient(t *testing.T) {
+	plain := NewInstrumentedClient(&stubClient{})
+	if _, ok := As[RecurringEventLister](plain); ok {
+		t.Error("Expected a wrapped client without unexpanded listing not to be a RecurringEventLister")
+	}
+	if _, ok := As[FreeBusySource](plain); ok {
+		t.Error("Expected a wrapped client without free/busy not to be a FreeBusySource")
+	}
+
+	snapshot := NewInstrumentedClient(NewSnapshotCalendarClient(&Snapshot{CalendarName: "Work Sync"}))
+	lister, ok := As[RecurringEventLister](snapshot)
+	if !ok {
+		t.Fatal("Expected a wrapped snapshot client to be a RecurringEventLister")
+	}
+	// Calls through the interface are still recorded
+	lister.GetEventsUnexpanded(snapshotCalendarID, time.Time{}, time.Time{})
+	if stats := snapshot.Stats(); stats["GetEventsUnexpanded"].Calls != 1 {
+		t.Errorf("Expected the unexpanded listing to be recorded, got %v", stats)
+	}
+}
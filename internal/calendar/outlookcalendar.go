@@ -0,0 +1,461 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// outlookGraphURL is the Microsoft Graph API base URL.
+const outlookGraphURL = "https://graph.microsoft.com/v1.0"
+
+// outlookPropertySet is the GUID of the extended property set used to tag
+// synced events. Graph identifies custom properties by set GUID and name.
+const outlookPropertySet = "{9d1b6a0e-3c4f-4f0b-a7a2-5d1d2b0c6e41}"
+
+var (
+	// outlookWorkIDProperty holds the workEventId, as its own property so it can be filtered on.
+	outlookWorkIDProperty = "String " + outlookPropertySet + " Name workEventId"
+	// outlookPrivateProperty holds the remaining private extended properties, JSON encoded.
+	outlookPrivateProperty = "String " + outlookPropertySet + " Name privateProperties"
+)
+
+// outlookConferenceKey stores the video conference link among the private
+// properties, as Graph does not allow setting an arbitrary meeting URL.
+const outlookConferenceKey = "conferenceUrl"
+
+// OutlookCalendarClient is a client for Outlook.com / Office 365 calendars using Microsoft Graph.
+type OutlookCalendarClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewOutlookCalendarClient creates a new Outlook calendar client. httpClient must
+// add Microsoft Graph OAuth tokens to its requests.
+func NewOutlookCalendarClient(ctx context.Context, httpClient *http.Client) (*OutlookCalendarClient, error) {
+	if httpClient == nil {
+		return nil, fmt.Errorf("outlook: an authenticated HTTP client is required")
+	}
+	return &OutlookCalendarClient{httpClient: httpClient, baseURL: outlookGraphURL}, nil
+}
+
+// graphDateTime is a Graph dateTimeTimeZone value.
+type graphDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+// graphBody is a Graph itemBody value.
+type graphBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+// graphLocation is a Graph location value.
+type graphLocation struct {
+	DisplayName string `json:"displayName"`
+}
+
+// graphExtendedProperty is a Graph singleValueLegacyExtendedProperty.
+type graphExtendedProperty struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// graphEvent is the subset of a Graph event resource that is synced.
+type graphEvent struct {
+	ID                            string                  `json:"id,omitempty"`
+	Subject                       string                  `json:"subject"`
+	Body                          *graphBody              `json:"body,omitempty"`
+	Location                      *graphLocation          `json:"location,omitempty"`
+	Start                         *graphDateTime          `json:"start,omitempty"`
+	End                           *graphDateTime          `json:"end,omitempty"`
+	IsAllDay                      bool                    `json:"isAllDay"`
+	ShowAs                        string                  `json:"showAs,omitempty"`
+	SingleValueExtendedProperties []graphExtendedProperty `json:"singleValueExtendedProperties,omitempty"`
+}
+
+// graphCalendar is the subset of a Graph calendar resource that is used.
+type graphCalendar struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// graphError is the error body returned by Graph.
+type graphError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// doRequest sends a Graph request with an optional JSON body and decodes a JSON
+// response into out, if given. Non-2xx responses are returned as errors that
+// include the status code and Graph error message.
+func (c *OutlookCalendarClient) doRequest(method, rawURL string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("outlook: failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	if !strings.HasPrefix(rawURL, "http") {
+		rawURL = c.baseURL + rawURL
+	}
+	req, err := http.NewRequest(method, rawURL, reader)
+	if err != nil {
+		return 0, fmt.Errorf("outlook: failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "calendar-sync/1.0")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Return event times in UTC so they convert directly to RFC3339, and
+	// descriptions as plain text as they are written
+	req.Header.Set("Prefer", `outlook.timezone="UTC", outlook.body-content-type="text"`)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var gErr graphError
+		respBody, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(respBody, &gErr) == nil && gErr.Error.Message != "" {
+			return resp.StatusCode, fmt.Errorf("%s %s returned status %d: %s: %s", method, req.URL.Path, resp.StatusCode, gErr.Error.Code, gErr.Error.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s returned status %d: %s", method, req.URL.Path, resp.StatusCode, string(respBody))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("outlook: failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// expandProperties is the $expand query that returns the sync extended properties with events.
+func expandProperties() string {
+	return fmt.Sprintf("singleValueExtendedProperties($filter=id eq '%s' or id eq '%s')", outlookWorkIDProperty, outlookPrivateProperty)
+}
+
+// listEvents follows Graph paging and returns every event from the given URL.
+func (c *OutlookCalendarClient) listEvents(rawURL string) ([]*calendar.Event, error) {
+	var events []*calendar.Event
+	for rawURL != "" {
+		var page struct {
+			Value    []graphEvent `json:"value"`
+			NextLink string       `json:"@odata.nextLink"`
+		}
+		if _, err := c.doRequest(http.MethodGet, rawURL, nil, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Value {
+			events = append(events, graphToGoogleEvent(&page.Value[i]))
+		}
+		rawURL = page.NextLink
+	}
+	return events, nil
+}
+
+// FindCalendarByName returns the ID of the calendar with the given name, or
+// ErrCalendarNotFound if there is none.
+func (c *OutlookCalendarClient) FindCalendarByName(name string) (string, error) {
+	rawURL := "/me/calendars?$select=id,name"
+	for rawURL != "" {
+		var page struct {
+			Value    []graphCalendar `json:"value"`
+			NextLink string          `json:"@odata.nextLink"`
+		}
+		if _, err := c.doRequest(http.MethodGet, rawURL, nil, &page); err != nil {
+			return "", fmt.Errorf("outlook: failed to list calendars: %w", err)
+		}
+		for _, cal := range page.Value {
+			if cal.Name == name {
+				return cal.ID, nil
+			}
+		}
+		rawURL = page.NextLink
+	}
+	return "", fmt.Errorf("outlook: calendar '%s': %w", name, ErrCalendarNotFound)
+}
+
+// FindOrCreateCalendarByName finds an existing calendar by name or creates a new one.
+// Returns the calendar ID. Graph calendar colors don't correspond to Google color
+// IDs, so colorID is not used.
+func (c *OutlookCalendarClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	id, err := c.FindCalendarByName(name)
+	if !errors.Is(err, ErrCalendarNotFound) {
+		return id, err
+	}
+
+	var created graphCalendar
+	if _, err := c.doRequest(http.MethodPost, "/me/calendars", map[string]string{"name": name}, &created); err != nil {
+		return "", fmt.Errorf("outlook: failed to create calendar: %w", err)
+	}
+	return created.ID, nil
+}
+
+// GetEvents retrieves events from a calendar within the specified time window.
+// The calendar view expands recurring events into single instances.
+func (c *OutlookCalendarClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	query := url.Values{}
+	query.Set("startDateTime", timeMin.UTC().Format(time.RFC3339))
+	query.Set("endDateTime", timeMax.UTC().Format(time.RFC3339))
+	query.Set("$top", "1000")
+	query.Set("$expand", expandProperties())
+
+	events, err := c.listEvents(fmt.Sprintf("/me/calendars/%s/calendarView?%s", url.PathEscape(calendarID), query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("outlook: failed to list events: %w", err)
+	}
+	return events, nil
+}
+
+// GetEvent retrieves a single event by ID.
+func (c *OutlookCalendarClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
+	query := url.Values{}
+	query.Set("$expand", expandProperties())
+
+	var event graphEvent
+	if _, err := c.doRequest(http.MethodGet, fmt.Sprintf("/me/events/%s?%s", url.PathEscape(eventID), query.Encode()), nil, &event); err != nil {
+		return nil, fmt.Errorf("outlook: failed to get event: %w", err)
+	}
+	return graphToGoogleEvent(&event), nil
+}
+
+// InsertEvent inserts a new event into a calendar.
+// Graph does not send invitations for events without attendees, and attendees
+// are never copied, so no notifications are sent.
+func (c *OutlookCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	status, err := c.doRequest(http.MethodPost, fmt.Sprintf("/me/calendars/%s/events", url.PathEscape(calendarID)), googleToGraphEvent(event), nil)
+	if err != nil {
+		return fmt.Errorf("outlook: failed to insert event: %w", c.checkCalendarGone(calendarID, status, err))
+	}
+	return nil
+}
+
+// UpdateEvent updates an existing event in a calendar.
+func (c *OutlookCalendarClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	status, err := c.doRequest(http.MethodPatch, fmt.Sprintf("/me/events/%s", url.PathEscape(eventID)), googleToGraphEvent(event), nil)
+	if err != nil {
+		return fmt.Errorf("outlook: failed to update event: %w", c.checkCalendarGone(calendarID, status, err))
+	}
+	return nil
+}
+
+// DeleteEvent deletes an event from a calendar.
+func (c *OutlookCalendarClient) DeleteEvent(calendarID, eventID string) error {
+	status, err := c.doRequest(http.MethodDelete, fmt.Sprintf("/me/events/%s", url.PathEscape(eventID)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("outlook: failed to delete event: %w", c.checkCalendarGone(calendarID, status, err))
+	}
+	return nil
+}
+
+// checkCalendarGone distinguishes a 404 for a missing event from a 404 for a
+// missing calendar. If the calendar no longer exists, ErrCalendarNotFound is
+// returned; otherwise err is returned unchanged.
+func (c *OutlookCalendarClient) checkCalendarGone(calendarID string, status int, err error) error {
+	if status != http.StatusNotFound {
+		return err
+	}
+	if getStatus, getErr := c.doRequest(http.MethodGet, fmt.Sprintf("/me/calendars/%s?$select=id", url.PathEscape(calendarID)), nil, nil); getErr != nil && getStatus == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrCalendarNotFound, calendarID)
+	}
+	return err
+}
+
+// ClearRange deletes every event in a calendar within the specified time window.
+func (c *OutlookCalendarClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	events, err := c.GetEvents(calendarID, timeMin, timeMax)
+	if err != nil {
+		return fmt.Errorf("outlook: failed to list events to clear: %w", err)
+	}
+
+	var errs []error
+	for _, event := range events {
+		if err := c.DeleteEvent(calendarID, event.Id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FindEventsByWorkID finds events tagged with the given workEventId.
+func (c *OutlookCalendarClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
+	query := url.Values{}
+	query.Set("$filter", fmt.Sprintf("singleValueExtendedProperties/Any(ep: ep/id eq '%s' and ep/value eq '%s')",
+		outlookWorkIDProperty, strings.ReplaceAll(workEventID, "'", "''")))
+	query.Set("$expand", expandProperties())
+
+	events, err := c.listEvents(fmt.Sprintf("/me/calendars/%s/events?%s", url.PathEscape(calendarID), query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("outlook: failed to find events by workEventId: %w", err)
+	}
+	return events, nil
+}
+
+// googleToGraphEvent converts a Google Calendar event to a Graph event.
+func googleToGraphEvent(event *calendar.Event) *graphEvent {
+	gEvent := &graphEvent{
+		Subject:  event.Summary,
+		Body:     &graphBody{ContentType: "text", Content: event.Description},
+		Location: &graphLocation{DisplayName: event.Location},
+	}
+
+	if event.Start != nil && event.Start.Date != "" {
+		// All-day events run from midnight to midnight and have no time zone
+		gEvent.IsAllDay = true
+		gEvent.Start = &graphDateTime{DateTime: event.Start.Date + "T00:00:00", TimeZone: "UTC"}
+		if event.End != nil && event.End.Date != "" {
+			gEvent.End = &graphDateTime{DateTime: event.End.Date + "T00:00:00", TimeZone: "UTC"}
+		}
+	} else {
+		gEvent.Start = googleToGraphTime(event.Start)
+		gEvent.End = googleToGraphTime(event.End)
+	}
+
+	if event.Transparency == "transparent" {
+		gEvent.ShowAs = "free"
+	} else {
+		gEvent.ShowAs = "busy"
+	}
+
+	private := make(map[string]string)
+	if event.ExtendedProperties != nil {
+		for key, value := range event.ExtendedProperties.Private {
+			private[key] = value
+		}
+	}
+	if meetURL := conferenceURL(event); meetURL != "" {
+		private[outlookConferenceKey] = meetURL
+	}
+	if workID := private["workEventId"]; workID != "" {
+		gEvent.SingleValueExtendedProperties = append(gEvent.SingleValueExtendedProperties,
+			graphExtendedProperty{ID: outlookWorkIDProperty, Value: workID})
+		delete(private, "workEventId")
+	}
+	if len(private) > 0 {
+		data, _ := json.Marshal(private)
+		gEvent.SingleValueExtendedProperties = append(gEvent.SingleValueExtendedProperties,
+			graphExtendedProperty{ID: outlookPrivateProperty, Value: string(data)})
+	}
+
+	return gEvent
+}
+
+// googleToGraphTime converts a timed Google event time to UTC for Graph.
+func googleToGraphTime(t *calendar.EventDateTime) *graphDateTime {
+	if t == nil || t.DateTime == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, t.DateTime)
+	if err != nil {
+		return &graphDateTime{DateTime: t.DateTime, TimeZone: "UTC"}
+	}
+	return &graphDateTime{DateTime: parsed.UTC().Format("2006-01-02T15:04:05"), TimeZone: "UTC"}
+}
+
+// graphToGoogleEvent converts a Graph event to a Google Calendar event.
+func graphToGoogleEvent(gEvent *graphEvent) *calendar.Event {
+	event := &calendar.Event{
+		Id:      gEvent.ID,
+		Summary: gEvent.Subject,
+	}
+	if gEvent.Body != nil {
+		event.Description = gEvent.Body.Content
+	}
+	if gEvent.Location != nil {
+		event.Location = gEvent.Location.DisplayName
+	}
+
+	if gEvent.IsAllDay {
+		if gEvent.Start != nil && len(gEvent.Start.DateTime) >= 10 {
+			event.Start = &calendar.EventDateTime{Date: gEvent.Start.DateTime[:10]}
+		}
+		if gEvent.End != nil && len(gEvent.End.DateTime) >= 10 {
+			event.End = &calendar.EventDateTime{Date: gEvent.End.DateTime[:10]}
+		}
+	} else {
+		event.Start = graphToGoogleTime(gEvent.Start)
+		event.End = graphToGoogleTime(gEvent.End)
+	}
+
+	if gEvent.ShowAs == "free" {
+		event.Transparency = "transparent"
+	}
+
+	private := make(map[string]string)
+	for _, prop := range gEvent.SingleValueExtendedProperties {
+		switch {
+		case strings.EqualFold(prop.ID, outlookWorkIDProperty):
+			private["workEventId"] = prop.Value
+		case strings.EqualFold(prop.ID, outlookPrivateProperty):
+			var stored map[string]string
+			if err := json.Unmarshal([]byte(prop.Value), &stored); err == nil {
+				for key, value := range stored {
+					private[key] = value
+				}
+			}
+		}
+	}
+	if meetURL := private[outlookConferenceKey]; meetURL != "" {
+		event.ConferenceData = &calendar.ConferenceData{
+			EntryPoints: []*calendar.EntryPoint{{EntryPointType: "video", Uri: meetURL}},
+		}
+		delete(private, outlookConferenceKey)
+	}
+	if len(private) > 0 {
+		event.ExtendedProperties = &calendar.EventExtendedProperties{Private: private}
+	}
+
+	return event
+}
+
+// graphToGoogleTime converts a Graph time, returned in UTC, to an RFC3339 event time.
+func graphToGoogleTime(t *graphDateTime) *calendar.EventDateTime {
+	if t == nil {
+		return nil
+	}
+	loc := time.UTC
+	if t.TimeZone != "" && t.TimeZone != "UTC" {
+		if l, err := time.LoadLocation(t.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	// Graph returns up to seven fractional digits and no offset
+	parsed, err := time.ParseInLocation("2006-01-02T15:04:05.9999999", t.DateTime, loc)
+	if err != nil {
+		return &calendar.EventDateTime{DateTime: t.DateTime}
+	}
+	return &calendar.EventDateTime{DateTime: parsed.UTC().Format(time.RFC3339)}
+}
+
+// conferenceURL returns the video conference link of an event, if any.
+func conferenceURL(event *calendar.Event) string {
+	if event.ConferenceData == nil {
+		return ""
+	}
+	for _, entryPoint := range event.ConferenceData.EntryPoints {
+		if entryPoint.EntryPointType == "video" && entryPoint.Uri != "" {
+			return entryPoint.Uri
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestOutlookEventRoundTrip(t *testing.T) {
+	event := &calendar.Event{
+		Summary:      "Planning",
+		Description:  "Quarterly planning",
+		Location:     "Room 1",
+		Transparency: "transparent",
+		Start:        &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00+01:00"},
+		End:          &calendar.EventDateTime{DateTime: "2024-01-15T12:00:00+01:00"},
+		ConferenceData: &calendar.ConferenceData{
+			EntryPoints: []*calendar.EntryPoint{{EntryPointType: "video", Uri: "https://meet.google.com/abc-defg-hij"}},
+		},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{"workEventId": "work-1", "geo": "37.422000;-122.084100"},
+		},
+	}
+
+	gEvent := googleToGraphEvent(event)
+	if gEvent.Start.DateTime != "2024-01-15T10:00:00" || gEvent.Start.TimeZone != "UTC" {
+		t.Errorf("Expected start converted to UTC, got %+v", gEvent.Start)
+	}
+	if gEvent.ShowAs != "free" {
+		t.Errorf("Expected showAs 'free' for a transparent event, got %q", gEvent.ShowAs)
+	}
+
+	// Graph returns times with fractional seconds
+	gEvent.Start.DateTime += ".0000000"
+	gEvent.End.DateTime += ".0000000"
+	got := graphToGoogleEvent(gEvent)
+
+	if got.Summary != event.Summary || got.Description != event.Description || got.Location != event.Location {
+		t.Errorf("Expected summary, description and location to round-trip, got %q, %q, %q", got.Summary, got.Description, got.Location)
+	}
+	if got.Start.DateTime != "2024-01-15T10:00:00Z" || got.End.DateTime != "2024-01-15T11:00:00Z" {
+		t.Errorf("Expected times 10:00Z-11:00Z, got %s-%s", got.Start.DateTime, got.End.DateTime)
+	}
+	if got.Transparency != "transparent" {
+		t.Errorf("Expected transparency 'transparent', got %q", got.Transparency)
+	}
+	if conferenceURL(got) != "https://meet.google.com/abc-defg-hij" {
+		t.Errorf("Expected the conference link to round-trip, got %q", conferenceURL(got))
+	}
+	if got.ExtendedProperties.Private["workEventId"] != "work-1" || got.ExtendedProperties.Private["geo"] != "37.422000;-122.084100" {
+		t.Errorf("Expected private properties to round-trip, got %v", got.ExtendedProperties.Private)
+	}
+}
+
+func TestOutlookEventRoundTrip_AllDay(t *testing.T) {
+	event := &calendar.Event{
+		Summary: "Holiday",
+		Start:   &calendar.EventDateTime{Date: "2024-01-15"},
+		End:     &calendar.EventDateTime{Date: "2024-01-16"},
+	}
+
+	gEvent := googleToGraphEvent(event)
+	if !gEvent.IsAllDay || gEvent.Start.DateTime != "2024-01-15T00:00:00" {
+		t.Errorf("Expected an all-day event starting at midnight, got isAllDay=%v start=%+v", gEvent.IsAllDay, gEvent.Start)
+	}
+
+	got := graphToGoogleEvent(gEvent)
+	if got.Start.Date != "2024-01-15" || got.End.Date != "2024-01-16" || got.Start.DateTime != "" {
+		t.Errorf("Expected dates 2024-01-15 to 2024-01-16, got %+v to %+v", got.Start, got.End)
+	}
+}
+
+func TestOutlookCalendar_FindEventsByWorkID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/me/calendars/cal-1/events" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		filter := r.URL.Query().Get("$filter")
+		if !strings.Contains(filter, outlookWorkIDProperty) || !strings.Contains(filter, "ep/value eq 'work-1'") {
+			t.Errorf("Expected a filter on the workEventId property, got %q", filter)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"value": []graphEvent{{
+				ID:      "outlook-1",
+				Subject: "Planning",
+				Start:   &graphDateTime{DateTime: "2024-01-15T10:00:00.0000000", TimeZone: "UTC"},
+				End:     &graphDateTime{DateTime: "2024-01-15T11:00:00.0000000", TimeZone: "UTC"},
+				SingleValueExtendedProperties: []graphExtendedProperty{
+					{ID: outlookWorkIDProperty, Value: "work-1"},
+				},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewOutlookCalendarClient(context.Background(), server.Client())
+	if err != nil {
+		t.Fatalf("NewOutlookCalendarClient() returned an error: %v", err)
+	}
+	client.baseURL = server.URL
+
+	events, err := client.FindEventsByWorkID("cal-1", "work-1")
+	if err != nil {
+		t.Fatalf("FindEventsByWorkID() returned an error: %v", err)
+	}
+	if len(events) != 1 || events[0].Id != "outlook-1" {
+		t.Fatalf("Expected event outlook-1, got %+v", events)
+	}
+	if events[0].ExtendedProperties.Private["workEventId"] != "work-1" {
+		t.Errorf("Expected workEventId 'work-1', got %v", events[0].ExtendedProperties.Private)
+	}
+}
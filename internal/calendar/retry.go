@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy is how a calendar client retries requests that failed with a
+// transient error.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Backoff before the first retry, doubled for each one after
+}
+
+// DefaultRetryPolicy is used by clients that were not given a policy.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: time.Second}
+
+// orDefault returns the policy, or DefaultRetryPolicy if it was left unset.
+func (p RetryPolicy) orDefault() RetryPolicy {
+	if p == (RetryPolicy{}) {
+		return DefaultRetryPolicy
+	}
+	return p
+}
+
+// backoff returns the wait before retry number attempt, counting from 0. It is
+// jittered between half and all of the exponential delay, so that requests
+// which failed together aren't retried together.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleepContext waits for d, returning early with the context's error if ctx
+// is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isTransientStatus reports whether an HTTP status says the request may
+// succeed if retried.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isNetworkError reports whether err is a failure to reach the server or to
+// read its response, other than the request being cancelled.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && !errors.Is(err, context.Canceled)
+}
+
+// retryAfterHeader returns the backoff suggested by a Retry-After header,
+// given either as a number of seconds or as an HTTP date.
+func retryAfterHeader(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return max(time.Until(when), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// errSnapshotReadOnly is returned by the write operations of a snapshot client.
+var errSnapshotReadOnly = errors.New("snapshot calendars are read-only")
+
+// snapshotCalendarID is the calendar ID a snapshot client reports for its calendar.
+const snapshotCalendarID = "snapshot"
+
+// Snapshot is a saved copy of the events in a destination calendar, used to
+// review what a sync would change without touching the live calendar.
+type Snapshot struct {
+	Destination  string            `json:"destination"`
+	CalendarName string            `json:"calendar_name"`
+	TakenAt      time.Time         `json:"taken_at"`
+	Events       []*calendar.Event `json:"events"`
+}
+
+// SaveSnapshot writes the snapshot to path as JSON.
+func SaveSnapshot(path string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// SnapshotCalendarClient serves a saved snapshot as a destination calendar.
+// It holds the single calendar the snapshot was taken of and lists all of its
+// events regardless of the requested time range, as the snapshot was already
+// limited to a sync window. All write operations fail, so it is only useful
+// for dry runs.
+type SnapshotCalendarClient struct {
+	snapshot *Snapshot
+}
+
+// NewSnapshotCalendarClient creates a client reading from snapshot.
+func NewSnapshotCalendarClient(snapshot *Snapshot) *SnapshotCalendarClient {
+	return &SnapshotCalendarClient{snapshot: snapshot}
+}
+
+// FindCalendarByName returns the snapshot's calendar if it has the name.
+func (c *SnapshotCalendarClient) FindCalendarByName(name string) (string, error) {
+	if name != c.snapshot.CalendarName {
+		return "", fmt.Errorf("snapshot of '%s' has no calendar '%s': %w", c.snapshot.CalendarName, name, ErrCalendarNotFound)
+	}
+	return snapshotCalendarID, nil
+}
+
+// FindOrCreateCalendarByName returns the snapshot's calendar; it can't create others.
+func (c *SnapshotCalendarClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	id, err := c.FindCalendarByName(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errSnapshotReadOnly, err)
+	}
+	return id, nil
+}
+
+// GetEvents returns every event in the snapshot.
+func (c *SnapshotCalendarClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	if calendarID != snapshotCalendarID {
+		return nil, fmt.Errorf("snapshot has no calendar %s: %w", calendarID, ErrCalendarNotFound)
+	}
+	return c.snapshot.Events, nil
+}
+
+// GetEventsUnexpanded returns every event in the snapshot, which holds
+// whatever form the events were listed in when it was taken.
+func (c *SnapshotCalendarClient) GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	return c.GetEvents(calendarID, timeMin, timeMax)
+}
+
+// GetEvent returns the snapshot event with the ID.
+func (c *SnapshotCalendarClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
+	events, err := c.GetEvents(calendarID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.Id == eventID {
+			return event, nil
+		}
+	}
+	return nil, fmt.Errorf("event %s not found in snapshot", eventID)
+}
+
+// FindEventsByWorkID returns the snapshot events tagged with workEventID.
+func (c *SnapshotCalendarClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
+	events, err := c.GetEvents(calendarID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var found []*calendar.Event
+	for _, event := range events {
+		if event.ExtendedProperties != nil && event.ExtendedProperties.Private["workEventId"] == workEventID {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
+
+// InsertEvent always fails, as snapshots are read-only.
+func (c *SnapshotCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	return errSnapshotReadOnly
+}
+
+// UpdateEvent always fails, as snapshots are read-only.
+func (c *SnapshotCalendarClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	return errSnapshotReadOnly
+}
+
+// DeleteEvent always fails, as snapshots are read-only.
+func (c *SnapshotCalendarClient) DeleteEvent(calendarID, eventID string) error {
+	return errSnapshotReadOnly
+}
+
+// ClearRange always fails, as snapshots are read-only.
+func (c *SnapshotCalendarClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	return errSnapshotReadOnly
+}
@@ -1,12 +1,38 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
+	"maps"
+	neturl "net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config_version of the config file format this
+// release reads. MinConfigVersion is the oldest version it still accepts:
+// files between the two load with a warning, as some of their settings may
+// have changed meaning, and older ones are rejected.
+var (
+	CurrentConfigVersion = 1
+	MinConfigVersion     = 1
 )
 
+// StrictKeys makes loading a config file fail on keys that no setting uses,
+// such as a misspelled one that would otherwise be silently ignored.
+var StrictKeys = true
+
 // GoogleCredentials represents the structure of Google OAuth credentials JSON file.
 type GoogleCredentials struct {
 	Installed struct {
@@ -42,55 +68,588 @@ func LoadGoogleCredentials(path string) (clientID, clientSecret string, err erro
 	return "", "", fmt.Errorf("no client_id found in credentials file (expected 'installed' or 'web' section)")
 }
 
+// DefaultGoogleOAuthScopes are the Google OAuth scopes requested when none are configured.
+var DefaultGoogleOAuthScopes = []string{
+	"https://www.googleapis.com/auth/calendar",
+	"https://www.googleapis.com/auth/calendar.events",
+}
+
+// googleWriteScopes are the Google OAuth scopes that allow writing events to a
+// destination calendar.
+var googleWriteScopes = []string{
+	"https://www.googleapis.com/auth/calendar",
+	"https://www.googleapis.com/auth/calendar.events",
+	"https://www.googleapis.com/auth/calendar.app.created",
+}
+
 // Destination represents a single destination calendar configuration.
 type Destination struct {
 	Name            string `json:"name"`                        // Name for logging (e.g., "Personal Google", "iCloud")
-	Type            string `json:"type"`                        // "google" or "apple"
+	Type            string `json:"type"`                        // "google", "apple", "caldav" or "outlook"
 	TokenPath       string `json:"token_path,omitempty"`        // For Google: path to OAuth token file
 	CalendarName    string `json:"calendar_name,omitempty"`     // Name of the calendar to create/use
 	CalendarColorID string `json:"calendar_color_id,omitempty"` // Color ID for the calendar
+	Enabled         *bool  `json:"enabled,omitempty"`           // Set to false to skip this destination without removing it (default: true)
+
+	// ForceTransparency controls how synced events show for free/busy:
+	// "source" (default) copies the work event, "opaque" always shows busy,
+	// "transparent" always shows free.
+	ForceTransparency string `json:"force_transparency,omitempty"`
+
+	// PrivacyMode controls how much of each work event is copied: "full" (default)
+	// copies event details, "busy" syncs free/busy blocks titled "Busy" only, and
+	// "redact" syncs each event's times under PrivacyPlaceholder with no details.
+	PrivacyMode        string `json:"privacy_mode,omitempty"`
+	PrivacyPlaceholder string `json:"privacy_placeholder,omitempty"` // Title of redacted events (default: "Busy")
+
+	// DescriptionFormat controls how event descriptions are copied to CalDAV
+	// destinations: "source" (default) copies them as written, "text" turns
+	// the HTML Google descriptions may contain into plain text, which CalDAV
+	// clients show as is.
+	DescriptionFormat string `json:"description_format,omitempty"`
+
+	// SyncWindowWeeks and SyncWindowWeeksPast override the global sync window
+	// for this destination; when left out, the global values apply.
+	SyncWindowWeeks     *int `json:"sync_window_weeks,omitempty"`
+	SyncWindowWeeksPast *int `json:"sync_window_weeks_past,omitempty"`
+
+	// RedactFields lists event fields left blank in this destination's copies:
+	// "description", "location" (including coordinates) or "conference".
+	RedactFields []string `json:"redact_fields,omitempty"`
+
+	// Routes send some classes of work events to other calendars in the same
+	// account. Events matching no route go to CalendarName.
+	Routes []Route `json:"routes,omitempty"`
+
+	// ReverseBusyBlock blocks the time of personal events in this destination's
+	// calendar (those without a workEventId) with "Busy (personal)" events on
+	// the work calendar, instead of deleting them. Only one destination may set
+	// it, and the work account needs a scope that can write events.
+	ReverseBusyBlock bool `json:"reverse_busy_block,omitempty"`
+
+	// Outlook specific fields
+	OutlookTokenPath string `json:"outlook_token_path,omitempty"` // Path to the Microsoft OAuth token file
 
 	// Apple Calendar specific fields
 	ServerURL string `json:"server_url,omitempty"` // CalDAV server URL (e.g., "https://caldav.icloud.com")
+	AuthMode  string `json:"auth_mode,omitempty"`  // "basic" (default) or "oauth" for bearer tokens stored at token_path
 	Username  string `json:"username,omitempty"`   // iCloud email
 	Password  string `json:"password,omitempty"`   // App-specific password
+
+	// PasswordSource is where the app-specific password comes from: "config"
+	// (default) for password, or "keychain" for the macOS keychain item with
+	// KeychainService and KeychainAccount (default: username).
+	PasswordSource  string `json:"password_source,omitempty"`
+	KeychainService string `json:"keychain_service,omitempty"`
+	KeychainAccount string `json:"keychain_account,omitempty"`
+
+	// CalendarPath is the path (or URL) of the calendar on the CalDAV server.
+	// When set, that calendar is used rather than the one named CalendarName,
+	// so renaming it doesn't break the sync; it is never created.
+	CalendarPath string `json:"calendar_path,omitempty"`
+}
+
+// Route sends work events of certain classes to a separate calendar.
+type Route struct {
+	// Events lists the event classes that must all match: "all_day", "timed"
+	// or "out_of_office". The first matching route is used.
+	Events          []string `json:"events"`
+	CalendarName    string   `json:"calendar_name"`               // Name of the calendar to create/use for matching events
+	CalendarColorID string   `json:"calendar_color_id,omitempty"` // Color ID for the calendar (default: the destination's)
+}
+
+// IsEnabled reports whether the destination should be synced.
+func (d Destination) IsEnabled() bool {
+	return d.Enabled == nil || *d.Enabled
+}
+
+// IsCalDAV reports whether the destination is reached over CalDAV: iCloud
+// ("apple") or any other CalDAV server ("caldav").
+func (d Destination) IsCalDAV() bool {
+	return d.Type == "apple" || d.Type == "caldav"
+}
+
+// ExpandsRecurring reports whether recurring events are synced instance by instance.
+func (c *Config) ExpandsRecurring() bool {
+	return c.ExpandRecurring == nil || *c.ExpandRecurring
+}
+
+// MergesUntagged reports whether untagged destination events matching a work
+// event are adopted rather than deleted.
+func (c *Config) MergesUntagged() bool {
+	return c.MergeUntagged == nil || *c.MergeUntagged
+}
+
+// OverrideCalendarName makes every destination sync to the calendar name
+// instead of its configured calendars, for --calendar-name. Routes are dropped,
+// so that no event goes to the configured calendars.
+func (c *Config) OverrideCalendarName(name string) {
+	for i := range c.Destinations {
+		c.Destinations[i].CalendarName = name
+		c.Destinations[i].Routes = nil
+	}
+}
+
+// DayWindow returns the daily time window, in minutes after midnight.
+func (c *Config) DayWindow() (start, end int) {
+	start, end = 360, 1440
+	if c.DayWindowStartMinutes != nil {
+		start = *c.DayWindowStartMinutes
+	}
+	if c.DayWindowEndMinutes != nil {
+		end = *c.DayWindowEndMinutes
+	}
+	return start, end
+}
+
+// HomeLocation returns the location of HomeTimeZone, or nil if it isn't set
+// (or isn't valid, which LoadConfig rejects).
+func (c *Config) HomeLocation() *time.Location {
+	if c.HomeTimeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(c.HomeTimeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// SourceCalendarIDs returns the IDs of the work calendars to sync from.
+func (c *Config) SourceCalendarIDs() []string {
+	if len(c.SourceCalendars) > 0 {
+		return c.SourceCalendars
+	}
+	if c.SourceCalendarID == "" {
+		return []string{"primary"}
+	}
+	return []string{c.SourceCalendarID}
 }
 
 // Config holds the configuration for the calendar sync tool.
 type Config struct {
+	ConfigVersion         int           `json:"config_version,omitempty"` // Version of the file's format; unset for files that predate it
 	WorkTokenPath         string        `json:"work_token_path,omitempty"`
 	WorkEmail             string        `json:"work_email,omitempty"`
+	SourceCalendarID      string        `json:"source_calendar_id,omitempty"` // ID of the work calendar to sync from (default: "primary")
+	SourceCalendars       []string      `json:"source_calendars,omitempty"`   // IDs of several work calendars to merge, instead of source_calendar_id
 	GoogleCredentialsPath string        `json:"google_credentials_path,omitempty"`
+	OutlookClientID       string        `json:"outlook_client_id,omitempty"`     // Microsoft app (client) ID, required for Outlook destinations
+	OutlookClientSecret   string        `json:"outlook_client_secret,omitempty"` // Optional, for confidential client apps
 	IncludeOOO            bool          `json:"include_ooo,omitempty"`
 	Destinations          []Destination `json:"destinations"` // Array of destination configurations (required)
 
+	// TokenStore selects how OAuth tokens are stored: "file" (the default) as
+	// plain JSON files, "encrypted" as files encrypted with the passphrase in
+	// the CALSYNC_TOKEN_KEY environment variable, or "keyring" in the OS
+	// keyring, under the token paths, which then only name the tokens.
+	TokenStore string `json:"token_store,omitempty"`
+
+	// ServiceAccountKeyPath and ImpersonateSubject authenticate the work
+	// account without the browser flow: the service account's JSON key, with
+	// domain-wide delegation, acts as the user ImpersonateSubject. When set,
+	// work_token_path isn't used.
+	ServiceAccountKeyPath string `json:"service_account_key_path,omitempty"`
+	ImpersonateSubject    string `json:"impersonate_subject,omitempty"`
+
+	// WorkOAuthScopes and DestinationOAuthScopes are the Google OAuth scopes
+	// requested for the work account and for Google destination accounts
+	// (default: DefaultGoogleOAuthScopes). The work account only needs read
+	// access, e.g. calendar.readonly; destinations need a write scope.
+	WorkOAuthScopes        []string `json:"work_oauth_scopes,omitempty"`
+	DestinationOAuthScopes []string `json:"destination_oauth_scopes,omitempty"`
+
+	// DestinationsFile names a JSON file holding an array of additional
+	// destinations, merged after the inline ones. A relative path is resolved
+	// against the directory of the config file.
+	DestinationsFile string `json:"destinations_file,omitempty"`
+
 	// Sync window configuration
 	SyncWindowWeeks     int `json:"sync_window_weeks,omitempty"`      // Number of weeks to sync forward from start of current week (default: 2)
 	SyncWindowWeeksPast int `json:"sync_window_weeks_past,omitempty"` // Number of weeks to sync backward from start of current week (default: 0)
+
+	// MaxSyncWindowWeeks caps the sync window, forward and backward weeks
+	// together, of every destination, so a mistyped sync_window_weeks is
+	// rejected rather than listing years of events (default: 104).
+	MaxSyncWindowWeeks int `json:"max_sync_window_weeks,omitempty"`
+
+	// DayWindowStartMinutes and DayWindowEndMinutes bound the part of each day,
+	// in minutes after midnight, that timed events must overlap to be synced
+	// (default: 360 to 1440, i.e. 6:00 AM to midnight).
+	DayWindowStartMinutes *int `json:"day_window_start_minutes,omitempty"`
+	DayWindowEndMinutes   *int `json:"day_window_end_minutes,omitempty"`
+
+	// HomeTimeZone is the IANA time zone (e.g. "Asia/Tokyo") the daily window
+	// and the sync window's weeks are evaluated in, whatever zone the work
+	// events carry (default: the events' own zone, and the local one for weeks).
+	HomeTimeZone string `json:"home_time_zone,omitempty"`
+	Timezone     string `json:"timezone,omitempty"` // Another name for HomeTimeZone
+
+	// ExpandRecurring controls whether recurring events are synced as one copy
+	// per instance (the default) or as a single recurring event carrying the
+	// series' RRULE/EXDATE lines. Not supported for Outlook destinations.
+	ExpandRecurring *bool `json:"expand_recurring,omitempty"`
+
+	// SkipPastEvents drops events that have already ended, so the destination
+	// only mirrors current and upcoming events.
+	SkipPastEvents bool `json:"skip_past_events,omitempty"`
+
+	// MaxAllDaySpanDays skips all-day events that span more than this many days
+	// (e.g. multi-week vacation blocks). 0 means unlimited.
+	MaxAllDaySpanDays int `json:"max_all_day_span_days,omitempty"`
+
+	// SkipVisibilities lists work event visibilities ("default", "public",
+	// "private", "confidential") that should not be synced.
+	SkipVisibilities []string `json:"skip_visibilities,omitempty"`
+
+	// IncludeOrganizerDomains, if set, limits syncing to events whose organizer
+	// email is in one of these domains. ExcludeOrganizerDomains skips events
+	// organized from any of its domains.
+	IncludeOrganizerDomains []string `json:"include_organizer_domains,omitempty"`
+	ExcludeOrganizerDomains []string `json:"exclude_organizer_domains,omitempty"`
+
+	// OnlyMyEvents limits syncing to events organized or created by WorkEmail,
+	// dropping those the user is only invited to.
+	OnlyMyEvents bool `json:"only_my_events,omitempty"`
+
+	// ExcludeSummaryKeywords drops events whose summary contains any of these
+	// keywords, ignoring case (e.g. "Lunch", "Focus time").
+	ExcludeSummaryKeywords []string `json:"exclude_summary_keywords,omitempty"`
+
+	// FilterExpression is an expression, in the expr language, that work
+	// events must satisfy to be synced, e.g. "durationMinutes >= 15 &&
+	// attendeeCount > 1". It can use the fields of FilterEnv.
+	FilterExpression string `json:"filter_expression,omitempty"`
+
+	// PreserveTag lists private extended property keys (e.g. "keepMe") that
+	// mark destination events without a workEventId as added on purpose:
+	// they are kept instead of being deleted as manually created.
+	PreserveTag []string `json:"preserve_tag,omitempty"`
+
+	// MergeUntagged controls what happens to destination events without a
+	// workEventId that match a work event on summary, start and end: they are
+	// adopted by tagging them (the default), or with false deleted and replaced
+	// by a fresh copy.
+	MergeUntagged *bool `json:"merge_untagged,omitempty"`
+
+	// CopyAttendees copies the guest list of work events, with each guest's
+	// response, to Google destinations. No invitations are sent. Destinations
+	// in "redact" privacy mode get hashed addresses without names.
+	CopyAttendees bool `json:"copy_attendees,omitempty"`
+
+	// IncludeSourceLink ends the description of synced events with a link to
+	// the work event in Google Calendar, to open it for editing.
+	IncludeSourceLink bool `json:"include_source_link,omitempty"`
+
+	// DuplicateCalendars sets what happens when several Google calendars have
+	// a destination's calendar name: "error", "use-first" (the default),
+	// "use-newest" (the most recently modified) or "merge" (the others' events
+	// are moved into the first).
+	DuplicateCalendars string `json:"duplicate_calendars,omitempty"`
+
+	// ICalProductID is the PRODID of the events written to CalDAV
+	// destinations and ICS feeds, naming the program that wrote them.
+	// Unset uses "-//Calendar Sync//EN".
+	ICalProductID string `json:"ical_product_id,omitempty"`
+
+	// ColorCategories maps Google event color IDs ("1" to "11") to the
+	// category that copies of events of that color get on CalDAV
+	// destinations, which have no per-event colors, e.g. {"11": "Urgent"}.
+	ColorCategories map[string]string `json:"color_categories,omitempty"`
+
+	// PreserveReminders copies the reminders of work events that don't use
+	// the calendar's default ones to Google and Apple destinations.
+	PreserveReminders bool `json:"preserve_reminders,omitempty"`
+
+	// KeepCancelled syncs cancelled work events still in the window, rather
+	// than deleting their copies: Apple destinations show them as cancelled,
+	// and other destinations get "Cancelled: " before the title. Work events
+	// are then always listed in full, without the sync state.
+	KeepCancelled bool `json:"keep_cancelled,omitempty"`
+
+	// DedupeAcrossSources syncs an event found on several source_calendars
+	// under different IDs, such as a meeting both calendars were invited to,
+	// once: events with the same summary, start and end are taken as one,
+	// from the first calendar, with a warning.
+	DedupeAcrossSources bool `json:"dedupe_across_sources,omitempty"`
+
+	// VerifyWrites re-reads inserted/updated events after each sync and reports
+	// any that are missing or differ from what was written.
+	VerifyWrites bool `json:"verify_writes,omitempty"`
+
+	// DebugSummaryFilter, with --verbose, logs each destination event whose
+	// summary contains it, ignoring case, to trace how a specific event is
+	// synced. Empty turns this off.
+	DebugSummaryFilter string `json:"debug_summary_filter,omitempty"`
+
+	// DryRun reads both calendars and logs the inserts, updates and deletes a
+	// sync would make, without applying any of them.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// SafeMode only inserts missing events, logging the updates and deletes a
+	// sync would make without applying them.
+	SafeMode bool `json:"safe_mode,omitempty"`
+
+	// ReminderUpdateIntervalHours is the minimum time between rewrites of the
+	// token-refresh reminder event when its date has not changed (default: 24).
+	ReminderUpdateIntervalHours int `json:"reminder_update_interval_hours,omitempty"`
+
+	// ResumeJournalPath enables an on-disk journal of the events written during
+	// a sync, so an interrupted run restarted within ResumeWindowMinutes
+	// (default: 60) skips the events it already wrote.
+	ResumeJournalPath   string `json:"resume_journal_path,omitempty"`
+	ResumeWindowMinutes int    `json:"resume_window_minutes,omitempty"`
+
+	// SyncStatePath enables incremental listing of the work calendars: the
+	// Google sync token of each calendar and the events listed so far are kept
+	// there, so later runs only fetch the events changed since. Unset by default
+	// (every run lists all events).
+	SyncStatePath string `json:"sync_state_path,omitempty"`
+
+	// DestinationStateDir enables a state file per destination in this
+	// directory, named after the destination, recording its last sync: when
+	// it was, its counts, the work calendars' sync tokens and the IDs its
+	// calendars resolved to. Unset by default (no state is kept).
+	DestinationStateDir string `json:"destination_state_dir,omitempty"`
+
+	// FullResync is set by --full-resync and ignores the stored sync tokens,
+	// listing all work events again.
+	FullResync bool `json:"-"`
+
+	// MaxRetries is how many times a Google or CalDAV request that failed with a
+	// transient error (429, 5xx or a network error) is retried (default: 3).
+	// RetryBaseDelaySeconds is the backoff before the first retry, doubled for
+	// each retry after it (default: 1).
+	MaxRetries            int `json:"max_retries,omitempty"`
+	RetryBaseDelaySeconds int `json:"retry_base_delay_seconds,omitempty"`
+
+	// OAuthTimeoutMinutes is how long the interactive OAuth flow waits for the
+	// user to authorize access (default: 5).
+	OAuthTimeoutMinutes int `json:"oauth_timeout_minutes,omitempty"`
+
+	// AcknowledgementsPath is where the destinations whose destructive syncs have
+	// been acknowledged are recorded. Until a destination is acknowledged, its
+	// sync deletes nothing (default: acknowledgements.json next to the work token).
+	AcknowledgementsPath string `json:"acknowledgements_path,omitempty"`
+
+	// AcknowledgeDestructive is set by --i-understand-destructive and acknowledges
+	// that a destination's first sync deletes events missing from the work calendar.
+	AcknowledgeDestructive bool `json:"-"`
+
+	// Profiles holds named sets of settings that override the top-level values
+	// when selected with --profile. Each profile takes the same keys as the top
+	// level; keys left out of a profile inherit the top-level value.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
 }
 
-// LoadConfigFromFile loads configuration from a JSON file.
+// LoadConfigFromFile loads configuration from a JSON file, or a YAML one if
+// its name ends in .yaml or .yml. $VAR and ${VAR} in string values are
+// replaced by the environment variable's value, and $$ by a literal $.
 func LoadConfigFromFile(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	data, err := readConfigFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	if err := decodeConfig(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if config.DestinationsFile != "" {
+		destinations, err := loadDestinationsFile(filepath.Dir(path), config.DestinationsFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Destinations = append(config.Destinations, destinations...)
+	}
+
 	return &config, nil
 }
 
+// checkConfigVersion checks a config file's config_version is one this
+// release can read, warning about a supported but outdated one. Files without
+// a version predate the setting and are read as the current version.
+func checkConfigVersion(version int) error {
+	switch {
+	case version == 0 || version == CurrentConfigVersion:
+		return nil
+	case version > CurrentConfigVersion:
+		return fmt.Errorf("config_version %d is newer than this calsync supports (%d); upgrade calsync", version, CurrentConfigVersion)
+	case version < MinConfigVersion:
+		return fmt.Errorf("config_version %d is no longer supported (oldest supported: %d); update the config file as described under config_version in the README, then set config_version to %d", version, MinConfigVersion, CurrentConfigVersion)
+	default:
+		log.Printf("Warning: config_version %d is outdated (current: %d); review the config file against the README's config_version notes, then set config_version to %d", version, CurrentConfigVersion, CurrentConfigVersion)
+		return nil
+	}
+}
+
+// loadDestinationsFile loads an array of destinations from a JSON or YAML
+// file. A relative path is resolved against configDir, the config file's
+// directory.
+func loadDestinationsFile(configDir, path string) ([]Destination, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destinations file: %w", err)
+	}
+
+	var destinations []Destination
+	if err := decodeConfig(data, &destinations); err != nil {
+		return nil, fmt.Errorf("failed to parse destinations file: %w", err)
+	}
+
+	return destinations, nil
+}
+
+// decodeConfig parses JSON config data into v. With StrictKeys set, a key
+// that v has no field for is an error naming the key.
+func decodeConfig(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if StrictKeys {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		if StrictKeys && strings.HasPrefix(err.Error(), "json: unknown field") {
+			return fmt.Errorf("%w (check its spelling, or use --strict-config=false to ignore unknown keys)", err)
+		}
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// readConfigFile reads a JSON config file, converting it to JSON first if it
+// is a YAML file (.yaml or .yml). YAML files take the same keys as JSON ones,
+// and are parsed as JSON from there, so profiles and the JSON-specific
+// parsing of the config work alike for both. Environment variables in string
+// values are expanded (see expandEnv).
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var value any
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+		}
+		value, err = jsonValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+		}
+		return json.Marshal(expandEnvValues(value))
+	}
+	return expandEnv(data), nil
+}
+
+// expandEnv substitutes environment variables, written $VAR or ${VAR}, in the
+// string values of JSON config data, so secrets such as passwords can be kept
+// out of the file. $$ stands for a literal $, and unset variables are empty.
+// Data that isn't valid JSON is returned as is, for decodeConfig to report.
+func expandEnv(data []byte) []byte {
+	if !bytes.Contains(data, []byte("$")) {
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return data
+	}
+	expanded, err := json.Marshal(expandEnvValues(value))
+	if err != nil {
+		return data
+	}
+	return expanded
+}
+
+// expandEnvValues expands the environment variables in the strings of a
+// decoded JSON value, in place where it can.
+func expandEnvValues(value any) any {
+	switch v := value.(type) {
+	case string:
+		return os.Expand(v, func(name string) string {
+			if name == "$" {
+				return "$"
+			}
+			return os.Getenv(name)
+		})
+	case map[string]any:
+		for key, item := range v {
+			v[key] = expandEnvValues(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = expandEnvValues(item)
+		}
+	}
+	return value
+}
+
+// jsonValue turns a value decoded from YAML into one encoding/json can
+// marshal: mappings need string keys.
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys must be strings, got %v", key)
+			}
+			item, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			converted[name] = item
+		}
+		return converted, nil
+	case []any:
+		for i, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// Overrides are the settings given on the command line, which take precedence
+// over the environment and the config file. Unset fields override nothing.
+type Overrides struct {
+	Profile               string // Name of the profile in the config file to apply
+	WorkTokenPath         string
+	WorkEmail             string
+	GoogleCredentialsPath string
+	IncludeOOO            bool
+}
+
 // LoadConfig loads configuration with the following precedence (highest to lowest):
 // 1. Command-line flags
 // 2. Environment variables
-// 3. Config file
-// 4. Defaults
+// 3. Selected profile in the config file
+// 4. Config file
+// 5. Defaults
 // Returns an error if any required value is missing.
-func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCredentialsPathFlag string, includeOOOFlag bool) (*Config, error) {
+func LoadConfig(configFile string, overrides Overrides) (*Config, error) {
 	var config Config
 
 	// Step 1: Load from config file if provided
@@ -100,8 +659,19 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 			return nil, err
 		}
 		config = *fileConfig
+		if err := checkConfigVersion(config.ConfigVersion); err != nil {
+			return nil, err
+		}
 	}
 
+	// Apply the selected profile on top of the top-level settings
+	if overrides.Profile != "" {
+		if err := config.applyProfile(overrides.Profile, filepath.Dir(configFile)); err != nil {
+			return nil, err
+		}
+	}
+	config.Profiles = nil
+
 	// Step 2: Override with environment variables
 	if workTokenPath := os.Getenv("WORK_TOKEN_PATH"); workTokenPath != "" {
 		config.WorkTokenPath = workTokenPath
@@ -138,21 +708,24 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 	}
 
 	// Step 3: Override with command-line flags (highest priority)
-	if workTokenPathFlag != "" {
-		config.WorkTokenPath = workTokenPathFlag
+	if overrides.WorkTokenPath != "" {
+		config.WorkTokenPath = overrides.WorkTokenPath
 	}
-	if workEmailFlag != "" {
-		config.WorkEmail = workEmailFlag
+	if overrides.WorkEmail != "" {
+		config.WorkEmail = overrides.WorkEmail
 	}
-	if googleCredentialsPathFlag != "" {
-		config.GoogleCredentialsPath = googleCredentialsPathFlag
+	if overrides.GoogleCredentialsPath != "" {
+		config.GoogleCredentialsPath = overrides.GoogleCredentialsPath
 	}
-	if includeOOOFlag {
-		config.IncludeOOO = includeOOOFlag
+	if overrides.IncludeOOO {
+		config.IncludeOOO = true
 	}
 
 	// Step 4: Apply defaults and validate required fields
-	if config.WorkTokenPath == "" {
+	if (config.ServiceAccountKeyPath == "") != (config.ImpersonateSubject == "") {
+		return nil, fmt.Errorf("service_account_key_path and impersonate_subject must be set together")
+	}
+	if config.WorkTokenPath == "" && config.ServiceAccountKeyPath == "" {
 		return nil, fmt.Errorf("work_token_path must be provided via --work-token-path flag, WORK_TOKEN_PATH environment variable, or config file")
 	}
 
@@ -165,7 +738,20 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 		return nil, fmt.Errorf("destinations array must be provided in config file. At least one destination is required")
 	}
 
+	// Default to full calendar access for both accounts
+	if len(config.WorkOAuthScopes) == 0 {
+		config.WorkOAuthScopes = DefaultGoogleOAuthScopes
+	}
+	if len(config.DestinationOAuthScopes) == 0 {
+		config.DestinationOAuthScopes = DefaultGoogleOAuthScopes
+	}
+	canWrite := slices.ContainsFunc(config.DestinationOAuthScopes, func(scope string) bool {
+		return slices.Contains(googleWriteScopes, scope)
+	})
+
 	// Validate and set defaults for each destination
+	names := make(map[string]bool)
+	reverseBusyBlock := ""
 	for i := range config.Destinations {
 		dest := &config.Destinations[i]
 
@@ -174,9 +760,16 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 			dest.Name = fmt.Sprintf("Destination %d", i+1)
 		}
 
+		// Names key per-destination state, so they must be unique across the
+		// inline destinations and those loaded from destinations_file
+		if names[dest.Name] {
+			return nil, fmt.Errorf("destination[%d]: duplicate destination name '%s'", i, dest.Name)
+		}
+		names[dest.Name] = true
+
 		// Validate destination type
-		if dest.Type != "google" && dest.Type != "apple" {
-			return nil, fmt.Errorf("destination[%d].type must be 'google' or 'apple', got '%s'", i, dest.Type)
+		if dest.Type != "google" && dest.Type != "apple" && dest.Type != "caldav" && dest.Type != "outlook" {
+			return nil, fmt.Errorf("destination[%d].type must be 'google', 'apple', 'caldav' or 'outlook', got '%s'", i, dest.Type)
 		}
 
 		// Validate and set defaults based on type
@@ -184,15 +777,64 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 			if dest.TokenPath == "" {
 				return nil, fmt.Errorf("destination[%d] (name: %s): token_path must be provided for Google Calendar destination", i, dest.Name)
 			}
-		} else if dest.Type == "apple" {
-			if dest.ServerURL == "" {
-				return nil, fmt.Errorf("destination[%d] (name: %s): server_url must be provided for Apple Calendar destination", i, dest.Name)
+			if !canWrite {
+				return nil, fmt.Errorf("destination[%d] (name: %s): destination_oauth_scopes must include a write scope (one of %v)", i, dest.Name, googleWriteScopes)
 			}
-			if dest.Username == "" {
-				return nil, fmt.Errorf("destination[%d] (name: %s): username must be provided for Apple Calendar destination", i, dest.Name)
+		} else if dest.Type == "outlook" {
+			if !config.ExpandsRecurring() {
+				return nil, fmt.Errorf("destination[%d] (name: %s): expand_recurring false is not supported for Outlook destinations", i, dest.Name)
 			}
-			if dest.Password == "" {
-				return nil, fmt.Errorf("destination[%d] (name: %s): password must be provided for Apple Calendar destination", i, dest.Name)
+			if dest.OutlookTokenPath == "" {
+				return nil, fmt.Errorf("destination[%d] (name: %s): outlook_token_path must be provided for Outlook destination", i, dest.Name)
+			}
+			if config.OutlookClientID == "" {
+				return nil, fmt.Errorf("destination[%d] (name: %s): outlook_client_id must be provided in the config file for Outlook destinations", i, dest.Name)
+			}
+		} else if dest.IsCalDAV() {
+			kind := "Apple Calendar"
+			if dest.Type == "caldav" {
+				kind = "CalDAV"
+			}
+			if dest.ServerURL == "" {
+				return nil, fmt.Errorf("destination[%d] (name: %s): server_url must be provided for %s destination", i, dest.Name, kind)
+			}
+			switch dest.AuthMode {
+			case "", "basic":
+				dest.AuthMode = "basic"
+				if dest.Username == "" {
+					return nil, fmt.Errorf("destination[%d] (name: %s): username must be provided for %s destination", i, dest.Name, kind)
+				}
+				switch dest.PasswordSource {
+				case "", "config":
+					dest.PasswordSource = "config"
+					if dest.Password == "" {
+						return nil, fmt.Errorf("destination[%d] (name: %s): password must be provided for %s destination", i, dest.Name, kind)
+					}
+				case "keychain":
+					if dest.Password != "" {
+						return nil, fmt.Errorf("destination[%d] (name: %s): password must not be set with password_source 'keychain'", i, dest.Name)
+					}
+					if dest.KeychainService == "" {
+						return nil, fmt.Errorf("destination[%d] (name: %s): keychain_service must be provided with password_source 'keychain'", i, dest.Name)
+					}
+					if dest.KeychainAccount == "" {
+						dest.KeychainAccount = dest.Username
+					}
+				default:
+					return nil, fmt.Errorf("destination[%d] (name: %s): password_source must be 'config' or 'keychain', got '%s'", i, dest.Name, dest.PasswordSource)
+				}
+			case "oauth":
+				if dest.Type == "caldav" {
+					return nil, fmt.Errorf("destination[%d] (name: %s): auth_mode 'oauth' is only supported for Apple Calendar destinations", i, dest.Name)
+				}
+				if dest.TokenPath == "" {
+					return nil, fmt.Errorf("destination[%d] (name: %s): token_path must be provided for Apple Calendar destination with auth_mode 'oauth'", i, dest.Name)
+				}
+				if !canWrite {
+					return nil, fmt.Errorf("destination[%d] (name: %s): destination_oauth_scopes must include a write scope (one of %v)", i, dest.Name, googleWriteScopes)
+				}
+			default:
+				return nil, fmt.Errorf("destination[%d] (name: %s): auth_mode must be 'basic' or 'oauth', got '%s'", i, dest.Name, dest.AuthMode)
 			}
 		}
 
@@ -203,6 +845,104 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 		if dest.CalendarColorID == "" {
 			dest.CalendarColorID = "7"
 		}
+
+		// Validate routes and default their colors to the destination's
+		for j := range dest.Routes {
+			route := &dest.Routes[j]
+			if route.CalendarName == "" {
+				return nil, fmt.Errorf("destination[%d] (name: %s): routes[%d].calendar_name must be provided", i, dest.Name, j)
+			}
+			if len(route.Events) == 0 {
+				return nil, fmt.Errorf("destination[%d] (name: %s): routes[%d].events must list at least one event class", i, dest.Name, j)
+			}
+			for _, class := range route.Events {
+				switch class {
+				case "all_day", "timed", "out_of_office":
+				default:
+					return nil, fmt.Errorf("destination[%d] (name: %s): routes[%d].events must contain only 'all_day', 'timed' or 'out_of_office', got '%s'", i, dest.Name, j, class)
+				}
+			}
+			if route.CalendarColorID == "" {
+				route.CalendarColorID = dest.CalendarColorID
+			}
+		}
+
+		// Validate and default the privacy mode
+		switch dest.PrivacyMode {
+		case "":
+			dest.PrivacyMode = "full"
+		case "full", "busy", "redact":
+		default:
+			return nil, fmt.Errorf("destination[%d] (name: %s): privacy_mode must be 'full', 'busy' or 'redact', got '%s'", i, dest.Name, dest.PrivacyMode)
+		}
+		if dest.PrivacyPlaceholder == "" {
+			dest.PrivacyPlaceholder = "Busy"
+		}
+
+		// Validate and default the transparency override
+		switch dest.ForceTransparency {
+		case "":
+			dest.ForceTransparency = "source"
+		case "source", "opaque", "transparent":
+		default:
+			return nil, fmt.Errorf("destination[%d] (name: %s): force_transparency must be 'source', 'opaque' or 'transparent', got '%s'", i, dest.Name, dest.ForceTransparency)
+		}
+
+		if dest.CalendarPath != "" {
+			if !dest.IsCalDAV() {
+				return nil, fmt.Errorf("destination[%d] (name: %s): calendar_path is only supported for Apple Calendar and CalDAV destinations", i, dest.Name)
+			}
+			calendarPath, err := normalizeCalendarPath(dest.CalendarPath)
+			if err != nil {
+				return nil, fmt.Errorf("destination[%d] (name: %s): %w", i, dest.Name, err)
+			}
+			dest.CalendarPath = calendarPath
+		}
+
+		switch dest.DescriptionFormat {
+		case "":
+			dest.DescriptionFormat = "source"
+		case "source":
+		case "text":
+			if !dest.IsCalDAV() {
+				return nil, fmt.Errorf("destination[%d] (name: %s): description_format 'text' is only supported for Apple Calendar and CalDAV destinations", i, dest.Name)
+			}
+		default:
+			return nil, fmt.Errorf("destination[%d] (name: %s): description_format must be 'source' or 'text', got '%s'", i, dest.Name, dest.DescriptionFormat)
+		}
+
+		if dest.SyncWindowWeeks != nil && *dest.SyncWindowWeeks <= 0 {
+			return nil, fmt.Errorf("destination[%d] (name: %s): sync_window_weeks must be positive, got %d", i, dest.Name, *dest.SyncWindowWeeks)
+		}
+		if dest.SyncWindowWeeksPast != nil && *dest.SyncWindowWeeksPast < 0 {
+			return nil, fmt.Errorf("destination[%d] (name: %s): sync_window_weeks_past must not be negative, got %d", i, dest.Name, *dest.SyncWindowWeeksPast)
+		}
+
+		for _, field := range dest.RedactFields {
+			switch field {
+			case "description", "location", "conference":
+			default:
+				return nil, fmt.Errorf("destination[%d] (name: %s): redact_fields must contain only 'description', 'location' or 'conference', got '%s'", i, dest.Name, field)
+			}
+		}
+
+		// Busy blocks are matched by the ID of the personal event alone, so
+		// they can only mirror a single calendar
+		if dest.ReverseBusyBlock {
+			if reverseBusyBlock != "" {
+				return nil, fmt.Errorf("destination[%d] (name: %s): reverse_busy_block is already set for destination '%s'", i, dest.Name, reverseBusyBlock)
+			}
+			reverseBusyBlock = dest.Name
+			if len(dest.Routes) > 0 {
+				return nil, fmt.Errorf("destination[%d] (name: %s): reverse_busy_block is not supported with routes", i, dest.Name)
+			}
+			// calendar.app.created can't write to the work calendar itself
+			if !slices.ContainsFunc(config.WorkOAuthScopes, func(scope string) bool {
+				return slices.Contains(DefaultGoogleOAuthScopes, scope)
+			}) {
+				return nil, fmt.Errorf("destination[%d] (name: %s): reverse_busy_block needs work_oauth_scopes to include a write scope (one of %v)", i, dest.Name, DefaultGoogleOAuthScopes)
+			}
+		}
 	}
 
 	// Default sync window to 2 weeks forward (current week + next week)
@@ -210,15 +950,254 @@ func LoadConfig(configFile string, workTokenPathFlag, workEmailFlag, googleCrede
 		config.SyncWindowWeeks = 2
 	}
 
+	if config.MaxSyncWindowWeeks == 0 {
+		config.MaxSyncWindowWeeks = 104
+	}
+	if config.MaxSyncWindowWeeks < 0 {
+		return nil, fmt.Errorf("max_sync_window_weeks must be positive, got %d", config.MaxSyncWindowWeeks)
+	}
+	if weeks := config.SyncWindowWeeks + config.SyncWindowWeeksPast; weeks > config.MaxSyncWindowWeeks {
+		return nil, fmt.Errorf("sync_window_weeks (%d) and sync_window_weeks_past (%d) add up to %d weeks, more than max_sync_window_weeks (%d); raise max_sync_window_weeks if this is intended", config.SyncWindowWeeks, config.SyncWindowWeeksPast, weeks, config.MaxSyncWindowWeeks)
+	}
+	for i, dest := range config.Destinations {
+		weeks, weeksPast := config.SyncWindowWeeks, config.SyncWindowWeeksPast
+		if dest.SyncWindowWeeks != nil {
+			weeks = *dest.SyncWindowWeeks
+		}
+		if dest.SyncWindowWeeksPast != nil {
+			weeksPast = *dest.SyncWindowWeeksPast
+		}
+		if weeks+weeksPast > config.MaxSyncWindowWeeks {
+			return nil, fmt.Errorf("destination[%d] (name: %s): sync window of %d weeks forward and %d back is more than max_sync_window_weeks (%d); raise max_sync_window_weeks if this is intended", i, dest.Name, weeks, weeksPast, config.MaxSyncWindowWeeks)
+		}
+	}
+
+	// Default to refreshing an unchanged token reminder at most once a day
+	if config.ReminderUpdateIntervalHours == 0 {
+		config.ReminderUpdateIntervalHours = 24
+	}
+	if config.ReminderUpdateIntervalHours < 0 {
+		return nil, fmt.Errorf("reminder_update_interval_hours must not be negative, got %d", config.ReminderUpdateIntervalHours)
+	}
+
+	if config.OnlyMyEvents && config.WorkEmail == "" {
+		return nil, fmt.Errorf("only_my_events requires work_email, to tell which events are yours")
+	}
+
+	for _, visibility := range config.SkipVisibilities {
+		switch visibility {
+		case "default", "public", "private", "confidential":
+		default:
+			return nil, fmt.Errorf("skip_visibilities must contain only 'default', 'public', 'private' or 'confidential', got '%s'", visibility)
+		}
+	}
+
+	// Default the resume window to an hour
+	if config.ResumeWindowMinutes == 0 {
+		config.ResumeWindowMinutes = 60
+	}
+	if config.ResumeWindowMinutes < 0 {
+		return nil, fmt.Errorf("resume_window_minutes must not be negative, got %d", config.ResumeWindowMinutes)
+	}
+
+	// Sync from the work account's main calendar unless told otherwise
+	if len(config.SourceCalendars) > 0 && config.SourceCalendarID != "" {
+		return nil, fmt.Errorf("source_calendar_id and source_calendars are mutually exclusive")
+	}
+	for i, id := range config.SourceCalendars {
+		if id == "" {
+			return nil, fmt.Errorf("source_calendars[%d] must not be empty", i)
+		}
+	}
+	if config.SourceCalendarID == "" && len(config.SourceCalendars) == 0 {
+		config.SourceCalendarID = "primary"
+	}
+
+	// Default to three retries, starting a second apart
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("max_retries must not be negative, got %d", config.MaxRetries)
+	}
+	if config.RetryBaseDelaySeconds == 0 {
+		config.RetryBaseDelaySeconds = 1
+	}
+	if config.RetryBaseDelaySeconds < 0 {
+		return nil, fmt.Errorf("retry_base_delay_seconds must not be negative, got %d", config.RetryBaseDelaySeconds)
+	}
+
+	// Default the OAuth timeout to five minutes
+	if config.OAuthTimeoutMinutes == 0 {
+		config.OAuthTimeoutMinutes = 5
+	}
+	if config.OAuthTimeoutMinutes < 0 {
+		return nil, fmt.Errorf("oauth_timeout_minutes must not be negative, got %d", config.OAuthTimeoutMinutes)
+	}
+
+	// Default to recording acknowledgements alongside the work token, or the
+	// service account key that replaces it
+	if config.AcknowledgementsPath == "" {
+		workCredentials := config.WorkTokenPath
+		if config.ServiceAccountKeyPath != "" {
+			workCredentials = config.ServiceAccountKeyPath
+		}
+		config.AcknowledgementsPath = filepath.Join(filepath.Dir(workCredentials), "acknowledgements.json")
+	}
+
+	if start, end := config.DayWindow(); start < 0 || end > 1440 || start >= end {
+		return nil, fmt.Errorf("day_window_start_minutes and day_window_end_minutes must satisfy 0 <= start < end <= 1440, got %d and %d", start, end)
+	}
+
+	switch config.TokenStore {
+	case "":
+		config.TokenStore = "file"
+	case "file", "encrypted", "keyring":
+	default:
+		return nil, fmt.Errorf("token_store must be 'file', 'encrypted' or 'keyring', got '%s'", config.TokenStore)
+	}
+
+	switch config.DuplicateCalendars {
+	case "":
+		config.DuplicateCalendars = "use-first"
+	case "error", "use-first", "use-newest", "merge":
+	default:
+		return nil, fmt.Errorf("duplicate_calendars must be 'error', 'use-first', 'use-newest' or 'merge', got '%s'", config.DuplicateCalendars)
+	}
+
+	if strings.ContainsAny(config.ICalProductID, "\r\n") {
+		return nil, fmt.Errorf("ical_product_id must be a single line")
+	}
+
+	for colorID, category := range config.ColorCategories {
+		if n, err := strconv.Atoi(colorID); err != nil || n < 1 || n > 11 {
+			return nil, fmt.Errorf("color_categories keys must be event color IDs from '1' to '11', got '%s'", colorID)
+		}
+		if category == "" || strings.ContainsAny(category, ",\r\n") {
+			return nil, fmt.Errorf("color_categories['%s'] must be a single category, without commas", colorID)
+		}
+	}
+
+	for _, key := range config.PreserveTag {
+		if key == "" || key == "workEventId" {
+			return nil, fmt.Errorf("preserve_tag must contain only non-empty keys other than 'workEventId', got '%s'", key)
+		}
+	}
+
+	switch {
+	case config.HomeTimeZone == "":
+		config.HomeTimeZone = config.Timezone
+	case config.Timezone != "" && config.Timezone != config.HomeTimeZone:
+		return nil, fmt.Errorf("timezone and home_time_zone name the same setting and must not differ, got '%s' and '%s'", config.Timezone, config.HomeTimeZone)
+	}
+	if config.HomeTimeZone != "" {
+		if _, err := time.LoadLocation(config.HomeTimeZone); err != nil {
+			return nil, fmt.Errorf("home_time_zone must be an IANA time zone such as 'Europe/Berlin', got '%s': %w", config.HomeTimeZone, err)
+		}
+	}
+
+	if config.FilterExpression != "" {
+		if _, err := CompileFilterExpression(config.FilterExpression); err != nil {
+			return nil, fmt.Errorf("filter_expression is invalid: %w", err)
+		}
+	}
+
+	if config.MaxAllDaySpanDays < 0 {
+		return nil, fmt.Errorf("max_all_day_span_days must not be negative, got %d", config.MaxAllDaySpanDays)
+	}
+
 	// Default sync window past to 0 weeks (no past events)
 	// No need to set default as 0 is already the zero value
 
 	return &config, nil
 }
 
+// applyProfile overlays the named profile on the top-level settings. Only the
+// keys present in the profile override, so a profile can also turn a boolean
+// off or empty a list. Lists such as destinations are replaced, not merged.
+// A destinations_file in the profile is resolved relative to configDir and
+// added to the destinations in effect after the overlay.
+func (c *Config) applyProfile(name, configDir string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("profile '%s' not found in config file (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal(profile, &overrides); err != nil {
+		return fmt.Errorf("failed to parse profile '%s': %w", name, err)
+	}
+
+	// Overlay at the level of top-level keys, going through JSON so that
+	// nested values from the profile replace the top-level ones wholesale
+	base := *c
+	base.Profiles = nil
+	data, err := json.Marshal(base)
+	if err != nil {
+		return fmt.Errorf("failed to apply profile '%s': %w", name, err)
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return fmt.Errorf("failed to apply profile '%s': %w", name, err)
+	}
+	maps.Copy(merged, overrides)
+	delete(merged, "profiles")
+
+	if data, err = json.Marshal(merged); err != nil {
+		return fmt.Errorf("failed to apply profile '%s': %w", name, err)
+	}
+	var result Config
+	if err := decodeConfig(data, &result); err != nil {
+		return fmt.Errorf("failed to parse profile '%s': %w", name, err)
+	}
+	if _, ok := overrides["destinations_file"]; ok && result.DestinationsFile != "" {
+		destinations, err := loadDestinationsFile(configDir, result.DestinationsFile)
+		if err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+		result.Destinations = append(result.Destinations, destinations...)
+	}
+	*c = result
+	return nil
+}
+
+// FilterEnv holds the fields of a work event that a filter_expression can use.
+type FilterEnv struct {
+	Summary         string `expr:"summary"`
+	IsAllDay        bool   `expr:"isAllDay"`
+	DurationMinutes int    `expr:"durationMinutes"`
+	AttendeeCount   int    `expr:"attendeeCount"`
+	OrganizerDomain string `expr:"organizerDomain"` // Lower-cased, "" without an organizer
+}
+
+// CompileFilterExpression compiles a filter_expression, which must evaluate
+// to a boolean over the fields of FilterEnv.
+func CompileFilterExpression(expression string) (*vm.Program, error) {
+	return expr.Compile(expression, expr.Env(FilterEnv{}), expr.AsBool())
+}
+
 // parseInt parses a string to an integer.
 func parseInt(s string) (int, error) {
 	var result int
 	_, err := fmt.Sscanf(s, "%d", &result)
 	return result, err
 }
+
+// normalizeCalendarPath turns a calendar_path, given as a path or a URL, into
+// the collection path CalDAV clients use as the calendar's ID: the path with
+// a leading and a trailing slash.
+func normalizeCalendarPath(calendarPath string) (string, error) {
+	u, err := neturl.Parse(strings.TrimSpace(calendarPath))
+	if err != nil || u.Path == "" || u.Path == "/" || (!u.IsAbs() && !strings.HasPrefix(u.Path, "/")) {
+		return "", fmt.Errorf("calendar_path must be a calendar's path, such as '/123456/calendars/work/', or its URL, got '%s'", calendarPath)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		return u.Path + "/", nil
+	}
+	return u.Path, nil
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -30,7 +33,7 @@ func TestLoadConfig(t *testing.T) {
 	}
 
 	// Test loading from config file
-	config, err := LoadConfig(configPath, "", "", "")
+	config, err := LoadConfig(configPath, Overrides{})
 	if err != nil {
 		t.Fatalf("LoadConfig() returned an error: %v", err)
 	}
@@ -79,7 +82,7 @@ func TestLoadConfig_CommandLineFlags(t *testing.T) {
 	}
 
 	// Test that command-line flags override config file
-	config, err := LoadConfig(configPath, "/flag/work_token.json", "", "/flag/credentials.json")
+	config, err := LoadConfig(configPath, Overrides{WorkTokenPath: "/flag/work_token.json", GoogleCredentialsPath: "/flag/credentials.json"})
 	if err != nil {
 		t.Fatalf("LoadConfig() returned an error: %v", err)
 	}
@@ -115,7 +118,7 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	}
 
 	// Test that defaults are used when calendar name/color are not specified
-	config, err := LoadConfig(configPath, "", "", "")
+	config, err := LoadConfig(configPath, Overrides{})
 	if err != nil {
 		t.Fatalf("LoadConfig() returned an error: %v", err)
 	}
@@ -134,6 +137,345 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_DestinationSyncWindow(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"sync_window_weeks": 2,
+		"destinations": [
+			{
+				"name": "iCloud",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json"
+			},
+			{
+				"name": "Planning",
+				"type": "google",
+				"token_path": "/tmp/planning_token.json",
+				"sync_window_weeks": 1,
+				"sync_window_weeks_past": %s
+			}
+		]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "6")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if config.Destinations[0].SyncWindowWeeks != nil || config.Destinations[0].SyncWindowWeeksPast != nil {
+		t.Errorf("Expected no window override for iCloud, got %v and %v", config.Destinations[0].SyncWindowWeeks, config.Destinations[0].SyncWindowWeeksPast)
+	}
+	planning := config.Destinations[1]
+	if planning.SyncWindowWeeks == nil || *planning.SyncWindowWeeks != 1 || planning.SyncWindowWeeksPast == nil || *planning.SyncWindowWeeksPast != 6 {
+		t.Errorf("Expected a 1 week forward, 6 weeks past window for Planning, got %v and %v", planning.SyncWindowWeeks, planning.SyncWindowWeeksPast)
+	}
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "-1")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "sync_window_weeks_past") {
+		t.Errorf("Expected a sync_window_weeks_past error, got %v", err)
+	}
+}
+
+func TestLoadConfig_MaxSyncWindowWeeks(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"%s}]
+	}`
+
+	tests := []struct {
+		name        string
+		global      string
+		destination string
+		wantErr     string
+	}{
+		{"default window", "", "", ""},
+		{"two years", `"sync_window_weeks": 100, "sync_window_weeks_past": 4,`, "", ""},
+		{"a decade", `"sync_window_weeks": 520,`, "", "more than max_sync_window_weeks (104)"},
+		{"past weeks count", `"sync_window_weeks": 52, "sync_window_weeks_past": 60,`, "", "more than max_sync_window_weeks"},
+		{"destination override", "", `, "sync_window_weeks": 520`, "destination[0] (name: Personal): sync window of 520 weeks"},
+		{"raised cap", `"sync_window_weeks": 520, "max_sync_window_weeks": 600,`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.global, tt.destination)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			_, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("LoadConfig() returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UnknownKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"%s}],
+		"profiles": {"test": {%s}}
+	}`
+
+	tests := []struct {
+		name        string
+		global      string
+		destination string
+		profile     string
+		wantErr     string
+	}{
+		{"known keys", `"sync_window_weeks": 4,`, "", "", ""},
+		{"misspelled key", `"sync_window_week": 4,`, "", "", `unknown field "sync_window_week"`},
+		{"destination key", "", `, "calender_name": "Work"`, "", `unknown field "calender_name"`},
+		{"profile key", "", "", `"dry_rnu": true`, `unknown field "dry_rnu"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := fmt.Sprintf(configJSON, tt.global, tt.destination, tt.profile)
+			if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			_, err := LoadConfig(configPath, Overrides{Profile: "test"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("LoadConfig() returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+
+			// Without strict keys, the unknown key is ignored
+			StrictKeys = false
+			defer func() { StrictKeys = true }()
+			if _, err := LoadConfig(configPath, Overrides{Profile: "test"}); err != nil {
+				t.Errorf("LoadConfig() without strict keys returned an error: %v", err)
+			}
+		})
+	}
+}
+func TestLoadConfig_OAuthScopes(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"work_oauth_scopes": ["https://www.googleapis.com/auth/calendar.readonly"],
+		%s
+		"destinations": [
+			{
+				"name": "Personal",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json"
+			}
+		]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if len(config.WorkOAuthScopes) != 1 || config.WorkOAuthScopes[0] != "https://www.googleapis.com/auth/calendar.readonly" {
+		t.Errorf("Expected the configured work scopes, got %v", config.WorkOAuthScopes)
+	}
+	if len(config.DestinationOAuthScopes) != len(DefaultGoogleOAuthScopes) {
+		t.Errorf("Expected the default destination scopes, got %v", config.DestinationOAuthScopes)
+	}
+
+	// A Google destination can't be written with read-only scopes
+	readOnly := `"destination_oauth_scopes": ["https://www.googleapis.com/auth/calendar.readonly"],`
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, readOnly)), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "destination_oauth_scopes") {
+		t.Errorf("Expected a destination_oauth_scopes error, got %v", err)
+	}
+}
+
+func TestLoadConfig_TokenStore(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	tests := []struct {
+		setting string
+		want    string
+		wantErr bool
+	}{
+		{"", "file", false},
+		{`"token_store": "encrypted",`, "encrypted", false},
+		{`"token_store": "keyring",`, "keyring", false},
+		{`"token_store": "vault",`, "", true},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.setting)), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		config, err := LoadConfig(configPath, Overrides{})
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "token_store") {
+				t.Errorf("%s: expected a token_store error, got %v", tt.setting, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadConfig() returned an error: %v", tt.setting, err)
+		}
+		if config.TokenStore != tt.want {
+			t.Errorf("%s: expected token store %q, got %q", tt.setting, tt.want, config.TokenStore)
+		}
+	}
+}
+
+func TestLoadConfig_ServiceAccount(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	// No work_token_path: the service account replaces it
+	configJSON := `{
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	tests := []struct {
+		setting string
+		wantErr string
+	}{
+		{`"service_account_key_path": "/etc/calsync/key.json", "impersonate_subject": "user@example.com",`, ""},
+		{`"service_account_key_path": "/etc/calsync/key.json",`, "must be set together"},
+		{"", "work_token_path must be provided"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.setting)), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		config, err := LoadConfig(configPath, Overrides{})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected an error containing %q, got %v", tt.setting, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: LoadConfig() returned an error: %v", tt.setting, err)
+		}
+		if config.AcknowledgementsPath != "/etc/calsync/acknowledgements.json" {
+			t.Errorf("Expected acknowledgements alongside the service account key, got %s", config.AcknowledgementsPath)
+		}
+	}
+}
+
+func TestLoadConfig_OnlyMyEvents(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		%s
+		"only_my_events": true,
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, `"work_email": "user@example.com",`)), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if !config.OnlyMyEvents {
+		t.Error("Expected only_my_events to be set")
+	}
+
+	// Without work_email there is no telling which events are the user's
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "only_my_events requires work_email") {
+		t.Errorf("Expected an error requiring work_email, got %v", err)
+	}
+}
+
+func TestLoadConfig_ConfigVersion(t *testing.T) {
+	// Pretend the format has moved on twice, with version 2 still read
+	oldCurrent, oldMin := CurrentConfigVersion, MinConfigVersion
+	CurrentConfigVersion, MinConfigVersion = 3, 2
+	defer func() { CurrentConfigVersion, MinConfigVersion = oldCurrent, oldMin }()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		%s
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	tests := []struct {
+		setting string
+		wantErr string
+	}{
+		{"", ""},
+		{`"config_version": 3,`, ""},
+		{`"config_version": 2,`, ""},
+		{`"config_version": 1,`, "no longer supported"},
+		{`"config_version": 4,`, "newer than this calsync supports"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.setting)), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		_, err := LoadConfig(configPath, Overrides{})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: LoadConfig() returned an error: %v", tt.setting, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.setting, tt.wantErr, err)
+		}
+	}
+}
+
 func TestLoadConfig_ConfigFile(t *testing.T) {
 	// Create a temporary config file
 	tempDir := t.TempDir()
@@ -158,7 +500,7 @@ func TestLoadConfig_ConfigFile(t *testing.T) {
 	}
 
 	// Load config from file
-	config, err := LoadConfig(configPath, "", "", "")
+	config, err := LoadConfig(configPath, Overrides{})
 	if err != nil {
 		t.Fatalf("LoadConfig() returned an error: %v", err)
 	}
@@ -214,7 +556,7 @@ func TestLoadConfig_EnvVarsOverrideConfigFile(t *testing.T) {
 	t.Setenv("GOOGLE_CREDENTIALS_PATH", "/env/credentials.json")
 
 	// Load config - env var should override config file
-	config, err := LoadConfig(configPath, "", "", "")
+	config, err := LoadConfig(configPath, Overrides{})
 	if err != nil {
 		t.Fatalf("LoadConfig() returned an error: %v", err)
 	}
@@ -235,7 +577,7 @@ func TestLoadConfigMissing(t *testing.T) {
 	os.Clearenv()
 
 	// Try to load config without a config file (config file is required)
-	config, err := LoadConfig("", "", "", "")
+	config, err := LoadConfig("", Overrides{})
 	if err == nil {
 		t.Error("LoadConfig() should have returned an error when config file is missing")
 	}
@@ -259,7 +601,7 @@ func TestLoadConfigMissingDestinations(t *testing.T) {
 	}
 
 	// Try to load config without destinations array
-	config, err := LoadConfig(configPath, "", "", "")
+	config, err := LoadConfig(configPath, Overrides{})
 	if err == nil {
 		t.Error("LoadConfig() should have returned an error when destinations array is missing")
 	}
@@ -268,62 +610,957 @@ func TestLoadConfigMissingDestinations(t *testing.T) {
 	}
 }
 
-func TestLoadGoogleCredentials_Installed(t *testing.T) {
-	// Create a temporary credentials file with "installed" format
+func TestLoadConfig_Profiles(t *testing.T) {
 	tempDir := t.TempDir()
-	credsPath := filepath.Join(tempDir, "credentials.json")
+	configPath := filepath.Join(tempDir, "config.json")
 
-	credsJSON := `{
-		"installed": {
-			"client_id": "test-client-id",
-			"client_secret": "test-client-secret"
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [
+			{
+				"name": "Default",
+				"type": "google",
+				"token_path": "/tmp/default_token.json"
+			}
+		],
+		"profiles": {
+			"personal": {
+				"destinations": [
+					{
+						"name": "Personal",
+						"type": "google",
+						"token_path": "/tmp/personal_token.json"
+					}
+				]
+			},
+			"side-gig": {
+				"work_token_path": "/tmp/sidegig_work_token.json",
+				"sync_window_weeks": 4,
+				"destinations": [
+					{
+						"name": "Side Gig",
+						"type": "apple",
+						"server_url": "https://caldav.icloud.com",
+						"username": "me@icloud.com",
+						"password": "secret"
+					}
+				]
+			}
 		}
 	}`
 
-	if err := os.WriteFile(credsPath, []byte(credsJSON), 0644); err != nil {
-		t.Fatalf("Failed to write credentials file: %v", err)
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
 	}
 
-	clientID, clientSecret, err := LoadGoogleCredentials(credsPath)
+	personal, err := LoadConfig(configPath, Overrides{Profile: "personal"})
 	if err != nil {
-		t.Fatalf("LoadGoogleCredentials() returned an error: %v", err)
+		t.Fatalf("LoadConfig() with profile 'personal' returned an error: %v", err)
+	}
+	sideGig, err := LoadConfig(configPath, Overrides{Profile: "side-gig"})
+	if err != nil {
+		t.Fatalf("LoadConfig() with profile 'side-gig' returned an error: %v", err)
 	}
 
-	if clientID != "test-client-id" {
-		t.Errorf("Expected clientID to be 'test-client-id', got '%s'", clientID)
+	if len(personal.Destinations) != 1 || personal.Destinations[0].Name != "Personal" {
+		t.Errorf("Expected 'personal' profile to resolve to the 'Personal' destination, got %+v", personal.Destinations)
+	}
+	if len(sideGig.Destinations) != 1 || sideGig.Destinations[0].Name != "Side Gig" {
+		t.Errorf("Expected 'side-gig' profile to resolve to the 'Side Gig' destination, got %+v", sideGig.Destinations)
 	}
 
-	if clientSecret != "test-client-secret" {
-		t.Errorf("Expected clientSecret to be 'test-client-secret', got '%s'", clientSecret)
+	// Unset profile fields inherit the top-level values
+	if personal.WorkTokenPath != "/tmp/work_token.json" {
+		t.Errorf("Expected 'personal' profile to inherit WorkTokenPath, got '%s'", personal.WorkTokenPath)
+	}
+	if personal.SyncWindowWeeks != 2 {
+		t.Errorf("Expected 'personal' profile to use the default SyncWindowWeeks of 2, got %d", personal.SyncWindowWeeks)
+	}
+	if sideGig.WorkTokenPath != "/tmp/sidegig_work_token.json" {
+		t.Errorf("Expected 'side-gig' profile to override WorkTokenPath, got '%s'", sideGig.WorkTokenPath)
+	}
+	if sideGig.SyncWindowWeeks != 4 {
+		t.Errorf("Expected 'side-gig' profile SyncWindowWeeks to be 4, got %d", sideGig.SyncWindowWeeks)
+	}
+
+	// Without a profile the top-level destinations are used
+	defaults, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() without a profile returned an error: %v", err)
+	}
+	if len(defaults.Destinations) != 1 || defaults.Destinations[0].Name != "Default" {
+		t.Errorf("Expected top-level 'Default' destination without a profile, got %+v", defaults.Destinations)
+	}
+
+	// Flags still take precedence over the profile
+	flagged, err := LoadConfig(configPath, Overrides{Profile: "side-gig", WorkTokenPath: "/flag/work_token.json"})
+	if err != nil {
+		t.Fatalf("LoadConfig() with profile and flag returned an error: %v", err)
+	}
+	if flagged.WorkTokenPath != "/flag/work_token.json" {
+		t.Errorf("Expected flag to override profile WorkTokenPath, got '%s'", flagged.WorkTokenPath)
 	}
 }
 
-func TestLoadGoogleCredentials_Web(t *testing.T) {
-	// Create a temporary credentials file with "web" format
+func TestLoadConfig_YAML(t *testing.T) {
 	tempDir := t.TempDir()
-	credsPath := filepath.Join(tempDir, "credentials.json")
 
-	credsJSON := `{
-		"web": {
-			"client_id": "web-client-id",
-			"client_secret": "web-client-secret"
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"include_ooo": true,
+		"exclude_summary_keywords": ["Lunch", "Focus time"],
+		"destinations": [
+			{
+				"name": "Test",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json",
+				"calendar_name": "Work Sync",
+				"calendar_color_id": "7",
+				"sync_window_weeks": 3
+			}
+		],
+		"profiles": {
+			"side-gig": {
+				"sync_window_weeks": 4,
+				"destinations": [
+					{
+						"name": "Side Gig",
+						"type": "apple",
+						"server_url": "https://caldav.icloud.com",
+						"username": "me@icloud.com",
+						"password": "secret"
+					}
+				]
+			}
 		}
 	}`
+	configYAML := `
+work_token_path: /tmp/work_token.json
+google_credentials_path: /tmp/credentials.json
+include_ooo: true
+exclude_summary_keywords: [Lunch, Focus time]
+destinations:
+  - name: Test
+    type: google
+    token_path: /tmp/personal_token.json
+    calendar_name: Work Sync
+    calendar_color_id: "7"
+    sync_window_weeks: 3
+profiles:
+  side-gig:
+    sync_window_weeks: 4
+    destinations:
+      - name: Side Gig
+        type: apple
+        server_url: https://caldav.icloud.com
+        username: me@icloud.com
+        password: secret
+`
 
-	if err := os.WriteFile(credsPath, []byte(credsJSON), 0644); err != nil {
-		t.Fatalf("Failed to write credentials file: %v", err)
+	jsonPath := filepath.Join(tempDir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	for _, name := range []string{"config.yaml", "config.yml"} {
+		yamlPath := filepath.Join(tempDir, name)
+		if err := os.WriteFile(yamlPath, []byte(configYAML), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		for _, profile := range []string{"", "side-gig"} {
+			want, err := LoadConfig(jsonPath, Overrides{Profile: profile})
+			if err != nil {
+				t.Fatalf("LoadConfig() of the JSON file returned an error: %v", err)
+			}
+			got, err := LoadConfig(yamlPath, Overrides{Profile: profile})
+			if err != nil {
+				t.Fatalf("LoadConfig() of %s returned an error: %v", name, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %s with profile %q to load like the JSON file:\nwant %+v\ngot  %+v", name, profile, want, got)
+			}
+		}
 	}
 
-	clientID, clientSecret, err := LoadGoogleCredentials(credsPath)
-	if err != nil {
-		t.Fatalf("LoadGoogleCredentials() returned an error: %v", err)
+	if err := os.WriteFile(filepath.Join(tempDir, "bad.yaml"), []byte("destinations: [\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
 	}
+	if _, err := LoadConfig(filepath.Join(tempDir, "bad.yaml"), Overrides{}); err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Errorf("Expected an invalid YAML error, got %v", err)
+	}
+}
 
-	if clientID != "web-client-id" {
-		t.Errorf("Expected clientID to be 'web-client-id', got '%s'", clientID)
+func TestLoadConfig_ExpandsEnvironmentVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("ICLOUD_APP_PASSWORD", "abcd-efgh-ijkl-mnop")
+	t.Setenv("CALSYNC_DIR", "/home/me/.calsync")
+
+	configJSON := `{
+		"work_token_path": "$CALSYNC_DIR/work_token.json",
+		"google_credentials_path": "${CALSYNC_DIR}/credentials.json",
+		"sync_window_weeks": 3,
+		"destinations": [
+			{
+				"name": "iCloud",
+				"type": "apple",
+				"server_url": "https://caldav.icloud.com",
+				"username": "me@icloud.com",
+				"password": "${ICLOUD_APP_PASSWORD}",
+				"calendar_name": "Work $$ Sync"
+			}
+		]
+	}`
+	configYAML := `
+work_token_path: $CALSYNC_DIR/work_token.json
+google_credentials_path: ${CALSYNC_DIR}/credentials.json
+sync_window_weeks: 3
+destinations:
+  - name: iCloud
+    type: apple
+    server_url: https://caldav.icloud.com
+    username: me@icloud.com
+    password: ${ICLOUD_APP_PASSWORD}
+    calendar_name: Work $$ Sync
+`
+
+	for name, data := range map[string]string{"config.json": configJSON, "config.yaml": configYAML} {
+		configPath := filepath.Join(tempDir, name)
+		if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		config, err := LoadConfig(configPath, Overrides{})
+		if err != nil {
+			t.Fatalf("LoadConfig() of %s returned an error: %v", name, err)
+		}
+		if config.Destinations[0].Password != "abcd-efgh-ijkl-mnop" {
+			t.Errorf("%s: expected the password from ICLOUD_APP_PASSWORD, got %q", name, config.Destinations[0].Password)
+		}
+		if config.WorkTokenPath != "/home/me/.calsync/work_token.json" || config.GoogleCredentialsPath != "/home/me/.calsync/credentials.json" {
+			t.Errorf("%s: expected paths under CALSYNC_DIR, got %q and %q", name, config.WorkTokenPath, config.GoogleCredentialsPath)
+		}
+		if config.Destinations[0].CalendarName != "Work $ Sync" {
+			t.Errorf("%s: expected $$ to stand for a literal $, got calendar name %q", name, config.Destinations[0].CalendarName)
+		}
+		if config.SyncWindowWeeks != 3 {
+			t.Errorf("%s: expected sync_window_weeks 3, got %d", name, config.SyncWindowWeeks)
+		}
 	}
+}
 
-	if clientSecret != "web-client-secret" {
-		t.Errorf("Expected clientSecret to be 'web-client-secret', got '%s'", clientSecret)
+func TestLoadConfig_ProfileOverridesOnlyPresentKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"include_ooo": true,
+		"dry_run": true,
+		"skip_visibilities": ["private"],
+		"destinations": [
+			{
+				"name": "Default",
+				"type": "google",
+				"token_path": "/tmp/default_token.json",
+				"calendar_name": "Top Calendar"
+			}
+		],
+		"profiles": {
+			"live": {
+				"dry_run": false,
+				"skip_visibilities": [],
+				"destinations": [
+					{
+						"name": "Live",
+						"type": "google",
+						"token_path": "/tmp/live_token.json"
+					}
+				]
+			}
+		}
+	}`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath, Overrides{Profile: "live"})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+
+	if config.DryRun {
+		t.Error("Expected the profile to turn dry_run off")
+	}
+	if !config.IncludeOOO {
+		t.Error("Expected include_ooo to be inherited from the top level")
+	}
+	if len(config.SkipVisibilities) != 0 {
+		t.Errorf("Expected the profile to empty skip_visibilities, got %v", config.SkipVisibilities)
+	}
+	if len(config.Destinations) != 1 || config.Destinations[0].Name != "Live" {
+		t.Fatalf("Expected the profile's destinations to replace the top-level ones, got %+v", config.Destinations)
+	}
+	// Profile destinations replace the top-level ones wholesale, not field by field
+	if config.Destinations[0].CalendarName != "Work Sync" {
+		t.Errorf("Expected the default calendar name rather than the top-level destination's, got '%s'", config.Destinations[0].CalendarName)
+	}
+}
+
+func TestLoadConfig_UnknownProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [
+			{
+				"name": "Test",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json"
+			}
+		],
+		"profiles": {
+			"personal": {}
+		}
+	}`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath, Overrides{Profile: "missing"})
+	if err == nil {
+		t.Error("LoadConfig() should have returned an error for an unknown profile")
+	}
+	if config != nil {
+		t.Error("LoadConfig() should have returned nil config when there's an error")
+	}
+}
+
+func TestLoadConfig_AppleOAuthAuthMode(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [
+			{
+				"name": "Google CalDAV",
+				"type": "apple",
+				"server_url": "https://apidata.googleusercontent.com/caldav/v2/",
+				"auth_mode": "oauth",
+				"token_path": "/tmp/caldav_token.json"
+			}
+		]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	// Username and password are not required in OAuth mode
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if config.Destinations[0].AuthMode != "oauth" {
+		t.Errorf("Expected AuthMode 'oauth', got '%s'", config.Destinations[0].AuthMode)
+	}
+}
+
+func TestLoadConfig_ApplePasswordSource(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [
+			{
+				"name": "iCloud",
+				"type": "apple",
+				"server_url": "https://caldav.icloud.com",
+				"username": "user@icloud.com",
+				"password_source": "keychain"%s
+			}
+		]
+	}`
+
+	tests := []struct {
+		name        string
+		extra       string
+		wantErr     string
+		wantAccount string
+	}{
+		{name: "missing service", wantErr: "keychain_service"},
+		{name: "account defaults to username", extra: `, "keychain_service": "calendar-sync"`, wantAccount: "user@icloud.com"},
+		{name: "explicit account", extra: `, "keychain_service": "calendar-sync", "keychain_account": "icloud"`, wantAccount: "icloud"},
+		{name: "password also set", extra: `, "keychain_service": "calendar-sync", "password": "secret"`, wantErr: "password must not be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.extra)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected a %s error, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if config.Destinations[0].KeychainAccount != tt.wantAccount {
+				t.Errorf("Expected KeychainAccount %q, got %q", tt.wantAccount, config.Destinations[0].KeychainAccount)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_HomeTimeZone(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"home_time_zone": %q,
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "Asia/Tokyo")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if loc := config.HomeLocation(); loc == nil || loc.String() != "Asia/Tokyo" {
+		t.Errorf("Expected HomeLocation Asia/Tokyo, got %v", loc)
+	}
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "Mars/Olympus_Mons")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "home_time_zone") {
+		t.Errorf("Expected a home_time_zone error, got %v", err)
+	}
+
+	// timezone is another name for home_time_zone
+	aliasJSON := strings.Replace(configJSON, `"home_time_zone": %q`, `"timezone": %q`, 1)
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(aliasJSON, "America/New_York")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err = LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if loc := config.HomeLocation(); loc == nil || loc.String() != "America/New_York" {
+		t.Errorf("Expected HomeLocation America/New_York from timezone, got %v", loc)
+	}
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(aliasJSON, "Mars/Olympus_Mons")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "Mars/Olympus_Mons") {
+		t.Errorf("Expected an invalid time zone error, got %v", err)
+	}
+
+	bothJSON := strings.Replace(configJSON, `"home_time_zone": %q`, `"home_time_zone": "Asia/Tokyo", "timezone": %q`, 1)
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(bothJSON, "Europe/Berlin")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "must not differ") {
+		t.Errorf("Expected an error for differing time zones, got %v", err)
+	}
+}
+
+func TestLoadConfig_FilterExpression(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"filter_expression": %q,
+		"destinations": [{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json"}]
+	}`
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "durationMinutes >= 15 && !isAllDay")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+
+	// Unknown fields and non-boolean results are rejected up front
+	for _, expression := range []string{"duration > 15", "attendeeCount + 1"} {
+		if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, expression)), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "filter_expression") {
+			t.Errorf("Expected a filter_expression error for %q, got %v", expression, err)
+		}
+	}
+}
+
+func TestLoadConfig_CalDAVDestination(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [{"name": "Fastmail", "type": "caldav", %s}]
+	}`
+
+	tests := []struct {
+		name    string
+		fields  string
+		wantErr string
+	}{
+		{"valid", `"server_url": "https://fastmail.com", "username": "me@fastmail.com", "password": "app-password"`, ""},
+		{"missing server_url", `"username": "me@fastmail.com", "password": "app-password"`, "server_url must be provided for CalDAV destination"},
+		{"missing password", `"server_url": "https://fastmail.com", "username": "me@fastmail.com"`, "password must be provided for CalDAV destination"},
+		{"oauth", `"server_url": "https://fastmail.com", "auth_mode": "oauth", "token_path": "/tmp/token.json"`, "auth_mode 'oauth'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.fields)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if dest := config.Destinations[0]; !dest.IsCalDAV() || dest.AuthMode != "basic" {
+				t.Errorf("Expected a CalDAV destination with basic auth, got type %q auth_mode %q", dest.Type, dest.AuthMode)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_DescriptionFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [%s]
+	}`
+
+	tests := []struct {
+		name        string
+		destination string
+		want        string
+		wantErr     string
+	}{
+		{"default", `{"name": "iCloud", "type": "apple", "server_url": "https://caldav.icloud.com", "username": "me", "password": "pw"}`, "source", ""},
+		{"text for apple", `{"name": "iCloud", "type": "apple", "server_url": "https://caldav.icloud.com", "username": "me", "password": "pw", "description_format": "text"}`, "text", ""},
+		{"text for google", `{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json", "description_format": "text"}`, "", "only supported for Apple Calendar and CalDAV"},
+		{"unknown", `{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json", "description_format": "markdown"}`, "", "description_format must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.destination)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if got := config.Destinations[0].DescriptionFormat; got != tt.want {
+				t.Errorf("Expected description_format %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_CalendarPath(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [%s]
+	}`
+
+	tests := []struct {
+		name        string
+		destination string
+		want        string
+		wantErr     string
+	}{
+		{"path", `{"name": "iCloud", "type": "apple", "server_url": "https://caldav.icloud.com", "username": "me", "password": "pw", "calendar_path": "/123456/calendars/work"}`, "/123456/calendars/work/", ""},
+		{"URL", `{"name": "Fastmail", "type": "caldav", "server_url": "https://fastmail.com", "username": "me", "password": "pw", "calendar_path": "https://caldav.fastmail.com/dav/calendars/user/me/work/"}`, "/dav/calendars/user/me/work/", ""},
+		{"relative", `{"name": "iCloud", "type": "apple", "server_url": "https://caldav.icloud.com", "username": "me", "password": "pw", "calendar_path": "calendars/work"}`, "", "calendar_path must be"},
+		{"google", `{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json", "calendar_path": "/calendars/work/"}`, "", "only supported for Apple Calendar and CalDAV"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.destination)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if got := config.Destinations[0].CalendarPath; got != tt.want {
+				t.Errorf("Expected calendar_path %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ReverseBusyBlock(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [
+			{"name": "Personal", "type": "google", "token_path": "/tmp/personal.json", "reverse_busy_block": true}%s
+		]
+	}`
+
+	tests := []struct {
+		name        string
+		extraConfig string
+		extraDest   string
+		wantErr     string
+	}{
+		{name: "valid"},
+		{name: "second destination", extraDest: `, {"name": "Family", "type": "google", "token_path": "/tmp/family.json", "reverse_busy_block": true}`, wantErr: "already set for destination 'Personal'"},
+		{name: "read-only work scope", extraConfig: `"work_oauth_scopes": ["https://www.googleapis.com/auth/calendar.readonly"],`, wantErr: "work_oauth_scopes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.extraConfig, tt.extraDest)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected a %s error, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if !config.Destinations[0].ReverseBusyBlock {
+				t.Error("Expected ReverseBusyBlock to be set")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_OutlookDestination(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [
+			{
+				"name": "Outlook",
+				"type": "outlook",
+				"outlook_token_path": "/tmp/outlook_token.json"
+			}
+		]
+	}`
+
+	// The Microsoft app client ID is required for Outlook destinations
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, "")), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "outlook_client_id") {
+		t.Errorf("Expected an outlook_client_id error, got %v", err)
+	}
+
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, `"outlook_client_id": "client-id",`)), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if config.Destinations[0].OutlookTokenPath != "/tmp/outlook_token.json" {
+		t.Errorf("Expected OutlookTokenPath '/tmp/outlook_token.json', got '%s'", config.Destinations[0].OutlookTokenPath)
+	}
+
+	// Graph always expands recurring events, so unexpanded series are refused
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, `"outlook_client_id": "client-id", "expand_recurring": false,`)), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "expand_recurring") {
+		t.Errorf("Expected an expand_recurring error, got %v", err)
+	}
+}
+
+func TestLoadConfig_DestinationsFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+	destinationsPath := filepath.Join(tempDir, "destinations.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations_file": "destinations.json",
+		"destinations": [
+			{
+				"name": "Personal Google",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json"
+			}
+		]
+	}`
+	destinationsJSON := `[
+		{
+			"name": "Family Google",
+			"type": "google",
+			"token_path": "/tmp/family_token.json"
+		}
+	]`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(destinationsPath, []byte(destinationsJSON), 0644); err != nil {
+		t.Fatalf("Failed to write destinations file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath, Overrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if len(config.Destinations) != 2 {
+		t.Fatalf("Expected 2 destinations, got %d", len(config.Destinations))
+	}
+	if config.Destinations[0].Name != "Personal Google" || config.Destinations[1].Name != "Family Google" {
+		t.Errorf("Expected inline destination followed by file destination, got '%s', '%s'",
+			config.Destinations[0].Name, config.Destinations[1].Name)
+	}
+	if config.Destinations[1].CalendarName != "Work Sync" {
+		t.Errorf("Expected defaults applied to file destination, got CalendarName '%s'", config.Destinations[1].CalendarName)
+	}
+
+	// A name used both inline and in the destinations file is rejected
+	destinationsJSON = `[{"name": "Personal Google", "type": "google", "token_path": "/tmp/other_token.json"}]`
+	if err := os.WriteFile(destinationsPath, []byte(destinationsJSON), 0644); err != nil {
+		t.Fatalf("Failed to write destinations file: %v", err)
+	}
+	if _, err := LoadConfig(configPath, Overrides{}); err == nil || !strings.Contains(err.Error(), "duplicate destination name") {
+		t.Errorf("Expected duplicate destination name error, got %v", err)
+	}
+}
+
+func TestLoadConfig_ProfileDestinationsFile(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+	if err := os.Mkdir(filepath.Join(tempDir, "profiles"), 0755); err != nil {
+		t.Fatalf("Failed to create profiles directory: %v", err)
+	}
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		"destinations": [
+			{
+				"name": "Default",
+				"type": "google",
+				"token_path": "/tmp/default_token.json"
+			}
+		],
+		"profiles": {
+			"family": {
+				"destinations": [],
+				"destinations_file": "profiles/family.json"
+			}
+		}
+	}`
+	destinationsJSON := `[
+		{
+			"name": "Family Google",
+			"type": "google",
+			"token_path": "/tmp/family_token.json"
+		}
+	]`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "profiles", "family.json"), []byte(destinationsJSON), 0644); err != nil {
+		t.Fatalf("Failed to write destinations file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath, Overrides{Profile: "family"})
+	if err != nil {
+		t.Fatalf("LoadConfig() returned an error: %v", err)
+	}
+	if len(config.Destinations) != 1 || config.Destinations[0].Name != "Family Google" {
+		t.Errorf("Expected the profile's destinations file to be loaded relative to the config file, got %+v", config.Destinations)
+	}
+}
+
+func TestLoadGoogleCredentials_Installed(t *testing.T) {
+	// Create a temporary credentials file with "installed" format
+	tempDir := t.TempDir()
+	credsPath := filepath.Join(tempDir, "credentials.json")
+
+	credsJSON := `{
+		"installed": {
+			"client_id": "test-client-id",
+			"client_secret": "test-client-secret"
+		}
+	}`
+
+	if err := os.WriteFile(credsPath, []byte(credsJSON), 0644); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+
+	clientID, clientSecret, err := LoadGoogleCredentials(credsPath)
+	if err != nil {
+		t.Fatalf("LoadGoogleCredentials() returned an error: %v", err)
+	}
+
+	if clientID != "test-client-id" {
+		t.Errorf("Expected clientID to be 'test-client-id', got '%s'", clientID)
+	}
+
+	if clientSecret != "test-client-secret" {
+		t.Errorf("Expected clientSecret to be 'test-client-secret', got '%s'", clientSecret)
+	}
+}
+
+func TestLoadGoogleCredentials_Web(t *testing.T) {
+	// Create a temporary credentials file with "web" format
+	tempDir := t.TempDir()
+	credsPath := filepath.Join(tempDir, "credentials.json")
+
+	credsJSON := `{
+		"web": {
+			"client_id": "web-client-id",
+			"client_secret": "web-client-secret"
+		}
+	}`
+
+	if err := os.WriteFile(credsPath, []byte(credsJSON), 0644); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+
+	clientID, clientSecret, err := LoadGoogleCredentials(credsPath)
+	if err != nil {
+		t.Fatalf("LoadGoogleCredentials() returned an error: %v", err)
+	}
+
+	if clientID != "web-client-id" {
+		t.Errorf("Expected clientID to be 'web-client-id', got '%s'", clientID)
+	}
+
+	if clientSecret != "web-client-secret" {
+		t.Errorf("Expected clientSecret to be 'web-client-secret', got '%s'", clientSecret)
+	}
+}
+
+func TestLoadConfig_RetrySettings(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	configJSON := `{
+		"work_token_path": "/tmp/work_token.json",
+		"google_credentials_path": "/tmp/credentials.json",
+		%s
+		"destinations": [
+			{
+				"name": "Personal",
+				"type": "google",
+				"token_path": "/tmp/personal_token.json"
+			}
+		]
+	}`
+
+	tests := []struct {
+		name           string
+		settings       string
+		wantRetries    int
+		wantDelay      int
+		wantErrContain string
+	}{
+		{name: "defaults", wantRetries: 3, wantDelay: 1},
+		{name: "configured", settings: `"max_retries": 5, "retry_base_delay_seconds": 2,`, wantRetries: 5, wantDelay: 2},
+		{name: "negative retries", settings: `"max_retries": -1,`, wantErrContain: "max_retries"},
+		{name: "negative delay", settings: `"retry_base_delay_seconds": -1,`, wantErrContain: "retry_base_delay_seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(configPath, []byte(fmt.Sprintf(configJSON, tt.settings)), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			config, err := LoadConfig(configPath, Overrides{})
+			if tt.wantErrContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+					t.Errorf("Expected a %s error, got %v", tt.wantErrContain, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() returned an error: %v", err)
+			}
+			if config.MaxRetries != tt.wantRetries || config.RetryBaseDelaySeconds != tt.wantDelay {
+				t.Errorf("Expected %d retries from %ds, got %d from %ds", tt.wantRetries, tt.wantDelay, config.MaxRetries, config.RetryBaseDelaySeconds)
+			}
+		})
+	}
+}
+
+func TestConfig_OverrideCalendarName(t *testing.T) {
+	config := &Config{Destinations: []Destination{
+		{Name: "Personal", CalendarName: "Work Sync"},
+		{Name: "iCloud", CalendarName: "Work"},
+		{Name: "Family", CalendarName: "Work Sync", Routes: []Route{{Events: []string{"all_day"}, CalendarName: "Work Sync - OOF"}}},
+	}}
+
+	config.OverrideCalendarName("Throwaway")
+	for _, dest := range config.Destinations {
+		if dest.CalendarName != "Throwaway" {
+			t.Errorf("Expected destination %s to sync to 'Throwaway', got '%s'", dest.Name, dest.CalendarName)
+		}
+		if len(dest.Routes) != 0 {
+			t.Errorf("Expected destination %s to route no events elsewhere, got %v", dest.Name, dest.Routes)
+		}
 	}
 }
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"time"
+)
+
+// errDeletesNotAcknowledged is returned for deletes withheld because nobody has
+// acknowledged that syncing to the destination deletes events.
+var errDeletesNotAcknowledged = errors.New("destructive sync not acknowledged for this destination")
+
+// acknowledgementsMu serializes updates of the acknowledgements file, which
+// destinations synced concurrently share.
+var acknowledgementsMu stdsync.Mutex
+
+// loadAcknowledgements reads the destinations whose destructive syncs have been
+// acknowledged, keyed by destination name. A missing file means none have.
+func loadAcknowledgements(path string) (map[string]time.Time, error) {
+	acknowledged := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return acknowledged, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read acknowledgements: %w", err)
+	}
+	if err := json.Unmarshal(data, &acknowledged); err != nil {
+		return nil, fmt.Errorf("failed to parse acknowledgements: %w", err)
+	}
+	return acknowledged, nil
+}
+
+// saveAcknowledgement records that destructive syncs to destination were
+// acknowledged at now. The file is rewritten atomically, so an interruption
+// can't lose the other destinations' acknowledgements.
+func saveAcknowledgement(path, destination string, now time.Time) error {
+	acknowledgementsMu.Lock()
+	defer acknowledgementsMu.Unlock()
+
+	acknowledged, err := loadAcknowledgements(path)
+	if err != nil {
+		return err
+	}
+	acknowledged[destination] = now
+
+	data, err := json.MarshalIndent(acknowledged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode acknowledgements: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write acknowledgements: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"fmt"
+	"time"
+
+	calclient "github.com/beekhof/calendar-sync/internal/calendar"
+)
+
+// APICalls counts the calls a sync made to a calendar backend, by kind.
+type APICalls struct {
+	List   int // Calendar and event listings, searches and free/busy queries
+	Get    int // Single event reads
+	Insert int
+	Update int
+	Delete int
+
+	Errors   int           // Calls of any kind that failed
+	Duration time.Duration // Total time spent in the calls
+}
+
+// Total returns the number of calls of every kind.
+func (c APICalls) Total() int {
+	return c.List + c.Get + c.Insert + c.Update + c.Delete
+}
+
+func (c APICalls) String() string {
+	return fmt.Sprintf("%d in %v (list %d, get %d, insert %d, update %d, delete %d, failed %d)",
+		c.Total(), c.Duration.Round(time.Millisecond), c.List, c.Get, c.Insert, c.Update, c.Delete, c.Errors)
+}
+
+// apiCallsFrom sums the calls recorded by an InstrumentedClient by kind.
+func apiCallsFrom(stats map[string]calclient.CallStats) APICalls {
+	var calls APICalls
+	for method, stat := range stats {
+		switch method {
+		case "GetEvent":
+			calls.Get += stat.Calls
+		case "InsertEvent":
+			calls.Insert += stat.Calls
+		case "UpdateEvent":
+			calls.Update += stat.Calls
+		case "DeleteEvent":
+			calls.Delete += stat.Calls
+		default:
+			calls.List += stat.Calls
+		}
+		calls.Errors += stat.Errors
+		calls.Duration += stat.Duration
+	}
+	return calls
+}
+
+// instrument wraps client to record its calls. A nil client stays nil, with
+// nothing recorded.
+func instrument(client calclient.CalendarClient) (calclient.CalendarClient, *calclient.InstrumentedClient) {
+	if client == nil {
+		return nil, nil
+	}
+	instrumented := calclient.NewInstrumentedClient(client)
+	return instrumented, instrumented
+}
@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// destEventIDKey is the private extended property tagging a busy block on the
+// work calendar with the ID of the personal event it blocks.
+const destEventIDKey = "destEventId"
+
+// busyBlockSummary is the title of the busy blocks on the work calendar.
+const busyBlockSummary = "Busy (personal)"
+
+// getDestEventID returns the personal event a work event blocks time for, or
+// "" if it isn't a busy block.
+func getDestEventID(event *calendar.Event) string {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private == nil {
+		return ""
+	}
+	return event.ExtendedProperties.Private[destEventIDKey]
+}
+
+// blocksTime reports whether a personal event should be blocked on the work
+// calendar: timed, busy and not cancelled, starting within the window.
+// All-day events, like birthdays or trips, are left out.
+func blocksTime(event *calendar.Event, timeMin, timeMax time.Time) bool {
+	if event.Status == "cancelled" || event.Transparency == "transparent" {
+		return false
+	}
+	if !hasEventTimes(event) || event.Start.Date != "" {
+		return false
+	}
+	start, ok := eventStartTime(event, timeMin.Location())
+	return ok && !start.Before(timeMin) && start.Before(timeMax)
+}
+
+// busyBlockEvent returns the work calendar event blocking a personal event's time.
+// It is private and has no reminders, so it only shows the time as taken.
+func busyBlockEvent(personal *calendar.Event) *calendar.Event {
+	start, end := *personal.Start, *personal.End
+	return &calendar.Event{
+		Summary:      busyBlockSummary,
+		Start:        &start,
+		End:          &end,
+		Recurrence:   personal.Recurrence,
+		Transparency: "opaque",
+		Visibility:   "private",
+		Reminders: &calendar.EventReminders{
+			UseDefault:      false,
+			ForceSendFields: []string{"UseDefault"},
+		},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{destEventIDKey: personal.Id},
+		},
+	}
+}
+
+// busyBlockEqual reports whether a busy block still covers the time of the
+// block it should be.
+func busyBlockEqual(existing, desired *calendar.Event) bool {
+	if equal, _ := timesEqual(existing.Start, desired.Start, "start", nil); !equal {
+		return false
+	}
+	if equal, _ := timesEqual(existing.End, desired.End, "end", nil); !equal {
+		return false
+	}
+	return existing.Summary == desired.Summary &&
+		slices.Equal(sortedRecurrence(existing), sortedRecurrence(desired))
+}
+
+// syncBusyBlocks brings the busy blocks on the work calendar in line with the
+// personal events in the destination, inserting a block for each new personal
+// event, moving those whose event moved, and deleting those whose event is
+// gone. Blocks are matched to personal events by destEventId. The changes are
+// counted in result; safe mode and dry runs apply as for the destination.
+func (s *Syncer) syncBusyBlocks(ctx context.Context, personalEvents []*calendar.Event, timeMin, timeMax time.Time, result *SyncResult) error {
+	workCalendarID := s.sourceCalendarIDs()[0]
+	workEvents, err := s.listEvents(s.workClient, workCalendarID, timeMin, timeMax)
+	if err != nil {
+		return err
+	}
+
+	// Existing blocks by the personal event they block, ignoring those that
+	// started outside the window like the personal events are
+	blocksByDestID := make(map[string][]*calendar.Event)
+	for _, event := range workEvents {
+		if destID := getDestEventID(event); destID != "" && event.Status != "cancelled" {
+			if start, ok := eventStartTime(event, timeMin.Location()); ok && (start.Before(timeMin) || !start.Before(timeMax)) {
+				continue
+			}
+			blocksByDestID[destID] = append(blocksByDestID[destID], event)
+		}
+	}
+
+	desired := make(map[string]*calendar.Event)
+	for _, event := range personalEvents {
+		if blocksTime(event, timeMin, timeMax) {
+			desired[event.Id] = busyBlockEvent(event)
+		}
+	}
+
+	for _, destID := range slices.Sorted(maps.Keys(desired)) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		block := desired[destID]
+		existing := blocksByDestID[destID]
+		delete(blocksByDestID, destID)
+
+		// Keep the first block and drop any duplicates
+		for _, duplicate := range existing[min(1, len(existing)):] {
+			s.deleteBusyBlock(workCalendarID, duplicate, "duplicate", result)
+		}
+
+		switch {
+		case len(existing) == 0:
+			if !s.config.DryRun {
+				if err := s.workClient.InsertEvent(workCalendarID, block); err != nil {
+					log.Printf("Warning: failed to insert busy block for personal event %s: %v", destID, err)
+					result.Failed++
+					continue
+				}
+			}
+			s.logChange("Inserted busy block on the work calendar for personal event %s (%s - %s)", destID, block.Start.DateTime, block.End.DateTime)
+			result.BlocksInserted++
+		case !busyBlockEqual(existing[0], block):
+			if s.config.SafeMode {
+				s.logSkipped("Would update busy block %s for personal event %s", existing[0].Id, destID)
+				result.SafeModeSkipped++
+				continue
+			}
+			if !s.config.DryRun {
+				if err := s.workClient.UpdateEvent(workCalendarID, existing[0].Id, block); err != nil {
+					log.Printf("Warning: failed to update busy block %s for personal event %s: %v", existing[0].Id, destID, err)
+					result.Failed++
+					continue
+				}
+			}
+			s.logChange("Updated busy block %s for personal event %s (%s - %s)", existing[0].Id, destID, block.Start.DateTime, block.End.DateTime)
+			result.BlocksUpdated++
+		}
+	}
+
+	// Whatever is left blocks time for personal events that are gone
+	for _, destID := range slices.Sorted(maps.Keys(blocksByDestID)) {
+		for _, block := range blocksByDestID[destID] {
+			s.deleteBusyBlock(workCalendarID, block, "stale", result)
+		}
+	}
+	return nil
+}
+
+// deleteBusyBlock deletes a busy block from the work calendar, counting the
+// outcome in result.
+func (s *Syncer) deleteBusyBlock(workCalendarID string, block *calendar.Event, kind string, result *SyncResult) {
+	destID := getDestEventID(block)
+	if s.config.SafeMode {
+		s.logSkipped("Would delete %s busy block %s for personal event %s", kind, block.Id, destID)
+		result.SafeModeSkipped++
+		return
+	}
+	if !s.config.DryRun {
+		if err := s.workClient.DeleteEvent(workCalendarID, block.Id); err != nil {
+			log.Printf("Warning: failed to delete %s busy block %s for personal event %s: %v", kind, block.Id, destID, err)
+			result.Failed++
+			return
+		}
+	}
+	s.logChange("Deleted %s busy block %s for personal event %s", kind, block.Id, destID)
+	result.BlocksDeleted++
+}
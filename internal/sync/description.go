@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlTagPattern matches the tags Google Calendar writes in descriptions. A
+// description without any is plain text, even if it contains '<' or '&'.
+var htmlTagPattern = regexp.MustCompile(`(?i)</?(a|b|i|u|br|p|div|span|strong|em|ul|ol|li|h[1-6]|table|tr|td|html|body)(\s[^>]*)?/?>`)
+
+// whitespacePattern matches a run of whitespace.
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// blockTags are the tags that start and end lines of their own.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "ul": true, "ol": true, "table": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// htmlToText turns an HTML event description into readable plain text: line
+// breaks and paragraphs become newlines, list items lines starting with "- ",
+// and links their text followed by the URL in brackets. Descriptions without
+// HTML tags are returned unchanged.
+func htmlToText(description string) string {
+	if !htmlTagPattern.MatchString(description) {
+		return description
+	}
+
+	var text strings.Builder
+	// blockBreak starts a new line unless the text is at the start of one
+	blockBreak := func() {
+		if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+			text.WriteString("\n")
+		}
+	}
+
+	var href, linkText string
+	var inLink bool
+	skip := 0 // Depth of script and style elements
+	tokenizer := html.NewTokenizer(strings.NewReader(description))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return tidyText(text.String())
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			// Runs of whitespace show as one space, as in a browser
+			chunk := whitespacePattern.ReplaceAllString(string(tokenizer.Text()), " ")
+			text.WriteString(chunk)
+			if inLink {
+				linkText += chunk
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				skip++
+			case tag == "br":
+				text.WriteString("\n")
+			case tag == "li":
+				blockBreak()
+				text.WriteString("- ")
+			case tag == "a":
+				href, linkText, inLink = "", "", true
+				for {
+					key, val, more := tokenizer.TagAttr()
+					if string(key) == "href" {
+						href = string(val)
+					}
+					if !more {
+						break
+					}
+				}
+			case blockTags[tag]:
+				blockBreak()
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				skip = max(0, skip-1)
+			case tag == "a" && inLink:
+				inLink = false
+				// Keep the URL unless the link shows it already
+				label := strings.TrimSpace(linkText)
+				if href != "" && label != href && "mailto:"+label != href {
+					text.WriteString(" (" + href + ")")
+				}
+			case tag == "li" || blockTags[tag]:
+				blockBreak()
+			}
+		}
+	}
+}
+
+// tidyText trims the spaces around each line and keeps at most one blank
+// line in a row.
+func tidyText(text string) string {
+	lines := strings.Split(text, "\n")
+	tidy := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(tidy) == 0 || tidy[len(tidy)-1] == "") {
+			continue
+		}
+		tidy = append(tidy, line)
+	}
+	return strings.TrimSpace(strings.Join(tidy, "\n"))
+}
+
+// sourceLinkPrefix starts the line linking a synced event to its work event.
+const sourceLinkPrefix = "Work event: "
+
+// withSourceLink ends a description with a line linking to the work event,
+// replacing any such line it already ends with, so a description that came
+// from a synced copy doesn't collect links.
+func withSourceLink(description, link string) string {
+	description = stripSourceLink(description)
+	if description == "" {
+		return sourceLinkPrefix + link
+	}
+	return description + "\n\n" + sourceLinkPrefix + link
+}
+
+// stripSourceLink removes the work event link withSourceLink added, if any.
+func stripSourceLink(description string) string {
+	i := strings.LastIndex(description, sourceLinkPrefix)
+	if i < 0 || strings.Contains(description[i:], "\n") || (i > 0 && !strings.HasSuffix(description[:i], "\n\n")) {
+		return description
+	}
+	return strings.TrimSuffix(description[:i], "\n\n")
+}
@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	calclient "github.com/beekhof/calendar-sync/internal/calendar"
+)
+
+// destinationState is what is kept between runs of a destination, in a file
+// of its own under destination_state_dir: when it was last synced and what
+// that run changed, the sync tokens of the work calendars it was synced from,
+// and the IDs its calendars were resolved to.
+type destinationState struct {
+	LastSync   time.Time         `json:"last_sync"`
+	SyncTokens map[string]string `json:"sync_tokens,omitempty"` // By work calendar ID, when sync_state_path is set
+	Calendars  map[string]string `json:"calendars,omitempty"`   // Calendar IDs by calendar name
+	Inserted   int               `json:"inserted"`
+	Updated    int               `json:"updated"`
+	Deleted    int               `json:"deleted"`
+	Unchanged  int               `json:"unchanged"`
+	Failed     int               `json:"failed"`
+}
+
+// destinationStatePath returns the path of a destination's state file in dir.
+// The name is escaped, so any destination name makes a single file name.
+func destinationStatePath(dir, destination string) string {
+	return filepath.Join(dir, url.PathEscape(destination)+".json")
+}
+
+// loadDestinationState reads the state file at path. A missing file, as on a
+// destination's first run, has an empty state.
+func loadDestinationState(path string) (*destinationState, error) {
+	state := &destinationState{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read destination state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse destination state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveDestinationState writes the state file at path. The file is replaced
+// atomically, so an interrupted write leaves the previous state.
+func saveDestinationState(path string, state *destinationState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode destination state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write destination state: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write destination state: %w", err)
+	}
+	return nil
+}
+
+// openDestinationState loads the destination's state when a state directory
+// is configured. Problems reading it are logged and the sync starts from an
+// empty state.
+func (s *Syncer) openDestinationState() {
+	s.destState = nil
+	if s.config.DestinationStateDir == "" {
+		return
+	}
+	state, err := loadDestinationState(destinationStatePath(s.config.DestinationStateDir, s.destination.Name))
+	if err != nil {
+		log.Printf("[%s] Warning: ignoring destination state: %v", s.destination.Name, err)
+		state = &destinationState{}
+	}
+	s.destState = state
+}
+
+// recordCalendar notes the ID a calendar of the destination resolved to.
+func (s *Syncer) recordCalendar(calendarName, calendarID string) {
+	if s.destState == nil || calendarID == "" {
+		return
+	}
+	if s.destState.Calendars == nil {
+		s.destState.Calendars = make(map[string]string)
+	}
+	s.destState.Calendars[calendarName] = calendarID
+}
+
+// cachedCalendar returns the ID the destination's calendar resolved to on an
+// earlier run, if the personal client confirms it still exists, to save
+// finding the calendar by name. A calendar that's gone is forgotten and, like
+// any other failed check, leaves the calendar to be resolved afresh.
+func (s *Syncer) cachedCalendar() (string, bool) {
+	if s.destState == nil {
+		return "", false
+	}
+	id := s.destState.Calendars[s.destination.CalendarName]
+	if id == "" {
+		return "", false
+	}
+	checker, ok := calclient.As[calclient.CalendarChecker](s.personalClient)
+	if !ok {
+		return "", false
+	}
+	if err := checker.CalendarExists(id); err != nil {
+		if errors.Is(err, calclient.ErrCalendarNotFound) {
+			log.Printf("[%s] Calendar '%s' recorded in the destination state is gone; looking it up again", s.destination.Name, s.destination.CalendarName)
+			delete(s.destState.Calendars, s.destination.CalendarName)
+		} else {
+			log.Printf("[%s] Warning: failed to check calendar '%s' recorded in the destination state: %v", s.destination.Name, s.destination.CalendarName, err)
+		}
+		return "", false
+	}
+	s.debugLog("[%s] Using calendar %s recorded in the destination state", s.destination.Name, id)
+	return id, true
+}
+
+// writeDestinationState records a successful run in the destination's state
+// file. A dry run changes nothing, so it isn't recorded.
+func (s *Syncer) writeDestinationState(result *SyncResult) {
+	if s.destState == nil || s.config.DryRun {
+		return
+	}
+	state := s.destState
+	state.LastSync = s.currentTime()
+	state.Inserted, state.Updated, state.Deleted = result.Inserted, result.Updated, result.Deleted
+	state.Unchanged, state.Failed = result.Unchanged, result.Failed
+
+	state.SyncTokens = nil
+	if s.config.SyncStatePath != "" {
+		for _, calendarID := range s.sourceCalendarIDs() {
+			source, err := loadSourceState(s.config.SyncStatePath, calendarID)
+			if err != nil || source == nil {
+				continue
+			}
+			if state.SyncTokens == nil {
+				state.SyncTokens = make(map[string]string)
+			}
+			state.SyncTokens[calendarID] = source.SyncToken
+		}
+	}
+
+	if err := saveDestinationState(destinationStatePath(s.config.DestinationStateDir, s.destination.Name), state); err != nil {
+		log.Printf("[%s] Warning: failed to save destination state: %v", s.destination.Name, err)
+	}
+}
@@ -0,0 +1,186 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	stdsync "sync"
+	"time"
+)
+
+// journalEntry is the progress recorded for one destination.
+type journalEntry struct {
+	UpdatedAt time.Time
+	Done      []string // workEventIds successfully upserted this run
+}
+
+// journalRecord is one line of the journal file, recording a single workEventId
+// written for a destination.
+type journalRecord struct {
+	Destination string    `json:"destination"`
+	At          time.Time `json:"at"`
+	Done        string    `json:"done"`
+}
+
+// progressJournal records which workEventIds have been written during a sync run,
+// so that a run interrupted part-way can be resumed without redoing them.
+// One journal file holds the progress of every destination as JSON lines.
+// Each upsert appends a line; the file is only rewritten, dropping the
+// destination's lines, when its progress is cleared or has expired.
+// A nil *progressJournal is valid and records nothing.
+type progressJournal struct {
+	path        string
+	destination string
+	entries     map[string]*journalEntry
+}
+
+// journalMu serializes access to progress journals, which destinations synced
+// concurrently share.
+var journalMu stdsync.Mutex
+
+// loadProgressJournal opens the journal at path and returns the workEventIds
+// already done for destination. Progress older than window is discarded.
+func loadProgressJournal(path, destination string, now time.Time, window time.Duration) (*progressJournal, map[string]bool, error) {
+	journalMu.Lock()
+	entries, err := readJournalEntries(path)
+	journalMu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	journal := &progressJournal{
+		path:        path,
+		destination: destination,
+		entries:     entries,
+	}
+
+	done := make(map[string]bool)
+	entry := journal.entries[destination]
+	if entry == nil {
+		return journal, done, nil
+	}
+	if now.Sub(entry.UpdatedAt) > window {
+		// Nothing to resume, drop the stale record before this run appends to it
+		if err := journal.clear(); err != nil {
+			return nil, nil, err
+		}
+		return journal, done, nil
+	}
+
+	for _, workID := range entry.Done {
+		done[workID] = true
+	}
+	return journal, done, nil
+}
+
+// readJournalEntries reads the progress of every destination from the journal
+// at path. A missing journal has no progress.
+func readJournalEntries(path string) (map[string]*journalEntry, error) {
+	entries := make(map[string]*journalEntry)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read progress journal: %w", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if i == len(lines)-1 {
+				// The last append was cut short; everything before it is intact
+				break
+			}
+			return nil, fmt.Errorf("failed to parse progress journal: %w", err)
+		}
+		entry := entries[record.Destination]
+		if entry == nil {
+			entry = &journalEntry{}
+			entries[record.Destination] = entry
+		}
+		entry.Done = append(entry.Done, record.Done)
+		entry.UpdatedAt = record.At
+	}
+	return entries, nil
+}
+
+// markDone records workID as written by appending it to the journal.
+func (j *progressJournal) markDone(workID string, now time.Time) error {
+	if j == nil {
+		return nil
+	}
+	line, err := json.Marshal(journalRecord{Destination: j.destination, At: now, Done: workID})
+	if err != nil {
+		return fmt.Errorf("failed to encode progress journal: %w", err)
+	}
+
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write progress journal: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write progress journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write progress journal: %w", err)
+	}
+
+	entry := j.entries[j.destination]
+	if entry == nil {
+		entry = &journalEntry{}
+		j.entries[j.destination] = entry
+	}
+	entry.Done = append(entry.Done, workID)
+	entry.UpdatedAt = now
+	return nil
+}
+
+// clear drops the destination's progress once a run has completed, compacting
+// the journal to the other destinations' records.
+func (j *progressJournal) clear() error {
+	if j == nil {
+		return nil
+	}
+	if _, ok := j.entries[j.destination]; !ok {
+		return nil
+	}
+
+	// Other destinations may have appended since the journal was loaded
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	entries, err := readJournalEntries(j.path)
+	if err != nil {
+		return err
+	}
+	j.entries = entries
+	delete(j.entries, j.destination)
+	return j.save()
+}
+
+// save rewrites the journal atomically, so an interruption mid-write can't corrupt it.
+func (j *progressJournal) save() error {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	encoder := json.NewEncoder(w)
+	for destination, entry := range j.entries {
+		for _, workID := range entry.Done {
+			if err := encoder.Encode(journalRecord{Destination: destination, At: entry.UpdatedAt, Done: workID}); err != nil {
+				return fmt.Errorf("failed to encode progress journal: %w", err)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to encode progress journal: %w", err)
+	}
+
+	if err := writeFileAtomic(j.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write progress journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Preview is what a sync of a destination would do, worked out by a dry run.
+type Preview struct {
+	Destination string            `json:"destination"`
+	TimeMin     time.Time         `json:"time_min"`
+	TimeMax     time.Time         `json:"time_max"`
+	Events      []*calendar.Event `json:"events"`  // The work events in the window that pass the filters
+	Changes     []Change          `json:"changes"` // The inserts, updates and deletes the sync would make
+}
+
+// Preview works out what Sync would change in the destination, without
+// writing to it. The sync state is neither used nor stored, so the work
+// events are listed in full and the next sync is unaffected.
+func (s *Syncer) Preview(ctx context.Context) (*Preview, error) {
+	cfg := *s.config
+	cfg.DryRun = true
+	cfg.SyncStatePath = ""
+	cfg.DestinationStateDir = ""
+	previewer := *s
+	previewer.config = &cfg
+
+	// List the work events once, for the preview and the dry run
+	timeMin, timeMax := previewer.timeWindow(previewer.currentTime())
+	sourceEvents, err := previewer.getSourceEvents(timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	events, _ := previewer.filterEventsCounted(sourceEvents)
+	if previewer.destination.PrivacyMode != "busy" {
+		previewer.sourceEvents, previewer.sharedSource = sourceEvents, true
+	}
+
+	result, err := previewer.Sync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	preview := &Preview{
+		Destination: s.destination.Name,
+		TimeMin:     timeMin,
+		TimeMax:     timeMax,
+		Events:      events,
+		Changes:     result.Changes,
+	}
+	if preview.Events == nil {
+		preview.Events = []*calendar.Event{}
+	}
+	if preview.Changes == nil {
+		preview.Changes = []Change{}
+	}
+	return preview, nil
+}
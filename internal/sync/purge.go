@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	calclient "github.com/beekhof/calendar-sync/internal/calendar"
+)
+
+// Purge deletes every event in the destination calendar within the sync
+// window, synced or not, so the next sync starts from an empty calendar. It
+// returns the number of events in the window. A calendar that doesn't exist is
+// left alone, and a dry run only counts the events.
+func (s *Syncer) Purge(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	calendarID := s.destination.CalendarPath
+	if calendarID == "" {
+		id, err := s.personalClient.FindCalendarByName(s.destination.CalendarName)
+		if errors.Is(err, calclient.ErrCalendarNotFound) {
+			log.Printf("[%s] Calendar '%s' doesn't exist, nothing to purge", s.destination.Name, s.destination.CalendarName)
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to find destination calendar: %w", err)
+		}
+		calendarID = id
+	}
+
+	timeMin, timeMax := s.timeWindow(s.currentTime())
+	events, err := s.personalClient.GetEvents(calendarID, timeMin, timeMax)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list destination events: %w", err)
+	}
+	if s.config.DryRun {
+		s.logChange("[%s] Would delete %d events from '%s' between %s and %s", s.destination.Name, len(events),
+			s.destination.CalendarName, timeMin.Format("2006-01-02"), timeMax.Format("2006-01-02"))
+		return len(events), nil
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := s.personalClient.ClearRange(calendarID, timeMin, timeMax); err != nil {
+		return len(events), fmt.Errorf("failed to purge destination calendar: %w", err)
+	}
+	log.Printf("[%s] Deleted %d events from '%s' between %s and %s", s.destination.Name, len(events),
+		s.destination.CalendarName, timeMin.Format("2006-01-02"), timeMax.Format("2006-01-02"))
+	return len(events), nil
+}
@@ -0,0 +1,154 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// selfTestWorkID is the workEventId used to tag the self-test event. A copy
+// left behind by an interrupted self-test is removed by the next one, or as
+// stale by the next sync.
+const selfTestWorkID = "CALSYNC_SELF_TEST"
+
+// selfTestSummary is the title of the self-test event.
+const selfTestSummary = "calsync self-test (safe to delete)"
+
+// SelfTestStep is the outcome of one step of a self-test.
+type SelfTestStep struct {
+	Name    string
+	Err     error // Why the step failed, nil if it passed or was skipped
+	Skipped bool  // Not run, as an earlier step failed
+}
+
+// SelfTest checks that the destination calendar can be written to by
+// inserting a temporary event, reading it back, updating it and deleting it,
+// each a step of the returned report. Steps after a failed one are skipped,
+// and the event is deleted whatever happened. It writes even in a dry run.
+func (s *Syncer) SelfTest(ctx context.Context) []SelfTestStep {
+	var steps []SelfTestStep
+	failed := false
+	step := func(name string, run func() error) {
+		if failed || ctx.Err() != nil {
+			steps = append(steps, SelfTestStep{Name: name, Skipped: true})
+			return
+		}
+		err := run()
+		failed = err != nil
+		steps = append(steps, SelfTestStep{Name: name, Err: err})
+	}
+
+	var destCalendarID, eventID string
+	var inserted bool
+	step("resolve calendar", func() error {
+		id, err := s.resolveCalendar()
+		if err == nil && id == "" {
+			err = fmt.Errorf("calendar '%s' doesn't exist", s.destination.CalendarName)
+		}
+		destCalendarID = id
+		return err
+	})
+
+	// Remove what an interrupted self-test left behind, so "get" finds one event
+	step("clean up earlier self-tests", func() error {
+		return s.deleteSelfTestEvents(destCalendarID)
+	})
+
+	start := s.currentTime().Add(time.Hour).Truncate(time.Hour)
+	event := &calendar.Event{
+		Summary:      selfTestSummary,
+		Description:  "Created by calsync selftest to check that events can be written to this calendar. It is deleted when the test ends.",
+		Start:        &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:          &calendar.EventDateTime{DateTime: start.Add(15 * time.Minute).Format(time.RFC3339)},
+		Transparency: "transparent",
+		Reminders: &calendar.EventReminders{
+			UseDefault:      false,
+			ForceSendFields: []string{"UseDefault"},
+		},
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{"workEventId": selfTestWorkID},
+		},
+	}
+	step("insert", func() error {
+		err := s.personalClient.InsertEvent(destCalendarID, event)
+		inserted = err == nil
+		return err
+	})
+
+	step("get", func() error {
+		found, err := s.personalClient.FindEventsByWorkID(destCalendarID, selfTestWorkID)
+		if err != nil {
+			return err
+		}
+		if len(found) != 1 {
+			return fmt.Errorf("expected the inserted event, found %d", len(found))
+		}
+		eventID = found[0].Id
+		got, err := s.personalClient.GetEvent(destCalendarID, eventID)
+		if err != nil {
+			return err
+		}
+		if got.Summary != selfTestSummary {
+			return fmt.Errorf("expected summary %q, read back %q", selfTestSummary, got.Summary)
+		}
+		return nil
+	})
+
+	step("update", func() error {
+		updated := *event
+		updated.Id = eventID
+		updated.Summary = selfTestSummary + " - updated"
+		if err := s.personalClient.UpdateEvent(destCalendarID, eventID, &updated); err != nil {
+			return err
+		}
+		got, err := s.personalClient.GetEvent(destCalendarID, eventID)
+		if err != nil {
+			return err
+		}
+		if got.Summary != updated.Summary {
+			return fmt.Errorf("expected summary %q, read back %q", updated.Summary, got.Summary)
+		}
+		return nil
+	})
+
+	step("delete", func() error {
+		if err := s.personalClient.DeleteEvent(destCalendarID, eventID); err != nil {
+			return err
+		}
+		inserted = false
+		found, err := s.personalClient.FindEventsByWorkID(destCalendarID, selfTestWorkID)
+		if err != nil {
+			return err
+		}
+		if len(found) != 0 {
+			return fmt.Errorf("expected no event after deleting it, found %d", len(found))
+		}
+		return nil
+	})
+
+	// Clean up after a failed step
+	if inserted {
+		if err := s.deleteSelfTestEvents(destCalendarID); err != nil {
+			steps = append(steps, SelfTestStep{Name: "clean up", Err: err})
+		}
+	}
+	return steps
+}
+
+// deleteSelfTestEvents deletes every self-test event in the calendar.
+func (s *Syncer) deleteSelfTestEvents(destCalendarID string) error {
+	found, err := s.personalClient.FindEventsByWorkID(destCalendarID, selfTestWorkID)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, event := range found {
+		if err := s.personalClient.DeleteEvent(destCalendarID, event.Id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete self-test event %s: %w", event.Id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -3,15 +3,24 @@ package sync
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+	stdsync "sync"
 	"time"
 
 	"github.com/beekhof/calendar-sync/internal/auth"
 	calclient "github.com/beekhof/calendar-sync/internal/calendar"
 	"github.com/beekhof/calendar-sync/internal/config"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
 	"golang.org/x/term"
 
 	"google.golang.org/api/calendar/v3"
@@ -22,19 +31,321 @@ type Syncer struct {
 	workClient     calclient.CalendarClient
 	personalClient calclient.CalendarClient
 	config         *config.Config
-	destination    *config.Destination // Destination-specific config (calendar name, color, etc.)
-	verbose        bool                // Enable verbose DEBUG logging
+	destination    *config.Destination        // Destination-specific config (calendar name, color, etc.)
+	verbose        bool                       // Enable verbose DEBUG logging
+	now            func() time.Time           // Clock used for window calculations (defaults to time.Now)
+	calendarCache  *calclient.CalendarIDCache // Optional cache of calendar IDs shared across destinations
+	route          *routeTarget               // Set when syncing one calendar of a destination with routes
+	sourceEvents   []*calendar.Event          // Work events fetched once for all destinations, when sharedSource is set
+	sharedSource   bool
+	workCalls      *calclient.InstrumentedClient // Records the calls to workClient, when created by NewSyncer
+	destCalls      *calclient.InstrumentedClient // Records the calls to personalClient, when created by NewSyncer
+
+	calendarRecreated bool  // Set once the destination calendar was recreated during the current pass
+	deletesAllowed    *bool // Whether deletes were acknowledged, once the first delete has asked
+
+	filterProgram *vm.Program       // FilterExpression, compiled when the first event is filtered
+	destState     *destinationState // State kept between runs, when destination_state_dir is set
+}
+
+// routeTarget selects the events a per-calendar Syncer handles when a
+// destination routes events to several calendars.
+type routeTarget struct {
+	calendarName    string         // Calendar this Syncer writes to
+	defaultCalendar string         // Calendar for events matching no route
+	routes          []config.Route // The destination's routes
+}
+
+// SyncResult summarizes the changes made to a destination during a single Sync run.
+type SyncResult struct {
+	Inserted        int // Number of events inserted into the destination calendar
+	Updated         int // Number of events updated in the destination calendar
+	Deleted         int // Number of events deleted from the destination calendar
+	Failed          int // Number of insert/update/delete operations that failed
+	Resumed         int // Number of events skipped because the progress journal shows an interrupted run already wrote them
+	Withheld        int // Number of deletes withheld because destructive syncs to the destination were not acknowledged
+	SafeModeSkipped int // Number of updates and deletes not made because of safe mode
+	Unchanged       int // Number of synced events that were already up to date
+
+	// BlocksInserted, BlocksUpdated and BlocksDeleted count the busy blocks
+	// written to the work calendar for personal events (reverse_busy_block).
+	BlocksInserted, BlocksUpdated, BlocksDeleted int
+
+	// TimeMin and TimeMax are the sync window the run used.
+	TimeMin, TimeMax time.Time
+
+	// Filtered counts the work events left out of the sync, by reason (see the Filter* constants).
+	Filtered map[string]int
+
+	// VerifyFailures lists written events that could not be read back intact.
+	// Only populated when write verification is enabled.
+	VerifyFailures []VerifyFailure
+
+	// Changes lists the events counted in Inserted, Updated and Deleted, in
+	// the order they were made, or planned in a dry run.
+	Changes []Change
+
+	// WorkCalls and DestinationCalls count the API calls the run made to the
+	// work and destination calendars. Work events listed once for all
+	// destinations by FetchSourceEvents are not included.
+	WorkCalls, DestinationCalls APICalls
+
+	// Cancelled is set when the sync context was cancelled (e.g. SIGINT) before
+	// all events were processed. The counts above reflect the work completed so far.
+	Cancelled bool
+}
+
+// add accumulates the counts of another run into r.
+func (r *SyncResult) add(other *SyncResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Deleted += other.Deleted
+	r.Failed += other.Failed
+	r.Resumed += other.Resumed
+	r.Withheld += other.Withheld
+	r.SafeModeSkipped += other.SafeModeSkipped
+	r.Unchanged += other.Unchanged
+	r.BlocksInserted += other.BlocksInserted
+	r.BlocksUpdated += other.BlocksUpdated
+	r.BlocksDeleted += other.BlocksDeleted
+	// Every pass filters the same work events over the same window, so their
+	// counts and window are kept, not summed
+	if r.Filtered == nil {
+		r.Filtered = other.Filtered
+	}
+	if r.TimeMin.IsZero() {
+		r.TimeMin, r.TimeMax = other.TimeMin, other.TimeMax
+	}
+	r.VerifyFailures = append(r.VerifyFailures, other.VerifyFailures...)
+	r.Changes = append(r.Changes, other.Changes...)
+	r.Cancelled = r.Cancelled || other.Cancelled
+}
+
+// Change is an insert, update or delete of a destination event.
+type Change struct {
+	Action      string `json:"action"`             // "insert", "update" or "delete"
+	EventID     string `json:"event_id,omitempty"` // ID of the destination event, unset for inserts
+	WorkEventID string `json:"work_event_id,omitempty"`
+	Summary     string `json:"summary"`
+}
+
+// record counts a change to the destination and adds it to Changes.
+func (r *SyncResult) record(action, eventID, workEventID, summary string) {
+	switch action {
+	case "insert":
+		r.Inserted++
+	case "update":
+		r.Updated++
+	case "delete":
+		r.Deleted++
+	}
+	r.Changes = append(r.Changes, Change{Action: action, EventID: eventID, WorkEventID: workEventID, Summary: summary})
+}
+
+// VerifyFailure describes an event that was written to the destination but
+// did not round-trip when read back.
+type VerifyFailure struct {
+	WorkEventID string // workEventId of the written event
+	Summary     string // Summary of the written event
+	Reason      string // "missing", or the name of the field that differs
 }
 
 // NewSyncer creates a new Syncer instance.
 func NewSyncer(workClient, personalClient calclient.CalendarClient, cfg *config.Config, dest *config.Destination, verbose bool) *Syncer {
+	workClient, workCalls := instrument(workClient)
+	personalClient, destCalls := instrument(personalClient)
 	return &Syncer{
 		workClient:     workClient,
 		personalClient: personalClient,
 		config:         cfg,
 		destination:    dest,
 		verbose:        verbose,
+		now:            time.Now,
+		workCalls:      workCalls,
+		destCalls:      destCalls,
+	}
+}
+
+// NewSyncerWithSource creates a Syncer that takes its work events from
+// sourceEvents, as listed by FetchSourceEvents, instead of fetching them
+// itself. The events are filtered and limited to the destination's window as
+// usual. Destinations in "busy" privacy mode still query free/busy.
+func NewSyncerWithSource(workClient, personalClient calclient.CalendarClient, cfg *config.Config, dest *config.Destination, sourceEvents []*calendar.Event, verbose bool) *Syncer {
+	s := NewSyncer(workClient, personalClient, cfg, dest, verbose)
+	s.sourceEvents = sourceEvents
+	s.sharedSource = true
+	return s
+}
+
+// FetchSourceEvents lists the work events once for the widest sync window of
+// the destinations, so their Syncers can share them through NewSyncerWithSource.
+func FetchSourceEvents(workClient calclient.CalendarClient, cfg *config.Config, destinations []config.Destination) ([]*calendar.Event, error) {
+	var timeMin, timeMax time.Time
+	for i := range destinations {
+		s := NewSyncer(workClient, nil, cfg, &destinations[i], false)
+		destMin, destMax := s.timeWindow(s.currentTime())
+		if timeMin.IsZero() || destMin.Before(timeMin) {
+			timeMin = destMin
+		}
+		if destMax.After(timeMax) {
+			timeMax = destMax
+		}
+	}
+
+	s := &Syncer{workClient: workClient, config: cfg}
+	return s.fetchWorkEvents(timeMin, timeMax)
+}
+
+// SetCalendarCache makes the Syncer resolve its destination calendar through a
+// cache shared with other Syncers, so destinations on the same account don't
+// race to create the same calendar.
+func (s *Syncer) SetCalendarCache(cache *calclient.CalendarIDCache) {
+	s.calendarCache = cache
+}
+
+// accountKey identifies the destination account for calendar ID caching.
+func (s *Syncer) accountKey() string {
+	if s.destination.IsCalDAV() && s.destination.AuthMode != "oauth" {
+		return s.destination.ServerURL + "|" + s.destination.Username
+	}
+	if s.destination.Type == "outlook" {
+		return "outlook|" + s.destination.OutlookTokenPath
+	}
+	return s.destination.Type + "|" + s.destination.ServerURL + "|" + s.destination.TokenPath
+}
+
+// resolveCalendar finds or creates the destination calendar, going through the
+// shared calendar cache when one is set. A dry run only looks the calendar up,
+// returning an empty ID if it doesn't exist yet. A configured calendar_path
+// is used as is.
+func (s *Syncer) resolveCalendar() (string, error) {
+	if s.destination.CalendarPath != "" {
+		return s.destination.CalendarPath, nil
+	}
+	if s.config.DryRun {
+		id, err := s.personalClient.FindCalendarByName(s.destination.CalendarName)
+		if errors.Is(err, calclient.ErrCalendarNotFound) {
+			s.logChange("[%s] Would create calendar '%s'", s.destination.Name, s.destination.CalendarName)
+			return "", nil
+		}
+		return id, err
+	}
+	if id, ok := s.cachedCalendar(); ok {
+		return id, nil
+	}
+	if s.calendarCache != nil {
+		return s.calendarCache.FindOrCreate(s.personalClient, s.accountKey(), s.destination.CalendarName, s.destination.CalendarColorID)
+	}
+	return s.personalClient.FindOrCreateCalendarByName(s.destination.CalendarName, s.destination.CalendarColorID)
+}
+
+// syncWindow returns the number of weeks to sync forward and backward, preferring
+// the destination's values over the global ones.
+func (s *Syncer) syncWindow() (weeks, weeksPast int) {
+	weeks, weeksPast = s.config.SyncWindowWeeks, s.config.SyncWindowWeeksPast
+	if s.destination.SyncWindowWeeks != nil {
+		weeks = *s.destination.SyncWindowWeeks
+	}
+	if s.destination.SyncWindowWeeksPast != nil {
+		weeksPast = *s.destination.SyncWindowWeeksPast
+	}
+	return weeks, weeksPast
+}
+
+// timeWindow returns the sync window around now: from the Monday of the first
+// past week to the end of the Sunday of the last future week.
+func (s *Syncer) timeWindow(now time.Time) (timeMin, timeMax time.Time) {
+	// Find the start of the current week (Monday)
+	weekday := int(now.Weekday())
+	if weekday == 0 {
+		weekday = 7 // Sunday = 7
+	}
+	daysFromMonday := weekday - 1
+	startOfCurrentWeek := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startOfCurrentWeek = startOfCurrentWeek.AddDate(0, 0, -daysFromMonday)
+
+	// Start of sync window (Monday at 00:00:00 of the first week in the past)
+	// If SyncWindowWeeksPast is 0, start from current week
+	// If SyncWindowWeeksPast is 1, go back 1 week (so include last week)
+	// The start is 7 * SyncWindowWeeksPast days before the current week's Monday
+	weeks, weeksPast := s.syncWindow()
+	timeMin = startOfCurrentWeek.AddDate(0, 0, -7*weeksPast)
+
+	// End of sync window (Sunday at 23:59:59 of the last week in the future)
+	// SyncWindowWeeks weeks means: current week + (SyncWindowWeeks - 1) additional weeks
+	// For example, 2 weeks = current week (7 days) + next week (7 days) = 14 days total
+	// The last day is Sunday of the last week, which is 7 * SyncWindowWeeks - 1 days from Monday
+	timeMax = startOfCurrentWeek.AddDate(0, 0, 7*weeks-1)
+	timeMax = time.Date(timeMax.Year(), timeMax.Month(), timeMax.Day(), 23, 59, 59, 0, timeMax.Location())
+	return timeMin, timeMax
+}
+
+// currentTime returns the current time from the injected clock, falling back
+// to time.Now when no clock has been set. With a home time zone it is in that
+// zone, so the sync window's weeks start at its midnight.
+func (s *Syncer) currentTime() time.Time {
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	if loc := s.homeLocation(); loc != nil {
+		now = now.In(loc)
+	}
+	return now
+}
+
+// homeLocation returns the configured home time zone, or nil if there is none.
+func (s *Syncer) homeLocation() *time.Location {
+	if s.config == nil {
+		return nil
+	}
+	return s.config.HomeLocation()
+}
+
+// skipsVisibility reports whether visibility is listed in skip. An empty
+// visibility is Google's "default".
+func skipsVisibility(skip []string, visibility string) bool {
+	if visibility == "" {
+		visibility = "default"
+	}
+	for _, v := range skip {
+		if strings.EqualFold(v, visibility) {
+			return true
+		}
+	}
+	return false
+}
+
+// organizerDomain returns the lower-cased domain of the event organizer's
+// email, or "" if the event has no organizer.
+func organizerDomain(event *calendar.Event) string {
+	if event.Organizer == nil {
+		return ""
+	}
+	_, domain, found := strings.Cut(event.Organizer.Email, "@")
+	if !found {
+		return ""
+	}
+	return strings.ToLower(domain)
+}
+
+// isOwnEvent reports whether email is the organizer or the creator of event.
+func isOwnEvent(event *calendar.Event, email string) bool {
+	if event.Organizer != nil && strings.EqualFold(event.Organizer.Email, email) {
+		return true
 	}
+	return event.Creator != nil && strings.EqualFold(event.Creator.Email, email)
+}
+
+// matchesDomain reports whether domain is listed in domains. Entries may be
+// written with or without a leading "@".
+func matchesDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimPrefix(d, "@"), domain) {
+			return true
+		}
+	}
+	return false
 }
 
 // debugLog logs a message only if verbose mode is enabled.
@@ -44,18 +355,81 @@ func (s *Syncer) debugLog(format string, v ...interface{}) {
 	}
 }
 
+// Reasons filterEvents drops an event for, as counted in SyncResult.Filtered.
+const (
+	FilterCancelled       = "cancelled"
+	FilterWorkingLocation = "working_location"
+	FilterDeclined        = "declined"
+	FilterVisibility      = "visibility"
+	FilterOrganizerDomain = "organizer_domain"
+	FilterNotMine         = "not_mine"
+	FilterPast            = "past"
+	FilterAllDaySpan      = "all_day_span"
+	FilterOutOfOffice     = "out_of_office"
+	FilterKeyword         = "keyword"
+	FilterExpression      = "expression"
+	FilterMissingTime     = "missing_time"
+	FilterInvalidTime     = "invalid_time"
+	FilterOutsideWindow   = "outside_window"
+	FilterBusyBlock       = "busy_block"
+)
+
 // filterEvents applies the filtering rules from the spec:
+// - Skip the busy blocks reverse_busy_block writes for personal events
+// - Skip malformed events missing a start or end time
 // - Keep all-day events (even OOF)
 // - Skip timed OOF events
-// - Skip events entirely outside 6:00 AM - 12:00 AM (midnight)
+// - Skip events entirely outside the daily window (default 6:00 AM - 12:00 AM midnight)
 // - Keep any event that partially overlaps the window
+// - Optionally skip events the user didn't organize or create (OnlyMyEvents)
+// - Optionally skip events that have already ended (SkipPastEvents)
+// - Optionally skip all-day events longer than MaxAllDaySpanDays
+// - Optionally skip events whose visibility is in SkipVisibilities
+// - Optionally filter by organizer domain (Include/ExcludeOrganizerDomains)
+// - Optionally skip events whose summary contains one of ExcludeSummaryKeywords
+// - Optionally skip events for which FilterExpression is false
 func (s *Syncer) filterEvents(events []*calendar.Event) []*calendar.Event {
+	filtered, _ := s.filterEventsCounted(events)
+	return filtered
+}
+
+// filterEventsCounted filters events like filterEvents and also returns the
+// number of events dropped for each reason (see the Filter* constants).
+func (s *Syncer) filterEventsCounted(events []*calendar.Event) ([]*calendar.Event, map[string]int) {
 	var filtered []*calendar.Event
+	dropped := make(map[string]int)
+	now := s.currentTime()
+	startMinutes, endMinutes := 360, 1440
+	if s.config != nil {
+		startMinutes, endMinutes = s.config.DayWindow()
+	}
+	home := s.homeLocation()
+	zones := make(map[string]*time.Location) // Event time zones, loaded once each
 
 	for _, event := range events {
 
-		// skip cancelled events
-		if event.Status == "cancelled" {
+		// skip cancelled events, unless they are kept
+		if event.Status == "cancelled" && (s.config == nil || !s.config.KeepCancelled) {
+			dropped[FilterCancelled]++
+			continue
+		}
+
+		// skip the busy blocks synced the other way for personal events
+		if getDestEventID(event) != "" {
+			dropped[FilterBusyBlock]++
+			continue
+		}
+
+		// skip malformed events that can't be placed in a calendar
+		if !hasEventTimes(event) {
+			log.Printf("Warning: skipping event %s (summary: %v): missing start or end time", event.Id, event.Summary)
+			dropped[FilterMissingTime]++
+			continue
+		}
+
+		// skip working location events (e.g. "Home", "Office")
+		if event.EventType == "workingLocation" {
+			dropped[FilterWorkingLocation]++
 			continue
 		}
 		// skip declined events
@@ -67,39 +441,117 @@ func (s *Syncer) filterEvents(events []*calendar.Event) []*calendar.Event {
 				}
 			}
 			if skip {
+				dropped[FilterDeclined]++
+				continue
+			}
+		}
+
+		// skip events with an excluded visibility (e.g. "confidential")
+		if s.config != nil && skipsVisibility(s.config.SkipVisibilities, event.Visibility) {
+			s.debugLog("skipping event %s (summary: %v): visibility %q", event.Id, event.Summary, event.Visibility)
+			dropped[FilterVisibility]++
+			continue
+		}
+
+		// skip events organized outside the included domains, or from an excluded one
+		if s.config != nil && (len(s.config.IncludeOrganizerDomains) > 0 || len(s.config.ExcludeOrganizerDomains) > 0) {
+			domain := organizerDomain(event)
+			if len(s.config.IncludeOrganizerDomains) > 0 && !matchesDomain(s.config.IncludeOrganizerDomains, domain) {
+				s.debugLog("skipping event %s (summary: %v): organizer domain %q not included", event.Id, event.Summary, domain)
+				dropped[FilterOrganizerDomain]++
+				continue
+			}
+			if matchesDomain(s.config.ExcludeOrganizerDomains, domain) {
+				s.debugLog("skipping event %s (summary: %v): organizer domain %q excluded", event.Id, event.Summary, domain)
+				dropped[FilterOrganizerDomain]++
+				continue
+			}
+		}
+
+		// skip events the user is only invited to
+		if s.config != nil && s.config.OnlyMyEvents && !isOwnEvent(event, s.config.WorkEmail) {
+			s.debugLog("skipping event %s (summary: %v): not organized or created by %s", event.Id, event.Summary, s.config.WorkEmail)
+			dropped[FilterNotMine]++
+			continue
+		}
+
+		// skip events the user's filter_expression rejects
+		if s.excludedByExpression(event) {
+			dropped[FilterExpression]++
+			continue
+		}
+
+		// skip events that have already ended (forward-only mirrors)
+		// Previously synced copies are then removed as stale by Sync
+		// A recurring series is kept by its first instance's times, so it is never skipped
+		if s.config != nil && s.config.SkipPastEvents && len(event.Recurrence) == 0 {
+			if endTime, ok := eventEndTime(event, now.Location()); ok && !endTime.After(now) {
+				dropped[FilterPast]++
 				continue
 			}
 		}
 
 		// Rule 1: Handle all-day events
 		if event.Start.Date != "" {
+			// Skip long all-day blocks (e.g. multi-week vacations) if a cap is configured
+			if s.config != nil && s.config.MaxAllDaySpanDays > 0 {
+				if span := allDaySpanDays(event); span > s.config.MaxAllDaySpanDays {
+					s.debugLog("skipping all-day event %s (summary: %v): spans %d days, limit is %d",
+						event.Id, event.Summary, span, s.config.MaxAllDaySpanDays)
+					dropped[FilterAllDaySpan]++
+					continue
+				}
+			}
+			if s.excludedByKeyword(event) {
+				dropped[FilterKeyword]++
+				continue
+			}
 			filtered = append(filtered, event)
 			continue
 		}
 
 		// Rule 2: Skip timed OOF events
 		// For recurring event instances, check the parent event's transparency
-		if (s.config == nil || !s.config.IncludeOOO) && isOutOfOffice(event, s.workClient) {
+		if (s.config == nil || !s.config.IncludeOOO) && isOutOfOffice(event, s.workClient, s.sourceEventRef) {
+			dropped[FilterOutOfOffice]++
+			continue
+		}
+
+		// Skip noise such as "Lunch" or "Focus time"
+		if s.excludedByKeyword(event) {
+			dropped[FilterKeyword]++
 			continue
 		}
 
-		// Rule 3: Check time window (6:00 AM - 12:00 AM)
+		// Rule 3: Check the daily time window (default 6:00 AM - 12:00 AM)
 		// Parse the start and end times
 		startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
 		if err != nil {
 			log.Printf("Warning: failed to parse event start time: %v", err)
+			dropped[FilterInvalidTime]++
 			continue
 		}
 
 		endTime, err := time.Parse(time.RFC3339, event.End.DateTime)
 		if err != nil {
 			log.Printf("Warning: failed to parse event end time: %v", err)
+			dropped[FilterInvalidTime]++
 			continue
 		}
 
-		// Window: 6:00 AM to 12:00 AM (midnight of next day)
-		windowStart := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 6, 0, 0, 0, startTime.Location())
-		windowEnd := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 24, 0, 0, 0, startTime.Location())
+		// Evaluate the window in the home time zone, or else the event's own,
+		// rather than in whatever offset its times were written with
+		loc := home
+		if loc == nil {
+			loc = eventTimeZone(event.Start, zones)
+		}
+		if loc != nil {
+			startTime, endTime = startTime.In(loc), endTime.In(loc)
+		}
+
+		// Window: minutes after midnight of the event's start day, 1440 being midnight of the next day
+		windowStart := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, startMinutes, 0, 0, startTime.Location())
+		windowEnd := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, endMinutes, 0, 0, startTime.Location())
 
 		// Check if event overlaps with the window
 		// Event overlaps if:
@@ -112,10 +564,160 @@ func (s *Syncer) filterEvents(events []*calendar.Event) []*calendar.Event {
 
 		if overlaps {
 			filtered = append(filtered, event)
+		} else {
+			dropped[FilterOutsideWindow]++
 		}
 	}
 
-	return filtered
+	return filtered, dropped
+}
+
+// tracesEvent reports whether the event's summary contains the configured
+// DebugSummaryFilter, ignoring case, so its details are logged in verbose mode.
+func (s *Syncer) tracesEvent(event *calendar.Event) bool {
+	if !s.verbose || s.config == nil || s.config.DebugSummaryFilter == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(event.Summary), strings.ToLower(s.config.DebugSummaryFilter))
+}
+
+// excludedByKeyword reports whether the event's summary contains one of the
+// configured ExcludeSummaryKeywords, ignoring case.
+func (s *Syncer) excludedByKeyword(event *calendar.Event) bool {
+	if s.config == nil {
+		return false
+	}
+	summary := strings.ToLower(event.Summary)
+	for _, keyword := range s.config.ExcludeSummaryKeywords {
+		if keyword != "" && strings.Contains(summary, strings.ToLower(keyword)) {
+			s.debugLog("skipping event %s (summary: %v): matches excluded keyword %q", event.Id, event.Summary, keyword)
+			return true
+		}
+	}
+	return false
+}
+
+// excludedByExpression reports whether the configured FilterExpression is
+// false for the event. An expression that fails to compile or run keeps the
+// event, with a warning, rather than dropping events by mistake.
+func (s *Syncer) excludedByExpression(event *calendar.Event) bool {
+	if s.config == nil || s.config.FilterExpression == "" {
+		return false
+	}
+	if s.filterProgram == nil {
+		program, err := config.CompileFilterExpression(s.config.FilterExpression)
+		if err != nil {
+			log.Printf("Warning: ignoring filter_expression: %v", err)
+			return false
+		}
+		s.filterProgram = program
+	}
+
+	env := config.FilterEnv{
+		Summary:         event.Summary,
+		IsAllDay:        event.Start.Date != "",
+		AttendeeCount:   len(event.Attendees),
+		OrganizerDomain: organizerDomain(event),
+	}
+	start, startOK := eventStartTime(event, time.UTC)
+	end, endOK := eventEndTime(event, time.UTC)
+	if startOK && endOK {
+		env.DurationMinutes = int(end.Sub(start).Minutes())
+	}
+
+	result, err := expr.Run(s.filterProgram, env)
+	if err != nil {
+		log.Printf("Warning: failed to evaluate filter_expression for event %s (summary: %v): %v", event.Id, event.Summary, err)
+		return false
+	}
+	if keep, _ := result.(bool); !keep {
+		s.debugLog("skipping event %s (summary: %v): filter_expression is false", event.Id, event.Summary)
+		return true
+	}
+	return false
+}
+
+// eventTimeZone returns the location of an event time's IANA time zone, or
+// nil if it has none or Go doesn't know it. Locations are kept in zones, as
+// loading one reads the time zone database.
+func eventTimeZone(dt *calendar.EventDateTime, zones map[string]*time.Location) *time.Location {
+	if dt.TimeZone == "" {
+		return nil
+	}
+	loc, ok := zones[dt.TimeZone]
+	if !ok {
+		loc, _ = time.LoadLocation(dt.TimeZone)
+		zones[dt.TimeZone] = loc
+	}
+	return loc
+}
+
+// hasEventTimes reports whether the event has both a start and an end, each
+// either a date or a date-time.
+func hasEventTimes(event *calendar.Event) bool {
+	isSet := func(dt *calendar.EventDateTime) bool {
+		return dt != nil && (dt.Date != "" || dt.DateTime != "")
+	}
+	return isSet(event.Start) && isSet(event.End)
+}
+
+// allDaySpanDays returns the number of days covered by an all-day event.
+// The end date is exclusive, so a single-day event spans 1 day.
+// Returns 0 if the dates cannot be parsed.
+func allDaySpanDays(event *calendar.Event) int {
+	if event.Start == nil || event.End == nil {
+		return 0
+	}
+	startDate, err := time.Parse("2006-01-02", event.Start.Date)
+	if err != nil {
+		return 0
+	}
+	endDate, err := time.Parse("2006-01-02", event.End.Date)
+	if err != nil {
+		return 0
+	}
+	return int(endDate.Sub(startDate).Hours() / 24)
+}
+
+// eventEndTime returns the time at which an event ends.
+// All-day end dates are exclusive and interpreted as midnight in loc.
+// Returns false if the event has no parseable end.
+func eventEndTime(event *calendar.Event, loc *time.Location) (time.Time, bool) {
+	if event.End == nil {
+		return time.Time{}, false
+	}
+	if event.End.Date != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", event.End.Date, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return endDate, true
+	}
+	endTime, err := time.Parse(time.RFC3339, event.End.DateTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return endTime, true
+}
+
+// eventStartTime returns when the event starts. All-day events start at
+// midnight in loc.
+func eventStartTime(event *calendar.Event, loc *time.Location) (time.Time, bool) {
+	if event.Start == nil {
+		return time.Time{}, false
+	}
+	if event.Start.Date != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", event.Start.Date, loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return startDate, true
+	}
+	startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return startTime, true
 }
 
 // isOutOfOffice checks if an event is marked as "Out of Office".
@@ -124,7 +726,8 @@ func (s *Syncer) filterEvents(events []*calendar.Event) []*calendar.Event {
 // 2. Transparency field (fallback - indicates free/busy status)
 // 3. Parent event check (for recurring event instances)
 // 4. Keyword matching in summary (last resort)
-func isOutOfOffice(event *calendar.Event, client calclient.CalendarClient) bool {
+// sourceRef maps an event ID to the work calendar and ID to fetch it by.
+func isOutOfOffice(event *calendar.Event, client calclient.CalendarClient, sourceRef func(eventID string) (calendarID, id string)) bool {
 	// Primary check: EventType field is the most reliable indicator
 	// Google Calendar sets this to "outOfOffice" for OOF events
 	if event.EventType == "outOfOffice" {
@@ -133,7 +736,7 @@ func isOutOfOffice(event *calendar.Event, client calclient.CalendarClient) bool
 
 	// For recurring event instances, check the parent event's EventType first
 	if event.RecurringEventId != "" {
-		parentEvent, err := client.GetEvent("primary", event.RecurringEventId)
+		parentEvent, err := client.GetEvent(sourceRef(event.RecurringEventId))
 		if err == nil && parentEvent != nil {
 			// Check parent's EventType first (most reliable)
 			if parentEvent.EventType == "outOfOffice" {
@@ -176,7 +779,8 @@ func (s *Syncer) prepareSyncEvent(sourceEvent *calendar.Event) *calendar.Event {
 		Start:          sourceEvent.Start,
 		End:            sourceEvent.End,
 		ConferenceData: sourceEvent.ConferenceData,
-		// Omit attendees (guest list)
+		Recurrence:     sourceEvent.Recurrence,
+		// Omit attendees (guest list), unless copy_attendees asks for them below
 		// Set reminders to use default
 		Reminders: &calendar.EventReminders{
 			UseDefault: true,
@@ -184,12 +788,237 @@ func (s *Syncer) prepareSyncEvent(sourceEvent *calendar.Event) *calendar.Event {
 		// Set extended properties to track the work event ID
 		ExtendedProperties: &calendar.EventExtendedProperties{
 			Private: map[string]string{
-				"workEventId": sourceEvent.Id,
+				"workEventId": sourceWorkID(sourceEvent),
 			},
 		},
 	}
-
-	return destEvent
+
+	// Carry the event's coordinates through, when the source has them
+	if geo := eventGeo(sourceEvent); geo != "" {
+		destEvent.ExtendedProperties.Private[calclient.GeoPropertyKey] = geo
+	}
+
+	// Apply the destination's transparency (free/busy) setting
+	switch s.destination.ForceTransparency {
+	case "opaque", "transparent":
+		destEvent.Transparency = s.destination.ForceTransparency
+	default:
+		// "source" (or unset): pass through the work event's value
+		destEvent.Transparency = sourceEvent.Transparency
+	}
+
+	// Pass the color on for color_categories to map to a category; CalDAV
+	// has no per-event colors
+	if s.destination.IsCalDAV() && len(s.config.ColorCategories) > 0 {
+		destEvent.ColorId = sourceEvent.ColorId
+	}
+
+	// CalDAV clients show HTML descriptions as markup
+	if s.destination.DescriptionFormat == "text" {
+		destEvent.Description = htmlToText(destEvent.Description)
+	}
+
+	// Link back to the work event, to open it for editing
+	if s.config != nil && s.config.IncludeSourceLink && sourceEvent.HtmlLink != "" {
+		destEvent.Description = withSourceLink(destEvent.Description, sourceEvent.HtmlLink)
+	}
+
+	// Keep the work event's own reminders where the destination can store them
+	if (s.destination.Type == "google" || s.destination.IsCalDAV()) && s.config.PreserveReminders {
+		if reminders := sourceEvent.Reminders; reminders != nil && !reminders.UseDefault {
+			destEvent.Reminders = s.syncReminders(reminders)
+		}
+	}
+
+	// Carry the event's status where the destination can store it. Google
+	// can't show a cancelled event, so other destinations are told in the title.
+	switch {
+	case sourceEvent.Status != "cancelled":
+		if s.destination.Type == "google" || s.destination.IsCalDAV() {
+			destEvent.Status = sourceEvent.Status
+		}
+	case s.keepsCancelled():
+		destEvent.Status = "cancelled"
+	default:
+		destEvent.Summary = "Cancelled: " + destEvent.Summary
+	}
+
+	// Copy the guest list where the destination can store it
+	if s.copiesAttendees() {
+		destEvent.Attendees = s.syncAttendees(sourceEvent)
+	}
+
+	// In "redact" privacy mode only the times and the workEventId are kept
+	if s.destination.PrivacyMode == "redact" {
+		destEvent.Summary = s.destination.PrivacyPlaceholder
+		if destEvent.Summary == "" {
+			destEvent.Summary = "Busy"
+		}
+		destEvent.Description = ""
+		destEvent.Location = ""
+		destEvent.ConferenceData = nil
+		delete(destEvent.ExtendedProperties.Private, calclient.GeoPropertyKey)
+	}
+
+	// Blank the fields this destination redacts
+	for _, field := range s.destination.RedactFields {
+		switch field {
+		case "description":
+			destEvent.Description = ""
+		case "location":
+			destEvent.Location = ""
+			delete(destEvent.ExtendedProperties.Private, calclient.GeoPropertyKey)
+		case "conference":
+			destEvent.ConferenceData = nil
+		}
+	}
+
+	return destEvent
+}
+
+// preserved reports whether a destination event carries one of the
+// preserve_tag keys among its private extended properties.
+func (s *Syncer) preserved(event *calendar.Event) bool {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private == nil {
+		return false
+	}
+	for _, key := range s.config.PreserveTag {
+		if _, ok := event.ExtendedProperties.Private[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keepsCancelled reports whether copies of cancelled work events are kept in
+// the destination as cancelled events, which only CalDAV destinations show.
+func (s *Syncer) keepsCancelled() bool {
+	return s.destination.IsCalDAV() && s.config != nil && s.config.KeepCancelled
+}
+
+// normalizeStatus returns an event's status; an empty one means confirmed.
+func normalizeStatus(status string) string {
+	if status == "" {
+		return "confirmed"
+	}
+	return status
+}
+
+// syncAttendees returns the guest list to copy from a work event: each guest's
+// address, name and response. In "redact" privacy mode the addresses are
+// replaced by hashes and the names left out.
+func (s *Syncer) syncAttendees(sourceEvent *calendar.Event) []*calendar.EventAttendee {
+	var attendees []*calendar.EventAttendee
+	for _, attendee := range sourceEvent.Attendees {
+		if attendee.Email == "" {
+			continue
+		}
+		copied := &calendar.EventAttendee{
+			Email:          attendee.Email,
+			DisplayName:    attendee.DisplayName,
+			ResponseStatus: attendee.ResponseStatus,
+			Optional:       attendee.Optional,
+			Resource:       attendee.Resource,
+		}
+		if s.destination.PrivacyMode == "redact" {
+			copied.Email = hashedEmail(attendee.Email)
+			copied.DisplayName = ""
+		}
+		attendees = append(attendees, copied)
+	}
+	return attendees
+}
+
+// syncReminders copies a work event's reminder overrides. CalDAV alarms are
+// written as pop-ups, so for CalDAV destinations every reminder becomes one.
+func (s *Syncer) syncReminders(reminders *calendar.EventReminders) *calendar.EventReminders {
+	synced := &calendar.EventReminders{ForceSendFields: []string{"UseDefault"}}
+	seen := make(map[string]bool)
+	for _, override := range reminders.Overrides {
+		method := override.Method
+		if s.destination.IsCalDAV() {
+			method = "popup"
+		}
+		key := fmt.Sprintf("%s %d", method, override.Minutes)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		synced.Overrides = append(synced.Overrides, &calendar.EventReminder{Method: method, Minutes: override.Minutes})
+	}
+	return synced
+}
+
+// reminderSet returns an event's reminder overrides, sorted, as "method
+// minutes" strings.
+func reminderSet(event *calendar.Event) []string {
+	var set []string
+	if event.Reminders != nil && !event.Reminders.UseDefault {
+		for _, override := range event.Reminders.Overrides {
+			set = append(set, fmt.Sprintf("%s %d", override.Method, override.Minutes))
+		}
+	}
+	sort.Strings(set)
+	return set
+}
+
+// hashedEmail returns an address that stands for email without revealing it.
+// The same address always gives the same hash, so guests stay distinguishable.
+func hashedEmail(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(sum[:8]) + "@hashed.invalid"
+}
+
+// copiesAttendees reports whether work events' guest lists are copied to the
+// destination, which copy_attendees does for Google destinations.
+func (s *Syncer) copiesAttendees() bool {
+	return s.destination.Type == "google" && s.config.CopyAttendees
+}
+
+// syncedEventsEqual compares a destination event with the copy prepared for
+// it like eventsEqual, and also compares their guest lists when they are
+// copied. Otherwise guests added to the copy are left alone.
+func (s *Syncer) syncedEventsEqual(destEvent, prepared *calendar.Event) (bool, string) {
+	if equal, diffField := eventsEqual(destEvent, prepared, s.debugLog); !equal {
+		return false, diffField
+	}
+	if !s.copiesAttendees() {
+		return true, ""
+	}
+	if attendees1, attendees2 := attendeeSet(destEvent), attendeeSet(prepared); !slices.Equal(attendees1, attendees2) {
+		s.debugLog("attendees mismatch: %v != %v", attendees1, attendees2)
+		return false, "attendees"
+	}
+	return true, ""
+}
+
+// attendeeSet returns an event's guests and their responses, sorted, as
+// "email response" strings. A guest without a response hasn't answered.
+func attendeeSet(event *calendar.Event) []string {
+	var set []string
+	for _, attendee := range event.Attendees {
+		status := attendee.ResponseStatus
+		if status == "" {
+			status = "needsAction"
+		}
+		set = append(set, strings.ToLower(attendee.Email)+" "+status)
+	}
+	sort.Strings(set)
+	return set
+}
+
+// eventGeo returns the normalized coordinates stored in an event's private or
+// shared extended properties, or "" if it has none.
+func eventGeo(event *calendar.Event) string {
+	if event.ExtendedProperties == nil {
+		return ""
+	}
+	for _, props := range []map[string]string{event.ExtendedProperties.Private, event.ExtendedProperties.Shared} {
+		if geo, ok := calclient.NormalizeGeo(props[calclient.GeoPropertyKey]); ok {
+			return geo
+		}
+	}
+	return ""
 }
 
 // eventsEqual checks if two events have the same key properties.
@@ -218,6 +1047,14 @@ func eventsEqual(event1, event2 *calendar.Event, debugLog func(string, ...interf
 		return false, "location"
 	}
 
+	// Compare coordinates in canonical form
+	if geo1, geo2 := eventGeo(event1), eventGeo(event2); geo1 != geo2 {
+		if debugLog != nil {
+			debugLog("geo mismatch: %v != %v", geo1, geo2)
+		}
+		return false, "geo"
+	}
+
 	// Compare start times (normalize timezones for DateTime comparisons)
 	if equal, field := timesEqual(event1.Start, event2.Start, "start", debugLog); !equal {
 		return false, field
@@ -228,6 +1065,30 @@ func eventsEqual(event1, event2 *calendar.Event, debugLog func(string, ...interf
 		return false, field
 	}
 
+	// Compare recurrence lines; the order of the lines has no meaning
+	if recurrence1, recurrence2 := sortedRecurrence(event1), sortedRecurrence(event2); !slices.Equal(recurrence1, recurrence2) {
+		if debugLog != nil {
+			debugLog("recurrence mismatch: %v != %v", recurrence1, recurrence2)
+		}
+		return false, "recurrence"
+	}
+
+	// Compare transparency (free/busy); an empty value means the default, opaque
+	if normalizeTransparency(event1.Transparency) != normalizeTransparency(event2.Transparency) {
+		if debugLog != nil {
+			debugLog("transparency mismatch: %v != %v", event1.Transparency, event2.Transparency)
+		}
+		return false, "transparency"
+	}
+
+	// Compare status (confirmed, tentative or cancelled)
+	if normalizeStatus(event1.Status) != normalizeStatus(event2.Status) {
+		if debugLog != nil {
+			debugLog("status mismatch: %v != %v", event1.Status, event2.Status)
+		}
+		return false, "status"
+	}
+
 	// Compare conference data (Google Meet links)
 	meetURL1 := getMeetURL(event1)
 	meetURL2 := getMeetURL(event2)
@@ -238,14 +1099,44 @@ func eventsEqual(event1, event2 *calendar.Event, debugLog func(string, ...interf
 		return false, "conference"
 	}
 
+	// Compare reminders when event2 sets its own, as with preserve_reminders;
+	// otherwise reminders added to the copy are left alone
+	if event2.Reminders != nil && !event2.Reminders.UseDefault {
+		if reminders1, reminders2 := reminderSet(event1), reminderSet(event2); !slices.Equal(reminders1, reminders2) {
+			if debugLog != nil {
+				debugLog("reminders mismatch: %v != %v", reminders1, reminders2)
+			}
+			return false, "reminders"
+		}
+	}
+
+	// ColorId isn't compared: CalDAV destinations keep it only as the
+	// category it maps to, which doesn't read back as a color
+
 	return true, ""
 }
 
+// sortedRecurrence returns a sorted copy of an event's recurrence lines.
+func sortedRecurrence(event *calendar.Event) []string {
+	recurrence := slices.Clone(event.Recurrence)
+	sort.Strings(recurrence)
+	return recurrence
+}
+
 // checkAndCreateTokenReminder checks OAuth token expiration and creates/updates reminder events.
 // This is only applicable for Google Calendar destinations that use OAuth tokens.
 func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID string) error {
+	// The expiry is estimated from the token file's age, which the keyring doesn't have
+	if s.config.TokenStore == "keyring" {
+		s.debugLog("[%s] skipping token refresh reminder: tokens are kept in the OS keyring", s.destination.Name)
+		return nil
+	}
+
 	// Load the token to check expiration
-	tokenStore := auth.NewFileTokenStore(s.destination.TokenPath)
+	tokenStore, err := auth.NewTokenStore(s.config.TokenStore, s.destination.TokenPath)
+	if err != nil {
+		return err
+	}
 	token, err := tokenStore.LoadToken()
 	if err != nil {
 		return fmt.Errorf("failed to load token: %w", err)
@@ -255,7 +1146,7 @@ func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID
 		return nil
 	}
 
-	now := time.Now()
+	now := s.currentTime()
 
 	// Determine when the token was last refreshed by checking the token file's modification time
 	// This gives us a better estimate than assuming 6 months
@@ -319,15 +1210,17 @@ func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID
 		reminderDate.Format("2006-01-02"),
 		expiryReason)
 
-	// Check if a reminder event already exists
-	reminderWorkID := "TOKEN_REFRESH_REMINDER"
-	existingReminders, err := s.personalClient.FindEventsByWorkID(destCalendarID, reminderWorkID)
-	if err != nil {
-		return fmt.Errorf("failed to find existing reminder events: %w", err)
-	}
+	reminderEvent := s.tokenReminderEvent(estimatedRefreshTokenExpiry, expiryReason, reminderDate, now)
+	return s.upsertTokenReminder(destCalendarID, reminderEvent, reminderDate, now, false)
+}
 
-	// Create or update the reminder event
-	reminderEvent := &calendar.Event{
+// tokenReminderWorkID is the workEventId used to tag the token-refresh reminder event.
+const tokenReminderWorkID = "TOKEN_REFRESH_REMINDER"
+
+// tokenReminderEvent builds the token-refresh reminder event for the given
+// estimated expiry, scheduled for reminderDate.
+func (s *Syncer) tokenReminderEvent(estimatedExpiry time.Time, expiryReason string, reminderDate, now time.Time) *calendar.Event {
+	return &calendar.Event{
 		Summary: "⚠️ Refresh OAuth Token for Calendar Sync",
 		Description: fmt.Sprintf(
 			"Your OAuth token for '%s' is estimated to expire on %s (%s).\n\n"+
@@ -339,7 +1232,7 @@ func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID
 				"Move your app to 'In production' in Google Cloud Console for longer-lived tokens.\n\n"+
 				"This reminder will be updated on the next sync.",
 			s.destination.Name,
-			estimatedRefreshTokenExpiry.Format("January 2, 2006"),
+			estimatedExpiry.Format("January 2, 2006"),
 			expiryReason,
 		),
 		Start: &calendar.EventDateTime{
@@ -353,14 +1246,29 @@ func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID
 		},
 		ExtendedProperties: &calendar.EventExtendedProperties{
 			Private: map[string]string{
-				"workEventId": reminderWorkID,
+				"workEventId":        tokenReminderWorkID,
+				reminderUpdatedAtKey: now.UTC().Format(time.RFC3339),
 			},
 		},
 	}
+}
+
+// upsertTokenReminder creates the reminder event or updates the existing one.
+// Unless force is set, an existing reminder is left alone when reminderNeedsUpdate
+// says nothing meaningful changed.
+func (s *Syncer) upsertTokenReminder(destCalendarID string, reminderEvent *calendar.Event, reminderDate, now time.Time, force bool) error {
+	existingReminders, err := s.personalClient.FindEventsByWorkID(destCalendarID, tokenReminderWorkID)
+	if err != nil {
+		return fmt.Errorf("failed to find existing reminder events: %w", err)
+	}
 
 	if len(existingReminders) > 0 {
-		// Update existing reminder
+		// Update existing reminder, unless nothing meaningful changed since it was last written
 		existingReminder := existingReminders[0]
+		if s.config.SafeMode || (!force && !s.reminderNeedsUpdate(existingReminder, reminderDate, now)) {
+			s.debugLog("Token refresh reminder unchanged and recently updated, skipping (ID: %s)", existingReminder.Id)
+			return nil
+		}
 		if err := s.personalClient.UpdateEvent(destCalendarID, existingReminder.Id, reminderEvent); err != nil {
 			return fmt.Errorf("failed to update reminder event: %w", err)
 		}
@@ -376,6 +1284,87 @@ func (s *Syncer) checkAndCreateTokenReminder(ctx context.Context, destCalendarID
 	return nil
 }
 
+// SendTestReminder creates or updates the token-refresh reminder event in the
+// destination calendar right away, scheduled shortly in the future, so the
+// reminder feature can be checked end-to-end without waiting for token expiry.
+// The next regular sync moves the reminder back to its estimated date.
+func (s *Syncer) SendTestReminder(ctx context.Context) error {
+	destCalendarID, err := s.resolveCalendar()
+	if err != nil {
+		return err
+	}
+
+	now := s.currentTime()
+	reminderDate := now.Add(15 * time.Minute).Truncate(time.Minute)
+	reminderEvent := s.tokenReminderEvent(reminderDate, "test reminder sent with calsync test-reminder", reminderDate, now)
+	if err := s.upsertTokenReminder(destCalendarID, reminderEvent, reminderDate, now, true); err != nil {
+		return err
+	}
+
+	log.Printf("[%s] Test reminder event scheduled for %s", s.destination.Name, reminderDate.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// reminderUpdatedAtKey is the private extended property recording when the
+// token-refresh reminder was last written.
+const reminderUpdatedAtKey = "reminderUpdatedAt"
+
+// TakeSnapshot saves the destination calendar's events, over the same range a
+// sync reads, so a later dry run against the snapshot shows what the sync would
+// change. A calendar that doesn't exist yet gives an empty snapshot.
+func (s *Syncer) TakeSnapshot(ctx context.Context) (*calclient.Snapshot, error) {
+	now := s.currentTime()
+	snapshot := &calclient.Snapshot{
+		Destination:  s.destination.Name,
+		CalendarName: s.destination.CalendarName,
+		TakenAt:      now,
+	}
+
+	destCalendarID := s.destination.CalendarPath
+	if destCalendarID == "" {
+		id, err := s.personalClient.FindCalendarByName(s.destination.CalendarName)
+		if errors.Is(err, calclient.ErrCalendarNotFound) {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		destCalendarID = id
+	}
+
+	timeMin, timeMax := s.timeWindow(now)
+	var err error
+	snapshot.Events, err = s.listEvents(s.personalClient, destCalendarID, timeMin.AddDate(0, -6, 0), timeMax.AddDate(0, 6, 0))
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// reminderNeedsUpdate reports whether an existing token-refresh reminder should be
+// rewritten: either its date has moved, or ReminderUpdateIntervalHours have passed
+// since the last update. Reminders without a recorded update time are always rewritten.
+func (s *Syncer) reminderNeedsUpdate(existing *calendar.Event, reminderDate, now time.Time) bool {
+	if existing.Start == nil {
+		return true
+	}
+	existingStart, err := time.Parse(time.RFC3339, existing.Start.DateTime)
+	if err != nil || existingStart.In(reminderDate.Location()).Format("2006-01-02") != reminderDate.Format("2006-01-02") {
+		return true
+	}
+
+	if existing.ExtendedProperties == nil || existing.ExtendedProperties.Private == nil {
+		return true
+	}
+	lastUpdated, err := time.Parse(time.RFC3339, existing.ExtendedProperties.Private[reminderUpdatedAtKey])
+	if err != nil {
+		return true
+	}
+
+	interval := time.Duration(s.config.ReminderUpdateIntervalHours) * time.Hour
+	return now.Sub(lastUpdated) >= interval
+}
+
 // timesEqual compares two EventDateTime values, normalizing timezones for DateTime comparisons.
 // For all-day events (Date field), it compares the date strings directly.
 // For timed events (DateTime field), it parses and compares the times in UTC.
@@ -402,54 +1391,525 @@ func timesEqual(dt1, dt2 *calendar.EventDateTime, fieldName string, debugLog fun
 			}
 			return false, fieldName
 		}
-		return true, ""
+		return true, ""
+	}
+
+	// Check if both are timed events (DateTime field)
+	if dt1.DateTime != "" && dt2.DateTime != "" {
+		// Parse both times and compare in UTC
+		t1, err1 := time.Parse(time.RFC3339, dt1.DateTime)
+		t2, err2 := time.Parse(time.RFC3339, dt2.DateTime)
+		if err1 != nil || err2 != nil {
+			// If parsing fails, fall back to string comparison
+			if dt1.DateTime != dt2.DateTime {
+				if debugLog != nil {
+					debugLog("%s time mismatch (parse failed): %v != %v", fieldName, dt1.DateTime, dt2.DateTime)
+				}
+				return false, fieldName
+			}
+			return true, ""
+		}
+		// Compare in UTC to normalize timezones
+		if !t1.UTC().Equal(t2.UTC()) {
+			if debugLog != nil {
+				debugLog("%s time mismatch: %v (UTC: %v) != %v (UTC: %v)", fieldName,
+					dt1.DateTime, t1.UTC(), dt2.DateTime, t2.UTC())
+			}
+			return false, fieldName
+		}
+		return true, ""
+	}
+
+	// One is Date, other is DateTime - they don't match
+	if debugLog != nil {
+		debugLog("%s time type mismatch: one is Date (%v), other is DateTime (%v)", fieldName,
+			dt1.Date != "", dt2.Date != "")
+	}
+	return false, fieldName
+}
+
+// normalizeTransparency maps an event's transparency to "opaque" or "transparent".
+// Google omits the field for opaque events, so an empty value is treated as opaque.
+func normalizeTransparency(transparency string) string {
+	if transparency == "transparent" {
+		return "transparent"
+	}
+	return "opaque"
+}
+
+// getMeetURL extracts the Google Meet URL from an event's conferenceData.
+func getMeetURL(event *calendar.Event) string {
+	if event.ConferenceData == nil || event.ConferenceData.EntryPoints == nil {
+		return ""
+	}
+	for _, entryPoint := range event.ConferenceData.EntryPoints {
+		if entryPoint.EntryPointType == "video" && entryPoint.Uri != "" {
+			return entryPoint.Uri
+		}
+	}
+	return ""
+}
+
+// sourceWorkID returns the workEventId used to tag copies of a source event.
+// Instances of a recurring series are keyed on the series ID and the
+// instance's original start time, which stay the same when a single instance
+// is moved or edited, so the existing copy is updated instead of orphaned.
+func sourceWorkID(event *calendar.Event) string {
+	if event.RecurringEventId == "" || event.OriginalStartTime == nil {
+		return event.Id
+	}
+
+	var originalStart string
+	if event.OriginalStartTime.DateTime != "" {
+		t, err := time.Parse(time.RFC3339, event.OriginalStartTime.DateTime)
+		if err != nil {
+			return event.Id
+		}
+		originalStart = t.UTC().Format("20060102T150405Z")
+	} else if event.OriginalStartTime.Date != "" {
+		originalStart = strings.ReplaceAll(event.OriginalStartTime.Date, "-", "")
+	} else {
+		return event.Id
+	}
+	return event.RecurringEventId + "_" + originalStart
+}
+
+// getWorkEventID returns the workEventId stored in an event's private extended
+// properties, or an empty string if the event is not tagged.
+func getWorkEventID(event *calendar.Event) string {
+	if event.ExtendedProperties == nil || event.ExtendedProperties.Private == nil {
+		return ""
+	}
+	return event.ExtendedProperties.Private["workEventId"]
+}
+
+// verifyWrites re-reads the destination calendar and checks that every event
+// written during this run can be found by its workEventId with the same key fields.
+// Some CalDAV servers accept a PUT but never return the event afterwards, so this
+// catches silent data loss that the write itself does not report.
+func (s *Syncer) verifyWrites(destCalendarID string, written []*calendar.Event, timeMin, timeMax time.Time) ([]VerifyFailure, error) {
+	readBack, err := s.listEvents(s.personalClient, destCalendarID, timeMin, timeMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-read destination events: %w", err)
+	}
+
+	readBackByWorkID := make(map[string]*calendar.Event)
+	for _, event := range readBack {
+		if workID := getWorkEventID(event); workID != "" {
+			readBackByWorkID[workID] = event
+		}
+	}
+
+	var failures []VerifyFailure
+	for _, event := range written {
+		workID := getWorkEventID(event)
+		stored, found := readBackByWorkID[workID]
+		if !found {
+			failures = append(failures, VerifyFailure{WorkEventID: workID, Summary: event.Summary, Reason: "missing"})
+			continue
+		}
+		if equal, diffField := s.syncedEventsEqual(stored, event); !equal {
+			failures = append(failures, VerifyFailure{WorkEventID: workID, Summary: event.Summary, Reason: diffField})
+		}
+	}
+
+	return failures, nil
+}
+
+// retagKey identifies a source event by summary, start and end, the fields an
+// untagged destination event must match to be re-tagged.
+type retagKey struct {
+	summary, start, end string
+}
+
+// newRetagKey builds the key for an event. Timed events are keyed in UTC, so
+// times that timesEqual treats as equal produce the same key.
+func newRetagKey(event *calendar.Event) retagKey {
+	timeKey := func(dt *calendar.EventDateTime) string {
+		switch {
+		case dt == nil:
+			return ""
+		case dt.Date != "":
+			return "date:" + dt.Date
+		}
+		if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+		return dt.DateTime
+	}
+	return retagKey{summary: event.Summary, start: timeKey(event.Start), end: timeKey(event.End)}
+}
+
+// retagCandidates indexes the source events an untagged destination event may
+// be matched to. Source events that already have a tagged copy in the
+// destination are left out, and each key's events are ordered by workEventId so
+// the choice between identical events is the same on every run.
+type retagCandidates map[retagKey][]*calendar.Event
+
+// newRetagCandidates builds the index once for all untagged destination events.
+func newRetagCandidates(sourceEventsMap map[string]*calendar.Event, destEventsByWorkID map[string][]*calendar.Event) retagCandidates {
+	workIDs := make([]string, 0, len(sourceEventsMap))
+	for workID := range sourceEventsMap {
+		if len(destEventsByWorkID[workID]) == 0 {
+			workIDs = append(workIDs, workID)
+		}
+	}
+	sort.Strings(workIDs)
+
+	candidates := make(retagCandidates)
+	for _, workID := range workIDs {
+		key := newRetagKey(sourceEventsMap[workID])
+		candidates[key] = append(candidates[key], sourceEventsMap[workID])
+	}
+	return candidates
+}
+
+// claim returns the first source event with the same summary, start and end as
+// an untagged destination event and removes it from the index, so it can't be
+// matched twice. Returns nil if there is no match.
+func (c retagCandidates) claim(destEvent *calendar.Event) *calendar.Event {
+	key := newRetagKey(destEvent)
+	events := c[key]
+	if len(events) == 0 {
+		return nil
+	}
+	c[key] = events[1:]
+	return events[0]
+}
+
+// getSourceEvents fetches the work events to sync. In "busy" privacy mode the
+// work calendar is queried for free/busy only, and each busy period becomes a
+// placeholder event without any details.
+func (s *Syncer) getSourceEvents(timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	if s.destination.PrivacyMode != "busy" && s.sharedSource {
+		return eventsInWindow(s.sourceEvents, timeMin, timeMax), nil
+	}
+	if s.destination.PrivacyMode != "busy" {
+		return s.fetchWorkEvents(timeMin, timeMax)
+	}
+
+	freeBusy, ok := calclient.As[calclient.FreeBusySource](s.workClient)
+	if !ok {
+		return nil, fmt.Errorf("privacy_mode 'busy' requires a source calendar that supports free/busy queries")
+	}
+	// Busy blocks carry no IDs of their own, so a block busy in several
+	// calendars comes out once
+	var events []*calendar.Event
+	seen := make(map[string]bool)
+	for _, calendarID := range s.sourceCalendarIDs() {
+		slots, err := freeBusy.GetFreeBusy(calendarID, timeMin, timeMax)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range slots {
+			event := busySlotEvent(slot)
+			if !seen[event.Id] {
+				seen[event.Id] = true
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// fetchWorkEvents lists the events of the work calendars in the window, with
+// the exception dates of unexpanded recurring series added to their masters.
+// With several source calendars, an event in more than one of them is kept
+// once, from the first, and the events' IDs are namespaced with their
+// calendar's so their workEventIds stay distinct across calendars. With
+// dedupe_across_sources, so is an event that has a different ID in each.
+func (s *Syncer) fetchWorkEvents(timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	calendarIDs := s.sourceCalendarIDs()
+	if len(calendarIDs) == 1 {
+		return s.fetchCalendarEvents(calendarIDs[0], timeMin, timeMax)
+	}
+
+	var merged []*calendar.Event
+	seen := make(map[string]bool)
+	seenMeetings := make(map[string]string) // Calendar IDs by meetingKey
+	for _, calendarID := range calendarIDs {
+		events, err := s.fetchCalendarEvents(calendarID, timeMin, timeMax)
+		if err != nil {
+			return nil, fmt.Errorf("source calendar %s: %w", calendarID, err)
+		}
+		for _, event := range events {
+			if seen[event.Id] {
+				continue
+			}
+			seen[event.Id] = true
+			if key, ok := meetingKey(event); ok && s.config.DedupeAcrossSources {
+				if firstCalendarID, dup := seenMeetings[key]; dup {
+					log.Printf("Warning: '%s' on work calendar %s is also on %s; syncing it once", event.Summary, calendarID, firstCalendarID)
+					continue
+				}
+				seenMeetings[key] = calendarID
+			}
+			merged = append(merged, namespacedEvent(calendarID, event))
+		}
+	}
+	return merged, nil
+}
+
+// meetingKey identifies an event by its summary, start and end, which a
+// meeting keeps on every calendar it's on. Returns false for an event without
+// valid times.
+func meetingKey(event *calendar.Event) (string, bool) {
+	start, ok := eventStartTime(event, time.UTC)
+	if !ok {
+		return "", false
+	}
+	end, ok := eventEndTime(event, time.UTC)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s|%d|%d", event.Summary, start.Unix(), end.Unix()), true
+}
+
+// fetchCalendarEvents lists one work calendar's events in the window.
+func (s *Syncer) fetchCalendarEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	if lister, ok := s.incrementalLister(); ok {
+		return s.fetchIncremental(lister, calendarID, timeMin, timeMax)
+	}
+	events, err := s.listEvents(s.workClient, calendarID, timeMin, timeMax)
+	if err != nil || s.expandsRecurring() {
+		return events, err
+	}
+	return withExceptionDates(events), nil
+}
+
+// incrementalLister returns the work client as an IncrementalEventLister when
+// the work events are to be listed incrementally.
+func (s *Syncer) incrementalLister() (calclient.IncrementalEventLister, bool) {
+	// Changes list deleted events as cancelled, so cancelled ones can't be kept
+	if s.config == nil || s.config.SyncStatePath == "" || !s.expandsRecurring() || s.config.KeepCancelled {
+		return nil, false
+	}
+	return calclient.As[calclient.IncrementalEventLister](s.workClient)
+}
+
+// fetchIncremental lists a work calendar's events in the window through the
+// sync token stored by the last run, applying only the events changed since to
+// the events stored then. Deleted events drop out, so the diff against each
+// destination deletes their copies as usual. All events are listed again when
+// there is no usable token: on the first run, with --full-resync, once Google
+// has expired the token, or when the window reaches past the stored events.
+func (s *Syncer) fetchIncremental(lister calclient.IncrementalEventLister, calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	path := s.config.SyncStatePath
+	state, err := loadSourceState(path, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state != nil && state.covers(timeMin, timeMax) && !s.config.FullResync {
+		changed, nextSyncToken, err := lister.GetEventsIncremental(calendarID, state.SyncToken, timeMin, timeMax)
+		switch {
+		case errors.Is(err, calclient.ErrSyncTokenExpired):
+			log.Printf("Sync token of work calendar %s expired, listing all of its events", calendarID)
+		case err != nil:
+			return nil, err
+		default:
+			for _, event := range changed {
+				if event.Status == "cancelled" {
+					delete(state.Events, event.Id)
+				} else {
+					state.Events[event.Id] = event
+				}
+			}
+			state.SyncToken = nextSyncToken
+			s.debugLog("work calendar %s: %d event(s) changed since the last run", calendarID, len(changed))
+			return s.storeSourceState(calendarID, state, timeMin, timeMax), nil
+		}
+	}
+
+	events, nextSyncToken, err := lister.GetEventsIncremental(calendarID, "", timeMin, timeMax)
+	if err != nil {
+		return nil, err
+	}
+	state = &sourceState{
+		SyncToken: nextSyncToken,
+		TimeMin:   timeMin,
+		TimeMax:   timeMax,
+		Events:    make(map[string]*calendar.Event, len(events)),
+	}
+	for _, event := range events {
+		state.Events[event.Id] = event
+	}
+	return s.storeSourceState(calendarID, state, timeMin, timeMax), nil
+}
+
+// storeSourceState saves a work calendar's state for the next run and returns
+// its events in the window, in start order.
+func (s *Syncer) storeSourceState(calendarID string, state *sourceState, timeMin, timeMax time.Time) []*calendar.Event {
+	// The events are still correct, and an older token only repeats changes
+	// already applied, so a failed save costs the next run some work
+	if err := saveSourceState(s.config.SyncStatePath, calendarID, state); err != nil {
+		log.Printf("Warning: failed to save the sync state of work calendar %s: %v", calendarID, err)
+	}
+
+	events := slices.Collect(maps.Values(state.Events))
+	slices.SortFunc(events, func(a, b *calendar.Event) int {
+		aStart, _ := eventStartTime(a, time.UTC)
+		bStart, _ := eventStartTime(b, time.UTC)
+		if c := aStart.Compare(bStart); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return eventsInWindow(events, timeMin, timeMax)
+}
+
+// namespacedEvent returns a copy of the event with its ID, and its series'
+// ID, prefixed with "calendarID:". The workEventIds derived from them are
+// then unique across source calendars.
+func namespacedEvent(calendarID string, event *calendar.Event) *calendar.Event {
+	namespaced := *event
+	namespaced.Id = calendarID + ":" + event.Id
+	if event.RecurringEventId != "" {
+		namespaced.RecurringEventId = calendarID + ":" + event.RecurringEventId
+	}
+	return &namespaced
+}
+
+// eventsInWindow returns the events overlapping the window, as the calendar
+// API would have listed them for it. Recurring series masters are kept, as
+// their first instance's times don't bound the series.
+func eventsInWindow(events []*calendar.Event, timeMin, timeMax time.Time) []*calendar.Event {
+	var inWindow []*calendar.Event
+	for _, event := range events {
+		if len(event.Recurrence) == 0 {
+			start, okStart := eventStartTime(event, timeMin.Location())
+			end, okEnd := eventEndTime(event, timeMin.Location())
+			if okStart && okEnd && (!start.Before(timeMax) || !end.After(timeMin)) {
+				continue
+			}
+		}
+		inWindow = append(inWindow, event)
+	}
+	return inWindow
+}
+
+// sourceCalendarIDs returns the IDs of the work calendars events are synced from.
+func (s *Syncer) sourceCalendarIDs() []string {
+	if s.config == nil {
+		return []string{"primary"}
+	}
+	return s.config.SourceCalendarIDs()
+}
+
+// sourceEventRef returns the work calendar and ID to look up an event by,
+// undoing the namespacing of events merged from several calendars.
+func (s *Syncer) sourceEventRef(eventID string) (calendarID, id string) {
+	calendarIDs := s.sourceCalendarIDs()
+	if len(calendarIDs) > 1 {
+		if calendarID, id, ok := strings.Cut(eventID, ":"); ok {
+			return calendarID, id
+		}
+	}
+	return calendarIDs[0], eventID
+}
+
+// expandsRecurring reports whether recurring events are synced instance by instance.
+func (s *Syncer) expandsRecurring() bool {
+	return s.config == nil || s.config.ExpandsRecurring()
+}
+
+// listEvents fetches the events of a calendar, listing recurring events as
+// their series master when expand_recurring is off.
+func (s *Syncer) listEvents(client calclient.CalendarClient, calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	if s.expandsRecurring() {
+		return client.GetEvents(calendarID, timeMin, timeMax)
+	}
+	lister, ok := calclient.As[calclient.RecurringEventLister](client)
+	if !ok {
+		return nil, fmt.Errorf("expand_recurring false requires calendars that can list recurring events unexpanded")
+	}
+	return lister.GetEventsUnexpanded(calendarID, timeMin, timeMax)
+}
+
+// withExceptionDates adds an EXDATE to each series master for every instance
+// that was moved, edited or cancelled. Moved and edited instances are synced as
+// events of their own, so the copied series must not repeat them; cancelled
+// instances are left to filterEvents to drop.
+func withExceptionDates(events []*calendar.Event) []*calendar.Event {
+	masters := make(map[string]*calendar.Event)
+	result := make([]*calendar.Event, len(events))
+	for i, event := range events {
+		if len(event.Recurrence) > 0 {
+			// Copy the master, so the listed event isn't changed
+			master := *event
+			master.Recurrence = slices.Clone(event.Recurrence)
+			masters[event.Id] = &master
+			event = &master
+		}
+		result[i] = event
 	}
 
-	// Check if both are timed events (DateTime field)
-	if dt1.DateTime != "" && dt2.DateTime != "" {
-		// Parse both times and compare in UTC
-		t1, err1 := time.Parse(time.RFC3339, dt1.DateTime)
-		t2, err2 := time.Parse(time.RFC3339, dt2.DateTime)
-		if err1 != nil || err2 != nil {
-			// If parsing fails, fall back to string comparison
-			if dt1.DateTime != dt2.DateTime {
-				if debugLog != nil {
-					debugLog("%s time mismatch (parse failed): %v != %v", fieldName, dt1.DateTime, dt2.DateTime)
-				}
-				return false, fieldName
-			}
-			return true, ""
+	for _, event := range events {
+		master := masters[event.RecurringEventId]
+		if master == nil || event.OriginalStartTime == nil {
+			continue
 		}
-		// Compare in UTC to normalize timezones
-		if !t1.UTC().Equal(t2.UTC()) {
-			if debugLog != nil {
-				debugLog("%s time mismatch: %v (UTC: %v) != %v (UTC: %v)", fieldName,
-					dt1.DateTime, t1.UTC(), dt2.DateTime, t2.UTC())
-			}
-			return false, fieldName
+		var exdate string
+		if event.OriginalStartTime.Date != "" {
+			exdate = "EXDATE;VALUE=DATE:" + strings.ReplaceAll(event.OriginalStartTime.Date, "-", "")
+		} else if t, err := time.Parse(time.RFC3339, event.OriginalStartTime.DateTime); err == nil {
+			exdate = "EXDATE:" + t.UTC().Format("20060102T150405Z")
+		} else {
+			continue
+		}
+		if !slices.Contains(master.Recurrence, exdate) {
+			master.Recurrence = append(master.Recurrence, exdate)
 		}
-		return true, ""
 	}
+	return result
+}
 
-	// One is Date, other is DateTime - they don't match
-	if debugLog != nil {
-		debugLog("%s time type mismatch: one is Date (%v), other is DateTime (%v)", fieldName,
-			dt1.Date != "", dt2.Date != "")
+// busySlotEvent converts a busy period into a placeholder event. The ID is
+// derived from the period so the same slot maps to the same workEventId on
+// every run.
+func busySlotEvent(slot calclient.TimeSlot) *calendar.Event {
+	return &calendar.Event{
+		Id:      fmt.Sprintf("busy-%d-%d", slot.Start.Unix(), slot.End.Unix()),
+		Summary: "Busy",
+		Start:   &calendar.EventDateTime{DateTime: slot.Start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: slot.End.Format(time.RFC3339)},
 	}
-	return false, fieldName
 }
 
-// getMeetURL extracts the Google Meet URL from an event's conferenceData.
-func getMeetURL(event *calendar.Event) string {
-	if event.ConferenceData == nil || event.ConferenceData.EntryPoints == nil {
-		return ""
+// openProgressJournal loads the progress journal when one is configured and
+// returns the workEventIds an interrupted run already wrote. Problems reading
+// the journal are logged and the sync proceeds without resuming.
+func (s *Syncer) openProgressJournal(now time.Time) (*progressJournal, map[string]bool) {
+	if s.config.ResumeJournalPath == "" {
+		return nil, nil
 	}
-	for _, entryPoint := range event.ConferenceData.EntryPoints {
-		if entryPoint.EntryPointType == "video" && entryPoint.Uri != "" {
-			return entryPoint.Uri
-		}
+	window := time.Duration(s.config.ResumeWindowMinutes) * time.Minute
+	journal, done, err := loadProgressJournal(s.config.ResumeJournalPath, s.destination.Name, now, window)
+	if err != nil {
+		log.Printf("[%s] Warning: ignoring progress journal: %v", s.destination.Name, err)
+		return nil, nil
+	}
+	if len(done) > 0 {
+		log.Printf("[%s] Resuming interrupted sync: %d event(s) already written", s.destination.Name, len(done))
+	}
+	return journal, done
+}
+
+// recordProgress marks workID as written in the progress journal.
+func (s *Syncer) recordProgress(journal *progressJournal, workID string) {
+	if err := journal.markDone(workID, s.currentTime()); err != nil {
+		log.Printf("[%s] Warning: failed to update progress journal: %v", s.destination.Name, err)
 	}
-	return ""
+}
+
+// interrupted marks result as a partial run after the sync context was cancelled.
+// It is checked between event operations, so the operation in flight when the
+// signal arrived is always allowed to finish before we stop.
+func (s *Syncer) interrupted(ctx context.Context, result *SyncResult) (*SyncResult, error) {
+	result.Cancelled = true
+	log.Printf("[%s] Sync interrupted, stopping after current operation (inserted: %d, updated: %d, deleted: %d, failed: %d).",
+		s.destination.Name, result.Inserted, result.Updated, result.Deleted, result.Failed)
+	return result, fmt.Errorf("sync interrupted: %w", ctx.Err())
 }
 
 // isInteractive checks if the program is running in an interactive terminal.
@@ -457,9 +1917,14 @@ func isInteractive() bool {
 	return term.IsTerminal(int(os.Stdin.Fd()))
 }
 
+// promptMu keeps confirmation prompts from interleaving when destinations are
+// synced concurrently.
+var promptMu stdsync.Mutex
+
 // promptForConfirmation prompts the user for confirmation and returns true if they confirm.
 // Only prompts if running in an interactive terminal. In non-interactive mode, returns false.
-func promptForConfirmation(message string) bool {
+// Returns false as soon as ctx is cancelled, so an interrupt doesn't wait on stdin.
+func promptForConfirmation(ctx context.Context, message string) bool {
 	if !isInteractive() {
 		// Running headless (e.g., cron job) - don't prompt, just log and return false
 		log.Printf("WARNING: Running in non-interactive mode. Skipping confirmation prompt.")
@@ -467,113 +1932,406 @@ func promptForConfirmation(message string) bool {
 		return false
 	}
 
+	// Destinations synced concurrently take turns to ask
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
 	fmt.Fprintf(os.Stderr, "\n%s\n", message)
 	fmt.Fprint(os.Stderr, "Do you want to continue? (yes/no): ")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
+	// Read on a separate goroutine; it is left blocked on stdin if ctx wins
+	responses := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		if scanner.Scan() {
+			responses <- scanner.Text()
+		}
+		close(responses)
+	}()
+
+	select {
+	case line := <-responses:
+		response := strings.TrimSpace(strings.ToLower(line))
+		return response == "yes" || response == "y"
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr)
 		return false
 	}
+}
+
+// recoverCalendar re-resolves the destination calendar after it disappeared
+// mid-run (e.g. the user deleted it), recreating it if necessary, and updates
+// destCalendarID in place.
+func (s *Syncer) recoverCalendar(destCalendarID *string) error {
+	if s.destination.CalendarPath != "" {
+		return fmt.Errorf("destination calendar %s (calendar_path) no longer exists", s.destination.CalendarPath)
+	}
+	log.Printf("[%s] Destination calendar %s no longer exists, re-resolving '%s'.",
+		s.destination.Name, *destCalendarID, s.destination.CalendarName)
+	if s.calendarCache != nil {
+		s.calendarCache.Invalidate(s.accountKey(), s.destination.CalendarName)
+	}
+	newID, err := s.resolveCalendar()
+	if err != nil {
+		return fmt.Errorf("failed to re-resolve destination calendar: %w", err)
+	}
+	*destCalendarID = newID
+	s.recordCalendar(s.destination.CalendarName, newID)
+	s.calendarRecreated = true
+	return nil
+}
 
-	response := strings.TrimSpace(strings.ToLower(scanner.Text()))
-	return response == "yes" || response == "y"
+// logChange logs a change made to the destination calendar. In dry-run mode
+// the change was only planned, and the message says so.
+func (s *Syncer) logChange(format string, v ...interface{}) {
+	if s.config.DryRun {
+		format = "DRY RUN, not applied: " + format
+	}
+	log.Printf(format, v...)
 }
 
-// Sync performs the main synchronization logic.
-func (s *Syncer) Sync(ctx context.Context) error {
+// errSafeMode is returned for updates and deletes not made because safe mode
+// only inserts events.
+var errSafeMode = errors.New("not applied in safe mode")
+
+// logSkipped logs a change that safe mode kept from being made.
+func (s *Syncer) logSkipped(format string, v ...interface{}) {
+	log.Printf("SAFE MODE, not applied: "+format, v...)
+}
+
+// updateEvent updates an event in the destination calendar. If the calendar
+// itself is gone, it is re-resolved and the event is inserted into the new one
+// instead, since the old event IDs went with the old calendar.
+// In dry-run mode nothing is written, and in safe mode it returns errSafeMode.
+func (s *Syncer) updateEvent(destCalendarID *string, eventID string, event *calendar.Event) error {
+	if s.config.DryRun {
+		return nil
+	}
+	if s.config.SafeMode && !s.calendarRecreated {
+		return errSafeMode
+	}
+	if s.calendarRecreated {
+		// The event went with the old calendar
+		return s.personalClient.InsertEvent(*destCalendarID, event)
+	}
+	err := s.personalClient.UpdateEvent(*destCalendarID, eventID, event)
+	if !errors.Is(err, calclient.ErrCalendarNotFound) {
+		return err
+	}
+	if err := s.recoverCalendar(destCalendarID); err != nil {
+		return err
+	}
+	return s.personalClient.InsertEvent(*destCalendarID, event)
+}
+
+// insertEvent inserts an event into the destination calendar, re-resolving the
+// calendar and retrying once if it no longer exists.
+// In dry-run mode nothing is written.
+func (s *Syncer) insertEvent(destCalendarID *string, event *calendar.Event) error {
+	if s.config.DryRun {
+		return nil
+	}
+	err := s.personalClient.InsertEvent(*destCalendarID, event)
+	if !errors.Is(err, calclient.ErrCalendarNotFound) {
+		return err
+	}
+	if err := s.recoverCalendar(destCalendarID); err != nil {
+		return err
+	}
+	return s.personalClient.InsertEvent(*destCalendarID, event)
+}
+
+// deleteEvent deletes an event from the destination calendar. If the calendar
+// itself is gone, so is the event; the calendar is re-resolved for the
+// remaining operations and the delete counts as done.
+// In dry-run mode nothing is deleted, and in safe mode it returns errSafeMode.
+// Until destructive syncs to the destination are acknowledged, deletes are
+// withheld with errDeletesNotAcknowledged.
+func (s *Syncer) deleteEvent(ctx context.Context, destCalendarID *string, eventID string) error {
+	if s.config.DryRun || s.calendarRecreated {
+		// Nothing to write, or the event already went with the old calendar
+		return nil
+	}
+	if s.config.SafeMode {
+		return errSafeMode
+	}
+	if !s.deletesAcknowledged(ctx) {
+		return errDeletesNotAcknowledged
+	}
+	err := s.personalClient.DeleteEvent(*destCalendarID, eventID)
+	if !errors.Is(err, calclient.ErrCalendarNotFound) {
+		return err
+	}
+	return s.recoverCalendar(destCalendarID)
+}
+
+// deletesAcknowledged reports whether events may be deleted from the destination.
+// The first sync to a destination deletes nothing until the user confirms, or
+// passes --i-understand-destructive; the acknowledgement is then stored so
+// later runs, including unattended ones, proceed. It is decided on the first
+// delete, so runs that delete nothing never ask.
+func (s *Syncer) deletesAcknowledged(ctx context.Context) bool {
+	if s.deletesAllowed == nil {
+		allowed := s.acknowledgeDeletes(ctx)
+		s.deletesAllowed = &allowed
+	}
+	return *s.deletesAllowed
+}
+
+// acknowledgeDeletes looks up or asks for the acknowledgement of destructive
+// syncs to the destination, storing it once given.
+func (s *Syncer) acknowledgeDeletes(ctx context.Context) bool {
 	destName := s.destination.Name
-	log.Printf("[%s] Starting sync...", destName)
+	path := s.config.AcknowledgementsPath
+	if path == "" {
+		return true
+	}
 
-	// Find or create the destination calendar
-	destCalendarID, err := s.personalClient.FindOrCreateCalendarByName(s.destination.CalendarName, s.destination.CalendarColorID)
+	acknowledged, err := loadAcknowledgements(path)
 	if err != nil {
-		return err
+		log.Printf("[%s] Warning: %v, withholding deletes", destName, err)
+		return false
+	}
+	if _, ok := acknowledged[destName]; ok {
+		return true
 	}
 
-	// Check token expiration and create reminder events for Google destinations
-	if s.destination.Type == "google" {
-		if err := s.checkAndCreateTokenReminder(ctx, destCalendarID); err != nil {
-			// Log but don't fail the sync if reminder creation fails
-			log.Printf("[%s] Warning: Failed to check/create token refresh reminder: %v", destName, err)
+	if !s.config.AcknowledgeDestructive {
+		message := fmt.Sprintf(
+			"\n⚠️  This is the first sync to destination '%s'.\n"+
+				"The work calendar is the source of truth: events in the calendar '%s' that are not in your work calendar will be DELETED, now and on every later run.\n\n"+
+				"Are you sure you want to proceed?",
+			destName, s.destination.CalendarName)
+		if !promptForConfirmation(ctx, message) {
+			log.Printf("[%s] Withholding deletes on the first sync to this destination; run with --i-understand-destructive to allow them", destName)
+			return false
 		}
 	}
 
-	// Check if calendar has manually created events (without workEventId) and prompt for confirmation
-	// Only prompt if there are events that don't have workEventId - these will be deleted
-	// Events with workEventId are expected (previously synced) and don't need confirmation
-	checkNow := time.Now()
-	wideTimeMin := checkNow.AddDate(-1, 0, 0) // 1 year ago
-	wideTimeMax := checkNow.AddDate(1, 0, 0)  // 1 year from now
-	existingEvents, err := s.personalClient.GetEvents(destCalendarID, wideTimeMin, wideTimeMax)
-	if err != nil {
-		// If we can't check for events, log a warning but continue
-		log.Printf("[%s] Warning: Could not check for existing events: %v", destName, err)
-	} else {
-		// Count manually created events (those without workEventId)
-		manuallyCreatedCount := 0
-		for _, event := range existingEvents {
-			workID := ""
-			if event.ExtendedProperties != nil && event.ExtendedProperties.Private != nil {
-				workID = event.ExtendedProperties.Private["workEventId"]
-			}
-			if workID == "" {
-				manuallyCreatedCount++
+	if err := saveAcknowledgement(path, destName, s.currentTime()); err != nil {
+		log.Printf("[%s] Warning: failed to store acknowledgement, you will be asked again: %v", destName, err)
+	}
+	return true
+}
+
+// matchesEventClass reports whether an event belongs to a route event class.
+func (s *Syncer) matchesEventClass(event *calendar.Event, class string) bool {
+	switch class {
+	case "all_day":
+		return event.Start != nil && event.Start.Date != ""
+	case "timed":
+		return event.Start != nil && event.Start.DateTime != ""
+	case "out_of_office":
+		return isOutOfOffice(event, s.workClient, s.sourceEventRef)
+	}
+	return false
+}
+
+// eventCalendar returns the calendar an event is routed to: that of the first
+// route whose event classes all match, or the default calendar.
+func (s *Syncer) eventCalendar(event *calendar.Event) string {
+	for _, route := range s.route.routes {
+		matched := true
+		for _, class := range route.Events {
+			if !s.matchesEventClass(event, class) {
+				matched = false
+				break
 			}
 		}
+		if matched {
+			return route.CalendarName
+		}
+	}
+	return s.route.defaultCalendar
+}
 
-		if manuallyCreatedCount > 0 {
-			// Calendar has manually created events - prompt for confirmation
-			message := fmt.Sprintf(
-				"\n⚠️  WARNING: The calendar '%s' contains %d manually created event(s) (without workEventId).\n"+
-					"This tool will DELETE these events as they are not present in your work calendar.\n\n"+
-					"Are you sure you want to proceed?",
-				s.destination.CalendarName, manuallyCreatedCount)
+// routedEvents returns the events routed to this Syncer's calendar.
+func (s *Syncer) routedEvents(events []*calendar.Event) []*calendar.Event {
+	var routed []*calendar.Event
+	for _, event := range events {
+		if s.eventCalendar(event) == s.route.calendarName {
+			routed = append(routed, event)
+		}
+	}
+	return routed
+}
 
-			if !promptForConfirmation(message) {
-				return fmt.Errorf("sync cancelled by user")
-			}
-			log.Printf("[%s] User confirmed - proceeding with sync", destName)
+// syncRoutes syncs a destination with routes by running a Syncer per calendar,
+// each handling only the events routed to it, and combines their results.
+// An event that changes class is removed from one calendar as stale and
+// inserted into the other.
+func (s *Syncer) syncRoutes(ctx context.Context) (*SyncResult, error) {
+	// The default calendar comes first, then each route calendar once
+	colors := map[string]string{s.destination.CalendarName: s.destination.CalendarColorID}
+	calendars := []string{s.destination.CalendarName}
+	for _, route := range s.destination.Routes {
+		if _, seen := colors[route.CalendarName]; !seen {
+			colors[route.CalendarName] = route.CalendarColorID
+			calendars = append(calendars, route.CalendarName)
 		}
 	}
 
-	// Calculate time window: from past weeks to future weeks from start of current week
-	now := time.Now()
+	result := &SyncResult{}
+	var errs []error
+	for _, calendarName := range calendars {
+		dest := *s.destination
+		dest.Name = fmt.Sprintf("%s / %s", s.destination.Name, calendarName)
+		dest.CalendarName = calendarName
+		dest.CalendarColorID = colors[calendarName]
+		if calendarName != s.destination.CalendarName {
+			dest.CalendarPath = "" // Route calendars are found by name
+		}
 
-	// Find the start of the current week (Monday)
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7 // Sunday = 7
+		calendarSyncer := *s
+		calendarSyncer.destination = &dest
+		calendarSyncer.route = &routeTarget{
+			calendarName:    calendarName,
+			defaultCalendar: s.destination.CalendarName,
+			routes:          s.destination.Routes,
+		}
+
+		calendarResult, err := calendarSyncer.Sync(ctx)
+		if calendarResult != nil {
+			result.add(calendarResult)
+		}
+		if err != nil && errors.Is(err, context.Canceled) {
+			return result, err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("calendar '%s': %w", calendarName, err))
+		}
 	}
-	daysFromMonday := weekday - 1
-	startOfCurrentWeek := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	startOfCurrentWeek = startOfCurrentWeek.AddDate(0, 0, -daysFromMonday)
 
-	// Start of sync window (Monday at 00:00:00 of the first week in the past)
-	// If SyncWindowWeeksPast is 0, start from current week
-	// If SyncWindowWeeksPast is 1, go back 1 week (so include last week)
-	// The start is 7 * SyncWindowWeeksPast days before the current week's Monday
-	timeMin := startOfCurrentWeek.AddDate(0, 0, -7*s.config.SyncWindowWeeksPast)
+	return result, errors.Join(errs...)
+}
 
-	// End of sync window (Sunday at 23:59:59 of the last week in the future)
-	// SyncWindowWeeks weeks means: current week + (SyncWindowWeeks - 1) additional weeks
-	// For example, 2 weeks = current week (7 days) + next week (7 days) = 14 days total
-	// The last day is Sunday of the last week, which is 7 * SyncWindowWeeks - 1 days from Monday
-	timeMax := startOfCurrentWeek.AddDate(0, 0, 7*s.config.SyncWindowWeeks-1)
-	timeMax = time.Date(timeMax.Year(), timeMax.Month(), timeMax.Day(), 23, 59, 59, 0, timeMax.Location())
+// Sync performs the main synchronization logic.
+// The returned SyncResult summarizes the changes made to the destination calendar.
+func (s *Syncer) Sync(ctx context.Context) (*SyncResult, error) {
+	if s.route != nil {
+		return s.syncDestinationCalendar(ctx)
+	}
+
+	// Count the calls of this run, across all of its calendars and passes
+	if s.workCalls != nil {
+		s.workCalls.Reset()
+	}
+	if s.destCalls != nil {
+		s.destCalls.Reset()
+	}
+	// Clients kept for several runs, as with --serve, list calendars afresh
+	for _, client := range []calclient.CalendarClient{s.workClient, s.personalClient} {
+		if cache, ok := calclient.As[calclient.EventCache](client); ok {
+			cache.ResetEventCache()
+		}
+	}
+	s.openDestinationState()
+	var result *SyncResult
+	var err error
+	if len(s.destination.Routes) > 0 {
+		result, err = s.syncRoutes(ctx)
+	} else {
+		result, err = s.syncDestinationCalendar(ctx)
+	}
+	if err == nil {
+		s.writeDestinationState(result)
+	}
+	if result != nil && s.workCalls != nil {
+		result.WorkCalls = apiCallsFrom(s.workCalls.Stats())
+	}
+	if result != nil && s.destCalls != nil {
+		result.DestinationCalls = apiCallsFrom(s.destCalls.Stats())
+	}
+	return result, err
+}
+
+// syncDestinationCalendar syncs one destination calendar, making a second pass
+// if the calendar had to be recreated during the first.
+func (s *Syncer) syncDestinationCalendar(ctx context.Context) (*SyncResult, error) {
+	s.calendarRecreated = false
+	result, err := s.syncCalendar(ctx)
+	if err != nil || !s.calendarRecreated {
+		return result, err
+	}
+
+	// Events that were unchanged when the calendar vanished went with it, and
+	// only a fresh pass against the new calendar sees that they are missing
+	log.Printf("[%s] Destination calendar was recreated, syncing again to restore its events", s.destination.Name)
+	s.calendarRecreated = false
+	again, err := s.syncCalendar(ctx)
+	if again != nil {
+		result.add(again)
+	}
+	return result, err
+}
+
+// syncCalendar makes one pass over the destination calendar, bringing it in
+// line with the source events.
+func (s *Syncer) syncCalendar(ctx context.Context) (*SyncResult, error) {
+	destName := s.destination.Name
+	log.Printf("[%s] Starting sync...", destName)
+
+	result := &SyncResult{}
+	// Events successfully written during this run, for optional write verification
+	var written []*calendar.Event
+
+	// Find or create the destination calendar
+	destCalendarID, err := s.resolveCalendar()
+	if err != nil {
+		return nil, err
+	}
+	s.recordCalendar(s.destination.CalendarName, destCalendarID)
+
+	// Check token expiration and create reminder events for Google destinations
+	if s.destination.Type == "google" && !s.config.DryRun && (s.route == nil || s.route.calendarName == s.route.defaultCalendar) {
+		if err := s.checkAndCreateTokenReminder(ctx, destCalendarID); err != nil {
+			// Log but don't fail the sync if reminder creation fails
+			log.Printf("[%s] Warning: Failed to check/create token refresh reminder: %v", destName, err)
+		}
+	}
+
+	// Calculate time window: from past weeks to future weeks from start of current week
+	now := s.currentTime()
+	timeMin, timeMax := s.timeWindow(now)
+	result.TimeMin, result.TimeMax = timeMin, timeMax
 
 	// Get source events from work calendar
-	sourceEvents, err := s.workClient.GetEvents("primary", timeMin, timeMax)
+	sourceEvents, err := s.getSourceEvents(timeMin, timeMax)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Filter events according to spec
-	filteredEvents := s.filterEvents(sourceEvents)
+	filteredEvents, dropped := s.filterEventsCounted(sourceEvents)
+	result.Filtered = dropped
+	for _, reason := range slices.Sorted(maps.Keys(dropped)) {
+		s.debugLog("filtered out %d event(s): %s", dropped[reason], reason)
+	}
+
+	// With routes, this Syncer only handles the events routed to its calendar
+	if s.route != nil {
+		filteredEvents = s.routedEvents(filteredEvents)
+	}
+
+	// Resume an interrupted run: skip events the progress journal says were already written
+	// A dry run writes nothing, so it neither resumes nor records progress
+	var journal *progressJournal
+	alreadyDone := make(map[string]bool)
+	if !s.config.DryRun {
+		journal, alreadyDone = s.openProgressJournal(now)
+	}
 
-	// Create a map of filtered events by ID for easy lookup
+	// Create a map of filtered events by workEventId for easy lookup
+	// Copies tagged with an instance ID that has since been replaced by a
+	// stable recurring key are matched through legacyWorkIDs
 	sourceEventsMap := make(map[string]*calendar.Event)
+	legacyWorkIDs := make(map[string]string)
 	for _, event := range filteredEvents {
-		sourceEventsMap[event.Id] = event
+		workID := sourceWorkID(event)
+		sourceEventsMap[workID] = event
+		if event.Id != workID {
+			legacyWorkIDs[event.Id] = workID
+		}
 	}
 
 	// Get destination events from personal calendar
@@ -581,9 +2339,13 @@ func (s *Syncer) Sync(ctx context.Context) error {
 	// Search 6 months before and 6 months after the sync window
 	wideTimeMinForSync := timeMin.AddDate(0, -6, 0)
 	wideTimeMaxForSync := timeMax.AddDate(0, 6, 0)
-	destEvents, err := s.personalClient.GetEvents(destCalendarID, wideTimeMinForSync, wideTimeMaxForSync)
-	if err != nil {
-		return err
+	// In a dry run the calendar may not exist yet, in which case every event is new
+	var destEvents []*calendar.Event
+	if destCalendarID != "" {
+		destEvents, err = s.listEvents(s.personalClient, destCalendarID, wideTimeMinForSync, wideTimeMaxForSync)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	log.Printf("Retrieved %d destination events (wide range: %s to %s) for duplicate detection",
@@ -593,33 +2355,45 @@ func (s *Syncer) Sync(ctx context.Context) error {
 	// Use ALL destEvents (wide range) for duplicate detection, not just those in the sync window
 	destEventsByWorkID := make(map[string][]*calendar.Event)
 	eventsWithoutWorkID := []*calendar.Event{}
+	var personalEvents []*calendar.Event // Kept and blocked on the work calendar, with reverse_busy_block
+	preserved := 0
 
 	// Use ALL destEvents for duplicate detection (wide range)
 	for _, destEvent := range destEvents {
+		// Unexpanded listings include cancelled instances, which are already
+		// gone, unless they are copies of cancelled work events
+		if destEvent.Status == "cancelled" && !s.keepsCancelled() {
+			continue
+		}
+
 		// Get the work event ID from extended properties
 		workID := ""
 		if destEvent.ExtendedProperties != nil && destEvent.ExtendedProperties.Private != nil {
 			workID = destEvent.ExtendedProperties.Private["workEventId"]
 		}
 
-		// Log events with "DR for Virtualization" in the summary for debugging
-		if destEvent.Summary != "" && strings.Contains(destEvent.Summary, "DR for Virtualization") {
-			workID := ""
-			if destEvent.ExtendedProperties != nil && destEvent.ExtendedProperties.Private != nil {
-				workID = destEvent.ExtendedProperties.Private["workEventId"]
-			}
+		if s.tracesEvent(destEvent) {
 			actualStart := ""
 			if destEvent.Start != nil {
-				if destEvent.Start.DateTime != "" {
-					actualStart = destEvent.Start.DateTime
-				} else if destEvent.Start.Date != "" {
+				actualStart = destEvent.Start.DateTime
+				if actualStart == "" {
 					actualStart = destEvent.Start.Date
 				}
 			}
-			s.debugLog("Found event '%s' with normalized_start=%s, actual_start=%s, workEventId=%q, ID=%s",
-				destEvent.Summary, destEvent.Start.DateTime, actualStart, workID, destEvent.Id)
+			s.debugLog("Found event '%s' with start=%s, workEventId=%q, ID=%s",
+				destEvent.Summary, actualStart, workID, destEvent.Id)
 		}
 
+		if workID == "" && s.destination.ReverseBusyBlock {
+			personalEvents = append(personalEvents, destEvent)
+			continue
+		}
+		if workID == "" && s.preserved(destEvent) {
+			// Added on purpose, so neither deleted nor adopted as a work event's copy
+			s.debugLog("keeping preserved event %s (summary: %v)", destEvent.Id, destEvent.Summary)
+			preserved++
+			continue
+		}
 		if workID == "" {
 			// This event doesn't have a workEventId - it was manually created
 			// Per spec: Work calendar is the source of truth, so manually created events should be deleted
@@ -627,27 +2401,123 @@ func (s *Syncer) Sync(ctx context.Context) error {
 			continue
 		}
 
+		if stableID, ok := legacyWorkIDs[workID]; ok {
+			workID = stableID
+		}
+
 		if len(destEventsByWorkID[workID]) > 0 {
 			s.debugLog("found duplicate event %s (summary: %v)", destEvent.Id, destEvent.Summary)
 		}
 		destEventsByWorkID[workID] = append(destEventsByWorkID[workID], destEvent)
 	}
 
+	if preserved > 0 {
+		log.Printf("[%s] Keeping %d event(s) without workEventId tagged with a preserve_tag key", destName, preserved)
+	}
+
+	// Before deleting untagged events, check whether any of them is actually a synced
+	// event whose workEventId tag was lost (e.g. a server that mangled the X- property).
+	// Those match a source event on summary, start and end; re-tag them instead of
+	// deleting and re-inserting, which would only cause churn. The same adopts events
+	// created by hand that match a work event. With merge_untagged false every
+	// untagged event is deleted, and the work events are inserted afresh.
+	untaggedToRetag := make(map[*calendar.Event]*calendar.Event) // dest event -> matching source event
+	untaggedToDelete := []*calendar.Event{}
+	candidates := newRetagCandidates(sourceEventsMap, destEventsByWorkID)
+	for _, destEvent := range eventsWithoutWorkID {
+		if !s.config.MergesUntagged() {
+			untaggedToDelete = append(untaggedToDelete, destEvent)
+		} else if sourceEvent := candidates.claim(destEvent); sourceEvent != nil {
+			untaggedToRetag[destEvent] = sourceEvent
+		} else {
+			untaggedToDelete = append(untaggedToDelete, destEvent)
+		}
+	}
+
+	// If the calendar has manually created events (without workEventId), prompt for confirmation
+	// Only prompt if there are events that will actually be deleted
+	// Events with workEventId are expected (previously synced) and don't need confirmation
+	if len(untaggedToDelete) > 0 && s.config.DryRun {
+		log.Printf("[%s] DRY RUN: the calendar '%s' contains %d manually created event(s) (without workEventId) that would be deleted",
+			destName, s.destination.CalendarName, len(untaggedToDelete))
+	} else if len(untaggedToDelete) > 0 && !s.config.SafeMode {
+		message := fmt.Sprintf(
+			"\n⚠️  WARNING: The calendar '%s' contains %d manually created event(s) (without workEventId).\n"+
+				"This tool will DELETE these events as they are not present in your work calendar.\n\n"+
+				"Are you sure you want to proceed?",
+			s.destination.CalendarName, len(untaggedToDelete))
+
+		if !promptForConfirmation(ctx, message) {
+			if ctx.Err() != nil {
+				return s.interrupted(ctx, result)
+			}
+			return nil, fmt.Errorf("sync cancelled by user")
+		}
+		log.Printf("[%s] User confirmed - proceeding with sync", destName)
+	}
+
+	for _, destEvent := range eventsWithoutWorkID {
+		sourceEvent, matched := untaggedToRetag[destEvent]
+		if !matched {
+			continue
+		}
+		if ctx.Err() != nil {
+			return s.interrupted(ctx, result)
+		}
+		preparedEvent := s.prepareSyncEvent(sourceEvent)
+		if err := s.updateEvent(&destCalendarID, destEvent.Id, preparedEvent); errors.Is(err, errSafeMode) {
+			s.logSkipped("Would re-tag untagged event %s matching work event (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, sourceWorkID(sourceEvent))
+			result.SafeModeSkipped++
+			// Still count it as the work event's copy, so it isn't inserted again
+			destEventsByWorkID[sourceWorkID(sourceEvent)] = []*calendar.Event{destEvent}
+			continue
+		} else if err != nil {
+			// Leave it alone rather than deleting an event we believe is ours
+			log.Printf("Warning: failed to re-tag untagged event %s (Summary: %s, workEventId: %s): %v", destEvent.Id, destEvent.Summary, sourceWorkID(sourceEvent), err)
+			result.Failed++
+			continue
+		}
+		s.logChange("Re-tagged untagged event %s matching work event (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, sourceWorkID(sourceEvent))
+		result.record("update", destEvent.Id, sourceWorkID(sourceEvent), preparedEvent.Summary)
+		s.recordProgress(journal, sourceWorkID(sourceEvent))
+		written = append(written, preparedEvent)
+		// Treat it as a tagged event from here on so it isn't inserted again
+		preparedEvent.Id = destEvent.Id
+		destEventsByWorkID[sourceWorkID(sourceEvent)] = []*calendar.Event{preparedEvent}
+	}
+	eventsWithoutWorkID = untaggedToDelete
+
 	// Delete manually created events (events without workEventId)
 	// Per spec: "The Work calendar is the single source of truth"
 	if len(eventsWithoutWorkID) > 0 {
 		log.Printf("Found %d manually created events (without workEventId), deleting them", len(eventsWithoutWorkID))
 		for _, destEvent := range eventsWithoutWorkID {
-			if err := s.personalClient.DeleteEvent(destCalendarID, destEvent.Id); err != nil {
+			if ctx.Err() != nil {
+				return s.interrupted(ctx, result)
+			}
+			err := s.deleteEvent(ctx, &destCalendarID, destEvent.Id)
+			if errors.Is(err, errDeletesNotAcknowledged) {
+				result.Withheld++
+			} else if errors.Is(err, errSafeMode) {
+				s.logSkipped("Would delete manually created event %s (Summary: %s)", destEvent.Id, destEvent.Summary)
+				result.SafeModeSkipped++
+			} else if err != nil {
 				log.Printf("Warning: failed to delete manually created event %s (Summary: %s): %v", destEvent.Id, destEvent.Summary, err)
+				result.Failed++
 			} else {
-				log.Printf("Deleted manually created event %s (Summary: %s)", destEvent.Id, destEvent.Summary)
+				s.logChange("Deleted manually created event %s (Summary: %s)", destEvent.Id, destEvent.Summary)
+				result.record("delete", destEvent.Id, "", destEvent.Summary)
 			}
 		}
 	}
 
 	// Process destination events grouped by workEventId
-	for workID, allDestEventsWithSameWorkID := range destEventsByWorkID {
+	// Keys are sorted so every run over the same events does the same operations in the same order
+	for _, workID := range slices.Sorted(maps.Keys(destEventsByWorkID)) {
+		allDestEventsWithSameWorkID := destEventsByWorkID[workID]
+		if ctx.Err() != nil {
+			return s.interrupted(ctx, result)
+		}
 		sourceEvent, exists := sourceEventsMap[workID]
 
 		// Filter to only events in the sync window for normal processing
@@ -670,6 +2540,13 @@ func (s *Syncer) Sync(ctx context.Context) error {
 			}
 		}
 
+		if exists && alreadyDone[workID] {
+			// Written by the interrupted run being resumed
+			result.Resumed++
+			delete(sourceEventsMap, workID)
+			continue
+		}
+
 		if exists {
 			// Event exists in source (Update/Check)
 
@@ -685,14 +2562,27 @@ func (s *Syncer) Sync(ctx context.Context) error {
 
 			// Check if the event has changed
 			preparedEvent := s.prepareSyncEvent(sourceEvent)
-			equal, diffField := eventsEqual(destEvent, preparedEvent, s.debugLog)
+			equal, diffField := s.syncedEventsEqual(destEvent, preparedEvent)
+			if equal && getWorkEventID(destEvent) != workID {
+				// Re-tag a copy still carrying the instance ID it was synced with
+				equal, diffField = false, "workEventId"
+			}
 			if !equal {
 				// Event has changed, update it
-				if err := s.personalClient.UpdateEvent(destCalendarID, destEvent.Id, preparedEvent); err != nil {
+				if err := s.updateEvent(&destCalendarID, destEvent.Id, preparedEvent); errors.Is(err, errSafeMode) {
+					s.logSkipped("Would update event %s (workEventId: %s, summary: %v, changed field: %s)", destEvent.Id, workID, preparedEvent.Summary, diffField)
+					result.SafeModeSkipped++
+				} else if err != nil {
 					log.Printf("Warning: failed to update event %s (summary: %v, changed field: %s): %v", destEvent.Id, preparedEvent.Summary, diffField, err)
+					result.Failed++
 				} else {
-					log.Printf("Updated event %s (workEventId: %s, summary: %v, changed field: %s)", destEvent.Id, workID, preparedEvent.Summary, diffField)
+					s.logChange("Updated event %s (workEventId: %s, summary: %v, changed field: %s)", destEvent.Id, workID, preparedEvent.Summary, diffField)
+					result.record("update", destEvent.Id, workID, preparedEvent.Summary)
+					s.recordProgress(journal, workID)
+					written = append(written, preparedEvent)
 				}
+			} else {
+				result.Unchanged++
 			}
 			// Remove from map to mark as processed
 			delete(sourceEventsMap, workID)
@@ -700,10 +2590,21 @@ func (s *Syncer) Sync(ctx context.Context) error {
 			// Event doesn't exist in source (Delete Stale)
 			// Delete all events with this workEventId since they're no longer in the source (wide range)
 			for _, destEvent := range allDestEventsWithSameWorkID {
-				if err := s.personalClient.DeleteEvent(destCalendarID, destEvent.Id); err != nil {
+				if ctx.Err() != nil {
+					return s.interrupted(ctx, result)
+				}
+				err := s.deleteEvent(ctx, &destCalendarID, destEvent.Id)
+				if errors.Is(err, errDeletesNotAcknowledged) {
+					result.Withheld++
+				} else if errors.Is(err, errSafeMode) {
+					s.logSkipped("Would delete stale event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, workID)
+					result.SafeModeSkipped++
+				} else if err != nil {
 					log.Printf("Warning: failed to delete stale event %s (Summary: %s, workEventId: %s): %v", destEvent.Id, destEvent.Summary, workID, err)
+					result.Failed++
 				} else {
-					log.Printf("Deleted stale event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, workID)
+					s.logChange("Deleted stale event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, workID)
+					result.record("delete", destEvent.Id, workID, destEvent.Summary)
 				}
 			}
 		}
@@ -712,7 +2613,16 @@ func (s *Syncer) Sync(ctx context.Context) error {
 	// Process remaining events in sourceEventsMap (these are new)
 	// Before inserting, check if there's already an event with the same summary+start time
 	// This prevents creating duplicates when workEventId matching fails
-	for _, newEvent := range sourceEventsMap {
+	for _, workID := range slices.Sorted(maps.Keys(sourceEventsMap)) {
+		newEvent := sourceEventsMap[workID]
+		if ctx.Err() != nil {
+			return s.interrupted(ctx, result)
+		}
+		if alreadyDone[workID] {
+			// Written by the interrupted run being resumed
+			result.Resumed++
+			continue
+		}
 		preparedEvent := s.prepareSyncEvent(newEvent)
 
 		// Check if there's already an event with the same summary and start time
@@ -724,43 +2634,111 @@ func (s *Syncer) Sync(ctx context.Context) error {
 			existingEvent = destEventsForWorkID[0]
 			log.Printf("Found %d duplicate events with workEventId %s, deleting them", len(destEventsForWorkID), preparedEvent.ExtendedProperties.Private["workEventId"])
 			for _, destEvent := range destEventsForWorkID {
-				if err := s.personalClient.DeleteEvent(destCalendarID, destEvent.Id); err != nil {
+				err := s.deleteEvent(ctx, &destCalendarID, destEvent.Id)
+				if errors.Is(err, errDeletesNotAcknowledged) {
+					result.Withheld++
+				} else if errors.Is(err, errSafeMode) {
+					s.logSkipped("Would delete duplicate event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, preparedEvent.ExtendedProperties.Private["workEventId"])
+					result.SafeModeSkipped++
+				} else if err != nil {
 					log.Printf("Warning: failed to delete duplicate event %s (Summary: %s, workEventId: %s): %v", destEvent.Id, destEvent.Summary, preparedEvent.ExtendedProperties.Private["workEventId"], err)
+					result.Failed++
 				} else {
-					log.Printf("Deleted duplicate event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, preparedEvent.ExtendedProperties.Private["workEventId"])
+					s.logChange("Deleted duplicate event %s (Summary: %s, workEventId: %s)", destEvent.Id, destEvent.Summary, preparedEvent.ExtendedProperties.Private["workEventId"])
+					result.record("delete", destEvent.Id, workID, destEvent.Summary)
 				}
 			}
 
 		} else if len(destEventsForWorkID) == 1 {
 			existingEvent = destEventsForWorkID[0]
 			log.Printf("Found existing event with same workEventId, updating instead of inserting: %s (existing ID: %s, workEventId: %s)",
-				preparedEvent.Summary, existingEvent.Id, newEvent.Id)
+				preparedEvent.Summary, existingEvent.Id, workID)
 		} else {
 			log.Printf("No existing event found with same workEventId, inserting new event: %s (workEventId: %s)",
-				preparedEvent.Summary, newEvent.Id)
+				preparedEvent.Summary, workID)
 		}
 
 		if existingEvent != nil {
 			// Update the existing event
-			if err := s.personalClient.UpdateEvent(destCalendarID, existingEvent.Id, preparedEvent); err != nil {
+			if err := s.updateEvent(&destCalendarID, existingEvent.Id, preparedEvent); errors.Is(err, errSafeMode) {
+				s.logSkipped("Would update existing event %s to prevent duplicate (workEventId: %s, summary: %v)", existingEvent.Id, workID, preparedEvent.Summary)
+				result.SafeModeSkipped++
+			} else if err != nil {
 				log.Printf("Warning: failed to update existing event %s (preventing duplicate to %v): %v", existingEvent.Id, preparedEvent.Description, err)
+				result.Failed++
 				// If update fails, try inserting anyway
 				//if err := s.personalClient.InsertEvent(destCalendarID, preparedEvent); err != nil {
 				//	log.Printf("Warning: failed to insert event %s: %v", newEvent.Id, err)
 				//}
 			} else {
-				log.Printf("Updated existing event %s to prevent duplicate (workEventId: %s, summary: %v)", existingEvent.Id, newEvent.Id, preparedEvent.Summary)
+				s.logChange("Updated existing event %s to prevent duplicate (workEventId: %s, summary: %v)", existingEvent.Id, workID, preparedEvent.Summary)
+				result.record("update", existingEvent.Id, workID, preparedEvent.Summary)
+				s.recordProgress(journal, workID)
+				written = append(written, preparedEvent)
 			}
 		} else {
 			// No existing event found, safe to insert
-			if err := s.personalClient.InsertEvent(destCalendarID, preparedEvent); err != nil {
+			if err := s.insertEvent(&destCalendarID, preparedEvent); err != nil {
 				log.Printf("Warning: failed to insert event %s (summary: %v): %v", newEvent.Id, preparedEvent.Summary, err)
+				result.Failed++
 			} else {
-				log.Printf("Inserted new event %s (workEventId: %s, summary: %v)", newEvent.Id, newEvent.Id, preparedEvent.Summary)
+				s.logChange("Inserted new event %s (workEventId: %s, summary: %v)", newEvent.Id, workID, preparedEvent.Summary)
+				result.record("insert", "", workID, preparedEvent.Summary)
+				s.recordProgress(journal, workID)
+				written = append(written, preparedEvent)
 			}
 		}
 	}
 
-	log.Printf("[%s] Sync complete.", destName)
-	return nil
+	// Block the time of personal events on the work calendar
+	if s.destination.ReverseBusyBlock {
+		if err := s.syncBusyBlocks(ctx, personalEvents, timeMin, timeMax, result); ctx.Err() != nil {
+			return s.interrupted(ctx, result)
+		} else if err != nil {
+			log.Printf("[%s] Warning: failed to sync busy blocks to the work calendar: %v", destName, err)
+		}
+	}
+
+	// Optionally re-read what we wrote to catch events the server silently dropped
+	if s.config.VerifyWrites && !s.config.DryRun && len(written) > 0 {
+		failures, err := s.verifyWrites(destCalendarID, written, wideTimeMinForSync, wideTimeMaxForSync)
+		if err != nil {
+			log.Printf("[%s] Warning: write verification failed: %v", destName, err)
+		} else if len(failures) > 0 {
+			log.Printf("[%s] Write verification: %d of %d written event(s) did not round-trip", destName, len(failures), len(written))
+			for _, failure := range failures {
+				log.Printf("[%s]   - %s (workEventId: %s): %s", destName, failure.Summary, failure.WorkEventID, failure.Reason)
+			}
+		} else {
+			log.Printf("[%s] Write verification: all %d written event(s) read back intact", destName, len(written))
+		}
+		result.VerifyFailures = failures
+	}
+
+	// The run finished, so there is nothing left to resume
+	if err := journal.clear(); err != nil {
+		log.Printf("[%s] Warning: failed to clear progress journal: %v", destName, err)
+	}
+
+	if result.Resumed > 0 {
+		log.Printf("[%s] Resumed interrupted sync, skipped %d event(s) already written.", destName, result.Resumed)
+	}
+	if result.Withheld > 0 {
+		log.Printf("[%s] Withheld %d delete(s) until destructive syncs to this destination are acknowledged.", destName, result.Withheld)
+	}
+	if s.destination.ReverseBusyBlock {
+		log.Printf("[%s] Busy blocks on the work calendar: inserted %d, updated %d, deleted %d.",
+			destName, result.BlocksInserted, result.BlocksUpdated, result.BlocksDeleted)
+	}
+	if result.SafeModeSkipped > 0 {
+		log.Printf("[%s] Safe mode: skipped %d update(s) and delete(s).", destName, result.SafeModeSkipped)
+	}
+	if s.config.DryRun {
+		log.Printf("[%s] Dry run complete, no changes made: would insert %d, update %d, delete %d.",
+			destName, result.Inserted, result.Updated, result.Deleted)
+		return result, nil
+	}
+	log.Printf("[%s] Sync complete (inserted: %d, updated: %d, deleted: %d, failed: %d).",
+		destName, result.Inserted, result.Updated, result.Deleted, result.Failed)
+	return result, nil
 }
@@ -1,12 +1,26 @@
 package sync
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	gosync "sync"
 	"testing"
 	"time"
 
+	"github.com/beekhof/calendar-sync/internal/auth"
+	calclient "github.com/beekhof/calendar-sync/internal/calendar"
 	"github.com/beekhof/calendar-sync/internal/config"
+	"golang.org/x/oauth2"
 
 	"google.golang.org/api/calendar/v3"
 )
@@ -41,10 +55,21 @@ func (m *mockGoogleCalendarClient) FindOrCreateCalendarByName(name string, color
 	return newID, nil
 }
 
+func (m *mockGoogleCalendarClient) FindCalendarByName(name string) (string, error) {
+	if id, exists := m.calendars[name]; exists {
+		return id, nil
+	}
+	return "", calclient.ErrCalendarNotFound
+}
+
 func (m *mockGoogleCalendarClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
 	return m.events[calendarID], nil
 }
 
+func (m *mockGoogleCalendarClient) GetEventsUnexpanded(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	return m.events[calendarID], nil
+}
+
 func (m *mockGoogleCalendarClient) GetEvent(calendarID, eventID string) (*calendar.Event, error) {
 	// Search through all events in the calendar to find the event
 	if events, exists := m.events[calendarID]; exists {
@@ -102,6 +127,15 @@ func (m *mockGoogleCalendarClient) DeleteEvent(calendarID, eventID string) error
 	return nil
 }
 
+func (m *mockGoogleCalendarClient) ClearRange(calendarID string, timeMin, timeMax time.Time) error {
+	for _, e := range append([]*calendar.Event(nil), m.events[calendarID]...) {
+		if err := m.DeleteEvent(calendarID, e.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *mockGoogleCalendarClient) FindEventsByWorkID(calendarID, workEventID string) ([]*calendar.Event, error) {
 	var results []*calendar.Event
 	if events, exists := m.events[calendarID]; exists {
@@ -367,6 +401,345 @@ func TestFilterEvents_ExactEndtime(t *testing.T) {
 	}
 }
 
+func TestFilterEvents_DayWindow(t *testing.T) {
+	// 5:00 AM to 10:00 PM
+	startMinutes, endMinutes := 300, 1320
+	syncer := &Syncer{
+		workClient:  newMockGoogleCalendarClient(),
+		destination: &config.Destination{Name: "Test"},
+		config: &config.Config{
+			DayWindowStartMinutes: &startMinutes,
+			DayWindowEndMinutes:   &endMinutes,
+		},
+	}
+
+	at := func(day, hour, minute int) string {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+	tests := []struct {
+		name       string
+		start, end string
+		want       bool
+	}{
+		{"entirely before", at(15, 3, 0), at(15, 4, 0), false},
+		{"ends at window start", at(15, 4, 0), at(15, 5, 0), false},
+		{"overlaps window start", at(15, 4, 30), at(15, 5, 30), true},
+		{"starts at window start", at(15, 5, 0), at(15, 6, 0), true},
+		{"inside", at(15, 12, 0), at(15, 13, 0), true},
+		{"overlaps window end", at(15, 21, 30), at(15, 22, 30), true},
+		{"ends at window end", at(15, 21, 0), at(15, 22, 0), true},
+		{"starts at window end", at(15, 22, 0), at(15, 23, 0), false},
+		{"entirely after", at(15, 22, 30), at(15, 23, 30), false},
+		{"spans the window", at(15, 4, 0), at(15, 23, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &calendar.Event{
+				Id:      "event-1",
+				Summary: tt.name,
+				Start:   &calendar.EventDateTime{DateTime: tt.start},
+				End:     &calendar.EventDateTime{DateTime: tt.end},
+			}
+			if got := len(syncer.filterEvents([]*calendar.Event{event})) == 1; got != tt.want {
+				t.Errorf("Expected kept=%v for %s-%s, got kept=%v", tt.want, tt.start, tt.end, got)
+			}
+		})
+	}
+}
+
+func TestFilterEvents_HomeTimeZone(t *testing.T) {
+	syncer := &Syncer{
+		workClient:  newMockGoogleCalendarClient(),
+		destination: &config.Destination{Name: "Test"},
+		config:      &config.Config{HomeTimeZone: "Asia/Tokyo"},
+	}
+
+	at := func(hour int) *calendar.EventDateTime {
+		return &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)}
+	}
+	tests := []struct {
+		name  string
+		start int
+		want  bool
+	}{
+		// 2:00 UTC is 11:00 in Tokyo
+		{"morning in Tokyo", 2, true},
+		// 16:00 UTC is 1:00 the next day in Tokyo
+		{"night in Tokyo", 16, false},
+		// 20:00 UTC is 5:00 in Tokyo
+		{"before the window in Tokyo", 20, false},
+		// 21:00 UTC is 6:00 in Tokyo
+		{"window start in Tokyo", 21, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &calendar.Event{Id: "event-1", Summary: tt.name, Start: at(tt.start), End: at(tt.start + 1)}
+			if got := len(syncer.filterEvents([]*calendar.Event{event})) == 1; got != tt.want {
+				t.Errorf("Expected kept=%v for an event at %d:00 UTC, got kept=%v", tt.want, tt.start, got)
+			}
+		})
+	}
+}
+
+func TestFilterEvents_EventTimeZone(t *testing.T) {
+	syncer := &Syncer{
+		workClient:  newMockGoogleCalendarClient(),
+		destination: &config.Destination{Name: "Test"},
+		config:      &config.Config{},
+	}
+
+	tests := []struct {
+		name       string
+		start, end string
+		timeZone   string
+		want       bool
+	}{
+		// 9:30 UTC is 5:30 in New York, before the window there
+		{"early in New York", "2024-03-15T09:30:00Z", "2024-03-15T09:55:00Z", "America/New_York", false},
+		// 10:30 UTC is 6:30 in New York
+		{"morning in New York", "2024-03-15T10:30:00Z", "2024-03-15T11:00:00Z", "America/New_York", true},
+		// 1:30 at +04:00 is 6:30 in Tokyo
+		{"morning in Tokyo", "2024-03-15T01:30:00+04:00", "2024-03-15T02:00:00+04:00", "Asia/Tokyo", true},
+		// 0:30 at +04:00 is 5:30 in Tokyo
+		{"early in Tokyo", "2024-03-15T00:30:00+04:00", "2024-03-15T00:55:00+04:00", "Asia/Tokyo", false},
+		// Without a time zone the offset the times were written with applies
+		{"no time zone", "2024-03-15T01:30:00+04:00", "2024-03-15T02:00:00+04:00", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &calendar.Event{
+				Id:      "event-1",
+				Summary: tt.name,
+				Start:   &calendar.EventDateTime{DateTime: tt.start, TimeZone: tt.timeZone},
+				End:     &calendar.EventDateTime{DateTime: tt.end, TimeZone: tt.timeZone},
+			}
+			if got := len(syncer.filterEvents([]*calendar.Event{event})) == 1; got != tt.want {
+				t.Errorf("Expected kept=%v for an event at %s in %q, got kept=%v", tt.want, tt.start, tt.timeZone, got)
+			}
+		})
+	}
+
+	// The home time zone takes precedence over the event's
+	syncer.config.HomeTimeZone = "America/New_York"
+	event := &calendar.Event{
+		Id:    "event-2",
+		Start: &calendar.EventDateTime{DateTime: "2024-03-15T01:30:00+04:00", TimeZone: "Asia/Tokyo"},
+		End:   &calendar.EventDateTime{DateTime: "2024-03-15T02:00:00+04:00", TimeZone: "Asia/Tokyo"},
+	}
+	// 21:30 UTC is 17:30 in New York
+	if len(syncer.filterEvents([]*calendar.Event{event})) != 1 {
+		t.Errorf("Expected the event to be kept in the home time zone")
+	}
+}
+
+func TestTimeWindow_HomeTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Sunday 20:00 UTC is already Monday 5:00 in Tokyo, a new week there
+	now := time.Date(2024, 1, 21, 20, 0, 0, 0, time.UTC)
+	syncer := &Syncer{
+		destination: &config.Destination{Name: "Test"},
+		config:      &config.Config{SyncWindowWeeks: 1, HomeTimeZone: "Asia/Tokyo"},
+		now:         func() time.Time { return now },
+	}
+
+	timeMin, timeMax := syncer.timeWindow(syncer.currentTime())
+	if want := time.Date(2024, 1, 22, 0, 0, 0, 0, tokyo); !timeMin.Equal(want) {
+		t.Errorf("Expected the window to start at %v, got %v", want, timeMin)
+	}
+	if want := time.Date(2024, 1, 28, 23, 59, 59, 0, tokyo); !timeMax.Equal(want) {
+		t.Errorf("Expected the window to end at %v, got %v", want, timeMax)
+	}
+}
+
+func TestFilterEvents_MissingTimes(t *testing.T) {
+	syncer := &Syncer{
+		workClient:  newMockGoogleCalendarClient(),
+		destination: &config.Destination{Name: "Test"},
+		config:      &config.Config{SkipPastEvents: true, MaxAllDaySpanDays: 5},
+	}
+	valid := &calendar.EventDateTime{DateTime: time.Now().Add(time.Hour).Format(time.RFC3339)}
+
+	events := []*calendar.Event{
+		{Id: "empty-start", Summary: "Empty start", Start: &calendar.EventDateTime{}, End: valid},
+		{Id: "nil-start", Summary: "No start", End: valid},
+		{Id: "nil-end", Summary: "No end", Start: &calendar.EventDateTime{Date: "2024-01-15"}},
+		{Id: "nothing", Summary: "No times"},
+	}
+	filtered, dropped := syncer.filterEventsCounted(events)
+	if len(filtered) != 0 {
+		t.Errorf("Expected events without times to be skipped, got %d kept", len(filtered))
+	}
+	if dropped[FilterMissingTime] != len(events) {
+		t.Errorf("Expected %d events dropped for %s, got %v", len(events), FilterMissingTime, dropped)
+	}
+}
+
+func TestFilterEvents_ExcludeSummaryKeywords(t *testing.T) {
+	syncer := &Syncer{
+		workClient:  newMockGoogleCalendarClient(),
+		destination: &config.Destination{Name: "Test"},
+		config: &config.Config{
+			ExcludeSummaryKeywords: []string{"Lunch", "focus time"},
+		},
+	}
+
+	timed := func(id, summary string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC).Format(time.RFC3339)},
+		}
+	}
+	allDay := func(id, summary string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{Date: "2024-01-15"},
+			End:     &calendar.EventDateTime{Date: "2024-01-16"},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *calendar.Event
+		want  bool
+	}{
+		{"timed keyword", timed("lunch", "Team Lunch"), false},
+		{"timed keyword, other case", timed("focus", "FOCUS TIME (do not book)"), false},
+		{"all-day keyword", allDay("lunch-day", "lunch & learn"), false},
+		{"no keyword", timed("planning", "Sprint planning"), true},
+		{"all-day without keyword", allDay("holiday", "Public holiday"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(syncer.filterEvents([]*calendar.Event{tt.event})) == 1; got != tt.want {
+				t.Errorf("Expected kept=%v for %q, got kept=%v", tt.want, tt.event.Summary, got)
+			}
+		})
+	}
+}
+
+func TestFilterEvents_FilterExpression(t *testing.T) {
+	newEvent := func(id string, minutes, attendees int, organizer string) *calendar.Event {
+		start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		event := &calendar.Event{
+			Id:        id,
+			Summary:   id,
+			Start:     &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:       &calendar.EventDateTime{DateTime: start.Add(time.Duration(minutes) * time.Minute).Format(time.RFC3339)},
+			Organizer: &calendar.EventOrganizer{Email: organizer},
+		}
+		for range attendees {
+			event.Attendees = append(event.Attendees, &calendar.EventAttendee{Email: "guest@example.com"})
+		}
+		return event
+	}
+	events := []*calendar.Event{
+		newEvent("1:1", 30, 2, "boss@example.com"),
+		newEvent("reminder", 5, 0, "me@example.com"),
+		newEvent("vendor call", 60, 3, "sales@vendor.io"),
+		{
+			Id:      "holiday",
+			Summary: "holiday",
+			Start:   &calendar.EventDateTime{Date: "2024-01-15"},
+			End:     &calendar.EventDateTime{Date: "2024-01-16"},
+		},
+	}
+
+	tests := []struct {
+		expression string
+		want       []string
+	}{
+		{"durationMinutes >= 15 && (attendeeCount > 1 || isAllDay)", []string{"1:1", "vendor call", "holiday"}},
+		{`organizerDomain != "vendor.io" && !isAllDay`, []string{"1:1", "reminder"}},
+		{`summary contains "call"`, []string{"vendor call"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			syncer := &Syncer{
+				workClient:  newMockGoogleCalendarClient(),
+				destination: &config.Destination{Name: "Test"},
+				config:      &config.Config{FilterExpression: tt.expression},
+			}
+
+			filtered, dropped := syncer.filterEventsCounted(events)
+			var got []string
+			for _, event := range filtered {
+				got = append(got, event.Id)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+			if dropped[FilterExpression] != len(events)-len(tt.want) {
+				t.Errorf("Expected %d events dropped for %s, got %v", len(events)-len(tt.want), FilterExpression, dropped)
+			}
+		})
+	}
+}
+
+func TestSync_CountsFilteredEventsByReason(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks:  2,
+		WorkEmail:        "user@example.com",
+		SkipVisibilities: []string{"confidential"},
+	}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+
+	at := func(hour int) *calendar.EventDateTime {
+		return &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, hour, 0, 0, 0, time.UTC).Format(time.RFC3339)}
+	}
+	event := func(id string, start, end int) *calendar.Event {
+		return &calendar.Event{Id: id, Summary: id, Start: at(start), End: at(end)}
+	}
+
+	cancelled := event("cancelled", 10, 11)
+	cancelled.Status = "cancelled"
+	declined := event("declined", 10, 11)
+	declined.Attendees = []*calendar.EventAttendee{{Email: "user@example.com", ResponseStatus: "declined"}}
+	confidential := event("confidential", 10, 11)
+	confidential.Visibility = "confidential"
+	location := &calendar.Event{Id: "location", EventType: "workingLocation", Start: &calendar.EventDateTime{Date: "2024-01-15"}, End: &calendar.EventDateTime{Date: "2024-01-16"}}
+	oof := event("oof", 12, 13)
+	oof.EventType = "outOfOffice"
+	badTime := &calendar.Event{Id: "bad-time", Start: &calendar.EventDateTime{DateTime: "soon"}, End: at(11)}
+
+	workClient.events["primary"] = []*calendar.Event{
+		event("kept", 10, 11),
+		cancelled, declined, confidential, location, oof, badTime,
+		event("early", 3, 4),
+		event("late-1", 0, 2),
+	}
+
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	want := map[string]int{
+		FilterCancelled:       1,
+		FilterDeclined:        1,
+		FilterVisibility:      1,
+		FilterWorkingLocation: 1,
+		FilterOutOfOffice:     1,
+		FilterInvalidTime:     1,
+		FilterOutsideWindow:   2,
+	}
+	if !maps.Equal(result.Filtered, want) {
+		t.Errorf("Expected filter counts %v, got %v", want, result.Filtered)
+	}
+	if result.Inserted != 1 {
+		t.Errorf("Expected only the kept event to be inserted, got %d inserts", result.Inserted)
+	}
+}
+
 func TestFilterEvents_CancelledAndDeclined(t *testing.T) {
 	mockClient := newMockGoogleCalendarClient()
 	dest := &config.Destination{Name: "Test"}
@@ -462,7 +835,7 @@ func TestSync_NewEvent(t *testing.T) {
 	workClient.events["primary"] = []*calendar.Event{workEvent}
 
 	ctx := context.Background()
-	err := syncer.Sync(ctx)
+	_, err := syncer.Sync(ctx)
 	if err != nil {
 		t.Fatalf("Sync() returned an error: %v", err)
 	}
@@ -520,7 +893,7 @@ func TestSync_DeletedEvent(t *testing.T) {
 	workClient.events["primary"] = []*calendar.Event{}
 
 	ctx := context.Background()
-	err := syncer.Sync(ctx)
+	_, err := syncer.Sync(ctx)
 	if err != nil {
 		t.Fatalf("Sync() returned an error: %v", err)
 	}
@@ -535,6 +908,65 @@ func TestSync_DeletedEvent(t *testing.T) {
 	}
 }
 
+func TestSync_FirstRunWithholdsDeletesUntilAcknowledged(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks:      2,
+		AcknowledgementsPath: filepath.Join(t.TempDir(), "acknowledgements.json"),
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	staleEvent := func(id string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: "Old Meeting",
+			Start:   &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": "work-" + id},
+			},
+		}
+	}
+	personalClient.events[destCalendarID] = []*calendar.Event{staleEvent("stale-1")}
+
+	// The first run, without the flag and without a terminal to confirm on, deletes nothing
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if len(personalClient.deletedEventIDs) != 0 || result.Withheld != 1 {
+		t.Fatalf("Expected the delete to be withheld, got deletes %v and %d withheld", personalClient.deletedEventIDs, result.Withheld)
+	}
+
+	// With the flag, the second run deletes and stores the acknowledgement
+	cfg.AcknowledgeDestructive = true
+	result, err = NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if !slices.Equal(personalClient.deletedEventIDs, []string{"stale-1"}) || result.Withheld != 0 {
+		t.Fatalf("Expected stale-1 to be deleted, got deletes %v and %d withheld", personalClient.deletedEventIDs, result.Withheld)
+	}
+
+	// Later runs don't need the flag
+	cfg.AcknowledgeDestructive = false
+	personalClient.events[destCalendarID] = []*calendar.Event{staleEvent("stale-2")}
+	if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if !slices.Equal(personalClient.deletedEventIDs, []string{"stale-1", "stale-2"}) {
+		t.Errorf("Expected stale-2 to be deleted without the flag, got deletes %v", personalClient.deletedEventIDs)
+	}
+}
+
 func TestSync_UnchangedEvent(t *testing.T) {
 	workClient := newMockGoogleCalendarClient()
 	personalClient := newMockGoogleCalendarClient()
@@ -583,7 +1015,7 @@ func TestSync_UnchangedEvent(t *testing.T) {
 	personalClient.events[destCalendarID] = []*calendar.Event{destEvent}
 
 	ctx := context.Background()
-	err := syncer.Sync(ctx)
+	_, err := syncer.Sync(ctx)
 	if err != nil {
 		t.Fatalf("Sync() returned an error: %v", err)
 	}
@@ -642,7 +1074,7 @@ func TestSync_ChangedEvent(t *testing.T) {
 	personalClient.events[destCalendarID] = []*calendar.Event{destEvent}
 
 	ctx := context.Background()
-	err := syncer.Sync(ctx)
+	_, err := syncer.Sync(ctx)
 	if err != nil {
 		t.Fatalf("Sync() returned an error: %v", err)
 	}
@@ -657,3 +1089,3217 @@ func TestSync_ChangedEvent(t *testing.T) {
 		t.Errorf("Expected updated event summary to be 'Work Meeting Updated', got '%s'", updated.Summary)
 	}
 }
+
+func TestSync_DryRunReportsChangesWithoutWriting(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		DryRun:          true,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		Type:            "google",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	newTimedEvent := func(id, summary string, hour int, workID string) *calendar.Event {
+		eventStart := start.Add(time.Duration(hour) * time.Hour)
+		event := &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: eventStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: eventStart.Add(time.Hour).Format(time.RFC3339)},
+		}
+		if workID != "" {
+			event.ExtendedProperties = &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": workID},
+			}
+		}
+		return event
+	}
+
+	workClient.events["primary"] = []*calendar.Event{
+		newTimedEvent("work-new", "New Meeting", 0, ""),
+		newTimedEvent("work-changed", "Renamed Meeting", 2, ""),
+	}
+
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		newTimedEvent("dest-changed", "Old Meeting", 2, "work-changed"),
+		newTimedEvent("dest-stale", "Cancelled Meeting", 4, "work-gone"),
+		// Manually created: listed instead of prompting for confirmation
+		newTimedEvent("dest-manual", "Dentist", 6, ""),
+	}
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if result.Inserted != 1 || result.Updated != 1 || result.Deleted != 2 {
+		t.Errorf("Expected would insert 1, update 1, delete 2, got insert %d, update %d, delete %d",
+			result.Inserted, result.Updated, result.Deleted)
+	}
+	if len(personalClient.insertedEvents) != 0 || len(personalClient.updatedEvents) != 0 || len(personalClient.deletedEventIDs) != 0 {
+		t.Errorf("Expected no writes in dry-run mode, got %d inserts, %d updates, %d deletes",
+			len(personalClient.insertedEvents), len(personalClient.updatedEvents), len(personalClient.deletedEventIDs))
+	}
+}
+
+// TestSync_SafeModeOnlyInserts verifies that in safe mode new events are
+// inserted while changed, stale and manually created ones are left alone.
+func TestSync_SafeModeOnlyInserts(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		SafeMode:        true,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		Type:            "google",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	newTimedEvent := func(id, summary string, hour int, workID string) *calendar.Event {
+		eventStart := start.Add(time.Duration(hour) * time.Hour)
+		event := &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: eventStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: eventStart.Add(time.Hour).Format(time.RFC3339)},
+		}
+		if workID != "" {
+			event.ExtendedProperties = &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": workID},
+			}
+		}
+		return event
+	}
+
+	workClient.events["primary"] = []*calendar.Event{
+		newTimedEvent("work-new", "New Meeting", 0, ""),
+		newTimedEvent("work-changed", "Renamed Meeting", 2, ""),
+	}
+
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		newTimedEvent("dest-changed", "Old Meeting", 2, "work-changed"),
+		newTimedEvent("dest-stale", "Cancelled Meeting", 4, "work-gone"),
+		// Manually created: kept without prompting for confirmation
+		newTimedEvent("dest-manual", "Dentist", 6, ""),
+	}
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if len(personalClient.insertedEvents) != 1 || personalClient.insertedEvents[0].Summary != "New Meeting" {
+		t.Errorf("Expected only 'New Meeting' to be inserted, got %v", personalClient.insertedEvents)
+	}
+	if len(personalClient.updatedEvents) != 0 || len(personalClient.deletedEventIDs) != 0 {
+		t.Errorf("Expected no updates or deletes in safe mode, got %d updates, %d deletes",
+			len(personalClient.updatedEvents), len(personalClient.deletedEventIDs))
+	}
+	if result.Inserted != 1 || result.Updated != 0 || result.Deleted != 0 || result.SafeModeSkipped != 3 {
+		t.Errorf("Expected insert 1 and skip 3, got insert %d, update %d, delete %d, skipped %d",
+			result.Inserted, result.Updated, result.Deleted, result.SafeModeSkipped)
+	}
+}
+
+func TestSync_ReverseBusyBlock(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	dest := &config.Destination{
+		Name:             "Test",
+		Type:             "google",
+		CalendarName:     "Work Sync",
+		CalendarColorID:  "7",
+		ReverseBusyBlock: true,
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	newTimedEvent := func(id, summary string, hour int) *calendar.Event {
+		eventStart := start.Add(time.Duration(hour) * time.Hour)
+		return &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: eventStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: eventStart.Add(time.Hour).Format(time.RFC3339)},
+		}
+	}
+
+	workClient.events["primary"] = []*calendar.Event{newTimedEvent("work-1", "Standup", 0)}
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	free := newTimedEvent("dest-free", "Lunch maybe", 4)
+	free.Transparency = "transparent"
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		newTimedEvent("dest-dentist", "Dentist", 2),
+		newTimedEvent("dest-gym", "Gym", 6),
+		free,
+	}
+
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	// Personal events are kept, and only the work event is copied
+	if len(personalClient.deletedEventIDs) != 0 || result.Inserted != 1 {
+		t.Errorf("Expected personal events kept and 1 insert, got deletes %v, %d inserts", personalClient.deletedEventIDs, result.Inserted)
+	}
+	var blocked []string
+	for _, event := range workClient.insertedEvents {
+		if event.Summary != "Busy (personal)" || event.Transparency != "opaque" {
+			t.Errorf("Expected an opaque 'Busy (personal)' block, got %q (%s)", event.Summary, event.Transparency)
+		}
+		blocked = append(blocked, getDestEventID(event))
+	}
+	if want := []string{"dest-dentist", "dest-gym"}; !slices.Equal(blocked, want) {
+		t.Fatalf("Expected busy blocks for %v, got %v", want, blocked)
+	}
+
+	// The dentist moves and the gym is cancelled: the blocks follow, and
+	// aren't copied back to the destination
+	for i, block := range workClient.insertedEvents {
+		block.Id = fmt.Sprintf("block-%d", i)
+	}
+	personalClient.events[destCalendarID] = append([]*calendar.Event{newTimedEvent("dest-dentist", "Dentist", 3)},
+		personalClient.events[destCalendarID][1:]...)
+	personalClient.DeleteEvent(destCalendarID, "dest-gym")
+	personalClient.insertedEvents, personalClient.deletedEventIDs = nil, nil
+	workClient.insertedEvents = nil
+
+	result, err = NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Filtered[FilterBusyBlock] != 2 || result.Inserted != 0 {
+		t.Errorf("Expected both busy blocks filtered from the sync, got %d filtered, %d inserted", result.Filtered[FilterBusyBlock], result.Inserted)
+	}
+	if result.BlocksInserted != 0 || result.BlocksUpdated != 1 || result.BlocksDeleted != 1 {
+		t.Errorf("Expected 1 block updated and 1 deleted, got inserted %d, updated %d, deleted %d",
+			result.BlocksInserted, result.BlocksUpdated, result.BlocksDeleted)
+	}
+	if !slices.Equal(workClient.deletedEventIDs, []string{"block-1"}) {
+		t.Errorf("Expected the gym's block to be deleted, got %v", workClient.deletedEventIDs)
+	}
+}
+
+func TestSync_PreserveTagKeepsEvents(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{SyncWindowWeeks: 2, PreserveTag: []string{"keepMe"}}
+	dest := &config.Destination{Name: "Test", Type: "google", CalendarName: "Work Sync", CalendarColorID: "7"}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	newTimedEvent := func(id, summary string, hour int, private map[string]string) *calendar.Event {
+		eventStart := start.Add(time.Duration(hour) * time.Hour)
+		event := &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: eventStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: eventStart.Add(time.Hour).Format(time.RFC3339)},
+		}
+		if private != nil {
+			event.ExtendedProperties = &calendar.EventExtendedProperties{Private: private}
+		}
+		return event
+	}
+
+	workClient.events["primary"] = []*calendar.Event{newTimedEvent("work-1", "Standup", 0, nil)}
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		newTimedEvent("dest-note", "Prep notes", 2, map[string]string{"keepMe": "1"}),
+		// Matches the work event, but is kept rather than adopted as its copy
+		newTimedEvent("dest-standup", "Standup", 0, map[string]string{"keepMe": "true"}),
+	}
+
+	// Nothing is left to delete, so the sync doesn't ask (and fail headless)
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if len(personalClient.deletedEventIDs) != 0 || len(personalClient.updatedEvents) != 0 {
+		t.Errorf("Expected preserved events untouched, got deletes %v and %d update(s)", personalClient.deletedEventIDs, len(personalClient.updatedEvents))
+	}
+	if result.Inserted != 1 {
+		t.Errorf("Expected the work event to get its own copy, got %d insert(s)", result.Inserted)
+	}
+}
+
+// TestSync_CalendarPath verifies that a destination with a calendar_path
+// syncs into that calendar without looking it up, or creating one, by name.
+func TestSync_CalendarPath(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	calendarPath := "/123456/calendars/0A1B2C/"
+	dest := &config.Destination{Name: "Test", Type: "apple", CalendarName: "Work Sync", CalendarPath: calendarPath}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	workClient.events["primary"] = []*calendar.Event{{
+		Id:      "work-1",
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+	}}
+	// The calendar was renamed, so the name finds nothing
+	personalClient.events[calendarPath] = []*calendar.Event{}
+
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Inserted != 1 || len(personalClient.events[calendarPath]) != 1 {
+		t.Errorf("Expected the work event inserted into %s, got %d insert(s) and events %v", calendarPath, result.Inserted, personalClient.events)
+	}
+	if len(personalClient.calendars) != 0 {
+		t.Errorf("Expected no calendar found or created by name, got %v", personalClient.calendars)
+	}
+}
+
+// TestSync_DryRunDoesNotCreateCalendar verifies that a dry run against a
+// calendar that doesn't exist yet leaves it uncreated and reports every event
+// as an insert.
+func TestSync_DryRunDoesNotCreateCalendar(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		DryRun:          true,
+	}
+	dest := &config.Destination{
+		Name:         "Test",
+		Type:         "google",
+		CalendarName: "Work Sync",
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-1",
+			Summary: "Standup",
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		},
+		{
+			Id:      "work-2",
+			Summary: "Planning",
+			Start:   &calendar.EventDateTime{DateTime: start.Add(2 * time.Hour).Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(3 * time.Hour).Format(time.RFC3339)},
+		},
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if _, exists := personalClient.calendars["Work Sync"]; exists {
+		t.Error("Expected the dry run not to create the destination calendar")
+	}
+	if result.Inserted != 2 {
+		t.Errorf("Expected would insert 2, got %d", result.Inserted)
+	}
+	if len(personalClient.insertedEvents) != 0 {
+		t.Errorf("Expected no writes in dry-run mode, got %d inserts", len(personalClient.insertedEvents))
+	}
+}
+
+// lossyCalendarClient accepts every insert but silently drops the event with the
+// given workEventId, simulating a CalDAV server that loses writes.
+type lossyCalendarClient struct {
+	*mockGoogleCalendarClient
+	loseWorkID string
+}
+
+func (m *lossyCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	if event.ExtendedProperties != nil && event.ExtendedProperties.Private["workEventId"] == m.loseWorkID {
+		m.insertedEvents = append(m.insertedEvents, event)
+		return nil
+	}
+	return m.mockGoogleCalendarClient.InsertEvent(calendarID, event)
+}
+
+func TestSync_MovedRecurringInstanceUpdatesMirror(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	originalStart := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	movedStart := originalStart.Add(4 * time.Hour)
+	stableID := "series1_" + originalStart.Format("20060102T150405Z")
+
+	// The instance has been moved, and Google gave it a new instance ID
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:                "series1_modified_instance",
+			RecurringEventId:  "series1",
+			OriginalStartTime: &calendar.EventDateTime{DateTime: originalStart.Format(time.RFC3339)},
+			Summary:           "Standup",
+			Start:             &calendar.EventDateTime{DateTime: movedStart.Format(time.RFC3339)},
+			End:               &calendar.EventDateTime{DateTime: movedStart.Add(30 * time.Minute).Format(time.RFC3339)},
+		},
+	}
+
+	// The mirror synced before the move
+	personalClient.calendars["Work Sync"] = "cal_123"
+	personalClient.events["cal_123"] = []*calendar.Event{
+		{
+			Id:      "dest-1",
+			Summary: "Standup",
+			Start:   &calendar.EventDateTime{DateTime: originalStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: originalStart.Add(30 * time.Minute).Format(time.RFC3339)},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": stableID},
+			},
+		},
+	}
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if result.Updated != 1 || result.Inserted != 0 || result.Deleted != 0 {
+		t.Errorf("Expected 1 update and no inserts or deletes, got inserted %d, updated %d, deleted %d",
+			result.Inserted, result.Updated, result.Deleted)
+	}
+	events := personalClient.events["cal_123"]
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 mirrored event, got %d", len(events))
+	}
+	if events[0].Start.DateTime != movedStart.Format(time.RFC3339) {
+		t.Errorf("Expected mirror to move to %s, got %s", movedStart.Format(time.RFC3339), events[0].Start.DateTime)
+	}
+	if got := getWorkEventID(events[0]); got != stableID {
+		t.Errorf("Expected workEventId %q, got %q", stableID, got)
+	}
+}
+
+func TestSync_UnexpandedRecurringSeries(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	expand := false
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		ExpandRecurring: &expand,
+		SkipPastEvents:  true,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	// The series started before now, so its first instance has already ended
+	seriesStart := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	movedOriginal := seriesStart.AddDate(0, 0, 1)
+	movedStart := movedOriginal.Add(4*time.Hour).AddDate(0, 0, 7)
+	cancelledOriginal := seriesStart.AddDate(0, 0, 2)
+
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:         "series1",
+			Summary:    "Standup",
+			Start:      &calendar.EventDateTime{DateTime: seriesStart.Format(time.RFC3339)},
+			End:        &calendar.EventDateTime{DateTime: seriesStart.Add(30 * time.Minute).Format(time.RFC3339)},
+			Recurrence: []string{"RRULE:FREQ=DAILY"},
+		},
+		{
+			Id:                "series1_moved",
+			RecurringEventId:  "series1",
+			OriginalStartTime: &calendar.EventDateTime{DateTime: movedOriginal.Format(time.RFC3339)},
+			Summary:           "Standup",
+			Start:             &calendar.EventDateTime{DateTime: movedStart.Format(time.RFC3339)},
+			End:               &calendar.EventDateTime{DateTime: movedStart.Add(30 * time.Minute).Format(time.RFC3339)},
+		},
+		{
+			Id:                "series1_cancelled",
+			RecurringEventId:  "series1",
+			OriginalStartTime: &calendar.EventDateTime{DateTime: cancelledOriginal.Format(time.RFC3339)},
+			Status:            "cancelled",
+		},
+	}
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Fatalf("Expected the series and the moved instance to be inserted, got %d inserts", result.Inserted)
+	}
+
+	var master *calendar.Event
+	for _, event := range personalClient.insertedEvents {
+		if getWorkEventID(event) == "series1" {
+			master = event
+		}
+	}
+	if master == nil {
+		t.Fatalf("Expected a copy of the series tagged with the master's workEventId")
+	}
+	want := []string{
+		"RRULE:FREQ=DAILY",
+		"EXDATE:" + movedOriginal.Format("20060102T150405Z"),
+		"EXDATE:" + cancelledOriginal.Format("20060102T150405Z"),
+	}
+	if !slices.Equal(master.Recurrence, want) {
+		t.Errorf("Expected recurrence %v, got %v", want, master.Recurrence)
+	}
+	if len(workClient.events["primary"][0].Recurrence) != 1 {
+		t.Errorf("Expected the source series to be left unchanged, got %v", workClient.events["primary"][0].Recurrence)
+	}
+
+	// A second run finds the series in sync
+	result, err = syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Inserted != 0 || result.Updated != 0 || result.Deleted != 0 {
+		t.Errorf("Expected no changes on the second run, got inserted %d, updated %d, deleted %d",
+			result.Inserted, result.Updated, result.Deleted)
+	}
+}
+
+func TestSourceWorkID_RecurringInstances(t *testing.T) {
+	instance := func(id, originalStart, originalDate string) *calendar.Event {
+		return &calendar.Event{
+			Id:                id,
+			RecurringEventId:  "series1",
+			OriginalStartTime: &calendar.EventDateTime{DateTime: originalStart, Date: originalDate},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *calendar.Event
+		want  string
+	}{
+		{
+			name:  "first instance",
+			event: instance("series1_20240115T100000Z", "2024-01-15T10:00:00Z", ""),
+			want:  "series1_20240115T100000Z",
+		},
+		{
+			name:  "second instance",
+			event: instance("series1_20240122T100000Z", "2024-01-22T10:00:00Z", ""),
+			want:  "series1_20240122T100000Z",
+		},
+		{
+			name:  "moved instance keeps its original start",
+			event: instance("series1_modified", "2024-01-29T10:00:00Z", ""),
+			want:  "series1_20240129T100000Z",
+		},
+		{
+			name:  "original start with offset is normalized to UTC",
+			event: instance("series1_20240205T100000Z", "2024-02-05T11:00:00+01:00", ""),
+			want:  "series1_20240205T100000Z",
+		},
+		{
+			name:  "all-day instance",
+			event: instance("series1_20240115", "", "2024-01-15"),
+			want:  "series1_20240115",
+		},
+		{
+			name:  "single event uses its ID",
+			event: &calendar.Event{Id: "single-1"},
+			want:  "single-1",
+		},
+		{
+			name:  "instance without original start falls back to its ID",
+			event: &calendar.Event{Id: "series1_x", RecurringEventId: "series1"},
+			want:  "series1_x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourceWorkID(tt.event); got != tt.want {
+				t.Errorf("sourceWorkID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync_VerifyWritesReportsLostEvent(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &lossyCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		loseWorkID:               "work-lost",
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		VerifyWrites:    true,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-kept",
+			Summary: "Kept Meeting",
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		},
+		{
+			Id:      "work-lost",
+			Summary: "Lost Meeting",
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		},
+	}
+
+	ctx := context.Background()
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if result.Inserted != 2 {
+		t.Errorf("Expected 2 inserts to be reported, got %d", result.Inserted)
+	}
+
+	if len(result.VerifyFailures) != 1 {
+		t.Fatalf("Expected 1 verification failure, got %d: %+v", len(result.VerifyFailures), result.VerifyFailures)
+	}
+
+	failure := result.VerifyFailures[0]
+	if failure.WorkEventID != "work-lost" {
+		t.Errorf("Expected verification failure for 'work-lost', got '%s'", failure.WorkEventID)
+	}
+	if failure.Reason != "missing" {
+		t.Errorf("Expected verification failure reason 'missing', got '%s'", failure.Reason)
+	}
+}
+
+// cancellingCalendarClient cancels the sync context as soon as the first event
+// has been inserted, simulating a SIGINT arriving mid-run.
+type cancellingCalendarClient struct {
+	*mockGoogleCalendarClient
+	cancel context.CancelFunc
+}
+
+func (m *cancellingCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	err := m.mockGoogleCalendarClient.InsertEvent(calendarID, event)
+	m.cancel()
+	return err
+}
+
+func TestSync_CancelledMidRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &cancellingCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		cancel:                   cancel,
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	for i := 0; i < 3; i++ {
+		workClient.events["primary"] = append(workClient.events["primary"], &calendar.Event{
+			Id:      fmt.Sprintf("work-%d", i),
+			Summary: fmt.Sprintf("Meeting %d", i),
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 10+i, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 11+i, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		})
+	}
+
+	result, err := syncer.Sync(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Sync() to return context.Canceled, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected a partial SyncResult, got nil")
+	}
+	if !result.Cancelled {
+		t.Error("Expected SyncResult to be marked as cancelled")
+	}
+	if result.Inserted != 1 {
+		t.Errorf("Expected exactly 1 insert before cancellation, got %d", result.Inserted)
+	}
+	if len(personalClient.insertedEvents) != 1 {
+		t.Errorf("Expected InsertEvent to be called once, but got %d calls", len(personalClient.insertedEvents))
+	}
+}
+
+func TestFilterEvents_SkipPastEvents(t *testing.T) {
+	mockClient := newMockGoogleCalendarClient()
+	dest := &config.Destination{Name: "Test"}
+	now := time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)
+	syncer := &Syncer{
+		workClient:  mockClient,
+		destination: dest,
+		config:      &config.Config{SkipPastEvents: true},
+		now:         func() time.Time { return now },
+	}
+
+	events := []*calendar.Event{
+		{
+			Id:      "past-1",
+			Summary: "Morning Standup",
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		},
+		{
+			Id:      "past-allday",
+			Summary: "Yesterday's Offsite",
+			Start: &calendar.EventDateTime{
+				Date: "2024-01-14",
+			},
+			End: &calendar.EventDateTime{
+				Date: "2024-01-15",
+			},
+		},
+		{
+			Id:      "future-1",
+			Summary: "Afternoon Review",
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 16, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		},
+		{
+			Id:      "today-allday",
+			Summary: "Team Day",
+			Start: &calendar.EventDateTime{
+				Date: "2024-01-15",
+			},
+			End: &calendar.EventDateTime{
+				Date: "2024-01-16",
+			},
+		},
+	}
+
+	filtered := syncer.filterEvents(events)
+
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 events to be kept, but got %d", len(filtered))
+	}
+	if filtered[0].Id != "future-1" || filtered[1].Id != "today-allday" {
+		t.Errorf("Expected 'future-1' and 'today-allday' to be kept, got '%s' and '%s'", filtered[0].Id, filtered[1].Id)
+	}
+}
+
+func TestSync_SkipPastEventsRemovesSyncedPastEvent(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+		SkipPastEvents:  true,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+	now := time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)
+	syncer.now = func() time.Time { return now }
+
+	pastStart := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	pastEnd := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-past",
+			Summary: "Morning Meeting",
+			Start:   &calendar.EventDateTime{DateTime: pastStart},
+			End:     &calendar.EventDateTime{DateTime: pastEnd},
+		},
+		{
+			Id:      "work-future",
+			Summary: "Afternoon Meeting",
+			Start: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+			End: &calendar.EventDateTime{
+				DateTime: time.Date(2024, 1, 15, 16, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		},
+	}
+
+	// The past event was synced on an earlier run
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		{
+			Id:      "dest-past",
+			Summary: "Morning Meeting",
+			Start:   &calendar.EventDateTime{DateTime: pastStart},
+			End:     &calendar.EventDateTime{DateTime: pastEnd},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{
+					"workEventId": "work-past",
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	_, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if len(personalClient.deletedEventIDs) != 1 || personalClient.deletedEventIDs[0] != "dest-past" {
+		t.Errorf("Expected the synced past event 'dest-past' to be deleted, got %v", personalClient.deletedEventIDs)
+	}
+	if len(personalClient.insertedEvents) != 1 {
+		t.Fatalf("Expected only the future event to be inserted, but got %d inserts", len(personalClient.insertedEvents))
+	}
+	if workID := personalClient.insertedEvents[0].ExtendedProperties.Private["workEventId"]; workID != "work-future" {
+		t.Errorf("Expected inserted event to be 'work-future', got '%s'", workID)
+	}
+}
+
+func TestSync_RedactPrivacyMode(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	for _, placeholder := range []string{"", "Unavailable"} {
+		workClient := newMockGoogleCalendarClient()
+		personalClient := newMockGoogleCalendarClient()
+		workClient.events["primary"] = []*calendar.Event{
+			{
+				Id:          "work-1",
+				Summary:     "Acquisition talks",
+				Description: "Confidential",
+				Location:    "Board room",
+				Start:       &calendar.EventDateTime{DateTime: start},
+				End:         &calendar.EventDateTime{DateTime: end},
+				Attendees:   []*calendar.EventAttendee{{Email: "ceo@example.com"}},
+				ConferenceData: &calendar.ConferenceData{
+					EntryPoints: []*calendar.EntryPoint{{EntryPointType: "video", Uri: "https://meet.google.com/abc-defg-hij"}},
+				},
+				ExtendedProperties: &calendar.EventExtendedProperties{
+					Shared: map[string]string{calclient.GeoPropertyKey: "37.422000;-122.084100"},
+				},
+			},
+		}
+
+		cfg := &config.Config{SyncWindowWeeks: 2}
+		dest := &config.Destination{Name: "Family", CalendarName: "Work Sync", PrivacyMode: "redact", PrivacyPlaceholder: placeholder}
+		if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+			t.Fatalf("Sync() returned an error: %v", err)
+		}
+		if len(personalClient.insertedEvents) != 1 {
+			t.Fatalf("Expected 1 inserted event, got %d", len(personalClient.insertedEvents))
+		}
+
+		wantSummary := placeholder
+		if wantSummary == "" {
+			wantSummary = "Busy"
+		}
+		got := personalClient.insertedEvents[0]
+		if got.Summary != wantSummary {
+			t.Errorf("Expected summary %q, got %q", wantSummary, got.Summary)
+		}
+		if got.Description != "" || got.Location != "" || got.ConferenceData != nil || len(got.Attendees) != 0 {
+			t.Errorf("Expected event details to be redacted, got %+v", got)
+		}
+		if got.Start.DateTime != start || got.End.DateTime != end {
+			t.Errorf("Expected times %s-%s, got %s-%s", start, end, got.Start.DateTime, got.End.DateTime)
+		}
+		if want := map[string]string{"workEventId": "work-1"}; !maps.Equal(got.ExtendedProperties.Private, want) {
+			t.Errorf("Expected private properties %v, got %v", want, got.ExtendedProperties.Private)
+		}
+		if _, ok := got.ExtendedProperties.Shared[calclient.GeoPropertyKey]; ok {
+			t.Errorf("Expected geo to be redacted")
+		}
+
+		// The redacted copy is what the next run prepares, so it is left alone
+		result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+		if err != nil {
+			t.Fatalf("Sync() returned an error: %v", err)
+		}
+		if result.Inserted != 0 || result.Updated != 0 || result.Deleted != 0 || result.Unchanged != 1 {
+			t.Errorf("Expected no changes on the second run, got inserted %d, updated %d, deleted %d, unchanged %d",
+				result.Inserted, result.Updated, result.Deleted, result.Unchanged)
+		}
+	}
+}
+
+func TestPrepareSyncEvent_CopyAttendees(t *testing.T) {
+	sourceEvent := &calendar.Event{
+		Id:      "work-1",
+		Summary: "Design Review",
+		Attendees: []*calendar.EventAttendee{
+			{Email: "alice@work.example", DisplayName: "Alice", ResponseStatus: "accepted"},
+			{Email: "bob@work.example", ResponseStatus: "declined", Optional: true},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		copyAttendees bool
+		destType      string
+		privacyMode   string
+		want          []string
+	}{
+		{name: "off", destType: "google", privacyMode: "full"},
+		{name: "google", copyAttendees: true, destType: "google", privacyMode: "full",
+			want: []string{"alice@work.example Alice accepted", "bob@work.example  declined"}},
+		{name: "redacted", copyAttendees: true, destType: "google", privacyMode: "redact",
+			want: []string{hashedEmail("alice@work.example") + "  accepted", hashedEmail("bob@work.example") + "  declined"}},
+		{name: "apple has no guest list", copyAttendees: true, destType: "apple", privacyMode: "full"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := &Syncer{
+				config:      &config.Config{CopyAttendees: tt.copyAttendees},
+				destination: &config.Destination{Name: "Test", Type: tt.destType, PrivacyMode: tt.privacyMode},
+			}
+			prepared := syncer.prepareSyncEvent(sourceEvent)
+
+			var got []string
+			for _, attendee := range prepared.Attendees {
+				got = append(got, attendee.Email+" "+attendee.DisplayName+" "+attendee.ResponseStatus)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected attendees %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if hashedEmail("Alice@Work.example") != hashedEmail("alice@work.example") || strings.Contains(hashedEmail("alice@work.example"), "alice") {
+		t.Errorf("Expected a case-insensitive hash hiding the address, got %s", hashedEmail("alice@work.example"))
+	}
+}
+
+func TestSyncedEventsEqual_ComparesAttendees(t *testing.T) {
+	newEvent := func(attendees ...*calendar.EventAttendee) *calendar.Event {
+		return &calendar.Event{Summary: "Design Review", Attendees: attendees}
+	}
+	alice := &calendar.EventAttendee{Email: "alice@work.example", ResponseStatus: "accepted"}
+	aliceUpper := &calendar.EventAttendee{Email: "Alice@work.example", ResponseStatus: "accepted"}
+	aliceTentative := &calendar.EventAttendee{Email: "alice@work.example", ResponseStatus: "tentative"}
+	bob := &calendar.EventAttendee{Email: "bob@work.example"}
+	bobPending := &calendar.EventAttendee{Email: "bob@work.example", ResponseStatus: "needsAction"}
+
+	syncer := &Syncer{config: &config.Config{CopyAttendees: true}, destination: &config.Destination{Name: "Test", Type: "google"}}
+	if equal, field := syncer.syncedEventsEqual(newEvent(alice, bob), newEvent(bobPending, aliceUpper)); !equal {
+		t.Errorf("Expected the same guests in another order to be equal, differed in %s", field)
+	}
+	if equal, field := syncer.syncedEventsEqual(newEvent(alice), newEvent(aliceTentative)); equal || field != "attendees" {
+		t.Errorf("Expected a changed response to differ in attendees, got %v, %q", equal, field)
+	}
+	if equal, field := syncer.syncedEventsEqual(newEvent(alice, bob), newEvent(alice)); equal || field != "attendees" {
+		t.Errorf("Expected a removed guest to differ in attendees, got %v, %q", equal, field)
+	}
+
+	// Guests aren't compared when they aren't copied to the destination
+	for _, syncer := range []*Syncer{
+		{config: &config.Config{}, destination: &config.Destination{Name: "Test", Type: "google"}},
+		{config: &config.Config{CopyAttendees: true}, destination: &config.Destination{Name: "Test", Type: "apple"}},
+	} {
+		if equal, field := syncer.syncedEventsEqual(newEvent(alice, bob), newEvent()); !equal {
+			t.Errorf("Expected guests to be ignored for %s destinations with copy_attendees %v, differed in %s",
+				syncer.destination.Type, syncer.config.CopyAttendees, field)
+		}
+	}
+}
+
+func TestPrepareSyncEvent_PreserveReminders(t *testing.T) {
+	sourceEvent := &calendar.Event{
+		Id:      "work-1",
+		Summary: "Design Review",
+		Reminders: &calendar.EventReminders{
+			Overrides: []*calendar.EventReminder{
+				{Method: "popup", Minutes: 10},
+				{Method: "email", Minutes: 10},
+				{Method: "email", Minutes: 1440},
+			},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		preserveReminders bool
+		destType          string
+		want              []string // nil for the calendar's default reminders
+	}{
+		{name: "off", destType: "google"},
+		{name: "google", preserveReminders: true, destType: "google", want: []string{"email 10", "email 1440", "popup 10"}},
+		{name: "apple alarms are pop-ups", preserveReminders: true, destType: "apple", want: []string{"popup 10", "popup 1440"}},
+		{name: "outlook", preserveReminders: true, destType: "outlook"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := &Syncer{
+				config:      &config.Config{PreserveReminders: tt.preserveReminders},
+				destination: &config.Destination{Name: "Test", Type: tt.destType},
+			}
+			prepared := syncer.prepareSyncEvent(sourceEvent)
+
+			if tt.want == nil {
+				if prepared.Reminders == nil || !prepared.Reminders.UseDefault {
+					t.Errorf("Expected the default reminders, got %+v", prepared.Reminders)
+				}
+				return
+			}
+			if got := reminderSet(prepared); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected reminders %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventsEqual_ComparesReminders(t *testing.T) {
+	newEvent := func(minutes ...int64) *calendar.Event {
+		event := &calendar.Event{Summary: "Design Review", Reminders: &calendar.EventReminders{UseDefault: true}}
+		if len(minutes) > 0 {
+			event.Reminders = &calendar.EventReminders{}
+			for _, m := range minutes {
+				event.Reminders.Overrides = append(event.Reminders.Overrides, &calendar.EventReminder{Method: "popup", Minutes: m})
+			}
+		}
+		return event
+	}
+
+	if equal, field := eventsEqual(newEvent(1440, 10), newEvent(10, 1440), nil); !equal {
+		t.Errorf("Expected the same reminders in another order to be equal, differed in %s", field)
+	}
+	if equal, field := eventsEqual(newEvent(), newEvent(10), nil); equal || field != "reminders" {
+		t.Errorf("Expected default reminders to differ from a 10-minute one, got %v, %q", equal, field)
+	}
+	// Reminders added to a copy are kept when the work event uses the defaults
+	if equal, field := eventsEqual(newEvent(30), newEvent(), nil); !equal {
+		t.Errorf("Expected reminders added to the copy to be left alone, differed in %s", field)
+	}
+}
+
+func TestPrepareSyncEvent_Status(t *testing.T) {
+	tests := []struct {
+		name          string
+		keepCancelled bool
+		destType      string
+		status        string
+		wantStatus    string
+		wantSummary   string
+	}{
+		{name: "tentative google", destType: "google", status: "tentative", wantStatus: "tentative", wantSummary: "Design Review"},
+		{name: "tentative apple", destType: "apple", status: "tentative", wantStatus: "tentative", wantSummary: "Design Review"},
+		{name: "tentative outlook", destType: "outlook", status: "tentative", wantSummary: "Design Review"},
+		{name: "cancelled apple", keepCancelled: true, destType: "apple", status: "cancelled", wantStatus: "cancelled", wantSummary: "Design Review"},
+		{name: "cancelled google", keepCancelled: true, destType: "google", status: "cancelled", wantSummary: "Cancelled: Design Review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := &Syncer{
+				config:      &config.Config{KeepCancelled: tt.keepCancelled},
+				destination: &config.Destination{Name: "Test", Type: tt.destType},
+			}
+			prepared := syncer.prepareSyncEvent(&calendar.Event{Id: "work-1", Summary: "Design Review", Status: tt.status})
+
+			if prepared.Status != tt.wantStatus {
+				t.Errorf("Expected status %q, got %q", tt.wantStatus, prepared.Status)
+			}
+			if prepared.Summary != tt.wantSummary {
+				t.Errorf("Expected summary %q, got %q", tt.wantSummary, prepared.Summary)
+			}
+		})
+	}
+}
+
+func TestPrepareSyncEvent_DescriptionFormat(t *testing.T) {
+	description := `<b>Agenda</b><br>Review the <a href="https://docs.example.com/q3">Q3 plan</a> &amp; budget` +
+		`<ul><li>Hiring</li><li>Launch   dates</li></ul><p>Notes: <a href="https://example.com/notes">https://example.com/notes</a></p>`
+	want := "Agenda\nReview the Q3 plan (https://docs.example.com/q3) & budget\n- Hiring\n- Launch dates\nNotes: https://example.com/notes"
+
+	syncer := &Syncer{
+		config:      &config.Config{},
+		destination: &config.Destination{Name: "Test", Type: "apple", DescriptionFormat: "text"},
+	}
+	prepared := syncer.prepareSyncEvent(&calendar.Event{Id: "work-1", Summary: "Planning", Description: description})
+	if prepared.Description != want {
+		t.Errorf("Expected description:\n%s\ngot:\n%s", want, prepared.Description)
+	}
+
+	// The stored plain text compares equal to the next run's conversion
+	if equal, field := eventsEqual(&calendar.Event{Summary: prepared.Summary, Description: want}, prepared, nil); !equal {
+		t.Errorf("Expected the converted description to compare equal, differed in %s", field)
+	}
+
+	// Plain text descriptions are left alone
+	plain := "Dial in: 555-0100\nBring <laptop> & charger"
+	if got := htmlToText(plain); got != plain {
+		t.Errorf("Expected plain text to be unchanged, got %q", got)
+	}
+
+	// "source" copies the description as written
+	syncer.destination.DescriptionFormat = "source"
+	if prepared := syncer.prepareSyncEvent(&calendar.Event{Id: "work-1", Description: description}); prepared.Description != description {
+		t.Errorf("Expected the description as written, got %q", prepared.Description)
+	}
+}
+
+func TestPrepareSyncEvent_IncludeSourceLink(t *testing.T) {
+	link := "https://www.google.com/calendar/event?eid=abc123"
+	source := &calendar.Event{Id: "work-1", Summary: "Planning", Description: "Agenda attached", HtmlLink: link}
+
+	syncer := &Syncer{
+		config:      &config.Config{IncludeSourceLink: true},
+		destination: &config.Destination{Name: "Test", Type: "google"},
+	}
+	prepared := syncer.prepareSyncEvent(source)
+	want := "Agenda attached\n\nWork event: " + link
+	if prepared.Description != want {
+		t.Errorf("Expected description %q, got %q", want, prepared.Description)
+	}
+
+	// The next run prepares the same description, so the copy stays unchanged
+	if equal, field := eventsEqual(prepared, syncer.prepareSyncEvent(source), nil); !equal {
+		t.Errorf("Expected the linked description to compare equal, differed in %s", field)
+	}
+
+	// A description ending in a link already gets it replaced, not repeated
+	relinked := syncer.prepareSyncEvent(&calendar.Event{Id: "work-1", Description: want, HtmlLink: link})
+	if relinked.Description != want {
+		t.Errorf("Expected one link, got %q", relinked.Description)
+	}
+
+	// Events without a description get the link alone
+	if prepared := syncer.prepareSyncEvent(&calendar.Event{Id: "work-1", HtmlLink: link}); prepared.Description != "Work event: "+link {
+		t.Errorf("Expected the link alone, got %q", prepared.Description)
+	}
+
+	// Redacted descriptions stay blank
+	syncer.destination.RedactFields = []string{"description"}
+	if prepared := syncer.prepareSyncEvent(source); prepared.Description != "" {
+		t.Errorf("Expected a redacted description, got %q", prepared.Description)
+	}
+
+	syncer.config.IncludeSourceLink = false
+	syncer.destination.RedactFields = nil
+	if prepared := syncer.prepareSyncEvent(source); prepared.Description != "Agenda attached" {
+		t.Errorf("Expected no link when include_source_link is off, got %q", prepared.Description)
+	}
+}
+
+func TestEventsEqual_ComparesStatus(t *testing.T) {
+	confirmed := &calendar.Event{Summary: "Design Review", Status: "confirmed"}
+	unset := &calendar.Event{Summary: "Design Review"}
+	tentative := &calendar.Event{Summary: "Design Review", Status: "tentative"}
+
+	if equal, field := eventsEqual(unset, confirmed, nil); !equal {
+		t.Errorf("Expected no status to equal confirmed, differed in %s", field)
+	}
+	if equal, field := eventsEqual(confirmed, tentative, nil); equal || field != "status" {
+		t.Errorf("Expected confirmed to differ from tentative, got %v, %q", equal, field)
+	}
+}
+
+func TestPrepareSyncEvent_ForceTransparency(t *testing.T) {
+	tests := []struct {
+		name              string
+		forceTransparency string
+		sourceValue       string
+		expected          string
+	}{
+		{name: "source passes through transparent", forceTransparency: "source", sourceValue: "transparent", expected: "transparent"},
+		{name: "source passes through opaque", forceTransparency: "source", sourceValue: "", expected: ""},
+		{name: "unset behaves like source", forceTransparency: "", sourceValue: "transparent", expected: "transparent"},
+		{name: "opaque overrides transparent source", forceTransparency: "opaque", sourceValue: "transparent", expected: "opaque"},
+		{name: "transparent overrides opaque source", forceTransparency: "transparent", sourceValue: "", expected: "transparent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := &Syncer{
+				destination: &config.Destination{Name: "Test", ForceTransparency: tt.forceTransparency},
+			}
+			sourceEvent := &calendar.Event{
+				Id:           "work-1",
+				Summary:      "Work Meeting",
+				Transparency: tt.sourceValue,
+			}
+
+			prepared := syncer.prepareSyncEvent(sourceEvent)
+
+			if prepared.Transparency != tt.expected {
+				t.Errorf("Expected transparency '%s', got '%s'", tt.expected, prepared.Transparency)
+			}
+		})
+	}
+}
+
+func TestSync_RedactFieldsPerDestination(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:          "work-1",
+			Summary:     "Offsite",
+			Description: "Agenda",
+			Location:    "Googleplex",
+			Start:       &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:         &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+			ConferenceData: &calendar.ConferenceData{
+				EntryPoints: []*calendar.EntryPoint{{EntryPointType: "video", Uri: "https://meet.google.com/abc-defg-hij"}},
+			},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Shared: map[string]string{calclient.GeoPropertyKey: "37.422000;-122.084100"},
+			},
+		},
+	}
+
+	// The phone mirror drops the location, the desktop mirror drops the description and link
+	phone := &config.Destination{Name: "Phone", CalendarName: "Work Sync", RedactFields: []string{"location"}}
+	desktop := &config.Destination{Name: "Desktop", CalendarName: "Work Sync", RedactFields: []string{"description", "conference"}}
+
+	phoneClient := newMockGoogleCalendarClient()
+	desktopClient := newMockGoogleCalendarClient()
+	for _, run := range []struct {
+		dest   *config.Destination
+		client *mockGoogleCalendarClient
+	}{{phone, phoneClient}, {desktop, desktopClient}} {
+		if _, err := NewSyncer(workClient, run.client, cfg, run.dest, false).Sync(context.Background()); err != nil {
+			t.Fatalf("Sync() to %s returned an error: %v", run.dest.Name, err)
+		}
+		if len(run.client.insertedEvents) != 1 {
+			t.Fatalf("Expected 1 event inserted into %s, got %d", run.dest.Name, len(run.client.insertedEvents))
+		}
+	}
+
+	phoneEvent := phoneClient.insertedEvents[0]
+	if phoneEvent.Location != "" || eventGeo(phoneEvent) != "" {
+		t.Errorf("Expected the phone copy to have no location, got %q (geo %q)", phoneEvent.Location, eventGeo(phoneEvent))
+	}
+	if phoneEvent.Description != "Agenda" || getMeetURL(phoneEvent) == "" {
+		t.Errorf("Expected the phone copy to keep description and conference link, got %q and %q", phoneEvent.Description, getMeetURL(phoneEvent))
+	}
+
+	desktopEvent := desktopClient.insertedEvents[0]
+	if desktopEvent.Description != "" || desktopEvent.ConferenceData != nil {
+		t.Errorf("Expected the desktop copy to have no description or conference link, got %q and %+v", desktopEvent.Description, desktopEvent.ConferenceData)
+	}
+	if desktopEvent.Location != "Googleplex" || eventGeo(desktopEvent) != "37.422000;-122.084100" {
+		t.Errorf("Expected the desktop copy to keep the location, got %q (geo %q)", desktopEvent.Location, eventGeo(desktopEvent))
+	}
+
+	// The redacted copies compare equal to what the next run would prepare
+	for _, run := range []struct {
+		dest   *config.Destination
+		client *mockGoogleCalendarClient
+	}{{phone, phoneClient}, {desktop, desktopClient}} {
+		result, err := NewSyncer(workClient, run.client, cfg, run.dest, false).Sync(context.Background())
+		if err != nil {
+			t.Fatalf("Sync() to %s returned an error: %v", run.dest.Name, err)
+		}
+		if result.Inserted != 0 || result.Updated != 0 || result.Deleted != 0 {
+			t.Errorf("Expected no changes on the second run to %s, got inserted %d, updated %d, deleted %d",
+				run.dest.Name, result.Inserted, result.Updated, result.Deleted)
+		}
+	}
+}
+
+func TestEventsEqual_Transparency(t *testing.T) {
+	opaque := &calendar.Event{Summary: "Meeting"}
+	explicitOpaque := &calendar.Event{Summary: "Meeting", Transparency: "opaque"}
+	transparent := &calendar.Event{Summary: "Meeting", Transparency: "transparent"}
+
+	if equal, field := eventsEqual(opaque, explicitOpaque, nil); !equal {
+		t.Errorf("Expected empty and 'opaque' transparency to be equal, but differed on %s", field)
+	}
+	if equal, field := eventsEqual(opaque, transparent, nil); equal || field != "transparency" {
+		t.Errorf("Expected transparency mismatch, got equal=%v field=%s", equal, field)
+	}
+}
+
+func TestFilterEvents_MaxAllDaySpanDays(t *testing.T) {
+	mockClient := newMockGoogleCalendarClient()
+	dest := &config.Destination{Name: "Test"}
+	syncer := &Syncer{
+		workClient:  mockClient,
+		destination: dest,
+		config:      &config.Config{MaxAllDaySpanDays: 7},
+	}
+
+	events := []*calendar.Event{
+		{
+			Id:      "vacation",
+			Summary: "Vacation",
+			Start: &calendar.EventDateTime{
+				Date: "2024-01-15",
+			},
+			End: &calendar.EventDateTime{
+				Date: "2024-01-29", // 14 days
+			},
+		},
+		{
+			Id:      "conference",
+			Summary: "Conference",
+			Start: &calendar.EventDateTime{
+				Date: "2024-02-05",
+			},
+			End: &calendar.EventDateTime{
+				Date: "2024-02-12", // exactly 7 days
+			},
+		},
+	}
+
+	filtered := syncer.filterEvents(events)
+
+	if len(filtered) != 1 || filtered[0].Id != "conference" {
+		t.Errorf("Expected only the 7-day event to be kept, but got %d events", len(filtered))
+	}
+}
+
+func TestSync_UntaggedMatchingEventIsRetagged(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-1",
+			Summary: "Work Meeting",
+			Start:   &calendar.EventDateTime{DateTime: start},
+			End:     &calendar.EventDateTime{DateTime: end},
+		},
+	}
+
+	// A previously synced copy whose workEventId tag could not be read back
+	destCalendarID := "cal_Work Sync"
+	personalClient.calendars["Work Sync"] = destCalendarID
+	personalClient.events[destCalendarID] = []*calendar.Event{
+		{
+			Id:      "dest-untagged",
+			Summary: "Work Meeting",
+			Start:   &calendar.EventDateTime{DateTime: start},
+			End:     &calendar.EventDateTime{DateTime: end},
+		},
+	}
+
+	ctx := context.Background()
+	_, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if len(personalClient.deletedEventIDs) != 0 {
+		t.Errorf("Expected the untagged event not to be deleted, got deletions %v", personalClient.deletedEventIDs)
+	}
+	if len(personalClient.insertedEvents) != 0 {
+		t.Errorf("Expected no inserts, but got %d", len(personalClient.insertedEvents))
+	}
+	if len(personalClient.updatedEvents) != 1 {
+		t.Fatalf("Expected the untagged event to be re-tagged with one update, but got %d updates", len(personalClient.updatedEvents))
+	}
+	if workID := personalClient.updatedEvents[0].ExtendedProperties.Private["workEventId"]; workID != "work-1" {
+		t.Errorf("Expected re-tagged event to have workEventId 'work-1', got '%s'", workID)
+	}
+}
+
+func TestSync_MergeUntagged(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	tests := []struct {
+		name                       string
+		merge                      bool
+		inserted, updated, deleted int
+	}{
+		// The pre-created event is adopted and tagged
+		{"merge", true, 0, 1, 0},
+		// The pre-created event is deleted and the work event inserted afresh
+		{"overwrite", false, 1, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workClient := newMockGoogleCalendarClient()
+			personalClient := newMockGoogleCalendarClient()
+			workClient.events["primary"] = []*calendar.Event{
+				{Id: "work-1", Summary: "Work Meeting", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+			}
+			personalClient.calendars["Work Sync"] = "cal_Work Sync"
+			personalClient.events["cal_Work Sync"] = []*calendar.Event{
+				{Id: "pre-created", Summary: "Work Meeting", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+			}
+
+			// A dry run reports the planned changes without prompting before deletes
+			cfg := &config.Config{SyncWindowWeeks: 2, MergeUntagged: &tt.merge, DryRun: true}
+			dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+			result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+			if err != nil {
+				t.Fatalf("Sync() returned an error: %v", err)
+			}
+			if result.Inserted != tt.inserted || result.Updated != tt.updated || result.Deleted != tt.deleted {
+				t.Errorf("Expected inserted %d, updated %d, deleted %d, got inserted %d, updated %d, deleted %d",
+					tt.inserted, tt.updated, tt.deleted, result.Inserted, result.Updated, result.Deleted)
+			}
+		})
+	}
+}
+
+func TestRetagCandidates_ClaimsInWorkIDOrder(t *testing.T) {
+	newEvent := func(id string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: "Standup",
+			Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2024-01-15T10:15:00Z"},
+		}
+	}
+	sourceEventsMap := map[string]*calendar.Event{
+		"work-b": newEvent("work-b"),
+		"work-a": newEvent("work-a"),
+		"work-c": newEvent("work-c"),
+	}
+	// work-c already has a tagged copy, so it is not a candidate
+	destEventsByWorkID := map[string][]*calendar.Event{"work-c": {newEvent("dest-c")}}
+
+	candidates := newRetagCandidates(sourceEventsMap, destEventsByWorkID)
+
+	// Same instant in another zone still matches
+	untagged := &calendar.Event{
+		Summary: "Standup",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00+01:00"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T11:15:00+01:00"},
+	}
+	var claimed []string
+	for range 3 {
+		if event := candidates.claim(untagged); event != nil {
+			claimed = append(claimed, event.Id)
+		}
+	}
+	if !slices.Equal(claimed, []string{"work-a", "work-b"}) {
+		t.Errorf("Expected work-a then work-b to be claimed, got %v", claimed)
+	}
+
+	other := newEvent("dest-other")
+	other.Summary = "Retro"
+	if event := candidates.claim(other); event != nil {
+		t.Errorf("Expected no match for a different summary, got %s", event.Id)
+	}
+}
+
+// deletedCalendarClient simulates the user deleting the destination calendar
+// after the sync has resolved it: writes to goneID fail with ErrCalendarNotFound
+// and the calendar can no longer be found by name.
+type deletedCalendarClient struct {
+	*mockGoogleCalendarClient
+	goneID string
+}
+
+func (m *deletedCalendarClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	if calendarID == m.goneID {
+		for name, id := range m.calendars {
+			if id == m.goneID {
+				delete(m.calendars, name)
+			}
+		}
+		return fmt.Errorf("failed to update event: %w", calclient.ErrCalendarNotFound)
+	}
+	return m.mockGoogleCalendarClient.UpdateEvent(calendarID, eventID, event)
+}
+
+func TestSync_CalendarDeletedMidRunIsRecreated(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &deletedCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		goneID:                   "cal_old",
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-1",
+			Summary: "Renamed Meeting",
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		},
+	}
+
+	// A previously synced copy that needs updating, in a calendar that vanishes
+	personalClient.calendars["Work Sync"] = "cal_old"
+	personalClient.events["cal_old"] = []*calendar.Event{
+		{
+			Id:      "dest-1",
+			Summary: "Old Meeting",
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": "work-1"},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	newID := personalClient.calendars["Work Sync"]
+	if newID == "" || newID == "cal_old" {
+		t.Fatalf("Expected the destination calendar to be recreated, got ID %q", newID)
+	}
+	if len(personalClient.events[newID]) != 1 || personalClient.events[newID][0].Summary != "Renamed Meeting" {
+		t.Errorf("Expected the updated event to be written to the recreated calendar, got %+v", personalClient.events[newID])
+	}
+	if result.Failed != 0 {
+		t.Errorf("Expected no failures, got %d", result.Failed)
+	}
+}
+
+// TestSync_CalendarDeletedMidRunRestoresAllEvents verifies that after the
+// calendar is recreated, events that needed no change and events processed
+// later are written to the new calendar rather than updated under their old IDs.
+func TestSync_CalendarDeletedMidRunRestoresAllEvents(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &deletedCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		goneID:                   "cal_old",
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:         "Test",
+		CalendarName: "Work Sync",
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	newTimedEvent := func(id, summary string, hour int, workID string) *calendar.Event {
+		eventStart := start.Add(time.Duration(hour) * time.Hour)
+		event := &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: eventStart.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: eventStart.Add(time.Hour).Format(time.RFC3339)},
+		}
+		if workID != "" {
+			event.ExtendedProperties = &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": workID},
+			}
+		}
+		return event
+	}
+
+	workClient.events["primary"] = []*calendar.Event{
+		newTimedEvent("work-1", "Renamed Meeting", 0, ""),
+		newTimedEvent("work-2", "Unchanged Meeting", 2, ""),
+		newTimedEvent("work-3", "Another Renamed Meeting", 4, ""),
+		newTimedEvent("work-4", "New Meeting", 6, ""),
+	}
+
+	// Synced copies in a calendar that vanishes on the first update
+	personalClient.calendars["Work Sync"] = "cal_old"
+	personalClient.events["cal_old"] = []*calendar.Event{
+		newTimedEvent("dest-1", "Old Meeting", 0, "work-1"),
+		newTimedEvent("dest-2", "Unchanged Meeting", 2, "work-2"),
+		newTimedEvent("dest-3", "Another Old Meeting", 4, "work-3"),
+		newTimedEvent("dest-stale", "Cancelled Meeting", 8, "work-gone"),
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	newID := personalClient.calendars["Work Sync"]
+	if newID == "" || newID == "cal_old" {
+		t.Fatalf("Expected the destination calendar to be recreated, got ID %q", newID)
+	}
+	var summaries []string
+	for _, event := range personalClient.events[newID] {
+		summaries = append(summaries, event.Summary)
+	}
+	slices.Sort(summaries)
+	want := []string{"Another Renamed Meeting", "New Meeting", "Renamed Meeting", "Unchanged Meeting"}
+	if !slices.Equal(summaries, want) {
+		t.Errorf("Expected the recreated calendar to hold %v, got %v", want, summaries)
+	}
+	if result.Failed != 0 {
+		t.Errorf("Expected no failures, got %d", result.Failed)
+	}
+}
+
+func TestCheckAndCreateTokenReminder_MinimumUpdateInterval(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	if err := auth.NewFileTokenStore(tokenPath).SaveToken(&oauth2.Token{RefreshToken: "refresh"}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	personalClient := newMockGoogleCalendarClient()
+	cfg := &config.Config{
+		SyncWindowWeeks:             2,
+		ReminderUpdateIntervalHours: 24,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		Type:            "google",
+		TokenPath:       tokenPath,
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	syncer := NewSyncer(newMockGoogleCalendarClient(), personalClient, cfg, dest, false)
+	ctx := context.Background()
+	firstRun := time.Now()
+
+	syncer.now = func() time.Time { return firstRun }
+	if err := syncer.checkAndCreateTokenReminder(ctx, "cal_Work Sync"); err != nil {
+		t.Fatalf("checkAndCreateTokenReminder() returned an error: %v", err)
+	}
+	if len(personalClient.insertedEvents) != 1 {
+		t.Fatalf("Expected the reminder to be created on the first run, got %d inserts", len(personalClient.insertedEvents))
+	}
+
+	// A second run an hour later computes the same reminder date and must not rewrite it
+	syncer.now = func() time.Time { return firstRun.Add(time.Hour) }
+	if err := syncer.checkAndCreateTokenReminder(ctx, "cal_Work Sync"); err != nil {
+		t.Fatalf("checkAndCreateTokenReminder() returned an error: %v", err)
+	}
+	if len(personalClient.updatedEvents) != 0 {
+		t.Errorf("Expected no reminder update within the interval, got %d updates", len(personalClient.updatedEvents))
+	}
+
+	// Once the interval has passed the reminder is refreshed again
+	syncer.now = func() time.Time { return firstRun.Add(25 * time.Hour) }
+	if err := syncer.checkAndCreateTokenReminder(ctx, "cal_Work Sync"); err != nil {
+		t.Fatalf("checkAndCreateTokenReminder() returned an error: %v", err)
+	}
+	if len(personalClient.updatedEvents) != 1 {
+		t.Errorf("Expected the reminder to be updated after the interval, got %d updates", len(personalClient.updatedEvents))
+	}
+}
+
+// failingUpdateClient is a mock destination whose event updates fail.
+type failingUpdateClient struct {
+	*mockGoogleCalendarClient
+}
+
+func (m failingUpdateClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	return errors.New("HTTP 403: read-only calendar")
+}
+
+func TestSelfTest(t *testing.T) {
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	dest := &config.Destination{Name: "Test", Type: "apple", CalendarName: "Work Sync"}
+	calendarID := "cal_Work Sync"
+	stepNames := func(steps []SelfTestStep) []string {
+		var names []string
+		for _, step := range steps {
+			status := "PASS"
+			if step.Skipped {
+				status = "SKIP"
+			} else if step.Err != nil {
+				status = "FAIL"
+			}
+			names = append(names, status+" "+step.Name)
+		}
+		return names
+	}
+
+	t.Run("passes", func(t *testing.T) {
+		personalClient := newMockGoogleCalendarClient()
+		// A self-test event left behind by an interrupted run
+		personalClient.events[calendarID] = []*calendar.Event{{
+			Id:                 "leftover",
+			Summary:            selfTestSummary,
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": selfTestWorkID}},
+		}}
+
+		steps := NewSyncer(nil, personalClient, cfg, dest, false).SelfTest(context.Background())
+		want := []string{"PASS resolve calendar", "PASS clean up earlier self-tests", "PASS insert", "PASS get", "PASS update", "PASS delete"}
+		if got := stepNames(steps); !slices.Equal(got, want) {
+			t.Errorf("Expected steps %v, got %v (%+v)", want, got, steps)
+		}
+		if len(personalClient.insertedEvents) != 1 || len(personalClient.updatedEvents) != 1 {
+			t.Errorf("Expected one insert and one update, got %d and %d", len(personalClient.insertedEvents), len(personalClient.updatedEvents))
+		}
+		if len(personalClient.events[calendarID]) != 0 {
+			t.Errorf("Expected the calendar to be left empty, got %d event(s)", len(personalClient.events[calendarID]))
+		}
+	})
+
+	t.Run("cleans up after a failure", func(t *testing.T) {
+		personalClient := newMockGoogleCalendarClient()
+		steps := NewSyncer(nil, failingUpdateClient{personalClient}, cfg, dest, false).SelfTest(context.Background())
+		want := []string{"PASS resolve calendar", "PASS clean up earlier self-tests", "PASS insert", "PASS get", "FAIL update", "SKIP delete"}
+		if got := stepNames(steps); !slices.Equal(got, want) {
+			t.Errorf("Expected steps %v, got %v (%+v)", want, got, steps)
+		}
+		if len(personalClient.events[calendarID]) != 0 {
+			t.Errorf("Expected the test event to be deleted, got %d event(s)", len(personalClient.events[calendarID]))
+		}
+	})
+}
+
+func TestSendTestReminder_CreatesReminderOnDemand(t *testing.T) {
+	personalClient := newMockGoogleCalendarClient()
+	cfg := &config.Config{
+		SyncWindowWeeks:             2,
+		ReminderUpdateIntervalHours: 24,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		Type:            "google",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	// No work client or token is needed to send a test reminder
+	syncer := NewSyncer(nil, personalClient, cfg, dest, false)
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	syncer.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if err := syncer.SendTestReminder(ctx); err != nil {
+		t.Fatalf("SendTestReminder() returned an error: %v", err)
+	}
+
+	if len(personalClient.insertedEvents) != 1 {
+		t.Fatalf("Expected the reminder to be created, got %d inserts", len(personalClient.insertedEvents))
+	}
+	reminder := personalClient.insertedEvents[0]
+	if reminder.ExtendedProperties.Private["workEventId"] != tokenReminderWorkID {
+		t.Errorf("Expected reminder workEventId %q, got %q", tokenReminderWorkID, reminder.ExtendedProperties.Private["workEventId"])
+	}
+	start, err := time.Parse(time.RFC3339, reminder.Start.DateTime)
+	if err != nil {
+		t.Fatalf("Failed to parse reminder start: %v", err)
+	}
+	if !start.After(now) || start.After(now.Add(time.Hour)) {
+		t.Errorf("Expected reminder in the near future, got %s", start)
+	}
+
+	// Sending again right away updates the reminder even within the update interval
+	if err := syncer.SendTestReminder(ctx); err != nil {
+		t.Fatalf("SendTestReminder() returned an error: %v", err)
+	}
+	if len(personalClient.insertedEvents) != 1 || len(personalClient.updatedEvents) != 1 {
+		t.Errorf("Expected the second test reminder to update the existing one, got %d inserts and %d updates",
+			len(personalClient.insertedEvents), len(personalClient.updatedEvents))
+	}
+}
+
+// slowCreatingCalendarClient is safe for concurrent use and widens the window
+// between looking up and creating a calendar, so unsynchronized callers would
+// both create it.
+type slowCreatingCalendarClient struct {
+	*mockGoogleCalendarClient
+	mu        gosync.Mutex
+	creations int
+}
+
+func (m *slowCreatingCalendarClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	m.mu.Lock()
+	id, exists := m.calendars[name]
+	m.mu.Unlock()
+	if exists {
+		return id, nil
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.creations++
+	id = fmt.Sprintf("cal_%s_%d", name, m.creations)
+	m.calendars[name] = id
+	return id, nil
+}
+
+func TestSyncer_SharedCalendarCacheCreatesOnce(t *testing.T) {
+	personalClient := &slowCreatingCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+	cache := calclient.NewCalendarIDCache()
+	cfg := &config.Config{SyncWindowWeeks: 2}
+
+	// Two destinations pointing at the same account and calendar name
+	var syncers []*Syncer
+	for _, name := range []string{"First", "Second"} {
+		dest := &config.Destination{
+			Name:            name,
+			Type:            "google",
+			TokenPath:       "/tmp/shared_token.json",
+			CalendarName:    "Work Sync",
+			CalendarColorID: "7",
+		}
+		syncer := NewSyncer(newMockGoogleCalendarClient(), personalClient, cfg, dest, false)
+		syncer.SetCalendarCache(cache)
+		syncers = append(syncers, syncer)
+	}
+
+	ids := make([]string, len(syncers))
+	var wg gosync.WaitGroup
+	for i, syncer := range syncers {
+		wg.Add(1)
+		go func(i int, syncer *Syncer) {
+			defer wg.Done()
+			id, err := syncer.resolveCalendar()
+			if err != nil {
+				t.Errorf("resolveCalendar() returned an error: %v", err)
+			}
+			ids[i] = id
+		}(i, syncer)
+	}
+	wg.Wait()
+
+	if personalClient.creations != 1 {
+		t.Errorf("Expected the calendar to be created once, got %d creations", personalClient.creations)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("Expected both destinations to resolve the same calendar ID, got %q and %q", ids[0], ids[1])
+	}
+}
+
+func TestEventsEqual_Geo(t *testing.T) {
+	newEvent := func(geo string) *calendar.Event {
+		event := &calendar.Event{
+			Summary: "Offsite",
+			Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+			ExtendedProperties: &calendar.EventExtendedProperties{
+				Private: map[string]string{"workEventId": "work-1"},
+			},
+		}
+		if geo != "" {
+			event.ExtendedProperties.Private[calclient.GeoPropertyKey] = geo
+		}
+		return event
+	}
+
+	// Differently formatted but identical coordinates compare equal
+	if equal, field := eventsEqual(newEvent("37.422;-122.0841"), newEvent("37.422000; -122.084100"), nil); !equal {
+		t.Errorf("Expected equivalent coordinates to compare equal, got difference in %q", field)
+	}
+	if equal, field := eventsEqual(newEvent("37.422;-122.0841"), newEvent("40.7128;-74.006"), nil); equal || field != "geo" {
+		t.Errorf("Expected different coordinates to differ in 'geo', got equal=%v field=%q", equal, field)
+	}
+	if equal, field := eventsEqual(newEvent(""), newEvent("37.422;-122.0841"), nil); equal || field != "geo" {
+		t.Errorf("Expected added coordinates to differ in 'geo', got equal=%v field=%q", equal, field)
+	}
+}
+
+// cancelAfterInsertsClient cancels the sync context once a given number of events
+// have been inserted, simulating an interruption part-way through a large sync.
+type cancelAfterInsertsClient struct {
+	*mockGoogleCalendarClient
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (m *cancelAfterInsertsClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	err := m.mockGoogleCalendarClient.InsertEvent(calendarID, event)
+	if len(m.insertedEvents) == m.cancelAfter {
+		m.cancel()
+	}
+	return err
+}
+
+func TestSync_ResumeJournalSkipsEventsFromInterruptedRun(t *testing.T) {
+	const total, interruptAfter = 5, 2
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &cancelAfterInsertsClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		cancelAfter:              interruptAfter,
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks:     2,
+		ResumeJournalPath:   filepath.Join(t.TempDir(), "journal.json"),
+		ResumeWindowMinutes: 60,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+	}
+
+	now := time.Now()
+	for i := 0; i < total; i++ {
+		start := time.Date(now.Year(), now.Month(), now.Day(), 9+i, 0, 0, 0, time.UTC)
+		workClient.events["primary"] = append(workClient.events["primary"], &calendar.Event{
+			Id:      fmt.Sprintf("work-%d", i),
+			Summary: fmt.Sprintf("Meeting %d", i),
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(30 * time.Minute).Format(time.RFC3339)},
+		})
+	}
+
+	// First run is interrupted after K inserts
+	ctx, cancel := context.WithCancel(context.Background())
+	personalClient.cancel = cancel
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+	if _, err := syncer.Sync(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the first run to be interrupted, got %v", err)
+	}
+	if len(personalClient.insertedEvents) != interruptAfter {
+		t.Fatalf("Expected %d inserts before the interruption, got %d", interruptAfter, len(personalClient.insertedEvents))
+	}
+
+	// The resumed run skips the K events already written and inserts the rest
+	personalClient.cancelAfter = -1
+	syncer = NewSyncer(workClient, personalClient, cfg, dest, false)
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Resumed Sync() returned an error: %v", err)
+	}
+	if result.Resumed != interruptAfter {
+		t.Errorf("Expected %d events to be skipped as already written, got %d", interruptAfter, result.Resumed)
+	}
+	if result.Inserted != total-interruptAfter {
+		t.Errorf("Expected %d inserts in the resumed run, got %d", total-interruptAfter, result.Inserted)
+	}
+	if result.Updated != 0 {
+		t.Errorf("Expected no updates in the resumed run, got %d", result.Updated)
+	}
+
+	// A completed run clears the journal, so the next run skips nothing
+	result, err = syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Resumed != 0 {
+		t.Errorf("Expected nothing to be resumed after a completed run, got %d", result.Resumed)
+	}
+}
+
+// TestProgressJournal_AppendsAndCompacts verifies that each upsert appends one
+// line to the journal, and that clearing a destination keeps the others' progress.
+func TestProgressJournal_AppendsAndCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	now := time.Now()
+	window := time.Hour
+
+	journalA, _, err := loadProgressJournal(path, "A", now, window)
+	if err != nil {
+		t.Fatalf("loadProgressJournal() returned an error: %v", err)
+	}
+	journalB, _, err := loadProgressJournal(path, "B", now, window)
+	if err != nil {
+		t.Fatalf("loadProgressJournal() returned an error: %v", err)
+	}
+	for _, workID := range []string{"a1", "a2", "a3"} {
+		if err := journalA.markDone(workID, now); err != nil {
+			t.Fatalf("markDone() returned an error: %v", err)
+		}
+	}
+	if err := journalB.markDone("b1", now); err != nil {
+		t.Fatalf("markDone() returned an error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 4 {
+		t.Errorf("Expected 4 journal lines, got %d:\n%s", lines, data)
+	}
+
+	journalA, done, err := loadProgressJournal(path, "A", now, window)
+	if err != nil {
+		t.Fatalf("loadProgressJournal() returned an error: %v", err)
+	}
+	if len(done) != 3 || !done["a1"] || !done["a3"] {
+		t.Errorf("Expected a1-a3 to be done, got %v", done)
+	}
+	if err := journalA.clear(); err != nil {
+		t.Fatalf("clear() returned an error: %v", err)
+	}
+
+	_, done, err = loadProgressJournal(path, "A", now, window)
+	if err != nil || len(done) != 0 {
+		t.Errorf("Expected no progress for A after clear, got %v (err %v)", done, err)
+	}
+	_, done, err = loadProgressJournal(path, "B", now, window)
+	if err != nil || !done["b1"] {
+		t.Errorf("Expected B's progress to survive A's clear, got %v (err %v)", done, err)
+	}
+}
+
+// TestProgressJournal_ClearKeepsConcurrentProgress verifies that clearing a
+// destination keeps progress another destination appended after it was loaded,
+// as happens when destinations are synced concurrently.
+func TestProgressJournal_ClearKeepsConcurrentProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	now := time.Now()
+	window := time.Hour
+
+	journalA, _, err := loadProgressJournal(path, "A", now, window)
+	if err != nil {
+		t.Fatalf("loadProgressJournal() returned an error: %v", err)
+	}
+	journalB, _, err := loadProgressJournal(path, "B", now, window)
+	if err != nil {
+		t.Fatalf("loadProgressJournal() returned an error: %v", err)
+	}
+	if err := journalA.markDone("a1", now); err != nil {
+		t.Fatalf("markDone() returned an error: %v", err)
+	}
+	if err := journalB.markDone("b1", now); err != nil {
+		t.Fatalf("markDone() returned an error: %v", err)
+	}
+	if err := journalA.clear(); err != nil {
+		t.Fatalf("clear() returned an error: %v", err)
+	}
+
+	_, done, err := loadProgressJournal(path, "B", now, window)
+	if err != nil || !done["b1"] {
+		t.Errorf("Expected B's progress to survive A's clear, got %v (err %v)", done, err)
+	}
+}
+
+func TestFilterEvents_SkipVisibilities(t *testing.T) {
+	mockClient := newMockGoogleCalendarClient()
+	dest := &config.Destination{Name: "Test"}
+	syncer := &Syncer{
+		workClient:  mockClient,
+		destination: dest,
+		config:      &config.Config{SkipVisibilities: []string{"confidential"}},
+	}
+
+	newEvent := func(id, visibility string) *calendar.Event {
+		return &calendar.Event{
+			Id:         id,
+			Summary:    id,
+			Visibility: visibility,
+			Start:      &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:        &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+		}
+	}
+	events := []*calendar.Event{
+		newEvent("confidential", "confidential"),
+		newEvent("default", ""),
+		newEvent("public", "public"),
+	}
+
+	filtered := syncer.filterEvents(events)
+
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 events to be kept, got %d", len(filtered))
+	}
+	for _, event := range filtered {
+		if event.Id == "confidential" {
+			t.Errorf("Expected the confidential event to be skipped")
+		}
+	}
+}
+
+func TestFilterEvents_OrganizerDomains(t *testing.T) {
+	newEvent := func(id, organizer string) *calendar.Event {
+		event := &calendar.Event{
+			Id:      id,
+			Summary: id,
+			Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+		}
+		if organizer != "" {
+			event.Organizer = &calendar.EventOrganizer{Email: organizer}
+		}
+		return event
+	}
+	events := []*calendar.Event{
+		newEvent("internal", "alice@Example.com"),
+		newEvent("external", "sales@vendor.io"),
+		newEvent("no-organizer", ""),
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{"no filters", nil, nil, []string{"internal", "external", "no-organizer"}},
+		{"include own domain", []string{"example.com"}, nil, []string{"internal"}},
+		{"exclude vendor domain", nil, []string{"@vendor.io"}, []string{"internal", "no-organizer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := &Syncer{
+				workClient:  newMockGoogleCalendarClient(),
+				destination: &config.Destination{Name: "Test"},
+				config: &config.Config{
+					IncludeOrganizerDomains: tt.include,
+					ExcludeOrganizerDomains: tt.exclude,
+				},
+			}
+
+			var got []string
+			for _, event := range syncer.filterEvents(events) {
+				got = append(got, event.Id)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterEvents_OnlyMyEvents(t *testing.T) {
+	newEvent := func(id, organizer, creator string) *calendar.Event {
+		return &calendar.Event{
+			Id:        id,
+			Summary:   id,
+			Start:     &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+			End:       &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+			Organizer: &calendar.EventOrganizer{Email: organizer},
+			Creator:   &calendar.EventCreator{Email: creator},
+		}
+	}
+	events := []*calendar.Event{
+		newEvent("organized", "User@example.com", "user@example.com"),
+		// Created for a shared calendar, which then organizes it
+		newEvent("created", "team@group.calendar.google.com", "user@example.com"),
+		newEvent("invited", "alice@example.com", "alice@example.com"),
+	}
+
+	for _, onlyMine := range []bool{false, true} {
+		syncer := &Syncer{
+			workClient:  newMockGoogleCalendarClient(),
+			destination: &config.Destination{Name: "Test"},
+			config:      &config.Config{WorkEmail: "user@example.com", OnlyMyEvents: onlyMine},
+		}
+
+		filtered, dropped := syncer.filterEventsCounted(events)
+		var got []string
+		for _, event := range filtered {
+			got = append(got, event.Id)
+		}
+		want := []string{"organized", "created", "invited"}
+		if onlyMine {
+			want = []string{"organized", "created"}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("only_my_events %v: expected events %v, got %v", onlyMine, want, got)
+		}
+		if onlyMine && dropped[FilterNotMine] != 1 {
+			t.Errorf("Expected 1 event filtered as %s, got %v", FilterNotMine, dropped)
+		}
+	}
+}
+
+// freeBusyCalendarClient is a work calendar that also answers free/busy queries.
+type freeBusyCalendarClient struct {
+	*mockGoogleCalendarClient
+	busy []calclient.TimeSlot
+}
+
+func (m *freeBusyCalendarClient) GetFreeBusy(calendarID string, timeMin, timeMax time.Time) ([]calclient.TimeSlot, error) {
+	return m.busy, nil
+}
+
+func TestSync_BusyPrivacyModeSyncsFreeBusyBlocks(t *testing.T) {
+	now := time.Now()
+	first := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	second := time.Date(now.Year(), now.Month(), now.Day(), 14, 0, 0, 0, time.UTC)
+
+	workClient := &freeBusyCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		busy: []calclient.TimeSlot{
+			{Start: first, End: first.Add(time.Hour)},
+			{Start: second, End: second.Add(30 * time.Minute)},
+		},
+	}
+	// Event details must not leak into the destination
+	workClient.events["primary"] = []*calendar.Event{
+		{
+			Id:      "work-1",
+			Summary: "Secret Project Review",
+			Start:   &calendar.EventDateTime{DateTime: first.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: first.Add(time.Hour).Format(time.RFC3339)},
+		},
+	}
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync",
+		CalendarColorID: "7",
+		PrivacyMode:     "busy",
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	if len(personalClient.insertedEvents) != 2 {
+		t.Fatalf("Expected 2 busy blocks to be inserted, got %d", len(personalClient.insertedEvents))
+	}
+	starts := map[string]bool{}
+	for _, event := range personalClient.insertedEvents {
+		if event.Summary != "Busy" {
+			t.Errorf("Expected busy block summary 'Busy', got %q", event.Summary)
+		}
+		starts[event.Start.DateTime] = true
+	}
+	for _, slot := range workClient.busy {
+		if !starts[slot.Start.Format(time.RFC3339)] {
+			t.Errorf("Expected a busy block starting at %s", slot.Start.Format(time.RFC3339))
+		}
+	}
+
+	// A second run maps the same slots to the same events and changes nothing
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Inserted != 0 || result.Updated != 0 || result.Deleted != 0 {
+		t.Errorf("Expected no changes on the second run, got %+v", result)
+	}
+}
+
+// insertRecordingCalendarClient records which calendar each insert went to.
+type insertRecordingCalendarClient struct {
+	*mockGoogleCalendarClient
+	insertedInto map[string][]string // calendarID -> workEventIds
+}
+
+func (m *insertRecordingCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	m.insertedInto[calendarID] = append(m.insertedInto[calendarID], getWorkEventID(event))
+	return m.mockGoogleCalendarClient.InsertEvent(calendarID, event)
+}
+
+func TestSync_RoutesEventsToCalendars(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	if err := auth.NewFileTokenStore(tokenPath).SaveToken(&oauth2.Token{RefreshToken: "refresh"}); err != nil {
+		t.Fatalf("Failed to save token: %v", err)
+	}
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := &insertRecordingCalendarClient{
+		mockGoogleCalendarClient: newMockGoogleCalendarClient(),
+		insertedInto:             make(map[string][]string),
+	}
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		Type:            "google",
+		TokenPath:       tokenPath,
+		CalendarName:    "Work Sync - Meetings",
+		CalendarColorID: "7",
+		Routes: []config.Route{
+			{Events: []string{"all_day", "out_of_office"}, CalendarName: "Work Sync - OOF", CalendarColorID: "4"},
+		},
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	meeting := &calendar.Event{
+		Id:      "work-meeting",
+		Summary: "Planning",
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+	}
+	vacation := &calendar.Event{
+		Id:        "work-ooo",
+		Summary:   "Vacation",
+		EventType: "outOfOffice",
+		Start:     &calendar.EventDateTime{Date: start.Format("2006-01-02")},
+		End:       &calendar.EventDateTime{Date: start.AddDate(0, 0, 1).Format("2006-01-02")},
+	}
+	workClient.events["primary"] = []*calendar.Event{meeting, vacation}
+
+	ctx := context.Background()
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Inserted != 2 {
+		t.Errorf("Expected 2 inserts across both calendars, got %d", result.Inserted)
+	}
+
+	meetingsID := personalClient.calendars["Work Sync - Meetings"]
+	oofID := personalClient.calendars["Work Sync - OOF"]
+	if meetingsID == "" || oofID == "" {
+		t.Fatalf("Expected both calendars to be created, got %v", personalClient.calendars)
+	}
+	if got := fmt.Sprint(personalClient.insertedInto[oofID]); got != "[work-ooo]" {
+		t.Errorf("Expected only the OOF event in %s, got %s", oofID, got)
+	}
+
+	// The token reminder only goes to the default calendar
+	meetingInserts := personalClient.insertedInto[meetingsID]
+	if len(meetingInserts) != 2 || !slices.Contains(meetingInserts, "work-meeting") || !slices.Contains(meetingInserts, tokenReminderWorkID) {
+		t.Errorf("Expected the meeting and the token reminder in %s, got %v", meetingsID, meetingInserts)
+	}
+}
+
+func TestSync_RoutedEventMovesWhenItsClassChanges(t *testing.T) {
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+
+	cfg := &config.Config{
+		SyncWindowWeeks: 2,
+	}
+	dest := &config.Destination{
+		Name:            "Test",
+		CalendarName:    "Work Sync - Meetings",
+		CalendarColorID: "7",
+		Routes: []config.Route{
+			{Events: []string{"all_day"}, CalendarName: "Work Sync - All Day", CalendarColorID: "7"},
+		},
+	}
+
+	syncer := NewSyncer(workClient, personalClient, cfg, dest, false)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	event := &calendar.Event{
+		Id:      "work-1",
+		Summary: "Offsite",
+		Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+	}
+	workClient.events["primary"] = []*calendar.Event{event}
+
+	ctx := context.Background()
+	if _, err := syncer.Sync(ctx); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	meetingsID := personalClient.calendars["Work Sync - Meetings"]
+	allDayID := personalClient.calendars["Work Sync - All Day"]
+	if len(personalClient.events[meetingsID]) != 1 || len(personalClient.events[allDayID]) != 0 {
+		t.Fatalf("Expected the timed event in the meetings calendar only, got %d and %d events",
+			len(personalClient.events[meetingsID]), len(personalClient.events[allDayID]))
+	}
+
+	// The event becomes all-day, so it moves to the other calendar
+	event.Start = &calendar.EventDateTime{Date: start.Format("2006-01-02")}
+	event.End = &calendar.EventDateTime{Date: start.AddDate(0, 0, 1).Format("2006-01-02")}
+
+	result, err := syncer.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if result.Deleted != 1 || result.Inserted != 1 {
+		t.Errorf("Expected 1 delete and 1 insert, got deleted %d, inserted %d", result.Deleted, result.Inserted)
+	}
+	if len(personalClient.events[meetingsID]) != 0 {
+		t.Errorf("Expected the event to be removed from the meetings calendar, got %d events", len(personalClient.events[meetingsID]))
+	}
+	if len(personalClient.events[allDayID]) != 1 || getWorkEventID(personalClient.events[allDayID][0]) != "work-1" {
+		t.Errorf("Expected the event in the all-day calendar, got %+v", personalClient.events[allDayID])
+	}
+}
+
+// windowRecordingCalendarClient records the time window of each GetEvents call.
+type windowRecordingCalendarClient struct {
+	*mockGoogleCalendarClient
+	timeMin, timeMax time.Time
+}
+
+func (m *windowRecordingCalendarClient) GetEvents(calendarID string, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	m.timeMin, m.timeMax = timeMin, timeMax
+	return m.mockGoogleCalendarClient.GetEvents(calendarID, timeMin, timeMax)
+}
+
+func TestSync_DestinationSyncWindowOverridesGlobal(t *testing.T) {
+	cfg := &config.Config{
+		SyncWindowWeeks:     2,
+		SyncWindowWeeksPast: 1,
+	}
+	// Monday 2024-01-15
+	now := time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC)
+
+	weeks, weeksPast := 1, 6
+	tests := []struct {
+		name    string
+		dest    *config.Destination
+		wantMin time.Time
+		wantMax time.Time
+	}{
+		{
+			name:    "global window",
+			dest:    &config.Destination{Name: "iCloud", CalendarName: "Work Sync"},
+			wantMin: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+			wantMax: time.Date(2024, 1, 28, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:    "destination window",
+			dest:    &config.Destination{Name: "Planning", CalendarName: "Work Sync", SyncWindowWeeks: &weeks, SyncWindowWeeksPast: &weeksPast},
+			wantMin: time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC),
+			wantMax: time.Date(2024, 1, 21, 23, 59, 59, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workClient := &windowRecordingCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+			syncer := NewSyncer(workClient, newMockGoogleCalendarClient(), cfg, tt.dest, false)
+			syncer.now = func() time.Time { return now }
+
+			if _, err := syncer.Sync(context.Background()); err != nil {
+				t.Fatalf("Sync() returned an error: %v", err)
+			}
+			if !workClient.timeMin.Equal(tt.wantMin) || !workClient.timeMax.Equal(tt.wantMax) {
+				t.Errorf("Expected window %s to %s, got %s to %s", tt.wantMin, tt.wantMax, workClient.timeMin, workClient.timeMax)
+			}
+		})
+	}
+}
+
+// operationRecordingCalendarClient records every write in the order it was made.
+type operationRecordingCalendarClient struct {
+	*mockGoogleCalendarClient
+	operations []string
+}
+
+func (m *operationRecordingCalendarClient) InsertEvent(calendarID string, event *calendar.Event) error {
+	m.operations = append(m.operations, "insert "+getWorkEventID(event))
+	return m.mockGoogleCalendarClient.InsertEvent(calendarID, event)
+}
+
+func (m *operationRecordingCalendarClient) UpdateEvent(calendarID, eventID string, event *calendar.Event) error {
+	m.operations = append(m.operations, "update "+eventID)
+	return m.mockGoogleCalendarClient.UpdateEvent(calendarID, eventID, event)
+}
+
+func (m *operationRecordingCalendarClient) DeleteEvent(calendarID, eventID string) error {
+	m.operations = append(m.operations, "delete "+eventID)
+	return m.mockGoogleCalendarClient.DeleteEvent(calendarID, eventID)
+}
+
+func TestSync_OperationOrderIsDeterministic(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+	event := func(id, summary string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		}
+	}
+	tagged := func(id, workID, summary string) *calendar.Event {
+		e := event(id, summary)
+		e.ExtendedProperties = &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": workID}}
+		return e
+	}
+
+	run := func() []string {
+		workClient := newMockGoogleCalendarClient()
+		personalClient := &operationRecordingCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+		for _, id := range []string{"work-e", "work-a", "work-d", "work-b", "work-c", "changed-2", "changed-1"} {
+			workClient.events["primary"] = append(workClient.events["primary"], event(id, "Meeting "+id))
+		}
+		personalClient.calendars["Work Sync"] = "cal_123"
+		personalClient.events["cal_123"] = []*calendar.Event{
+			tagged("dest-stale-2", "gone-2", "Gone"),
+			tagged("dest-changed-1", "changed-1", "Old title"),
+			tagged("dest-stale-1", "gone-1", "Gone"),
+			tagged("dest-changed-2", "changed-2", "Old title"),
+			tagged("dest-stale-3", "gone-3", "Gone"),
+		}
+
+		cfg := &config.Config{SyncWindowWeeks: 2}
+		dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+		if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+			t.Fatalf("Sync() returned an error: %v", err)
+		}
+		return personalClient.operations
+	}
+
+	want := []string{
+		"update dest-changed-1",
+		"update dest-changed-2",
+		"delete dest-stale-1",
+		"delete dest-stale-2",
+		"delete dest-stale-3",
+		"insert work-a",
+		"insert work-b",
+		"insert work-c",
+		"insert work-d",
+		"insert work-e",
+	}
+	for i := 0; i < 2; i++ {
+		if got := run(); !slices.Equal(got, want) {
+			t.Fatalf("Run %d: expected operations %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestSync_DiffAgainstSnapshot(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	workEvent := func(id, summary string) *calendar.Event {
+		return &calendar.Event{Id: id, Summary: summary, Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}}
+	}
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{
+		workEvent("work-1", "Standup"),
+		workEvent("work-2", "Planning"),
+		workEvent("work-3", "Retro"),
+	}
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	for i, event := range personalClient.events["cal_Work Sync"] {
+		event.Id = fmt.Sprintf("dest-%d", i)
+	}
+
+	snapshot, err := NewSyncer(nil, personalClient, cfg, dest, false).TakeSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("TakeSnapshot() returned an error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := calclient.SaveSnapshot(path, snapshot); err != nil {
+		t.Fatalf("SaveSnapshot() returned an error: %v", err)
+	}
+
+	// Since the snapshot, one event was renamed, one cancelled and one added,
+	// and the live calendar was emptied, which the diff must not see
+	workClient.events["primary"] = []*calendar.Event{
+		workEvent("work-1", "Standup"),
+		workEvent("work-2", "Quarterly planning"),
+		workEvent("work-4", "Demo"),
+	}
+	personalClient.events["cal_Work Sync"] = nil
+	personalClient.insertedEvents = nil
+
+	loaded, err := calclient.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() returned an error: %v", err)
+	}
+	if len(loaded.Events) != 3 || loaded.CalendarName != "Work Sync" || loaded.Destination != "Test" {
+		t.Fatalf("Expected a snapshot of 3 events in 'Work Sync' for 'Test', got %d in '%s' for '%s'",
+			len(loaded.Events), loaded.CalendarName, loaded.Destination)
+	}
+
+	diffCfg := &config.Config{SyncWindowWeeks: 2, DryRun: true}
+	result, err := NewSyncer(workClient, calclient.NewSnapshotCalendarClient(loaded), diffCfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() against the snapshot returned an error: %v", err)
+	}
+	if result.Inserted != 1 || result.Updated != 1 || result.Deleted != 1 {
+		t.Errorf("Expected inserted 1, updated 1, deleted 1, got inserted %d, updated %d, deleted %d",
+			result.Inserted, result.Updated, result.Deleted)
+	}
+	if len(personalClient.insertedEvents) != 0 || len(personalClient.updatedEvents) != 0 || len(personalClient.deletedEventIDs) != 0 {
+		t.Errorf("Expected the live calendar to be untouched by the diff")
+	}
+}
+
+func TestFetchSourceEvents_SharedAcrossDestinations(t *testing.T) {
+	now := time.Now()
+	near := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, now.Location())
+	far := near.AddDate(0, 0, 21)
+	workEvent := func(id string, start time.Time) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: id,
+			Start:   &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+		}
+	}
+
+	workClient := &windowRecordingCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+	workClient.events["primary"] = []*calendar.Event{workEvent("near", near), workEvent("far", far)}
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	weeks, weeksPast := 5, 1
+	destinations := []config.Destination{
+		{Name: "Short", CalendarName: "Work Sync"},
+		{Name: "Long", CalendarName: "Work Sync", SyncWindowWeeks: &weeks, SyncWindowWeeksPast: &weeksPast},
+	}
+
+	sourceEvents, err := FetchSourceEvents(workClient, cfg, destinations)
+	if err != nil {
+		t.Fatalf("FetchSourceEvents() returned an error: %v", err)
+	}
+	// The fetch covers the widest window, which here is Long's
+	wantMin, wantMax := NewSyncer(nil, nil, cfg, &destinations[1], false).timeWindow(now)
+	if !workClient.timeMin.Equal(wantMin) || !workClient.timeMax.Equal(wantMax) {
+		t.Errorf("Expected the work events to be fetched for %v - %v, got %v - %v", wantMin, wantMax, workClient.timeMin, workClient.timeMax)
+	}
+
+	// The Syncers don't fetch again, and each keeps to its own window
+	emptyWorkClient := newMockGoogleCalendarClient()
+	for _, tt := range []struct {
+		dest     *config.Destination
+		inserted int
+	}{
+		{&destinations[0], 1},
+		{&destinations[1], 2},
+	} {
+		personalClient := newMockGoogleCalendarClient()
+		result, err := NewSyncerWithSource(emptyWorkClient, personalClient, cfg, tt.dest, sourceEvents, false).Sync(context.Background())
+		if err != nil {
+			t.Fatalf("[%s] Sync() returned an error: %v", tt.dest.Name, err)
+		}
+		if result.Inserted != tt.inserted {
+			t.Errorf("[%s] Expected %d inserted event(s), got %d", tt.dest.Name, tt.inserted, result.Inserted)
+		}
+	}
+}
+
+func TestSync_SourceCalendarID(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{
+		{Id: "personal-1", Summary: "Dentist", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+	workClient.events["team@group.calendar.google.com"] = []*calendar.Event{
+		{Id: "team-1", Summary: "Team Sync", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+
+	cfg := &config.Config{SyncWindowWeeks: 2, SourceCalendarID: "team@group.calendar.google.com"}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if len(personalClient.insertedEvents) != 1 || personalClient.insertedEvents[0].Summary != "Team Sync" {
+		t.Errorf("Expected only the source calendar's event to be synced, got %v", personalClient.insertedEvents)
+	}
+}
+
+func TestSync_MergesSourceCalendars(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{
+		{Id: "event-1", Summary: "1:1", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		{Id: "shared-1", Summary: "All Hands", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+	workClient.events["team"] = []*calendar.Event{
+		{Id: "event-2", Summary: "Team Sync", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		// The same event, on both calendars
+		{Id: "shared-1", Summary: "All Hands", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+
+	cfg := &config.Config{SyncWindowWeeks: 2, SourceCalendars: []string{"primary", "team"}}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	workIDs := make(map[string]string)
+	for _, event := range personalClient.insertedEvents {
+		workIDs[event.ExtendedProperties.Private["workEventId"]] = event.Summary
+	}
+	expected := map[string]string{
+		"primary:event-1":  "1:1",
+		"primary:shared-1": "All Hands",
+		"team:event-2":     "Team Sync",
+	}
+	if len(workIDs) != len(expected) || len(personalClient.insertedEvents) != len(expected) {
+		t.Fatalf("Expected %d events, got %v", len(expected), workIDs)
+	}
+	for id, summary := range expected {
+		if workIDs[id] != summary {
+			t.Errorf("Expected %s to be %q, got %q", id, summary, workIDs[id])
+		}
+	}
+}
+
+func TestSync_DedupesAcrossSources(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	for _, dedupe := range []bool{false, true} {
+		workClient := newMockGoogleCalendarClient()
+		personalClient := newMockGoogleCalendarClient()
+		// The same meeting, with a different ID on each calendar
+		workClient.events["primary"] = []*calendar.Event{
+			{Id: "mine-1", Summary: "Design Review", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		}
+		workClient.events["team"] = []*calendar.Event{
+			{Id: "team-1", Summary: "Design Review", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+			{Id: "team-2", Summary: "Team Sync", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		}
+
+		cfg := &config.Config{SyncWindowWeeks: 2, SourceCalendars: []string{"primary", "team"}, DedupeAcrossSources: dedupe}
+		dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+		if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+			t.Fatalf("Sync() returned an error: %v", err)
+		}
+
+		var workIDs []string
+		for _, event := range personalClient.insertedEvents {
+			workIDs = append(workIDs, event.ExtendedProperties.Private["workEventId"])
+		}
+		sort.Strings(workIDs)
+		expected := []string{"primary:mine-1", "team:team-1", "team:team-2"}
+		if dedupe {
+			// One mirror of the meeting, from the first calendar
+			expected = []string{"primary:mine-1", "team:team-2"}
+		}
+		if !slices.Equal(workIDs, expected) {
+			t.Errorf("dedupe_across_sources %v: expected %v synced, got %v", dedupe, expected, workIDs)
+		}
+	}
+}
+
+func TestSync_CountsAPICalls(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	tagged := func(id, workID, summary string) *calendar.Event {
+		return &calendar.Event{
+			Id:                 id,
+			Summary:            summary,
+			Start:              &calendar.EventDateTime{DateTime: start},
+			End:                &calendar.EventDateTime{DateTime: end},
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": workID}},
+		}
+	}
+
+	workClient := newMockGoogleCalendarClient()
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{
+		{Id: "new", Summary: "New", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		{Id: "changed", Summary: "Renamed", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+	personalClient.calendars["Work Sync"] = "cal_Work Sync"
+	personalClient.events["cal_Work Sync"] = []*calendar.Event{
+		tagged("dest-changed", "changed", "Original"),
+		tagged("dest-stale", "stale", "Cancelled"),
+	}
+
+	cfg := &config.Config{SyncWindowWeeks: 2}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	// The writes counted are those the mock received
+	got := result.DestinationCalls
+	if got.Insert != len(personalClient.insertedEvents) || got.Update != len(personalClient.updatedEvents) || got.Delete != len(personalClient.deletedEventIDs) {
+		t.Errorf("Expected %d inserts, %d updates and %d deletes, got %v",
+			len(personalClient.insertedEvents), len(personalClient.updatedEvents), len(personalClient.deletedEventIDs), got)
+	}
+	if got.Insert != 1 || got.Update != 1 || got.Delete != 1 {
+		t.Errorf("Expected 1 insert, 1 update and 1 delete, got %v", got)
+	}
+	// Resolving the calendar and listing its events
+	if got.List != 2 || got.Get != 0 {
+		t.Errorf("Expected 2 list calls and no gets to the destination, got %v", got)
+	}
+	if result.WorkCalls.List != 1 || result.WorkCalls.Total() != 1 {
+		t.Errorf("Expected 1 list call to the work calendar, got %v", result.WorkCalls)
+	}
+}
+
+// incrementalCalendarClient is a work calendar that lists incrementally. Its
+// changes are returned for the last sync token it issued; any other token has
+// expired.
+type incrementalCalendarClient struct {
+	*mockGoogleCalendarClient
+	changes   []*calendar.Event
+	token     int
+	fullLists int
+}
+
+func (c *incrementalCalendarClient) GetEventsIncremental(calendarID, syncToken string, timeMin, timeMax time.Time) ([]*calendar.Event, string, error) {
+	current := fmt.Sprintf("token-%d", c.token)
+	c.token++
+	next := fmt.Sprintf("token-%d", c.token)
+	if syncToken == "" {
+		c.fullLists++
+		return c.events[calendarID], next, nil
+	}
+	if syncToken != current {
+		return nil, "", calclient.ErrSyncTokenExpired
+	}
+	changes := c.changes
+	c.changes = nil
+	return changes, next, nil
+}
+
+func TestSync_IncrementalSourceEvents(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	event := func(id, summary string) *calendar.Event {
+		return &calendar.Event{Id: id, Summary: summary, Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}}
+	}
+
+	workClient := &incrementalCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{event("standup", "Standup"), event("review", "Review"), event("retro", "Retro")}
+	cfg := &config.Config{SyncWindowWeeks: 2, SyncStatePath: filepath.Join(t.TempDir(), "state.json")}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	runSync := func() *SyncResult {
+		t.Helper()
+		result, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background())
+		if err != nil {
+			t.Fatalf("Sync() returned an error: %v", err)
+		}
+		return result
+	}
+
+	// The first run lists everything
+	if result := runSync(); result.Inserted != 3 || workClient.fullLists != 1 {
+		t.Fatalf("Expected a full listing and 3 inserts, got %d listing(s) and %+v", workClient.fullLists, result)
+	}
+
+	// Later runs apply the changes to the stored events
+	workClient.changes = []*calendar.Event{event("review", "Design Review"), {Id: "retro", Status: "cancelled"}}
+	result := runSync()
+	if workClient.fullLists != 1 {
+		t.Errorf("Expected the changes to be listed incrementally, got %d full listings", workClient.fullLists)
+	}
+	if result.Inserted != 0 || result.Updated != 1 || result.Deleted != 1 || result.Unchanged != 1 {
+		t.Errorf("Expected 1 update, 1 delete and 1 unchanged event, got %+v", result)
+	}
+
+	// An expired token falls back to a full listing
+	workClient.token += 5
+	workClient.events["primary"] = []*calendar.Event{event("standup", "Standup"), event("review", "Design Review")}
+	if result := runSync(); workClient.fullLists != 2 || result.Unchanged != 2 {
+		t.Errorf("Expected a full listing with 2 unchanged events, got %d listing(s) and %+v", workClient.fullLists, result)
+	}
+
+	// As does --full-resync
+	cfg.FullResync = true
+	if runSync(); workClient.fullLists != 3 {
+		t.Errorf("Expected --full-resync to list all events, got %d listing(s)", workClient.fullLists)
+	}
+}
+
+func TestDestinationState_LoadSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := destinationStatePath(dir, "Personal/Google")
+	if filepath.Dir(path) != dir {
+		t.Fatalf("Expected the state file of a name with a slash directly in %s, got %s", dir, path)
+	}
+
+	// The first run has no state yet
+	state, err := loadDestinationState(path)
+	if err != nil {
+		t.Fatalf("loadDestinationState() of a missing file returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(state, &destinationState{}) {
+		t.Errorf("Expected an empty state for a missing file, got %+v", state)
+	}
+
+	want := &destinationState{
+		LastSync:   time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		SyncTokens: map[string]string{"primary": "token-1"},
+		Calendars:  map[string]string{"Work Sync": "cal-123"},
+		Inserted:   3,
+		Updated:    1,
+		Deleted:    2,
+		Unchanged:  5,
+	}
+	if err := saveDestinationState(path, want); err != nil {
+		t.Fatalf("saveDestinationState() returned an error: %v", err)
+	}
+	got, err := loadDestinationState(path)
+	if err != nil {
+		t.Fatalf("loadDestinationState() returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the saved state back:\nwant %+v\ngot  %+v", want, got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the state file in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestSync_WritesDestinationState(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	workClient := &incrementalCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+	personalClient := newMockGoogleCalendarClient()
+	workClient.events["primary"] = []*calendar.Event{
+		{Id: "standup", Summary: "Standup", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+	}
+	dir := t.TempDir()
+	cfg := &config.Config{
+		SyncWindowWeeks:     2,
+		SyncStatePath:       filepath.Join(dir, "state.json"),
+		DestinationStateDir: filepath.Join(dir, "destinations"),
+	}
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+
+	if _, err := NewSyncer(workClient, personalClient, cfg, dest, false).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+
+	state, err := loadDestinationState(destinationStatePath(cfg.DestinationStateDir, "Test"))
+	if err != nil {
+		t.Fatalf("loadDestinationState() returned an error: %v", err)
+	}
+	calendarID, err := personalClient.FindCalendarByName("Work Sync")
+	if err != nil {
+		t.Fatalf("FindCalendarByName() returned an error: %v", err)
+	}
+	if state.Calendars["Work Sync"] != calendarID {
+		t.Errorf("Expected calendar ID %q recorded for Work Sync, got %v", calendarID, state.Calendars)
+	}
+	if state.Inserted != 1 || state.LastSync.IsZero() {
+		t.Errorf("Expected the run's time and its 1 insert recorded, got %+v", state)
+	}
+	if state.SyncTokens["primary"] != "token-1" {
+		t.Errorf("Expected the work calendar's sync token recorded, got %v", state.SyncTokens)
+	}
+}
+
+// checkingCalendarClient is a mock personal client that can check calendars
+// exist by ID, and counts the lookups by name.
+type checkingCalendarClient struct {
+	*mockGoogleCalendarClient
+	lookups int
+}
+
+func (m *checkingCalendarClient) FindOrCreateCalendarByName(name string, colorID string) (string, error) {
+	m.lookups++
+	return m.mockGoogleCalendarClient.FindOrCreateCalendarByName(name, colorID)
+}
+
+func (m *checkingCalendarClient) CalendarExists(calendarID string) error {
+	for _, id := range m.calendars {
+		if id == calendarID {
+			return nil
+		}
+	}
+	return fmt.Errorf("calendar %s: %w", calendarID, calclient.ErrCalendarNotFound)
+}
+
+func TestResolveCalendar_UsesDestinationState(t *testing.T) {
+	personalClient := &checkingCalendarClient{mockGoogleCalendarClient: newMockGoogleCalendarClient()}
+	personalClient.calendars["Work Sync"] = "cal_existing"
+	syncer := &Syncer{
+		personalClient: personalClient,
+		destination:    &config.Destination{Name: "Test", CalendarName: "Work Sync"},
+		config:         &config.Config{},
+		destState:      &destinationState{Calendars: map[string]string{"Work Sync": "cal_existing"}},
+	}
+
+	id, err := syncer.resolveCalendar()
+	if err != nil {
+		t.Fatalf("resolveCalendar() returned an error: %v", err)
+	}
+	if id != "cal_existing" {
+		t.Errorf("Expected the recorded calendar cal_existing, got %q", id)
+	}
+	if personalClient.lookups != 0 {
+		t.Errorf("Expected no lookup by name for a recorded calendar, got %d", personalClient.lookups)
+	}
+
+	// The recorded calendar was deleted: it's looked up by name again
+	delete(personalClient.calendars, "Work Sync")
+	id, err = syncer.resolveCalendar()
+	if err != nil {
+		t.Fatalf("resolveCalendar() returned an error: %v", err)
+	}
+	if id != "cal_Work Sync" {
+		t.Errorf("Expected a newly created calendar, got %q", id)
+	}
+	if personalClient.lookups != 1 {
+		t.Errorf("Expected 1 lookup by name for a deleted calendar, got %d", personalClient.lookups)
+	}
+	if _, ok := syncer.destState.Calendars["Work Sync"]; ok {
+		t.Errorf("Expected the deleted calendar forgotten, got %v", syncer.destState.Calendars)
+	}
+}
+
+func TestPrepareSyncEvent_ColorCategories(t *testing.T) {
+	source := &calendar.Event{
+		Id:      "incident",
+		Summary: "Incident review",
+		ColorId: "11",
+		Start:   &calendar.EventDateTime{DateTime: "2024-01-15T10:00:00Z"},
+		End:     &calendar.EventDateTime{DateTime: "2024-01-15T11:00:00Z"},
+	}
+	syncer := &Syncer{
+		destination: &config.Destination{Name: "Apple", Type: "apple"},
+		config:      &config.Config{ColorCategories: map[string]string{"11": "Urgent"}},
+	}
+
+	dest := syncer.prepareSyncEvent(source)
+	if dest.ColorId != "11" {
+		t.Errorf("Expected the color passed on for color_categories, got %q", dest.ColorId)
+	}
+
+	// The copy read back from CalDAV has only the category, not the color
+	existing := syncer.prepareSyncEvent(source)
+	existing.ColorId = ""
+	if equal, field := eventsEqual(existing, dest, nil); !equal {
+		t.Errorf("Expected the color to be left out of the comparison, got a %s mismatch", field)
+	}
+
+	// Google destinations keep their own colors
+	syncer.destination = &config.Destination{Name: "Personal", Type: "google"}
+	if dest := syncer.prepareSyncEvent(source); dest.ColorId != "" {
+		t.Errorf("Expected no color passed to a Google destination, got %q", dest.ColorId)
+	}
+}
+
+func TestSync_DebugSummaryFilter(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	tagged := func(id, workID, summary string) *calendar.Event {
+		return &calendar.Event{
+			Id:                 id,
+			Summary:            summary,
+			Start:              &calendar.EventDateTime{DateTime: start},
+			End:                &calendar.EventDateTime{DateTime: end},
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": workID}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filter  string
+		verbose bool
+		want    []string
+	}{
+		{"off by default", "", true, nil},
+		{"matching events only", "quarterly", true, []string{"dest-review"}},
+		{"not without verbose", "quarterly", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workClient := newMockGoogleCalendarClient()
+			personalClient := newMockGoogleCalendarClient()
+			personalClient.calendars["Work Sync"] = "cal_Work Sync"
+			personalClient.events["cal_Work Sync"] = []*calendar.Event{
+				tagged("dest-review", "review", "Quarterly Review"),
+				tagged("dest-standup", "standup", "Standup"),
+			}
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			cfg := &config.Config{SyncWindowWeeks: 2, DebugSummaryFilter: tt.filter}
+			dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+			_, err := NewSyncer(workClient, personalClient, cfg, dest, tt.verbose).Sync(context.Background())
+			log.SetOutput(os.Stderr)
+			if err != nil {
+				t.Fatalf("Sync() returned an error: %v", err)
+			}
+
+			var logged []string
+			for _, line := range strings.Split(logs.String(), "\n") {
+				if _, traced, found := strings.Cut(line, "DEBUG: Found event '"); found {
+					_, id, _ := strings.Cut(traced, ", ID=")
+					logged = append(logged, id)
+				}
+			}
+			if !slices.Equal(logged, tt.want) {
+				t.Errorf("Expected events %v logged, got %v in log:\n%s", tt.want, logged, logs.String())
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	for _, dryRun := range []bool{false, true} {
+		personalClient := newMockGoogleCalendarClient()
+		personalClient.calendars["Work Sync"] = "cal_Work Sync"
+		personalClient.events["cal_Work Sync"] = []*calendar.Event{
+			{Id: "synced", Summary: "Standup", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end},
+				ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{"workEventId": "standup"}}},
+			{Id: "manual", Summary: "Dentist", Start: &calendar.EventDateTime{DateTime: start}, End: &calendar.EventDateTime{DateTime: end}},
+		}
+
+		cfg := &config.Config{SyncWindowWeeks: 2, DryRun: dryRun}
+		dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+		count, err := NewSyncer(nil, personalClient, cfg, dest, false).Purge(context.Background())
+		if err != nil {
+			t.Fatalf("dry run %v: Purge() returned an error: %v", dryRun, err)
+		}
+		if count != 2 {
+			t.Errorf("dry run %v: expected 2 events purged, got %d", dryRun, count)
+		}
+		want := []string{"synced", "manual"}
+		if dryRun {
+			want = []string{}
+		}
+		if !slices.Equal(personalClient.deletedEventIDs, want) {
+			t.Errorf("dry run %v: expected %v deleted, got %v", dryRun, want, personalClient.deletedEventIDs)
+		}
+	}
+
+	// A calendar that was never created has nothing to purge, and stays uncreated
+	personalClient := newMockGoogleCalendarClient()
+	dest := &config.Destination{Name: "Test", CalendarName: "Work Sync"}
+	count, err := NewSyncer(nil, personalClient, &config.Config{SyncWindowWeeks: 2}, dest, false).Purge(context.Background())
+	if err != nil || count != 0 {
+		t.Errorf("Expected nothing purged from a missing calendar, got %d, %v", count, err)
+	}
+	if len(personalClient.calendars) != 0 {
+		t.Errorf("Expected no calendar to be created, got %v", personalClient.calendars)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, data := range []string{`{"old": true}`, `{"new": true}`} {
+		if err := writeFileAtomic(path, []byte(data)); err != nil {
+			t.Fatalf("writeFileAtomic() returned an error: %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("Expected %s, got %s (%v)", data, got, err)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to list directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file, got %d entries", len(entries))
+	}
+
+	// A directory that doesn't exist fails without touching anything
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("{}")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestSaveSourceState_KeepsOtherCalendars(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	for _, calendarID := range []string{"primary", "team"} {
+		if err := saveSourceState(path, calendarID, &sourceState{SyncToken: calendarID + "-token"}); err != nil {
+			t.Fatalf("saveSourceState() returned an error: %v", err)
+		}
+	}
+
+	for _, calendarID := range []string{"primary", "team"} {
+		state, err := loadSourceState(path, calendarID)
+		if err != nil || state == nil || state.SyncToken != calendarID+"-token" {
+			t.Errorf("Expected the state of %s kept, got %+v (%v)", calendarID, state, err)
+		}
+	}
+	// Replaced through a temporary file, which doesn't remain
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected only the state file, got %d entries", len(entries))
+	}
+}
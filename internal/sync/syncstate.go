@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// sourceState is what is kept between runs of a work calendar listed
+// incrementally: the token for the next listing, and the events known from the
+// listings so far. TimeMin and TimeMax are the window of the full listing the
+// events started from; changes are applied wherever they are.
+type sourceState struct {
+	SyncToken string                     `json:"sync_token"`
+	TimeMin   time.Time                  `json:"time_min"`
+	TimeMax   time.Time                  `json:"time_max"`
+	Events    map[string]*calendar.Event `json:"events"`
+}
+
+// covers reports whether the state can serve a listing of the window.
+func (st *sourceState) covers(timeMin, timeMax time.Time) bool {
+	return st.SyncToken != "" && !timeMin.Before(st.TimeMin) && !timeMax.After(st.TimeMax)
+}
+
+// syncStateMu serializes access to the sync state file, which destinations
+// synced concurrently share.
+var syncStateMu stdsync.Mutex
+
+// loadSourceState returns the stored state of a work calendar, or nil if there
+// is none.
+func loadSourceState(path, calendarID string) (*sourceState, error) {
+	syncStateMu.Lock()
+	defer syncStateMu.Unlock()
+	states, err := readSyncState(path)
+	if err != nil {
+		return nil, err
+	}
+	return states[calendarID], nil
+}
+
+// saveSourceState stores the state of a work calendar, keeping those of the
+// other calendars. The file is replaced atomically, so an interrupted write
+// leaves the previous states rather than a file readSyncState can't parse.
+func saveSourceState(path, calendarID string, state *sourceState) error {
+	syncStateMu.Lock()
+	defer syncStateMu.Unlock()
+	states, err := readSyncState(path)
+	if err != nil {
+		return err
+	}
+	states[calendarID] = state
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create sync state directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// readSyncState reads the states of every work calendar from the file at path.
+// A missing file has no states.
+func readSyncState(path string) (map[string]*sourceState, error) {
+	states := make(map[string]*sourceState)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state %s: %w", path, err)
+	}
+	return states, nil
+}